@@ -0,0 +1,158 @@
+// Package app enthält den gemeinsamen Aufbau der Kommandos: Konfiguration,
+// Logger und die Datenquelle nach DATA_SOURCE.
+package app
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/env"
+	"assecor-assessment-backend/internal/logging"
+	"assecor-assessment-backend/internal/repository"
+	"assecor-assessment-backend/internal/repository/composite"
+	csvrepo "assecor-assessment-backend/internal/repository/csv"
+	"assecor-assessment-backend/internal/repository/jsonfile"
+	"assecor-assessment-backend/internal/repository/memory"
+	"assecor-assessment-backend/internal/repository/retrying"
+	sqliterepo "assecor-assessment-backend/internal/repository/sqlite"
+)
+
+// Setup liest die Konfiguration aus der Umgebung, erstellt den Logger und
+// lädt einen eigenen Farbsatz aus COLORS. Ein gesetzter, aber nicht
+// umwandelbarer Wert ist ein Fehler (siehe env.Load); die übrigen Prüfungen
+// rufen Kommandos, die die ganze Konfiguration brauchen, selbst mit
+// Config.Validate auf.
+func Setup() (env.Config, *zap.Logger, error) {
+	cfg, err := env.Load()
+	if err != nil {
+		return cfg, nil, err
+	}
+	logger, err := logging.New(logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		return cfg, nil, fmt.Errorf("logger konnte nicht erstellt werden: %w", err)
+	}
+	if cfg.Colors != "" {
+		if err := domain.LoadColors(cfg.Colors); err != nil {
+			return cfg, logger, fmt.Errorf("farbkonfiguration ungültig: %w", err)
+		}
+		logger.Info("eigener farbsatz geladen", zap.Stringers("farben", domain.AllColors()))
+	}
+	if cfg.ColorHex != "" {
+		if err := domain.LoadColorHex(cfg.ColorHex); err != nil {
+			return cfg, logger, fmt.Errorf("farbwerte ungültig: %w", err)
+		}
+	}
+	return cfg, logger, nil
+}
+
+// OpenRepository erstellt je nach DATA_SOURCE das passende PersonRepository.
+// "sqlite" öffnet SQLITE_PATH; die zurückgegebene cleanup-Funktion schließt
+// die DB-Verbindung und ist sonst nil. "memory" startet leer und ohne jede
+// Datei, etwa für CI- und Lasttests. "json" lädt JSON_FILE_PATH einmalig und
+// arbeitet danach wie "memory". "csv+sqlite" legt SQLITE_PATH als
+// Schreibschicht über die unveränderte CSV.
+func OpenRepository(cfg env.Config, logger *zap.Logger) (repository.PersonRepository, func(), error) {
+	switch cfg.DataSource {
+	case "sqlite":
+		repo, err := OpenSQLite(cfg, cfg.MaxPersons, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Bei gleichzeitigen Schreibzugriffen meldet SQLite gelegentlich
+		// SQLITE_BUSY; ein erneuter Versuch gelingt dann meist sofort.
+		return retrying.New(repo, logger), func() { _ = repo.Close() }, nil
+
+	case "memory":
+		return memory.NewPersonRepository(cfg.MaxPersons), nil, nil
+
+	case "json":
+		repo, err := jsonfile.NewPersonRepository(cfg.JSONFilePath, cfg.MaxPersons, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("json-repository konnte nicht geladen werden: %w", err)
+		}
+		return repo, nil, nil
+
+	case "csv":
+		repo, err := OpenCSV(cfg, cfg.CSVFilePath, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, nil, nil
+
+	case "csv+sqlite":
+		seed, err := OpenCSV(cfg, cfg.CSVFilePath, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Die Kapazitätsgrenze prüft composite über beide Schichten.
+		db, err := OpenSQLite(cfg, 0, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		repo, err := composite.New(seed, retrying.New(db, logger), cfg.MaxPersons)
+		if err != nil {
+			_ = db.Close()
+			return nil, nil, fmt.Errorf("composite-repository konnte nicht angelegt werden: %w", err)
+		}
+		return repo, func() { _ = db.Close() }, nil
+
+	default:
+		// Validate lehnt unbekannte Werte bereits ab; ohne diesen Fall würde
+		// ein Tippfehler wie "sqlit" stillschweigend die CSV laden.
+		return nil, nil, fmt.Errorf("unbekannte datenquelle %q", cfg.DataSource)
+	}
+}
+
+// OpenSQLite öffnet SQLITE_PATH mit der Kapazitätsgrenze maxPersons.
+func OpenSQLite(cfg env.Config, maxPersons int, logger *zap.Logger) (*sqliterepo.PersonRepository, error) {
+	repo, err := sqliterepo.NewPersonRepository(cfg.SQLitePath, maxPersons, logger,
+		sqliterepo.WithPool(sqliterepo.PoolConfig{
+			MaxOpenConns:    cfg.DBMaxOpenConns,
+			MaxIdleConns:    cfg.DBMaxIdleConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("sqlite-repository konnte nicht initialisiert werden: %w", err)
+	}
+	return repo, nil
+}
+
+// OpenCSV lädt source mit allen CSV_*-Einstellungen. opts gelten zuletzt
+// und überschreiben so einzelne Einstellungen.
+func OpenCSV(cfg env.Config, source string, logger *zap.Logger, opts ...csvrepo.Option) (*csvrepo.PersonRepository, error) {
+	// Die Ersatzfarbe wird erst hier geprüft, weil COLORS den Farbsatz
+	// vorher ersetzen kann.
+	unknownColor, err := csvrepo.ParseUnknownColorPolicy(cfg.CSVUnknownColor)
+	if err != nil {
+		return nil, fmt.Errorf("csv_unknown_color ungültig: %w", err)
+	}
+	cachePath := cfg.CSVCachePath
+	if cachePath == "off" {
+		cachePath = ""
+	}
+	all := append([]csvrepo.Option{
+		csvrepo.WithDelimiter(cfg.CSVDelimiter),
+		csvrepo.WithFetchTimeout(cfg.CSVFetchTimeout),
+		csvrepo.WithFetchMaxBytes(cfg.CSVFetchMaxBytes),
+		csvrepo.WithBearerToken(cfg.CSVFetchToken),
+		csvrepo.WithS3(csvrepo.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			SessionToken:    cfg.S3SessionToken,
+		}),
+		csvrepo.WithCachePath(cachePath),
+		csvrepo.WithStrict(cfg.CSVStrict),
+		csvrepo.WithOverflowError(cfg.CSVOverflow == "error"),
+		csvrepo.WithAllowMissing(cfg.CSVAllowMissing),
+		csvrepo.WithUnknownColor(unknownColor),
+	}, opts...)
+	repo, err := csvrepo.NewPersonRepository(source, cfg.MaxPersons, logger, all...)
+	if err != nil {
+		return nil, fmt.Errorf("csv-repository konnte nicht geladen werden: %w", err)
+	}
+	return repo, nil
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/env"
+)
+
+// beispielCSV enthält zwei gültige Datensätze und einen mit unbekannter Farbe.
+const beispielCSV = `Müller, Hans, 67742 Lauterecken, 1
+Petersen, Peter, 18439 Stralsund, 2
+Fehler, Fritz, 12345 Nirgendwo, 99
+`
+
+// tempDatei legt content unter name in einem Testverzeichnis an.
+func tempDatei(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// testConfig lädt die Konfiguration mit den Umgebungsvariablen aus vars.
+func testConfig(t *testing.T, vars map[string]string) env.Config {
+	t.Helper()
+	for k, v := range vars {
+		t.Setenv(k, v)
+	}
+	return env.MustLoad()
+}
+
+func TestOpenRepository_Datenquellen(t *testing.T) {
+	csvPath := tempDatei(t, "persons.csv", beispielCSV)
+	jsonPath := tempDatei(t, "persons.json", `[{"name":"Hans","lastname":"Müller","zipcode":"67742","city":"Lauterecken","color":"blau"}]`)
+
+	tests := []struct {
+		source     string
+		want       int
+		mitSchluss bool
+		wantErr    string
+	}{
+		{source: "memory", want: 0},
+		{source: "csv", want: 2},
+		{source: "json", want: 1},
+		{source: "sqlite", want: 0, mitSchluss: true},
+		{source: "csv+sqlite", want: 2, mitSchluss: true},
+		{source: "sqlit", wantErr: `unbekannte datenquelle "sqlit"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			cfg := testConfig(t, map[string]string{
+				"DATA_SOURCE": tt.source, "CSV_FILE_PATH": csvPath, "JSON_FILE_PATH": jsonPath,
+			})
+			repo, cleanup, err := OpenRepository(cfg, zap.NewNop())
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr, "ein tippfehler lädt nicht stillschweigend die csv")
+				assert.Nil(t, repo)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.mitSchluss, cleanup != nil)
+			if cleanup != nil {
+				defer cleanup()
+			}
+			persons, err := repo.GetAll(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, persons, tt.want)
+		})
+	}
+}
+
+func TestOpenRepository_Fehler(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"unbekannte datenquelle", map[string]string{"DATA_SOURCE": "postgres"}, `unbekannte datenquelle "postgres"`},
+		{"csv fehlt", map[string]string{"DATA_SOURCE": "csv", "CSV_FILE_PATH": filepath.Join(t.TempDir(), "fehlt.csv")}, "csv-repository konnte nicht geladen werden"},
+		{"ungültige ersatzfarbe", map[string]string{"DATA_SOURCE": "csv", "CSV_UNKNOWN_COLOR": "default:lila"}, "csv_unknown_color ungültig"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := OpenRepository(testConfig(t, tt.vars), zap.NewNop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository"
+	csvrepo "assecor-assessment-backend/internal/repository/csv"
+)
+
+// Export schreibt alle nicht gelöschten Personen aus repo im Format der
+// Quell-CSV nach out und gibt ihre Anzahl zurück. Die IDs gehen verloren;
+// beim Laden ergeben sie sich neu aus der Reihenfolge.
+func Export(ctx context.Context, repo repository.PersonRepository, out io.Writer, delimiter rune) (int, error) {
+	w := csvrepo.NewWriter(out, delimiter)
+	n := 0
+	err := repo.GetAllStream(ctx, func(p domain.Person) error {
+		n++
+		return w.Write(p)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("personen lesen: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("csv schreiben: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository/memory"
+)
+
+func TestExport_OhneGeloeschte(t *testing.T) {
+	repo := memory.NewPersonRepository(0,
+		domain.Person{ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"},
+		domain.Person{ID: 2, Name: "Peter", Lastname: "Petersen", Zipcode: "18439", City: "Stralsund", Color: "grün"},
+		domain.Person{ID: 3, Name: "Klaus", Lastname: "Meyer, Dr.", Zipcode: "12345", City: "Bad Homburg", Color: "rot"},
+	)
+	require.NoError(t, repo.Delete(context.Background(), 2, time.Now()))
+	var out strings.Builder
+
+	n, err := Export(context.Background(), repo, &out, ';')
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "Müller;Hans;67742 Lauterecken;1\nMeyer, Dr.;Klaus;12345 Bad Homburg;4\n", out.String())
+}
+
+func TestExport_RundreiseUeberCSV(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"DATA_SOURCE": "csv", "CSV_FILE_PATH": tempDatei(t, "persons.csv", beispielCSV)})
+	repo, _, err := OpenRepository(cfg, zap.NewNop())
+	require.NoError(t, err)
+	var out strings.Builder
+	_, err = Export(context.Background(), repo, &out, ',')
+	require.NoError(t, err)
+
+	cfg.CSVFilePath = tempDatei(t, "export.csv", out.String())
+	reloaded, _, err := OpenRepository(cfg, zap.NewNop())
+	require.NoError(t, err)
+	want, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	got, err := reloaded.GetAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Lastname, got[i].Lastname)
+		assert.Equal(t, want[i].City, got[i].City)
+		assert.Equal(t, want[i].Color, got[i].Color)
+	}
+}
+
+// kaputterWriter lehnt jeden Schreibversuch ab.
+type kaputterWriter struct{}
+
+func (kaputterWriter) Write([]byte) (int, error) { return 0, errors.New("platte voll") }
+
+func TestExport_SchreibfehlerWirdGemeldet(t *testing.T) {
+	repo := memory.NewPersonRepository(0, domain.Person{ID: 1, Name: "Hans", Lastname: "Müller", Color: "blau"})
+	_, err := Export(context.Background(), repo, kaputterWriter{}, ',')
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "platte voll")
+}
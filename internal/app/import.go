@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/env"
+	sqliterepo "assecor-assessment-backend/internal/repository/sqlite"
+)
+
+// Import lädt die CSV unter source mit den CSV_*-Einstellungen und schreibt
+// alle gültigen Personen unter ihrer ID aus der CSV in die Datenbank unter
+// SQLITE_PATH. Es gibt die Zahl der importierten Personen zurück.
+//
+// Ist eine ID schon belegt, bricht Import ab; die bis dahin geschriebenen
+// Personen bleiben erhalten. Eine In-Memory-Datenbank lehnt es ab, weil der
+// Import mit dem Prozess verloren ginge.
+func Import(ctx context.Context, cfg env.Config, source string, logger *zap.Logger) (int, error) {
+	if sqliterepo.IsMemoryDSN(cfg.SQLitePath) {
+		return 0, fmt.Errorf("SQLITE_PATH=%q ist eine in-memory-datenbank, der import ginge verloren", cfg.SQLitePath)
+	}
+	persons, err := OpenCSV(cfg, source, logger)
+	if err != nil {
+		return 0, err
+	}
+	db, err := OpenSQLite(cfg, cfg.MaxPersons, logger)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = db.Close() }()
+
+	n := 0
+	err = persons.GetAllStream(ctx, func(p domain.Person) error {
+		if _, err := db.Insert(ctx, p); err != nil {
+			if errors.Is(err, domain.ErrVersionConflict) {
+				return fmt.Errorf("person %d existiert bereits in %s: %w", p.ID, cfg.SQLitePath, err)
+			}
+			return fmt.Errorf("person %d importieren: %w", p.ID, err)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	logger.Info("personen importiert", zap.Int("anzahl", n), zap.String("quelle", source), zap.String("ziel", cfg.SQLitePath))
+	return n, nil
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+func TestImport_SchreibtInDieDatenbank(t *testing.T) {
+	csvPath := tempDatei(t, "persons.csv", beispielCSV)
+	dbPath := filepath.Join(t.TempDir(), "persons.db")
+	cfg := testConfig(t, map[string]string{"SQLITE_PATH": dbPath})
+	ctx := context.Background()
+
+	n, err := Import(ctx, cfg, csvPath, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	db, err := OpenSQLite(cfg, 0, zap.NewNop())
+	require.NoError(t, err)
+	defer db.Close()
+	p, err := db.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Petersen", p.Lastname)
+	assert.Equal(t, domain.Color("grün"), p.Color)
+}
+
+func TestImport_BelegteIDBrichtAb(t *testing.T) {
+	csvPath := tempDatei(t, "persons.csv", beispielCSV)
+	cfg := testConfig(t, map[string]string{"SQLITE_PATH": filepath.Join(t.TempDir(), "persons.db")})
+
+	_, err := Import(context.Background(), cfg, csvPath, zap.NewNop())
+	require.NoError(t, err)
+	n, err := Import(context.Background(), cfg, csvPath, zap.NewNop())
+	require.ErrorIs(t, err, domain.ErrVersionConflict)
+	assert.Contains(t, err.Error(), "person 1 existiert bereits")
+	assert.Zero(t, n)
+}
+
+func TestImport_Fehler(t *testing.T) {
+	csvPath := tempDatei(t, "persons.csv", beispielCSV)
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"in-memory-datenbank", map[string]string{"SQLITE_PATH": ":memory:"}, "in-memory-datenbank"},
+		{"strikt mit fehlerhaftem datensatz", map[string]string{
+			"SQLITE_PATH": filepath.Join(t.TempDir(), "persons.db"), "CSV_STRICT": "true",
+		}, "1 ungültige datensätze"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(context.Background(), testConfig(t, tt.vars), csvPath, zap.NewNop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
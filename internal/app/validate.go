@@ -0,0 +1,39 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/env"
+	csvrepo "assecor-assessment-backend/internal/repository/csv"
+)
+
+// ValidateCSV liest die CSV unter source wie beim Laden des Servers und
+// schreibt je verworfenem Datensatz eine Zeile mit Grund und Feldern nach
+// out, gefolgt von einer Zusammenfassung. Der Bericht ist auch bei
+// verworfenen Datensätzen kein Fehler; der Aufrufer entscheidet anhand von
+// Skipped. Ein Fehler bedeutet, dass die Datei gar nicht gelesen wurde.
+//
+// CSV_STRICT, CSV_ALLOW_MISSING, der Cache und MAX_PERSONS gelten nicht:
+// Geprüft wird immer die ganze Datei, auch wenn sie fehlerhaft ist.
+func ValidateCSV(cfg env.Config, source string, out io.Writer) (domain.LoadReport, error) {
+	cfg.MaxPersons = 0
+	repo, err := OpenCSV(cfg, source, zap.NewNop(),
+		csvrepo.WithStrict(false),
+		csvrepo.WithOverflowError(false),
+		csvrepo.WithAllowMissing(false),
+		csvrepo.WithCachePath(""))
+	if err != nil {
+		return domain.LoadReport{}, err
+	}
+	report := repo.LoadReport()
+	for _, skipped := range report.Skipped {
+		fmt.Fprintf(out, "zeile %d: %s: %s\n", skipped.Line, skipped.Reason, strings.Join(skipped.Fields, ", "))
+	}
+	fmt.Fprintf(out, "%s: %d gültige datensätze, %d fehlerhaft\n", source, report.Loaded, len(report.Skipped))
+	return report, nil
+}
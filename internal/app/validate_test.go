@@ -0,0 +1,42 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCSV_BerichtJeZeile(t *testing.T) {
+	path := tempDatei(t, "persons.csv", beispielCSV)
+	var out strings.Builder
+
+	report, err := ValidateCSV(testConfig(t, nil), path, &out)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Loaded)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "zeile 3: unbekannte farb-id 99: ungültige eingabe: Fehler, Fritz, 12345 Nirgendwo, 99\n"+
+		path+": 2 gültige datensätze, 1 fehlerhaft\n", out.String())
+}
+
+func TestValidateCSV_IgnoriertStriktUndKapazitaet(t *testing.T) {
+	path := tempDatei(t, "persons.csv", beispielCSV)
+	cfg := testConfig(t, map[string]string{"CSV_STRICT": "true", "MAX_PERSONS": "1", "CSV_OVERFLOW": "error"})
+
+	report, err := ValidateCSV(cfg, path, &strings.Builder{})
+	require.NoError(t, err, "geprüft wird die ganze datei")
+	assert.Equal(t, 2, report.Loaded)
+	assert.Zero(t, report.Overflow)
+	assert.Len(t, report.Skipped, 1)
+}
+
+func TestValidateCSV_DateiFehlt(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"CSV_ALLOW_MISSING": "true"})
+	var out strings.Builder
+
+	_, err := ValidateCSV(cfg, filepath.Join(t.TempDir(), "fehlt.csv"), &out)
+	require.Error(t, err)
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,73 @@
+// Package audit hält Änderungen an Personen als strukturierte Ereignisse
+// fest, getrennt vom Betriebslog. Jedes Ereignis nennt Zeitpunkt, Vorgang,
+// Request-ID und die Kennung des API-Schlüssels sowie den Stand der Person
+// vor und nach der Änderung.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// Vorgänge eines Event.
+const (
+	OpCreate    = "create"
+	OpUpdate    = "update"
+	OpDelete    = "delete"
+	OpRestore   = "restore"
+	OpDeleteAll = "delete_all"
+)
+
+// Event beschreibt eine einzelne Änderung. Before fehlt bei neu angelegten
+// Personen, After bei gelöschten; bei OpDeleteAll fehlen beide.
+type Event struct {
+	Time      time.Time      `json:"time"`
+	Operation string         `json:"operation"`
+	PersonID  int            `json:"person_id,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	APIKey    string         `json:"api_key,omitempty"`
+	Before    *domain.Person `json:"before,omitempty"`
+	After     *domain.Person `json:"after,omitempty"`
+}
+
+// Auditor nimmt Ereignisse entgegen. Record muss nebenläufig aufrufbar sein.
+type Auditor interface {
+	Record(ctx context.Context, event Event) error
+}
+
+// NewEvent legt ein Ereignis für operation zum Zeitpunkt at an und übernimmt
+// Request-ID und Schlüsselkennung aus ctx.
+func NewEvent(ctx context.Context, operation string, at time.Time) Event {
+	return Event{
+		Time:      at,
+		Operation: operation,
+		RequestID: chimw.GetReqID(ctx),
+		APIKey:    KeyID(ctx),
+	}
+}
+
+type keyIDKey struct{}
+
+// WithKeyID legt die Kennung des API-Schlüssels der Anfrage in ctx ab.
+func WithKeyID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, keyIDKey{}, id)
+}
+
+// KeyID liefert die mit WithKeyID abgelegte Kennung oder "".
+func KeyID(ctx context.Context) string {
+	id, _ := ctx.Value(keyIDKey{}).(string)
+	return id
+}
+
+// KeyIDFor leitet aus einem API-Schlüssel eine Kennung ab, die ihn
+// unterscheidbar macht, ohne ihn preiszugeben.
+func KeyIDFor(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// leseZeilen liest alle JSON-Zeilen aus path.
+func leseZeilen(t *testing.T, path string) []Event {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []Event
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &e), sc.Text())
+		events = append(events, e)
+	}
+	require.NoError(t, sc.Err())
+	return events
+}
+
+// ─── NewEvent ─────────────────────────────────────────────────────────────────
+
+func TestNewEvent_UebernimmtKontext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), chimw.RequestIDKey, "req-1")
+	ctx = WithKeyID(ctx, KeyIDFor("geheim"))
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	e := NewEvent(ctx, OpUpdate, at)
+
+	assert.Equal(t, Event{Time: at, Operation: OpUpdate, RequestID: "req-1", APIKey: KeyIDFor("geheim")}, e)
+	assert.Regexp(t, `^sha256:[0-9a-f]{8}$`, e.APIKey)
+	assert.Empty(t, NewEvent(context.Background(), OpCreate, at).APIKey)
+}
+
+// ─── FileAuditor ──────────────────────────────────────────────────────────────
+
+func TestFileAuditor_SchreibtJSONZeilen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	a, err := NewFileAuditor(path, 0)
+	require.NoError(t, err)
+
+	after := domain.Person{ID: 3, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau", Version: 1}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, a.Record(context.Background(), Event{Time: at, Operation: OpCreate, PersonID: 3, RequestID: "req-1", After: &after}))
+	require.NoError(t, a.Record(context.Background(), Event{Time: at, Operation: OpDelete, PersonID: 3, Before: &after}))
+	require.NoError(t, a.Close())
+
+	events := leseZeilen(t, path)
+	require.Len(t, events, 2)
+	assert.Equal(t, OpCreate, events[0].Operation)
+	assert.Equal(t, "req-1", events[0].RequestID)
+	assert.Nil(t, events[0].Before)
+	assert.Equal(t, &after, events[0].After)
+	assert.Equal(t, OpDelete, events[1].Operation)
+	assert.Nil(t, events[1].After)
+}
+
+func TestFileAuditor_HaengtAnBestehendeDateiAn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for range 2 {
+		a, err := NewFileAuditor(path, 0)
+		require.NoError(t, err)
+		require.NoError(t, a.Record(context.Background(), Event{Operation: OpDeleteAll}))
+		require.NoError(t, a.Close())
+	}
+	assert.Len(t, leseZeilen(t, path), 2)
+}
+
+func TestFileAuditor_RotiertNachGroesse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	line, err := json.Marshal(Event{Operation: OpDelete, PersonID: 1})
+	require.NoError(t, err)
+	// Platz für genau zwei Zeilen je Datei.
+	a, err := NewFileAuditor(path, int64(2*(len(line)+1)))
+	require.NoError(t, err)
+	defer a.Close()
+
+	for id := 1; id <= 5; id++ {
+		require.NoError(t, a.Record(context.Background(), Event{Operation: OpDelete, PersonID: id}))
+	}
+
+	ids := func(events []Event) []int {
+		var out []int
+		for _, e := range events {
+			out = append(out, e.PersonID)
+		}
+		return out
+	}
+	assert.Equal(t, []int{5}, ids(leseZeilen(t, path)))
+	assert.Equal(t, []int{3, 4}, ids(leseZeilen(t, path+".1")), "ältere sicherungen werden ersetzt")
+}
+
+func TestFileAuditor_NachCloseFehler(t *testing.T) {
+	a, err := NewFileAuditor(filepath.Join(t.TempDir(), "audit.log"), 0)
+	require.NoError(t, err)
+	require.NoError(t, a.Close())
+
+	assert.Error(t, a.Record(context.Background(), Event{Operation: OpCreate}))
+}
+
+func TestNewFileAuditor_VerzeichnisFehlt(t *testing.T) {
+	_, err := NewFileAuditor(filepath.Join(t.TempDir(), "fehlt", "audit.log"), 0)
+	assert.Error(t, err)
+}
+
+// ─── LogAuditor ───────────────────────────────────────────────────────────────
+
+func TestLogAuditor(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	a := NewLogAuditor(zap.New(core))
+
+	after := domain.Person{ID: 7, Name: "Anna"}
+	require.NoError(t, a.Record(context.Background(), Event{Operation: OpCreate, PersonID: 7, APIKey: "sha256:01020304", After: &after}))
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "audit", entry.LoggerName)
+	fields := entry.ContextMap()
+	assert.Equal(t, OpCreate, fields["operation"])
+	assert.Equal(t, int64(7), fields["person_id"])
+	assert.Equal(t, "sha256:01020304", fields["api_key"])
+	assert.Equal(t, &after, fields["after"])
+}
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileAuditor schreibt jedes Ereignis als JSON-Zeile in eine Datei. Würde
+// die Datei maxBytes überschreiten, wird sie nach <pfad>.1 verschoben und
+// neu begonnen; eine ältere Sicherung wird dabei ersetzt.
+type FileAuditor struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+// NewFileAuditor öffnet path zum Anhängen und legt die Datei bei Bedarf an.
+// maxBytes <= 0 schaltet die Rotation ab.
+func NewFileAuditor(path string, maxBytes int64) (*FileAuditor, error) {
+	a := &FileAuditor{path: path, maxBytes: maxBytes}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *FileAuditor) open() error {
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("audit-datei öffnen: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("audit-datei prüfen: %w", err)
+	}
+	a.file, a.size = f, info.Size()
+	return nil
+}
+
+// Record hängt event als eine Zeile an.
+func (a *FileAuditor) Record(_ context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("audit-eintrag kodieren: %w", err)
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return fmt.Errorf("audit-datei %s ist geschlossen", a.path)
+	}
+	// Eine leere Datei wird nie rotiert, auch wenn schon eine Zeile zu groß ist.
+	if a.maxBytes > 0 && a.size > 0 && a.size+int64(len(line)) > a.maxBytes {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("audit-eintrag schreiben: %w", err)
+	}
+	return nil
+}
+
+// rotate verschiebt die volle Datei nach <pfad>.1 und öffnet eine neue.
+func (a *FileAuditor) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return fmt.Errorf("audit-datei schließen: %w", err)
+	}
+	a.file = nil
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		// Ohne Umbenennung wird an die bisherige Datei weitergeschrieben.
+		if openErr := a.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("audit-datei rotieren: %w", err)
+	}
+	return a.open()
+}
+
+// Close schließt die Datei. Weitere Aufrufe von Record scheitern.
+func (a *FileAuditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
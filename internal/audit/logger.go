@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogAuditor schreibt Ereignisse als Info-Einträge des Loggers "audit".
+// Er ist für Umgebungen gedacht, deren Logsammlung das Audit-Log übernimmt.
+type LogAuditor struct {
+	logger *zap.Logger
+}
+
+// NewLogAuditor gibt einen LogAuditor zurück, der über logger schreibt.
+func NewLogAuditor(logger *zap.Logger) *LogAuditor {
+	return &LogAuditor{logger: logger.Named("audit")}
+}
+
+// Record protokolliert event; es scheitert nie.
+func (a *LogAuditor) Record(_ context.Context, event Event) error {
+	a.logger.Info("audit",
+		zap.Time("time", event.Time),
+		zap.String("operation", event.Operation),
+		zap.Int("person_id", event.PersonID),
+		zap.String("request_id", event.RequestID),
+		zap.String("api_key", event.APIKey),
+		zap.Any("before", event.Before),
+		zap.Any("after", event.After),
+	)
+	return nil
+}
@@ -0,0 +1,37 @@
+// Package buildinfo enthält die beim Bauen eingebetteten Versionsangaben.
+//
+// Die Werte werden per -ldflags gesetzt, z. B.:
+//
+//	go build -ldflags "-X assecor-assessment-backend/internal/buildinfo.Version=1.2.0"
+//
+// Ohne ldflags gelten die Entwicklungs-Vorgaben "dev" bzw. "unknown".
+package buildinfo
+
+import "runtime"
+
+var (
+	// Version ist die ausgelieferte Version, z. B. ein Git-Tag.
+	Version = "dev"
+	// Commit ist der Git-Commit, aus dem gebaut wurde.
+	Commit = "unknown"
+	// BuiltAt ist der Bauzeitpunkt im RFC-3339-Format.
+	BuiltAt = "unknown"
+)
+
+// Info fasst die Versionsangaben des laufenden Binaries zusammen.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuiltAt   string `json:"built_at"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get liefert die Versionsangaben samt Go-Laufzeitversion.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuiltAt:   BuiltAt,
+		GoVersion: runtime.Version(),
+	}
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// Color ist ein normalisierter Farbname wie "blau" oder "grün".
+// In JSON wird eine Farbe als kleingeschriebener Name dargestellt.
+type Color string
+
+// colorAliases bildet Schreibweisen ohne Umlaute auf die kanonischen Namen ab.
+var colorAliases = map[string]string{
+	"gruen":   "grün",
+	"tuerkis": "türkis",
+	"weiss":   "weiß",
+}
+
+// DefaultColorSpec beschreibt die sieben Standardfarben im Format von LoadColors.
+const DefaultColorSpec = "1:blau,2:grün,3:violett,4:rot,5:gelb,6:türkis,7:weiß"
+
+// colorsMu schützt ColorMap und ColorNameID, die LoadColors zur Laufzeit
+// ersetzt, sowie ColorHex.
+var colorsMu sync.RWMutex
+
+// LoadColors ersetzt den Farbsatz anhand einer Spezifikation der Form
+// "1:blau,2:grün,8:orange". IDs müssen positiv und eindeutig sein, Namen
+// eindeutig und nicht leer. Bei einem Fehler bleibt der bisherige Satz aktiv.
+func LoadColors(spec string) error {
+	byID := make(map[int]string)
+	byName := make(map[string]int)
+
+	for _, entry := range strings.Split(spec, ",") {
+		idStr, name, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return fmt.Errorf("farbeintrag %q: erwartet id:name", entry)
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil || id <= 0 {
+			return fmt.Errorf("farbeintrag %q: id muss eine positive ganzzahl sein", entry)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return fmt.Errorf("farbeintrag %q: name fehlt", entry)
+		}
+		if _, dup := byID[id]; dup {
+			return fmt.Errorf("farb-id %d mehrfach vergeben", id)
+		}
+		if _, dup := byName[name]; dup {
+			return fmt.Errorf("farbname %q mehrfach vergeben", name)
+		}
+		byID[id] = name
+		byName[name] = id
+	}
+	if len(byID) == 0 {
+		return errors.New("keine farben angegeben")
+	}
+
+	setColors(byID)
+	return nil
+}
+
+// setColors ersetzt beide Farbtabellen atomar.
+func setColors(byID map[int]string) {
+	byName := make(map[string]int, len(byID))
+	for id, name := range byID {
+		byName[name] = id
+	}
+
+	colorsMu.Lock()
+	defer colorsMu.Unlock()
+	ColorMap = byID
+	ColorNameID = byName
+}
+
+// hexPattern beschreibt einen Farbwert der Form #RRGGBB.
+var hexPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// LoadColorHex setzt Farbwerte anhand einer Spezifikation der Form
+// "blau:#1E90FF,orange:#FFA500". Die Farben müssen bekannt sein, rufe es
+// also nach LoadColors auf; Werte werden großgeschrieben. Nicht genannte
+// Farben behalten ihren Wert. Bei einem Fehler bleibt ColorHex unverändert.
+func LoadColorHex(spec string) error {
+	values := make(map[Color]string)
+	for _, entry := range strings.Split(spec, ",") {
+		name, hex, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return fmt.Errorf("farbwert %q: erwartet farbe:#RRGGBB", entry)
+		}
+		color, err := ParseColor(name)
+		if err != nil {
+			return fmt.Errorf("farbwert %q: farbe unbekannt", entry)
+		}
+		hex = strings.TrimSpace(hex)
+		if !hexPattern.MatchString(hex) {
+			return fmt.Errorf("farbwert %q: erwartet #RRGGBB", entry)
+		}
+		if _, dup := values[color]; dup {
+			return fmt.Errorf("farbwert für %q mehrfach angegeben", color)
+		}
+		values[color] = strings.ToUpper(hex)
+	}
+
+	colorsMu.Lock()
+	defer colorsMu.Unlock()
+	merged := maps.Clone(ColorHex)
+	maps.Copy(merged, values)
+	ColorHex = merged
+	return nil
+}
+
+// AllColors gibt alle bekannten Farben sortiert nach ID zurück.
+func AllColors() []Color {
+	colorsMu.RLock()
+	defer colorsMu.RUnlock()
+
+	ids := make([]int, 0, len(ColorMap))
+	for id := range ColorMap {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	out := make([]Color, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, Color(ColorMap[id]))
+	}
+	return out
+}
+
+// ParseColor normalisiert s (Groß-/Kleinschreibung, Leerraum, Umlaut-Ersatz)
+// und prüft, ob die Farbe bekannt ist. Die Fehlermeldung enthält bewusst
+// nicht die Eingabe.
+func ParseColor(s string) (Color, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+
+	colorsMu.RLock()
+	defer colorsMu.RUnlock()
+
+	if _, ok := ColorNameID[name]; !ok {
+		alias, isAlias := colorAliases[name]
+		if _, known := ColorNameID[alias]; !isAlias || !known {
+			return "", ErrInvalidColor
+		}
+		name = alias
+	}
+	return Color(name), nil
+}
+
+// ColorByID gibt die Farbe zur numerischen ID aus der CSV-Datei zurück.
+// Für eine unbekannte ID ist errors.Is(err, ErrUnknownColor) erfüllt.
+func ColorByID(id int) (Color, error) {
+	colorsMu.RLock()
+	defer colorsMu.RUnlock()
+
+	name, ok := ColorMap[id]
+	if !ok {
+		return "", i18n.Wrap(ErrUnknownColor, i18n.CodeUnknownColorID, id)
+	}
+	return Color(name), nil
+}
+
+// String gibt den Farbnamen zurück.
+func (c Color) String() string {
+	return string(c)
+}
+
+// Hex gibt den Farbwert der Form #RRGGBB zurück, "" für Farben ohne Wert.
+func (c Color) Hex() string {
+	colorsMu.RLock()
+	defer colorsMu.RUnlock()
+	return ColorHex[c]
+}
+
+// ID gibt die numerische Farb-ID zurück, 0 für unbekannte Farben.
+func (c Color) ID() int {
+	colorsMu.RLock()
+	defer colorsMu.RUnlock()
+	return ColorNameID[string(c)]
+}
@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Color
+		wantErr bool
+	}{
+		{"blau", "blau", false},
+		{"  BLAU ", "blau", false},
+		{"Grün", "grün", false},
+		{"gruen", "grün", false},
+		{"TUERKIS", "türkis", false},
+		{"weiss", "weiß", false},
+		{"WEIß", "weiß", false},
+		{"pink", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseColor(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidInput)
+				if tt.input != "" {
+					assert.NotContains(t, err.Error(), tt.input)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestColorByID(t *testing.T) {
+	c, err := ColorByID(2)
+	require.NoError(t, err)
+	assert.Equal(t, Color("grün"), c)
+	assert.Equal(t, 2, c.ID())
+	assert.Equal(t, "grün", c.String())
+
+	_, err = ColorByID(99)
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	assert.Zero(t, Color("pink").ID())
+}
+
+func TestLoadColors(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, LoadColors(DefaultColorSpec)) })
+
+	require.NoError(t, LoadColors(DefaultColorSpec+", 8:Orange"))
+
+	c, err := ColorByID(8)
+	require.NoError(t, err)
+	assert.Equal(t, Color("orange"), c)
+
+	parsed, err := ParseColor("ORANGE")
+	require.NoError(t, err)
+	assert.Equal(t, 8, parsed.ID())
+	assert.Len(t, AllColors(), 8)
+	assert.Equal(t, Color("orange"), AllColors()[7])
+}
+
+func TestLoadColors_ErsetztStandardfarben(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, LoadColors(DefaultColorSpec)) })
+
+	require.NoError(t, LoadColors("10:schwarz,20:grün"))
+
+	_, err := ParseColor("blau")
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, err = ColorByID(1)
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	gruen, err := ParseColor("gruen")
+	require.NoError(t, err)
+	assert.Equal(t, 20, gruen.ID())
+	assert.Equal(t, []Color{"schwarz", "grün"}, AllColors())
+}
+
+func TestLoadColors_Ungueltig(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, LoadColors(DefaultColorSpec)) })
+
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"leer", ""},
+		{"ohne doppelpunkt", "1blau"},
+		{"id kein integer", "x:blau"},
+		{"id null", "0:blau"},
+		{"id negativ", "-1:blau"},
+		{"name leer", "1: "},
+		{"doppelte id", "1:blau,1:rot"},
+		{"doppelter name", "1:blau,2:Blau"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, LoadColors(tt.spec))
+			assert.Len(t, AllColors(), 7, "bisheriger farbsatz bleibt aktiv")
+		})
+	}
+}
+
+func TestDefaultColorSpec_EntsprichtColorMap(t *testing.T) {
+	before := AllColors()
+	require.NoError(t, LoadColors(DefaultColorSpec))
+	assert.Equal(t, before, AllColors())
+}
+
+// standardHexNachTest stellt ColorHex nach dem Test wieder her.
+func standardHexNachTest(t *testing.T) {
+	before := maps.Clone(ColorHex)
+	t.Cleanup(func() {
+		colorsMu.Lock()
+		defer colorsMu.Unlock()
+		ColorHex = before
+	})
+}
+
+func TestColorHex_JedeStandardfarbe(t *testing.T) {
+	for _, c := range AllColors() {
+		assert.Regexp(t, `^#[0-9A-F]{6}$`, c.Hex(), c)
+	}
+	assert.Equal(t, "#0000FF", Color("blau").Hex())
+	assert.Empty(t, Color("pink").Hex())
+}
+
+func TestLoadColorHex(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, LoadColors(DefaultColorSpec)) })
+	standardHexNachTest(t)
+	require.NoError(t, LoadColors(DefaultColorSpec+",8:orange"))
+
+	require.NoError(t, LoadColorHex("Blau:#1e90ff, orange:#FFA500"))
+
+	assert.Equal(t, "#1E90FF", Color("blau").Hex(), "ersetzt und großgeschrieben")
+	assert.Equal(t, "#FFA500", Color("orange").Hex(), "ergänzt")
+	assert.Equal(t, "#FF0000", Color("rot").Hex(), "nicht genannte farben bleiben")
+}
+
+func TestLoadColorHex_Ungueltig(t *testing.T) {
+	standardHexNachTest(t)
+
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{"leer", "", `farbwert "": erwartet farbe:#RRGGBB`},
+		{"ohne doppelpunkt", "blau#0000FF", `farbwert "blau#0000FF": erwartet farbe:#RRGGBB`},
+		{"unbekannte farbe", "pink:#FFC0CB", `farbwert "pink:#FFC0CB": farbe unbekannt`},
+		{"ohne raute", "blau:0000FF", `farbwert "blau:0000FF": erwartet #RRGGBB`},
+		{"kurzform", "blau:#00F", `farbwert "blau:#00F": erwartet #RRGGBB`},
+		{"keine hexziffern", "blau:#GGGGGG", `farbwert "blau:#GGGGGG": erwartet #RRGGBB`},
+		{"doppelt", "blau:#000000,Blau:#111111", `farbwert für "blau" mehrfach angegeben`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, LoadColorHex("rot:#111111,"+tt.spec), tt.want)
+			assert.Equal(t, "#FF0000", Color("rot").Hex(), "bei einem fehler bleibt ColorHex unverändert")
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// PersonFilter beschreibt eine Abfrage über Personen. Nullwerte bedeuten
+// jeweils „keine Einschränkung“; gesetzte Kriterien werden UND-verknüpft.
+// Ergebnisse sind stets nach ID sortiert.
+type PersonFilter struct {
+	Color        Color     // nur Personen mit dieser Lieblingsfarbe
+	CreatedAfter time.Time // nur Personen, die danach angelegt wurden
+	Limit        int       // max. Anzahl Treffer; 0 = unbegrenzt
+	Offset       int       // Anzahl übersprungener Treffer
+
+	IncludeDeleted bool // auch vorläufig gelöschte Personen liefern
+}
+
+// Matches meldet, ob p alle Kriterien des Filters erfüllt. Limit und Offset
+// bleiben dabei unberücksichtigt.
+func (f PersonFilter) Matches(p Person) bool {
+	if p.Deleted() && !f.IncludeDeleted {
+		return false
+	}
+	if f.Color != "" && p.Color != f.Color {
+		return false
+	}
+	return f.CreatedAfter.IsZero() || p.CreatedAt.After(f.CreatedAfter)
+}
+
+// Paginate gibt den Ausschnitt [offset, offset+limit) von persons zurück.
+// limit 0 bedeutet unbegrenzt; das Ergebnis ist nie nil.
+func Paginate(persons []Person, limit, offset int) []Person {
+	if offset >= len(persons) {
+		return []Person{}
+	}
+	persons = persons[offset:]
+	if limit > 0 && limit < len(persons) {
+		persons = persons[:limit]
+	}
+	return persons
+}
+
+// SortFields sind die Felder der JSON-Darstellung, nach denen sich
+// Personenlisten sortieren lassen.
+var SortFields = []string{"id", "name", "lastname", "zipcode", "city", "created_at", "updated_at"}
+
+// Order ist die Sortierung einer Personenliste nach Field, mit Desc
+// absteigend. Bei gleichem Wert entscheidet die ID aufsteigend, damit Seiten
+// über limit und offset stabil bleiben. Der Nullwert sortiert nach ID.
+type Order struct {
+	Field string
+	Desc  bool
+}
+
+// ParseOrder liest eine Sortierung wie "lastname" oder "-lastname"
+// (absteigend). "" ergibt den Nullwert. Ein Feld außerhalb von SortFields
+// ergibt ErrInvalidInput.
+func ParseOrder(s string) (Order, error) {
+	if s == "" {
+		return Order{}, nil
+	}
+	field, desc := strings.CutPrefix(s, "-")
+	o := Order{Field: field, Desc: desc}
+	return o, o.Validate()
+}
+
+// Validate meldet ErrInvalidInput, wenn Field nicht in SortFields steht;
+// leer ist es nur im Nullwert erlaubt. Repositories, die Field in eine
+// Abfrage übernehmen, prüfen damit auch Sortierungen, die nicht aus
+// ParseOrder stammen.
+func (o Order) Validate() error {
+	if o != (Order{}) && !slices.Contains(SortFields, o.Field) {
+		return i18n.Wrap(ErrInvalidInput, i18n.CodeUnknownSortField, o.Field, strings.Join(SortFields, ", "))
+	}
+	return nil
+}
+
+// IsZero meldet, ob o nach ID aufsteigend sortiert.
+func (o Order) IsZero() bool {
+	return (o.Field == "" || o.Field == "id") && !o.Desc
+}
+
+// Compare vergleicht a und b nach o, für slices.SortFunc. Zeichenketten
+// werden Byte für Byte verglichen, wie SQLite es ohne COLLATE tut.
+func (o Order) Compare(a, b Person) int {
+	var c int
+	switch o.Field {
+	case "name":
+		c = strings.Compare(a.Name, b.Name)
+	case "lastname":
+		c = strings.Compare(a.Lastname, b.Lastname)
+	case "zipcode":
+		c = strings.Compare(a.Zipcode, b.Zipcode)
+	case "city":
+		c = strings.Compare(a.City, b.City)
+	case "created_at":
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if o.Desc {
+		c = -c
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	return c
+}
+
+// SortPersons sortiert persons nach o.
+func SortPersons(persons []Person, o Order) {
+	if o.IsZero() {
+		slices.SortFunc(persons, func(a, b Person) int { return cmp.Compare(a.ID, b.ID) })
+		return
+	}
+	slices.SortFunc(persons, o.Compare)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Order
+		wantErr bool
+	}{
+		{"", Order{}, false},
+		{"lastname", Order{Field: "lastname"}, false},
+		{"-created_at", Order{Field: "created_at", Desc: true}, false},
+		{"-id", Order{Field: "id", Desc: true}, false},
+		{"color", Order{}, true},
+		{"lastname; DROP TABLE persons", Order{}, true},
+		{"-", Order{}, true},
+		{"--name", Order{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseOrder(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidInput)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSortPersons_GleichstandNachID(t *testing.T) {
+	persons := []Person{
+		{ID: 5, Lastname: "Müller"},
+		{ID: 4, Lastname: "Zander"},
+		{ID: 1, Lastname: "Müller"},
+		{ID: 3, Lastname: "Johnson"},
+	}
+	ids := func() []int {
+		out := make([]int, len(persons))
+		for i, p := range persons {
+			out[i] = p.ID
+		}
+		return out
+	}
+
+	SortPersons(persons, Order{Field: "lastname"})
+	assert.Equal(t, []int{3, 1, 5, 4}, ids())
+
+	SortPersons(persons, Order{Field: "lastname", Desc: true})
+	assert.Equal(t, []int{4, 1, 5, 3}, ids(), "absteigend, gleichstand weiter nach id aufsteigend")
+
+	SortPersons(persons, Order{})
+	assert.Equal(t, []int{1, 3, 4, 5}, ids())
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// LoadReport beschreibt, was beim Laden einer Datenquelle übernommen und was
+// verworfen wurde, damit Betreiber fehlende Datensätze ohne Log-Suche finden.
+type LoadReport struct {
+	Source   string          `json:"source"`
+	LoadedAt time.Time       `json:"loaded_at"`
+	Loaded   int             `json:"loaded"`
+	Overflow int             `json:"overflow"` // gültige Datensätze jenseits der Kapazitätsgrenze, nicht geladen
+	Skipped  []SkippedRecord `json:"skipped"`
+}
+
+// SkippedRecord ist ein beim Laden verworfener Datensatz.
+type SkippedRecord struct {
+	Line   int      `json:"line"`   // erste Quellzeile, 1-basiert
+	Fields []string `json:"fields"` // Felder wie in der Quelle, ohne Leerraum
+	Reason string   `json:"reason"`
+}
@@ -1,14 +1,66 @@
 package domain
 
-import "errors"
+import (
+	"time"
 
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// Die Fehler tragen Codes aus dem Katalog in i18n. Fehler mit Details
+// umschließen sie per i18n.Wrap, damit errors.Is weiter greift.
 var (
-	ErrNotFound        = errors.New("nicht gefunden")
-	ErrInvalidInput    = errors.New("ungültige eingabe")
-	ErrCapacityReached = errors.New("kapazitätsgrenze erreicht")
+	ErrNotFound        = i18n.New(i18n.CodeNotFound)
+	ErrInvalidInput    = i18n.New(i18n.CodeInvalidInput)
+	ErrCapacityReached = i18n.New(i18n.CodeCapacityReached)
+	ErrVersionConflict = i18n.New(i18n.CodeVersionConflict)
+
+	// ErrGone kennzeichnet eine vorläufig gelöschte Person. Er umschließt
+	// ErrNotFound, sodass Aufrufer ohne Sonderbehandlung 404 melden.
+	ErrGone = i18n.Wrap(ErrNotFound, i18n.CodeGone)
+
+	// ErrInvalidColor kennzeichnet eine unbekannte Farbe. Er umschließt
+	// ErrInvalidInput.
+	ErrInvalidColor = i18n.Wrap(ErrInvalidInput, i18n.CodeInvalidColor)
+
+	// ErrUnknownColor kennzeichnet eine Farb-ID ohne Farbe im aktiven
+	// Farbsatz, siehe ColorByID. Er umschließt ErrInvalidInput.
+	ErrUnknownColor = i18n.Wrap(ErrInvalidInput, i18n.CodeUnknownColor)
+
+	// ErrNoPersonsWithColor meldet, dass eine gültige Farbe auf keine Person
+	// passt. Er umschließt ErrNotFound; ob das ein Fehler oder eine leere
+	// Liste ist, entscheidet der Aufrufer.
+	ErrNoPersonsWithColor = i18n.Wrap(ErrNotFound, i18n.CodeNoPersonsWithColor)
 )
 
+// VersionConflictError meldet, dass eine Person seit dem Lesen durch den
+// Aufrufer geändert wurde. Current ist die aktuell gespeicherte Version.
+// errors.Is(err, ErrVersionConflict) ist für diesen Fehler erfüllt.
+type VersionConflictError struct {
+	ID      int
+	Current int
+}
+
+func (e *VersionConflictError) Error() string {
+	return e.Localize(i18n.Default)
+}
+
+// ErrorCode und Localize erfüllen i18n.Localized.
+func (e *VersionConflictError) ErrorCode() i18n.Code {
+	return i18n.CodePersonVersionConflict
+}
+
+func (e *VersionConflictError) Localize(lang i18n.Lang) string {
+	return i18n.Message(lang, i18n.CodePersonVersionConflict, e.ID, e.Current)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
 // ColorMap bildet Farben-IDs aus der CSV-Datei auf ihre Farbnamen ab.
+// Vorbelegt sind die sieben Standardfarben; LoadColors kann sie ersetzen.
+// Zugriffe außerhalb dieses Pakets sollten über ColorByID, ParseColor und
+// AllColors erfolgen.
 var ColorMap = map[int]string{
 	1: "blau",
 	2: "grün",
@@ -30,12 +82,71 @@ var ColorNameID = map[string]int{
 	"weiß":    7,
 }
 
+// ColorHex bildet Farben auf Farbwerte der Form #RRGGBB ab, mit denen
+// Frontends die Farbe darstellen. Vorbelegt sind die sieben Standardfarben;
+// LoadColorHex kann Werte ersetzen und ergänzen. Zugriffe außerhalb dieses
+// Pakets sollten über Color.Hex erfolgen.
+var ColorHex = map[Color]string{
+	"blau":    "#0000FF",
+	"grün":    "#00FF00",
+	"violett": "#EE82EE",
+	"rot":     "#FF0000",
+	"gelb":    "#FFFF00",
+	"türkis":  "#40E0D0",
+	"weiß":    "#FFFFFF",
+}
+
+// PersonStats fasst den Personenbestand zusammen: Gesamtzahl sowie Anzahl je
+// Lieblingsfarbe und je Stadt.
+type PersonStats struct {
+	Total   int            `json:"total"`
+	ByColor map[Color]int  `json:"byColor"`
+	ByCity  map[string]int `json:"byCity"`
+}
+
+// Capacity beschreibt die Auslastung der Kapazitätsgrenze. Used zählt nur
+// nicht gelöschte Personen; Max 0 bedeutet unbegrenzt.
+type Capacity struct {
+	Used int `json:"used"`
+	Max  int `json:"max"`
+}
+
+// Remaining gibt die Anzahl freier Plätze zurück, -1 bei unbegrenzter Kapazität.
+func (c Capacity) Remaining() int {
+	if c.Max <= 0 {
+		return -1
+	}
+	return max(c.Max-c.Used, 0)
+}
+
+// ColorCount gibt an, wie viele Personen eine Farbe als Lieblingsfarbe haben.
+type ColorCount struct {
+	Color Color `json:"color"`
+	Count int   `json:"count"`
+}
+
 // Person repräsentiert eine Person mit ihrer Lieblingsfarbe.
+// CreatedAt und UpdatedAt werden von der Service-Schicht gesetzt, für aus
+// der CSV geladene Personen auf den Ladezeitpunkt. Bei Datensätzen ohne
+// bekannte Zeitstempel (z. B. migrierte SQLite-Zeilen) fehlen sie im JSON.
+// Version vergeben die Repositories: 1 beim Anlegen, +1 bei jeder Änderung.
+// DeletedAt ist bei vorläufig gelöschten Personen gesetzt; solche Personen
+// erscheinen in keiner Abfrage, bis sie wiederhergestellt oder endgültig
+// entfernt werden.
 type Person struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Lastname string `json:"lastname"`
-	Zipcode  string `json:"zipcode"`
-	City     string `json:"city"`
-	Color    string `json:"color"`
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Lastname  string    `json:"lastname"`
+	Zipcode   string    `json:"zipcode"`
+	City      string    `json:"city"`
+	Color     Color     `json:"color"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+	Version   int       `json:"version"`
+	DeletedAt time.Time `json:"deleted_at,omitzero"`
+}
+
+// Deleted meldet, ob p vorläufig gelöscht ist.
+func (p Person) Deleted() bool {
+	return !p.DeletedAt.IsZero()
 }
@@ -0,0 +1,60 @@
+package env
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// dotEnvFile ist die Datei, aus der MustLoad Werte für die lokale
+// Entwicklung liest. Sie ist relativ zum Arbeitsverzeichnis.
+const dotEnvFile = ".env"
+
+// readDotEnv liest Zeilen der Form SCHLÜSSEL=wert aus path. Leere Zeilen und
+// Kommentare mit # überspringt es, ein vorangestelltes "export " ignoriert es
+// und einen Wert in einfachen oder doppelten Anführungszeichen übernimmt es
+// ohne diese. Fehlt die Datei, sind Ergebnis und Fehler leer; jede
+// ungültige Zeile ist ein eigener *ParseError.
+func readDotEnv(path string) (map[string]string, []error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s lesen: %w", path, err)}
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	var errs []error
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			errs = append(errs, &ParseError{Key: fmt.Sprintf("%s:%d", path, n), Value: line, Want: "SCHLÜSSEL=wert"})
+			continue
+		}
+		values[key] = unquote(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("%s lesen: %w", path, err))
+	}
+	return values, errs
+}
+
+// unquote entfernt ein umschließendes Paar einfacher oder doppelter
+// Anführungszeichen.
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
@@ -1,51 +1,386 @@
 package env
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Config enthält alle konfigurierbaren Werte der Anwendung, die über Umgebungsvariablen gesetzt werden können.
 type Config struct {
-	ServerAddr  string  // SERVER_ADDR – Adresse des HTTP-Servers (Standard: ":8081")
-	CSVFilePath string  // CSV_FILE_PATH – Path zur CSV-Datei (Standard: "sample-input.csv")
-	DataSource  string  // DATA_SOURCE – "csv" oder "sqlite" (Standard: "csv")
-	RateLimit   float64 // RATE_LIMIT – Erlaubte Anfragen pro Sekunde (Standard: 100)
-	MaxPersons  int     // MAX_PERSONS – Max. Anzahl Personen im Speicher (Standard: 10000)
+	ServerAddr   string  // SERVER_ADDR – TCP-Adresse oder "unix:/pfad.sock" für einen Unix-Socket (Standard: ":8081")
+	BasePath     string  // BASE_PATH – Präfix aller Routen, z. B. "/api/v1" hinter einem Gateway (Standard: "" = ohne Präfix)
+	CSVFilePath  string  // CSV_FILE_PATH – Pfad, http(s)-URL, "s3://bucket/key" oder "-" für stdin (Standard: "sample-input.csv")
+	CSVDelimiter rune    // CSV_DELIMITER – Feldtrennzeichen der CSV-Datei (Standard: ",")
+	DataSource   string  // DATA_SOURCE – "csv", "json", "sqlite", "memory" oder "csv+sqlite" (Standard: "csv")
+	RateLimit    float64 // RATE_LIMIT – Erlaubte Anfragen pro Sekunde; 0 = unbegrenzt, negative Werte sind ungültig (Standard: 100)
+	MaxPersons   int     // MAX_PERSONS – Max. Anzahl Personen im Speicher (Standard: 10000)
+	Colors       string  // COLORS – eigener Farbsatz, z. B. "1:blau,2:grün,8:orange" (Standard: die sieben Standardfarben)
+	ColorHex     string  // COLOR_HEX – eigene Farbwerte für color_hex und GET /colors, z. B. "blau:#1E90FF,orange:#FFA500" (Standard: Werte der Standardfarben)
+
+	// Die Zeitlimits gehen unverändert an http.Server; 0 bedeutet dort jeweils kein Limit.
+	ReadTimeout       time.Duration // READ_TIMEOUT – max. Dauer für das Lesen einer Anfrage samt Body (Standard: 10s)
+	ReadHeaderTimeout time.Duration // READ_HEADER_TIMEOUT – max. Dauer für das Lesen der Header; 0 = READ_TIMEOUT (Standard: 0)
+	WriteTimeout      time.Duration // WRITE_TIMEOUT – max. Dauer für das Schreiben einer Antwort; Streams setzen die Frist je Block neu (Standard: 10s)
+	IdleTimeout       time.Duration // IDLE_TIMEOUT – max. Wartezeit auf die nächste Anfrage einer Keep-Alive-Verbindung (Standard: 30s)
+	MaxHeaderBytes    int           // MAX_HEADER_BYTES – max. Größe der Anfrage-Header; 0 = Voreinstellung von net/http (1 MiB) (Standard: 0)
+
+	RateLimitRead  float64 // RATE_LIMIT_READ – Anfragen pro Sekunde für GET/HEAD/OPTIONS; 0 = RATE_LIMIT, < 0 = unbegrenzt (Standard: 0)
+	RateLimitWrite float64 // RATE_LIMIT_WRITE – Anfragen pro Sekunde für alle übrigen Methoden; 0 = RATE_LIMIT, < 0 = unbegrenzt (Standard: 0)
+
+	// Die Middleware-Schalter sind verneint, damit eine leere Config alles aktiviert.
+	DisableRateLimit  bool // ENABLE_RATE_LIMIT – "false" schaltet das Rate-Limit ab, z. B. hinter einem Gateway (Standard: true)
+	DisableRequestLog bool // ENABLE_REQUEST_LOG – "false" schaltet das Zugriffslog ab (Standard: true)
+	DisableRecovery   bool // ENABLE_RECOVERY – "false" überlässt Panics dem net/http-Server (Standard: true)
+
+	ExemptPaths []string // EXEMPT_PATHS – kommagetrennte Routenmuster ohne Rate-Limit, nur mit Debug-Zugriffslog; "*" am Ende = Präfix (Standard: "/healthz,/readyz,/metrics")
+
+	MaxConcurrent           int           // MAX_CONCURRENT_REQUESTS – max. gleichzeitig bearbeitete Anfragen; 0 = unbegrenzt (Standard: 0)
+	ConcurrencyQueueTimeout time.Duration // CONCURRENCY_QUEUE_TIMEOUT – max. Wartezeit auf einen freien Platz, z. B. "250ms" (Standard: 0 = sofort ablehnen)
+
+	SQLitePath        string        // SQLITE_PATH – Datei der SQLite-Datenbank; ":memory:" hält sie nur im Speicher (Standard: ":memory:")
+	DBMaxOpenConns    int           // DB_MAX_OPEN_CONNS – max. offene DB-Verbindungen; 0 = unbegrenzt (Standard: 0)
+	DBMaxIdleConns    int           // DB_MAX_IDLE_CONNS – max. ruhende DB-Verbindungen; 0 = Voreinstellung von database/sql (2)
+	DBConnMaxLifetime time.Duration // DB_CONN_MAX_LIFETIME – Höchstlebensdauer einer DB-Verbindung; 0 = unbegrenzt (Standard: 0)
+
+	CSVFetchTimeout  time.Duration // CSV_FETCH_TIMEOUT – max. Dauer für das Laden der CSV per HTTP oder S3 (Standard: 30s)
+	CSVFetchMaxBytes int64         // CSV_FETCH_MAX_BYTES – max. Größe einer per HTTP oder S3 geladenen CSV (Standard: 67108864)
+	CSVFetchToken    string        // CSV_FETCH_TOKEN – Bearer-Token für das Laden per HTTP(S); leer = ohne Authorization-Header
+	CSVCachePath     string        // CSV_CACHE_PATH – lokale Kopie einer entfernten CSV, Rückfall bei Reload; "off" = keine Kopie (Standard: <tmp>/persons-cache.csv)
+	CSVStrict        bool          // CSV_STRICT – Start abbrechen, wenn ein Datensatz der CSV ungültig ist (Standard: false)
+	CSVUnknownColor  string        // CSV_UNKNOWN_COLOR – "skip", "default:<farbe>" oder "error" für unbekannte Farb-IDs (Standard: "skip")
+	CSVOverflow      string        // CSV_OVERFLOW – "truncate" lädt höchstens MAX_PERSONS Personen, "error" bricht den Start ab (Standard: "truncate")
+	CSVAllowMissing  bool          // CSV_ALLOW_MISSING – fehlt die CSV-Datei, leer starten statt abzubrechen (Standard: false)
+
+	S3Endpoint        string // S3_ENDPOINT – Basis-URL eines S3-kompatiblen Speichers, z. B. "http://minio:9000" (Standard: AWS)
+	S3Region          string // AWS_REGION – Region für die Signatur (Standard: "us-east-1")
+	S3AccessKeyID     string // AWS_ACCESS_KEY_ID – leer = unsignierte Anfragen
+	S3SecretAccessKey string // AWS_SECRET_ACCESS_KEY
+	S3SessionToken    string // AWS_SESSION_TOKEN – nur für temporäre Zugangsdaten
+
+	JSONFilePath string // JSON_FILE_PATH – JSON-Datei mit einem Array von Personen für DATA_SOURCE=json (Standard: "persons.json")
+
+	CompressMinBytes int // COMPRESS_MIN_BYTES – Mindestgröße für gzip-komprimierte Antworten (Standard: 1024)
+
+	CacheMaxAgeList time.Duration // CACHE_MAX_AGE_LIST – max-age erfolgreicher GET-Antworten auf Listen wie /persons, /cities; < 0 = no-store (Standard: 0s)
+	CacheMaxAgeItem time.Duration // CACHE_MAX_AGE_ITEM – max-age erfolgreicher GET-Antworten auf /persons/{id}; < 0 = no-store (Standard: 0s)
+
+	BasicAuthUser string // BASIC_AUTH_USER – Benutzer für schreibende Endpunkte; leer deaktiviert Basic-Auth
+	BasicAuthPass string // BASIC_AUTH_PASS – Passwort für schreibende Endpunkte
+
+	APIKeys []string // API_KEYS – kommagetrennte API-Schlüssel für X-API-Key; leer deaktiviert die Prüfung
+
+	MaxBodyBytes int64 // MAX_BODY_BYTES – max. Größe eines Anfrage-Bodys in Bytes (Standard: 1048576)
+	MaxIDs       int   // MAX_IDS_PER_REQUEST – max. Anzahl IDs in GET /persons?ids= (Standard: 100)
+
+	MaxPageSize         int  // MAX_PAGE_SIZE – größtes erlaubtes ?limit= der Listen-Endpunkte; 0 = unbegrenzt (Standard: 100)
+	RejectOversizedPage bool // REJECT_OVERSIZED_PAGE – größeres limit mit 400 ablehnen statt kappen (Standard: false)
+
+	AllowDestructive bool // ALLOW_DESTRUCTIVE – erlaubt Massenlöschungen wie DELETE /persons (Standard: false)
+	RequireIfMatch   bool // REQUIRE_IF_MATCH – PUT/PATCH ohne If-Match mit 428 ablehnen (Standard: true)
+	PutUpsert        bool // PUT_UPSERT – PUT auf eine unbekannte ID legt die Person unter dieser ID an statt 404 (Standard: false)
+	ReadOnly         bool // READ_ONLY – schreibende Anfragen mit 405 ablehnen; per POST /admin/readonly umschaltbar (Standard: false)
+
+	EnableColorCache bool // ENABLE_COLOR_CACHE – Ergebnisse von GET /persons/color/{color} bis zur nächsten Änderung im Speicher halten (Standard: false)
+
+	ShowGone            bool          // SHOW_GONE – GET /persons/{id} meldet gelöschte Personen mit 410 statt 404 (Standard: false)
+	SoftDeleteRetention time.Duration // SOFT_DELETE_RETENTION – Aufbewahrung gelöschter Personen; 0 = nie endgültig löschen (Standard: 720h)
+	PurgeInterval       time.Duration // PURGE_INTERVAL – Abstand der Läufe, die abgelaufene Personen entfernen (Standard: 1h)
+
+	LogLevel      string // LOG_LEVEL – "debug", "info", "warn" oder "error" (Standard: "info")
+	LogFormat     string // LOG_FORMAT – "json" oder "console" (Standard: "json")
+	LogSampleRate int    // LOG_SAMPLE_RATE – nur jede n-te erfolgreiche Anfrage loggen (Standard: 1)
+
+	LogAccessFormat string // LOG_ACCESS_FORMAT – "zap" oder "combined" für Zugriffslogs im NCSA-Format auf stdout (Standard: "zap")
+
+	AuditLog      string // AUDIT_LOG – Ziel des Audit-Logs: Dateipfad für JSON-Zeilen oder "log" für den Anwendungslogger; leer deaktiviert es
+	AuditMaxBytes int64  // AUDIT_MAX_BYTES – Größe, ab der die Audit-Datei nach <pfad>.1 rotiert; 0 = nie (Standard: 10485760)
+	AuditStrict   bool   // AUDIT_STRICT – Änderungen mit 500 beantworten, wenn der Audit-Eintrag scheitert (Standard: false)
+
+	EnableMetrics          bool          // ENABLE_METRICS – Prometheus-Metriken unter /metrics bereitstellen (Standard: false)
+	MetricsRefreshInterval time.Duration // METRICS_REFRESH_INTERVAL – Abstand, in dem persons_total neu gezählt wird; 0 = nie (Standard: 0)
+
+	EnablePprof bool   // ENABLE_PPROF – pprof und /debug/runtime auf DEBUG_ADDR bereitstellen (Standard: false)
+	DebugAddr   string // DEBUG_ADDR – Adresse des Debug-Listeners, nie der öffentliche Port (Standard: "localhost:6060")
+
+	TracingEndpoint    string  // TRACING_OTLP_ENDPOINT – OTLP/HTTP-URL des Collectors, z. B. "http://tempo:4318"; leer deaktiviert Tracing
+	TracingSampleRatio float64 // TRACING_SAMPLE_RATIO – Anteil aufgezeichneter neuer Traces von 0 bis 1 (Standard: 1)
+	TracingServiceName string  // TRACING_SERVICE_NAME – service.name der Spans (Standard: "assecor-assessment-backend")
+
+	TLSCertFile     string // TLS_CERT_FILE – Server-Zertifikat (PEM); aktiviert HTTPS zusammen mit TLS_KEY_FILE
+	TLSKeyFile      string // TLS_KEY_FILE – privater Schlüssel zum Server-Zertifikat (PEM)
+	TLSClientCAFile string // TLS_CLIENT_CA_FILE – CA für Client-Zertifikate; aktiviert mTLS (optional)
+
+	errs     []error   // nicht umwandelbare Werte und Fehler der .env-Datei, siehe Validate
+	settings []Setting // wirksame Werte mit Herkunft, siehe Settings
+}
+
+// Load liest die Konfiguration wie MustLoad. Lässt sich ein gesetzter Wert
+// nicht umwandeln oder die .env-Datei nicht lesen, ist der Fehler ein
+// *ValidationError mit allen solchen Problemen; errors.As gibt daraus jeden
+// *ParseError frei. Die übrigen Prüfungen übernimmt Validate.
+func Load() (Config, error) {
+	cfg := MustLoad()
+	if len(cfg.errs) > 0 {
+		return cfg, newValidationError(nil, cfg.errs)
+	}
+	return cfg, nil
 }
 
-// MustLoad liest die Konfiguration aus Umgebungsvariablen.
+// MustLoad liest die Konfiguration aus Umgebungsvariablen und, für die
+// lokale Entwicklung, aus der Datei .env im Arbeitsverzeichnis, sofern sie
+// existiert. Eine gesetzte Umgebungsvariable hat Vorrang vor der .env-Datei,
+// beide vor der Vorgabe; ein leerer Wert gilt als nicht gesetzt. Werte, die
+// sich nicht umwandeln lassen, ersetzt MustLoad durch die Vorgabe und merkt
+// sie für Validate vor.
 func MustLoad() Config {
-	return Config{
-		ServerAddr:  getOr("SERVER_ADDR", ":8081"),
-		CSVFilePath: getOr("CSV_FILE_PATH", "sample-input.csv"),
-		DataSource:  getOr("DATA_SOURCE", "csv"),
-		RateLimit:   getFloatOr("RATE_LIMIT", 100),
-		MaxPersons:  getIntOr("MAX_PERSONS", 10_000),
+	var l loader
+	l.dotenv, l.errs = readDotEnv(dotEnvFile)
+	cfg := Config{
+		ServerAddr:   l.getOr("SERVER_ADDR", ":8081"),
+		BasePath:     l.getOr("BASE_PATH", ""),
+		CSVFilePath:  l.getOr("CSV_FILE_PATH", "sample-input.csv"),
+		CSVDelimiter: l.getRuneOr("CSV_DELIMITER", ','),
+		DataSource:   l.getOr("DATA_SOURCE", "csv"),
+		RateLimit:    l.getFloatOr("RATE_LIMIT", 100),
+		MaxPersons:   l.getIntOr("MAX_PERSONS", 10_000),
+		Colors:       l.getOr("COLORS", ""),
+		ColorHex:     l.getOr("COLOR_HEX", ""),
+
+		ReadTimeout:       l.getDurationOr("READ_TIMEOUT", 10*time.Second),
+		ReadHeaderTimeout: l.getDurationOr("READ_HEADER_TIMEOUT", 0),
+		WriteTimeout:      l.getDurationOr("WRITE_TIMEOUT", 10*time.Second),
+		IdleTimeout:       l.getDurationOr("IDLE_TIMEOUT", 30*time.Second),
+		MaxHeaderBytes:    l.getIntOr("MAX_HEADER_BYTES", 0),
+
+		RateLimitRead:  l.getFloatOr("RATE_LIMIT_READ", 0),
+		RateLimitWrite: l.getFloatOr("RATE_LIMIT_WRITE", 0),
+
+		DisableRateLimit:  !l.getBoolOr("ENABLE_RATE_LIMIT", true),
+		DisableRequestLog: !l.getBoolOr("ENABLE_REQUEST_LOG", true),
+		DisableRecovery:   !l.getBoolOr("ENABLE_RECOVERY", true),
+
+		ExemptPaths: l.getListOr("EXEMPT_PATHS", nil),
+
+		MaxConcurrent:           l.getIntOr("MAX_CONCURRENT_REQUESTS", 0),
+		ConcurrencyQueueTimeout: l.getDurationOr("CONCURRENCY_QUEUE_TIMEOUT", 0),
+
+		SQLitePath:        l.getOr("SQLITE_PATH", ":memory:"),
+		DBMaxOpenConns:    l.getIntOr("DB_MAX_OPEN_CONNS", 0),
+		DBMaxIdleConns:    l.getIntOr("DB_MAX_IDLE_CONNS", 0),
+		DBConnMaxLifetime: l.getDurationOr("DB_CONN_MAX_LIFETIME", 0),
+
+		CSVFetchTimeout: l.getDurationOr("CSV_FETCH_TIMEOUT", 30*time.Second),
+		CSVStrict:       l.getBoolOr("CSV_STRICT", false),
+		CSVUnknownColor: l.getOr("CSV_UNKNOWN_COLOR", "skip"),
+		CSVOverflow:     l.getOr("CSV_OVERFLOW", "truncate"),
+		CSVAllowMissing: l.getBoolOr("CSV_ALLOW_MISSING", false),
+
+		CSVFetchMaxBytes: int64(l.getIntOr("CSV_FETCH_MAX_BYTES", 64<<20)),
+		CSVFetchToken:    l.getSecret("CSV_FETCH_TOKEN"),
+		CSVCachePath:     l.getOr("CSV_CACHE_PATH", filepath.Join(os.TempDir(), "persons-cache.csv")),
+
+		S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+		S3Region:          l.getOr("AWS_REGION", "us-east-1"),
+		S3AccessKeyID:     l.getSecret("AWS_ACCESS_KEY_ID"),
+		S3SecretAccessKey: l.getSecret("AWS_SECRET_ACCESS_KEY"),
+		S3SessionToken:    l.getSecret("AWS_SESSION_TOKEN"),
+
+		JSONFilePath: l.getOr("JSON_FILE_PATH", "persons.json"),
+
+		CompressMinBytes: l.getIntOr("COMPRESS_MIN_BYTES", 1024),
+
+		CacheMaxAgeList: l.getDurationOr("CACHE_MAX_AGE_LIST", 0),
+		CacheMaxAgeItem: l.getDurationOr("CACHE_MAX_AGE_ITEM", 0),
+
+		BasicAuthUser: l.getOr("BASIC_AUTH_USER", ""),
+		BasicAuthPass: l.getSecret("BASIC_AUTH_PASS"),
+
+		APIKeys: splitList(l.getSecret("API_KEYS")),
+
+		MaxBodyBytes: int64(l.getIntOr("MAX_BODY_BYTES", 1<<20)),
+		MaxIDs:       l.getIntOr("MAX_IDS_PER_REQUEST", 100),
+
+		MaxPageSize:         l.getIntOr("MAX_PAGE_SIZE", 100),
+		RejectOversizedPage: l.getBoolOr("REJECT_OVERSIZED_PAGE", false),
+
+		AllowDestructive: l.getBoolOr("ALLOW_DESTRUCTIVE", false),
+		RequireIfMatch:   l.getBoolOr("REQUIRE_IF_MATCH", true),
+		PutUpsert:        l.getBoolOr("PUT_UPSERT", false),
+		ReadOnly:         l.getBoolOr("READ_ONLY", false),
+
+		EnableColorCache: l.getBoolOr("ENABLE_COLOR_CACHE", false),
+
+		ShowGone:            l.getBoolOr("SHOW_GONE", false),
+		SoftDeleteRetention: l.getDurationOr("SOFT_DELETE_RETENTION", 30*24*time.Hour),
+		PurgeInterval:       l.getDurationOr("PURGE_INTERVAL", time.Hour),
+
+		LogLevel:      l.getOr("LOG_LEVEL", "info"),
+		LogFormat:     l.getOr("LOG_FORMAT", "json"),
+		LogSampleRate: l.getIntOr("LOG_SAMPLE_RATE", 1),
+
+		LogAccessFormat: l.getOr("LOG_ACCESS_FORMAT", "zap"),
+
+		AuditLog:      l.getOr("AUDIT_LOG", ""),
+		AuditMaxBytes: int64(l.getIntOr("AUDIT_MAX_BYTES", 10<<20)),
+		AuditStrict:   l.getBoolOr("AUDIT_STRICT", false),
+
+		EnableMetrics:          l.getBoolOr("ENABLE_METRICS", false),
+		MetricsRefreshInterval: l.getDurationOr("METRICS_REFRESH_INTERVAL", 0),
+
+		EnablePprof: l.getBoolOr("ENABLE_PPROF", false),
+		DebugAddr:   l.getOr("DEBUG_ADDR", "localhost:6060"),
+
+		TracingEndpoint:    l.getOr("TRACING_OTLP_ENDPOINT", ""),
+		TracingSampleRatio: l.getFloatOr("TRACING_SAMPLE_RATIO", 1),
+		TracingServiceName: l.getOr("TRACING_SERVICE_NAME", "assecor-assessment-backend"),
+
+		TLSCertFile:     l.getOr("TLS_CERT_FILE", ""),
+		TLSKeyFile:      l.getOr("TLS_KEY_FILE", ""),
+		TLSClientCAFile: l.getOr("TLS_CLIENT_CA_FILE", ""),
 	}
+	cfg.errs, cfg.settings = l.errs, l.settings
+	return cfg
 }
 
-func getOr(key, fallback string) string {
+// Source gibt an, woher ein Konfigurationswert stammt.
+type Source string
+
+const (
+	SourceDefault Source = "standard" // nicht gesetzt, Vorgabe des Dienstes
+	SourceEnv     Source = "env"      // Umgebungsvariable
+	SourceDotEnv  Source = ".env"     // .env-Datei im Arbeitsverzeichnis
+)
+
+// Setting ist ein wirksamer Konfigurationswert, benannt nach seiner
+// Umgebungsvariablen. Value ist der gelesene Text oder die Vorgabe in
+// derselben Schreibweise.
+type Setting struct {
+	Key    string
+	Value  string
+	Source Source
+}
+
+// Settings gibt alle wirksamen Werte mit ihrer Herkunft in der Reihenfolge
+// der Config-Felder zurück. Geheimnisse wie Passwörter und Tokens sind
+// maskiert.
+func (c Config) Settings() []Setting {
+	return slices.Clone(c.settings)
+}
+
+// loader liest Umgebungsvariablen und die .env-Datei, hält die Herkunft
+// jedes Werts fest und sammelt Werte, die sich nicht in den erwarteten Typ
+// umwandeln lassen.
+type loader struct {
+	dotenv   map[string]string
+	errs     []error
+	settings []Setting
+}
+
+// lookup liefert den gesetzten Wert zu key und seine Herkunft; ohne Wert ist
+// das Ergebnis leer mit SourceDefault.
+func (l *loader) lookup(key string) (string, Source) {
 	if v := os.Getenv(key); v != "" {
+		return v, SourceEnv
+	}
+	if v := l.dotenv[key]; v != "" {
+		return v, SourceDotEnv
+	}
+	return "", SourceDefault
+}
+
+func (l *loader) record(key, value string, source Source) {
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source})
+}
+
+func (l *loader) invalid(key, value, want string) {
+	l.errs = append(l.errs, &ParseError{Key: key, Value: value, Want: want})
+}
+
+func (l *loader) getOr(key, fallback string) string {
+	if v, source := l.lookup(key); v != "" {
+		l.record(key, v, source)
 		return v
 	}
+	l.record(key, fallback, SourceDefault)
 	return fallback
 }
 
-func getIntOr(key string, fallback int) int {
-	if v := os.Getenv(key); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			return n
+// getSecret liest einen Wert ohne Vorgabe, dessen Inhalt Settings nur
+// maskiert zeigt.
+func (l *loader) getSecret(key string) string {
+	v, source := l.lookup(key)
+	masked := ""
+	if v != "" {
+		masked = "***"
+	}
+	l.record(key, masked, source)
+	return v
+}
+
+// parse setzt die Vorgabe fallback ein, wenn key fehlt, und meldet sonst
+// einen Wert, den conv nicht umwandeln kann, als *ParseError.
+func parse[T any](l *loader, key string, fallback T, want string, conv func(string) (T, error), format func(T) string) T {
+	v, source := l.lookup(key)
+	if v != "" {
+		if parsed, err := conv(v); err == nil {
+			l.record(key, v, source)
+			return parsed
 		}
+		l.invalid(key, v, want)
 	}
+	l.record(key, format(fallback), SourceDefault)
 	return fallback
 }
 
-func getFloatOr(key string, fallback float64) float64 {
-	if v := os.Getenv(key); v != "" {
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			return f
+func (l *loader) getIntOr(key string, fallback int) int {
+	return parse(l, key, fallback, "eine ganze zahl", strconv.Atoi, strconv.Itoa)
+}
+
+func (l *loader) getFloatOr(key string, fallback float64) float64 {
+	return parse(l, key, fallback, "eine zahl",
+		func(v string) (float64, error) { return strconv.ParseFloat(v, 64) },
+		func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) })
+}
+
+func (l *loader) getBoolOr(key string, fallback bool) bool {
+	return parse(l, key, fallback, "true oder false", strconv.ParseBool, strconv.FormatBool)
+}
+
+func (l *loader) getDurationOr(key string, fallback time.Duration) time.Duration {
+	return parse(l, key, fallback, `eine dauer wie "30s"`, time.ParseDuration, time.Duration.String)
+}
+
+// getListOr liest eine kommagetrennte Liste, siehe splitList.
+func (l *loader) getListOr(key string, fallback []string) []string {
+	return parse(l, key, fallback, "",
+		func(v string) ([]string, error) { return splitList(v), nil },
+		func(list []string) string { return strings.Join(list, ",") })
+}
+
+// splitList teilt eine kommagetrennte Liste und verwirft leere Einträge.
+func splitList(v string) []string {
+	var out []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
 		}
 	}
-	return fallback
+	return out
+}
+
+// getRuneOr liest genau ein Zeichen; ein leerer Wert ergibt fallback.
+func (l *loader) getRuneOr(key string, fallback rune) rune {
+	return parse(l, key, fallback, "genau ein zeichen", func(v string) (rune, error) {
+		if utf8.RuneCountInString(v) != 1 {
+			return 0, errors.New("kein einzelnes zeichen")
+		}
+		r, _ := utf8.DecodeRuneInString(v)
+		return r, nil
+	}, func(r rune) string { return string(r) })
 }
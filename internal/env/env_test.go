@@ -0,0 +1,341 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gueltigeConfig liefert eine Konfiguration, die Validate besteht.
+func gueltigeConfig(t *testing.T) Config {
+	t.Helper()
+	t.Setenv("DATA_SOURCE", "memory")
+	cfg := MustLoad()
+	require.NoError(t, cfg.Validate())
+	return cfg
+}
+
+func TestMustLoad_UngueltigeWerteWerdenVorgemerkt(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "abc")
+	t.Setenv("MAX_PERSONS", "zehn")
+	t.Setenv("ENABLE_RECOVERY", "vielleicht")
+	t.Setenv("PURGE_INTERVAL", "1 stunde")
+	t.Setenv("CSV_DELIMITER", ";;")
+	t.Setenv("DATA_SOURCE", "memory")
+
+	cfg := MustLoad()
+
+	assert.Equal(t, 100.0, cfg.RateLimit, "die vorgabe bleibt wirksam")
+	var invalid *ValidationError
+	require.ErrorAs(t, cfg.Validate(), &invalid)
+	assert.Equal(t, []string{
+		`CSV_DELIMITER=";;": erwartet genau ein zeichen`,
+		`RATE_LIMIT="abc": erwartet eine zahl`,
+		`MAX_PERSONS="zehn": erwartet eine ganze zahl`,
+		`ENABLE_RECOVERY="vielleicht": erwartet true oder false`,
+		`PURGE_INTERVAL="1 stunde": erwartet eine dauer wie "30s"`,
+	}, invalid.Problems)
+}
+
+func TestValidate_Bereiche(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   string
+	}{
+		{"negatives rate-limit", func(c *Config) { c.RateLimit = -1 }, "RATE_LIMIT=-1: darf nicht negativ sein, 0 schaltet die begrenzung ab"},
+		{"negative kapazität", func(c *Config) { c.MaxPersons = -5 }, "MAX_PERSONS=-5: darf nicht negativ sein, 0 bedeutet unbegrenzt"},
+		{"unbekannte datenquelle", func(c *Config) { c.DataSource = "postgres" }, `DATA_SOURCE="postgres": erwartet csv, json, sqlite, memory, csv+sqlite`},
+		{"unbekannte überlauf-regel", func(c *Config) { c.CSVOverflow = "drop" }, `CSV_OVERFLOW="drop": erwartet truncate oder error`},
+		{"unbekanntes zugriffslog", func(c *Config) { c.LogAccessFormat = "apache" }, `LOG_ACCESS_FORMAT="apache": erwartet zap oder combined`},
+		{"negative audit-größe", func(c *Config) { c.AuditMaxBytes = -1 }, "AUDIT_MAX_BYTES=-1: darf nicht negativ sein, 0 schaltet die rotation ab"},
+		{"strenges audit ohne ziel", func(c *Config) { c.AuditStrict = true }, "AUDIT_STRICT=true: verlangt AUDIT_LOG"},
+		{"keine größe für entfernte csv", func(c *Config) { c.CSVFetchMaxBytes = 0 }, "CSV_FETCH_MAX_BYTES=0: muss positiv sein"},
+		{"s3-schlüssel ohne geheimnis", func(c *Config) { c.S3AccessKeyID = "AKID" }, "AWS_ACCESS_KEY_ID und AWS_SECRET_ACCESS_KEY: nur gemeinsam setzen"},
+		{"negatives metrik-intervall", func(c *Config) { c.MetricsRefreshInterval = -time.Second }, "METRICS_REFRESH_INTERVAL=-1s: darf nicht negativ sein, 0 schaltet das neuzählen ab"},
+		{"sample-ratio über 1", func(c *Config) { c.TracingSampleRatio = 2 }, "TRACING_SAMPLE_RATIO=2: erwartet einen wert von 0 bis 1"},
+		{"adresse ohne port", func(c *Config) { c.ServerAddr = "localhost" }, `SERVER_ADDR="localhost": erwartet host:port, z. B. ":8081", oder "unix:/pfad.sock"`},
+		{"port außerhalb des bereichs", func(c *Config) { c.ServerAddr = ":80810" }, `SERVER_ADDR=":80810": ungültiger port, erwartet 0 bis 65535`},
+		{"port mit tippfehler", func(c *Config) { c.ServerAddr = ":808l" }, `SERVER_ADDR=":808l": ungültiger port, erwartet 0 bis 65535`},
+		{"negatives write-timeout", func(c *Config) { c.WriteTimeout = -time.Second }, "WRITE_TIMEOUT=-1s: darf nicht negativ sein, 0 bedeutet kein limit"},
+		{"header-timeout über read-timeout", func(c *Config) { c.ReadHeaderTimeout = time.Minute }, "READ_HEADER_TIMEOUT=1m0s: darf READ_TIMEOUT=10s nicht überschreiten"},
+		{"negative header-größe", func(c *Config) { c.MaxHeaderBytes = -1 }, "MAX_HEADER_BYTES=-1: darf nicht negativ sein, 0 bedeutet 1 MiB"},
+		{"basis-pfad ohne führenden slash", func(c *Config) { c.BasePath = "api/v1" }, `BASE_PATH="api/v1": erwartet einen pfad wie "/api/v1" ohne abschließenden "/"`},
+		{"basis-pfad mit abschließendem slash", func(c *Config) { c.BasePath = "/api/v1/" }, `BASE_PATH="/api/v1/": erwartet einen pfad wie "/api/v1" ohne abschließenden "/"`},
+		{"debug-adresse bei pprof", func(c *Config) { c.EnablePprof, c.DebugAddr = true, "localhost6060" }, `DEBUG_ADDR="localhost6060": erwartet host:port, z. B. ":8081", oder "unix:/pfad.sock"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := gueltigeConfig(t)
+			tt.change(&cfg)
+
+			var invalid *ValidationError
+			require.ErrorAs(t, cfg.Validate(), &invalid)
+			assert.Equal(t, []string{tt.want}, invalid.Problems)
+		})
+	}
+}
+
+func TestValidate_RateLimitNullSchaltetAb(t *testing.T) {
+	cfg := gueltigeConfig(t)
+	cfg.RateLimit = 0
+
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_CSVPfad(t *testing.T) {
+	fehlt := filepath.Join(t.TempDir(), "fehlt.csv")
+
+	tests := []struct {
+		name         string
+		path         string
+		allowMissing bool
+		want         string
+	}{
+		{"fehlende datei", fehlt, false, "datei existiert nicht (CSV_ALLOW_MISSING=true startet leer)"},
+		{"fehlende datei erlaubt", fehlt, true, ""},
+		{"verzeichnis", t.TempDir(), false, "ist ein verzeichnis"},
+		{"url", "https://example.org/persons.csv", false, ""},
+		{"standardeingabe", "-", false, ""},
+		{"s3", "s3://daten/persons.csv", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := gueltigeConfig(t)
+			cfg.DataSource = "csv"
+			cfg.CSVFilePath = tt.path
+			cfg.CSVAllowMissing = tt.allowMissing
+
+			err := cfg.Validate()
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var invalid *ValidationError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, []string{`CSV_FILE_PATH="` + tt.path + `": ` + tt.want}, invalid.Problems)
+		})
+	}
+}
+
+func TestValidate_JSONPfad(t *testing.T) {
+	vorhanden := filepath.Join(t.TempDir(), "persons.json")
+	require.NoError(t, os.WriteFile(vorhanden, []byte("[]"), 0o600))
+	fehlt := filepath.Join(t.TempDir(), "fehlt.json")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"vorhandene datei", vorhanden, ""},
+		{"fehlende datei", fehlt, "datei existiert nicht"},
+		{"verzeichnis", t.TempDir(), "ist ein verzeichnis"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := gueltigeConfig(t)
+			cfg.DataSource = "json"
+			cfg.JSONFilePath = tt.path
+
+			err := cfg.Validate()
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var invalid *ValidationError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, []string{`JSON_FILE_PATH="` + tt.path + `": ` + tt.want}, invalid.Problems)
+		})
+	}
+}
+
+func TestValidate_MeldetAlleProblemeAufEinmal(t *testing.T) {
+	cfg := gueltigeConfig(t)
+	cfg.RateLimit = -1
+	cfg.MaxPersons = -1
+	cfg.DataSource = "excel"
+
+	err := cfg.Validate()
+
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Len(t, invalid.Problems, 3)
+	assert.Contains(t, err.Error(), "konfiguration ungültig (3 probleme): RATE_LIMIT=-1")
+}
+
+func TestValidate_Adressen(t *testing.T) {
+	for _, addr := range []string{":8081", "127.0.0.1:0", "[::1]:443", "unix:/tmp/server.sock"} {
+		t.Run(addr, func(t *testing.T) {
+			cfg := gueltigeConfig(t)
+			cfg.ServerAddr = addr
+			assert.NoError(t, cfg.Validate())
+		})
+	}
+	t.Run("debug-adresse ohne pprof", func(t *testing.T) {
+		cfg := gueltigeConfig(t)
+		cfg.DebugAddr = "kaputt"
+		assert.NoError(t, cfg.Validate())
+	})
+}
+
+// ─── Load ─────────────────────────────────────────────────────────────────────
+
+func TestLoad_TypisierteParseErrors(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{"RATE_LIMIT", "abc", "eine zahl"},
+		{"MAX_PERSONS", "ten", "eine ganze zahl"},
+		{"CSV_FETCH_MAX_BYTES", "64MB", "eine ganze zahl"},
+		{"ENABLE_METRICS", "ja", "true oder false"},
+		{"PURGE_INTERVAL", "1 stunde", `eine dauer wie "30s"`},
+		{"CSV_DELIMITER", "tab", "genau ein zeichen"},
+		{"TRACING_SAMPLE_RATIO", "halb", "eine zahl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+
+			_, err := Load()
+
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, &ParseError{Key: tt.key, Value: tt.value, Want: tt.want}, parseErr)
+			var invalid *ValidationError
+			require.ErrorAs(t, err, &invalid)
+			assert.Len(t, invalid.Problems, 1)
+		})
+	}
+}
+
+func TestLoad_GueltigOhneFehler(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "0")
+	t.Setenv("PURGE_INTERVAL", "90m")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Zero(t, cfg.RateLimit)
+	assert.Equal(t, 90*time.Minute, cfg.PurgeInterval)
+}
+
+func TestLoad_PruefungenBleibenBeiValidate(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "-1")
+
+	cfg, err := Load()
+	require.NoError(t, err, "load meldet nur nicht umwandelbare werte")
+	assert.Error(t, cfg.Validate())
+}
+
+// mitDotEnv legt content als .env in einem leeren Arbeitsverzeichnis an.
+func mitDotEnv(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(content), 0o600))
+	t.Chdir(dir)
+}
+
+// setting sucht den wirksamen Wert zu key.
+func setting(t *testing.T, cfg Config, key string) Setting {
+	t.Helper()
+	for _, s := range cfg.Settings() {
+		if s.Key == key {
+			return s
+		}
+	}
+	t.Fatalf("%s fehlt in Settings", key)
+	return Setting{}
+}
+
+func TestLoad_Vorrang(t *testing.T) {
+	mitDotEnv(t, "RATE_LIMIT=50\nMAX_PERSONS=20\nLOG_LEVEL=debug\n")
+	t.Setenv("MAX_PERSONS", "30")
+	t.Setenv("LOG_LEVEL", "")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	tests := []struct {
+		key  string
+		want Setting
+	}{
+		{"MAX_PERSONS", Setting{"MAX_PERSONS", "30", SourceEnv}},
+		{"RATE_LIMIT", Setting{"RATE_LIMIT", "50", SourceDotEnv}},
+		{"LOG_LEVEL", Setting{"LOG_LEVEL", "debug", SourceDotEnv}},
+		{"SERVER_ADDR", Setting{"SERVER_ADDR", ":8081", SourceDefault}},
+		{"PURGE_INTERVAL", Setting{"PURGE_INTERVAL", "1h0m0s", SourceDefault}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, setting(t, cfg, tt.key))
+		})
+	}
+	assert.Equal(t, 30, cfg.MaxPersons, "umgebung vor .env")
+	assert.Equal(t, 50.0, cfg.RateLimit, ".env vor vorgabe")
+	assert.Equal(t, "debug", cfg.LogLevel, "leere umgebungsvariable gilt als nicht gesetzt")
+}
+
+func TestLoad_DotEnvFormat(t *testing.T) {
+	mitDotEnv(t, `# lokale entwicklung
+export DATA_SOURCE=memory
+
+SERVER_ADDR = "127.0.0.1:9000"
+TRACING_SERVICE_NAME='mit # und leerzeichen'
+`)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "memory", cfg.DataSource)
+	assert.Equal(t, "127.0.0.1:9000", cfg.ServerAddr)
+	assert.Equal(t, "mit # und leerzeichen", cfg.TracingServiceName)
+}
+
+func TestLoad_DotEnvFehler(t *testing.T) {
+	mitDotEnv(t, "DATA_SOURCE=memory\nkein gleichheitszeichen\nMAX_PERSONS=viele\n")
+
+	cfg, err := Load()
+
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []string{
+		`.env:2="kein gleichheitszeichen": erwartet SCHLÜSSEL=wert`,
+		`MAX_PERSONS="viele": erwartet eine ganze zahl`,
+	}, invalid.Problems)
+	assert.Equal(t, "memory", cfg.DataSource, "gültige zeilen gelten trotzdem")
+	assert.Equal(t, invalid.Problems, validationProblems(t, cfg), "validate meldet dieselben fehler")
+}
+
+func validationProblems(t *testing.T, cfg Config) []string {
+	t.Helper()
+	var invalid *ValidationError
+	require.ErrorAs(t, cfg.Validate(), &invalid)
+	return invalid.Problems
+}
+
+func TestSettings_GeheimnisseMaskiert(t *testing.T) {
+	t.Setenv("BASIC_AUTH_USER", "admin")
+	t.Setenv("BASIC_AUTH_PASS", "geheim")
+	t.Setenv("API_KEYS", "a,b")
+	t.Setenv("CSV_FETCH_TOKEN", "token")
+
+	cfg := MustLoad()
+
+	assert.Equal(t, Setting{"BASIC_AUTH_USER", "admin", SourceEnv}, setting(t, cfg, "BASIC_AUTH_USER"))
+	assert.Equal(t, Setting{"BASIC_AUTH_PASS", "***", SourceEnv}, setting(t, cfg, "BASIC_AUTH_PASS"))
+	assert.Equal(t, Setting{"API_KEYS", "***", SourceEnv}, setting(t, cfg, "API_KEYS"))
+	assert.Equal(t, Setting{"CSV_FETCH_TOKEN", "***", SourceEnv}, setting(t, cfg, "CSV_FETCH_TOKEN"))
+	assert.Equal(t, Setting{"AWS_SECRET_ACCESS_KEY", "", SourceDefault}, setting(t, cfg, "AWS_SECRET_ACCESS_KEY"))
+	assert.Equal(t, []string{"a", "b"}, cfg.APIKeys)
+	for _, s := range cfg.Settings() {
+		assert.NotContains(t, s.Value, "geheim")
+	}
+}
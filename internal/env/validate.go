@@ -0,0 +1,193 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dataSources sind die von main unterstützten Werte für DATA_SOURCE.
+var dataSources = []string{"csv", "json", "sqlite", "memory", "csv+sqlite"}
+
+// ParseError meldet eine gesetzte Umgebungsvariable, deren Wert sich nicht
+// in den erwarteten Typ umwandeln lässt.
+type ParseError struct {
+	Key   string
+	Value string
+	Want  string // erwartete Form, z. B. "eine ganze zahl"
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s=%q: erwartet %s", e.Key, e.Value, e.Want)
+}
+
+// ValidationError listet alle Probleme, die Load oder Validate gefunden hat.
+type ValidationError struct {
+	Problems []string
+	errs     []error // Fehler aus dem Laden, etwa *ParseError
+}
+
+// newValidationError fasst die Fehler aus dem Laden und die übrigen
+// Probleme zusammen; die Fehler aus dem Laden stehen vorn.
+func newValidationError(problems []string, errs []error) *ValidationError {
+	all := make([]string, 0, len(errs)+len(problems))
+	for _, err := range errs {
+		all = append(all, err.Error())
+	}
+	return &ValidationError{Problems: append(all, problems...), errs: errs}
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("konfiguration ungültig (%d probleme): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// Unwrap gibt die Fehler aus dem Laden frei, damit errors.As einen
+// *ParseError findet.
+func (e *ValidationError) Unwrap() []error {
+	return e.errs
+}
+
+// Validate prüft die Konfiguration auf Werte, mit denen der Dienst nicht
+// sinnvoll starten kann, und meldet alle Probleme auf einmal als
+// *ValidationError. Dazu gehören auch Umgebungsvariablen, die MustLoad nicht
+// umwandeln konnte und durch die Vorgabe ersetzt hat, sowie Fehler der
+// .env-Datei.
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.RateLimit < 0 {
+		add("RATE_LIMIT=%v: darf nicht negativ sein, 0 schaltet die begrenzung ab", c.RateLimit)
+	}
+	if c.MaxPersons < 0 {
+		add("MAX_PERSONS=%d: darf nicht negativ sein, 0 bedeutet unbegrenzt", c.MaxPersons)
+	}
+	if problem := checkAddr(c.ServerAddr); problem != "" {
+		add("SERVER_ADDR=%q: %s", c.ServerAddr, problem)
+	}
+	if c.BasePath != "" && (!strings.HasPrefix(c.BasePath, "/") || strings.HasSuffix(c.BasePath, "/")) {
+		add(`BASE_PATH=%q: erwartet einen pfad wie "/api/v1" ohne abschließenden "/"`, c.BasePath)
+	}
+	if c.EnablePprof {
+		if problem := checkAddr(c.DebugAddr); problem != "" {
+			add("DEBUG_ADDR=%q: %s", c.DebugAddr, problem)
+		}
+	}
+	for _, timeout := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"READ_TIMEOUT", c.ReadTimeout},
+		{"READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"WRITE_TIMEOUT", c.WriteTimeout},
+		{"IDLE_TIMEOUT", c.IdleTimeout},
+	} {
+		if timeout.value < 0 {
+			add("%s=%v: darf nicht negativ sein, 0 bedeutet kein limit", timeout.key, timeout.value)
+		}
+	}
+	if c.ReadTimeout > 0 && c.ReadHeaderTimeout > c.ReadTimeout {
+		add("READ_HEADER_TIMEOUT=%v: darf READ_TIMEOUT=%v nicht überschreiten", c.ReadHeaderTimeout, c.ReadTimeout)
+	}
+	if c.MaxHeaderBytes < 0 {
+		add("MAX_HEADER_BYTES=%d: darf nicht negativ sein, 0 bedeutet 1 MiB", c.MaxHeaderBytes)
+	}
+	if !slices.Contains(dataSources, c.DataSource) {
+		add("DATA_SOURCE=%q: erwartet %s", c.DataSource, strings.Join(dataSources, ", "))
+	}
+	if (c.DataSource == "csv" || c.DataSource == "csv+sqlite") && !c.CSVAllowMissing {
+		if problem := checkCSVPath(c.CSVFilePath); problem != "" {
+			add("CSV_FILE_PATH=%q: %s", c.CSVFilePath, problem)
+		}
+	}
+	if c.CSVFetchMaxBytes <= 0 {
+		add("CSV_FETCH_MAX_BYTES=%d: muss positiv sein", c.CSVFetchMaxBytes)
+	}
+	if (c.S3AccessKeyID == "") != (c.S3SecretAccessKey == "") {
+		add("AWS_ACCESS_KEY_ID und AWS_SECRET_ACCESS_KEY: nur gemeinsam setzen")
+	}
+	if c.DataSource == "json" {
+		if problem := checkFile(c.JSONFilePath); problem != "" {
+			add("JSON_FILE_PATH=%q: %s", c.JSONFilePath, problem)
+		}
+	}
+	if c.CSVOverflow != "truncate" && c.CSVOverflow != "error" {
+		add("CSV_OVERFLOW=%q: erwartet truncate oder error", c.CSVOverflow)
+	}
+	if c.LogAccessFormat != "zap" && c.LogAccessFormat != "combined" {
+		add("LOG_ACCESS_FORMAT=%q: erwartet zap oder combined", c.LogAccessFormat)
+	}
+	if c.AuditMaxBytes < 0 {
+		add("AUDIT_MAX_BYTES=%d: darf nicht negativ sein, 0 schaltet die rotation ab", c.AuditMaxBytes)
+	}
+	if c.AuditStrict && c.AuditLog == "" {
+		add("AUDIT_STRICT=true: verlangt AUDIT_LOG")
+	}
+	if c.MetricsRefreshInterval < 0 {
+		add("METRICS_REFRESH_INTERVAL=%v: darf nicht negativ sein, 0 schaltet das neuzählen ab", c.MetricsRefreshInterval)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO=%v: erwartet einen wert von 0 bis 1", c.TracingSampleRatio)
+	}
+
+	if len(problems) > 0 || len(c.errs) > 0 {
+		return newValidationError(problems, c.errs)
+	}
+	return nil
+}
+
+// checkAddr prüft, ob addr eine Adresse der Form host:port mit gültigem Port
+// oder ein Unix-Socket "unix:/pfad" ist, und beschreibt sonst das Problem.
+func checkAddr(addr string) string {
+	if strings.HasPrefix(addr, "unix:") {
+		return ""
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return `erwartet host:port, z. B. ":8081", oder "unix:/pfad.sock"`
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "ungültiger port, erwartet 0 bis 65535"
+	}
+	return ""
+}
+
+// checkCSVPath prüft, ob path auf eine lesbare Datei zeigt, und beschreibt
+// sonst das Problem. URLs und die Standardeingabe lassen sich vor dem Laden
+// nicht prüfen.
+func checkCSVPath(path string) string {
+	if path == "-" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "s3://") {
+		return ""
+	}
+	problem := checkFile(path)
+	if problem == fileMissing {
+		problem += " (CSV_ALLOW_MISSING=true startet leer)"
+	}
+	return problem
+}
+
+// fileMissing meldet checkFile für einen Pfad, unter dem nichts liegt.
+const fileMissing = "datei existiert nicht"
+
+// checkFile prüft, ob path auf eine vorhandene Datei zeigt, und beschreibt
+// sonst das Problem.
+func checkFile(path string) string {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fileMissing
+	case err != nil:
+		return err.Error()
+	case info.IsDir():
+		return "ist ein verzeichnis"
+	}
+	return ""
+}
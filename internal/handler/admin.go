@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// LoadReporter liefert den Bericht über das Laden der Datenquelle. Nur
+// Repositories mit externer Quelle (CSV) implementieren es.
+type LoadReporter interface {
+	LoadReport() domain.LoadReport
+}
+
+// LoadReport zeigt, welche Datensätze beim Laden der Quelle verworfen wurden.
+// Ohne Options.LoadReporter antwortet der Endpunkt mit 404.
+func (h *PersonHandler) LoadReport(w http.ResponseWriter, r *http.Request) {
+	if h.opts.LoadReporter == nil {
+		writeError(w, r, http.StatusNotFound, i18n.New(i18n.CodeNoLoadReport))
+		return
+	}
+	writeJSON(w, http.StatusOK, h.opts.LoadReporter.LoadReport())
+}
+
+// Reloader lädt die Datenquelle erneut und ersetzt den Bestand. Nur
+// Repositories mit externer Quelle (CSV) implementieren es.
+type Reloader interface {
+	Reload(ctx context.Context) (domain.LoadReport, error)
+}
+
+// reloadResponse fasst ein erfolgreiches Neuladen zusammen.
+type reloadResponse struct {
+	Loaded     int   `json:"loaded"`
+	Skipped    int   `json:"skipped"`
+	Overflow   int   `json:"overflow"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// Reload liest die Datenquelle neu ein. Ohne Options.Reloader antwortet der
+// Endpunkt mit 501. Lehnt die Quelle den neuen Bestand ab (strikter Modus,
+// Kapazität), bleibt der alte erhalten und die Antwort ist 422.
+func (h *PersonHandler) Reload(w http.ResponseWriter, r *http.Request) {
+	if h.opts.Reloader == nil {
+		writeError(w, r, http.StatusNotImplemented, i18n.New(i18n.CodeReloadUnsupported))
+		return
+	}
+	start := time.Now()
+	report, err := h.opts.Reloader.Reload(r.Context())
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) || errors.Is(err, domain.ErrCapacityReached) {
+			h.logger.Warn("neu laden abgelehnt, alter bestand bleibt erhalten", zap.Error(err))
+			writeError(w, r, http.StatusUnprocessableEntity, i18n.Wrap(err, i18n.CodeReloadRejected, err))
+			return
+		}
+		h.serverError(w, r, "datenquelle neu laden", err)
+		return
+	}
+	elapsed := time.Since(start)
+	h.logger.Info("datenquelle neu geladen",
+		zap.Int("anzahl", report.Loaded), zap.Int("verworfen", len(report.Skipped)), zap.Duration("dauer", elapsed))
+	writeJSON(w, http.StatusOK, reloadResponse{
+		Loaded:     report.Loaded,
+		Skipped:    len(report.Skipped),
+		Overflow:   report.Overflow,
+		DurationMs: elapsed.Milliseconds(),
+	})
+}
+
+// Capacity zeigt die Auslastung der Kapazitätsgrenze als {"used":…,"max":…};
+// max 0 bedeutet unbegrenzt.
+func (h *PersonHandler) Capacity(w http.ResponseWriter, r *http.Request) {
+	capacity, err := h.service.Capacity(r.Context())
+	if err != nil {
+		h.serverError(w, r, "kapazität abfragen", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, capacity)
+}
+
+// setCapacityRemaining setzt X-Capacity-Remaining, sofern eine Grenze gilt.
+// Der Header ist nur ein Hinweis; schlägt die Abfrage fehl, fehlt er.
+func (h *PersonHandler) setCapacityRemaining(w http.ResponseWriter, r *http.Request) {
+	capacity, err := h.service.Capacity(r.Context())
+	if err != nil {
+		h.logger.Warn("kapazität für antwort-header nicht ermittelt", zap.Error(err))
+		return
+	}
+	if remaining := capacity.Remaining(); remaining >= 0 {
+		w.Header().Set("X-Capacity-Remaining", strconv.Itoa(remaining))
+	}
+}
@@ -0,0 +1,69 @@
+// Package handler stellt die HTTP-Endpunkte für Personen bereit.
+//
+// # Optimistische Nebenläufigkeit
+//
+// Jede Person trägt eine Version, die mit jeder Änderung (PUT, PATCH,
+// DELETE, Restore) um 1 steigt; SQLite führt sie in der Spalte version, das
+// CSV-Repository im Speicher. Nach außen erscheint sie als schwaches ETag:
+//
+//   - GET und HEAD /persons/{id} sowie erfolgreiche PUT, PATCH und Restore
+//     antworten mit ETag: W/"<version>".
+//   - PUT und PATCH erwarten If-Match mit diesem ETag. Die starke Form "3"
+//     wird ebenfalls akzeptiert, "*" verzichtet ausdrücklich auf die Prüfung.
+//   - Weicht die gespeicherte Version ab, wird nichts geändert und mit 412
+//     Precondition Failed geantwortet. Die Antwort enthält das aktuelle ETag
+//     sowie current_version im Body, sodass der Client neu laden und erneut
+//     senden kann.
+//   - Fehlt If-Match, antwortet der Server mit 428 Precondition Required,
+//     solange REQUIRE_IF_MATCH nicht abgeschaltet ist. Ohne die Pflicht
+//     überschreibt PUT ohne Prüfung; PATCH prüft gegen den unmittelbar zuvor
+//     gelesenen Stand.
+//   - Ein If-Match, das kein gültiges ETag ist, ergibt 400.
+//   - Mit PUT_UPSERT legt PUT eine unbekannte ID an und antwortet mit 201.
+//     Das gilt nur ohne Versionsprüfung (If-Match: * oder ohne Pflicht gar
+//     keins); ein konkretes ETag auf eine fehlende Person bleibt 404.
+//
+// # Last-Modified
+//
+// Lesende Anfragen auf /persons, /colors/counts und /cities tragen den
+// Zeitpunkt der letzten Änderung am gesamten Bestand als Last-Modified (siehe
+// PersonHandler.LastModified). If-Modified-Since mit diesem oder einem
+// späteren Zeitpunkt ergibt 304; verglichen wird auf ganze Sekunden. Das
+// zugehörige Cache-Control setzt middleware.CacheControl je Routengruppe
+// (CACHE_MAX_AGE_LIST, CACHE_MAX_AGE_ITEM); Fehler und Änderungen sind no-store.
+//
+// # Feldauswahl
+//
+// Alle GET-Endpunkte, die Personen liefern, nehmen ?fields=id,name,color an
+// und kodieren dann nur diese Felder jeder Person; id ist immer dabei. Das
+// gilt gleichermaßen für einzelne Personen, Listen, Farbgruppen, NDJSON und
+// die Liste in listResponse und lässt Paginierung und Meta unberührt. Ein
+// unbekanntes Feld ergibt 400 mit den gültigen Namen; ein leeres fields=
+// liefert die vollständigen Personen. Die gültigen Namen sind die der
+// JSON-Darstellung von domain.Person.
+//
+// ?include=hex ergänzt an denselben Endpunkten color_hex, den Farbwert der
+// Lieblingsfarbe (siehe domain.Color.Hex), auch wenn fields color nicht
+// nennt. GET /colors liefert alle Farben mit ihren Farbwerten auf einmal.
+//
+// # JSON:API
+//
+// Mit "Accept: application/vnd.api+json" antworten die Personen-Endpunkte
+// mit einem JSON:API-Dokument (siehe Paket jsonapi), etwa
+// {"data":{"type":"persons","id":"1","attributes":{...}}}; Listen tragen ein
+// Array in data, mit ?envelope=true zusätzlich die Paginierung in meta.
+// ?fields= beschränkt die Attribute. Farbgruppen und NDJSON haben keine
+// JSON:API-Form und bleiben unverändert, ebenso Fehler (errorBody). Anfragen
+// erwarten weiterhin einfaches JSON.
+//
+// # Fehlermeldungen
+//
+// Jede Fehlerantwort (errorBody) trägt neben der Meldung in error einen
+// stabilen Code in code, etwa "person_not_found"; Clients sollten nach dem
+// Code unterscheiden, nicht nach dem Text. Die Meldung richtet sich nach
+// Accept-Language: Deutsch ist Standard und bleibt im bisherigen Wortlaut,
+// Englisch ("en", "en-US" usw.) wird ebenfalls unterstützt, alle anderen
+// Sprachen fallen auf Deutsch zurück. Das gilt auch für die Details einer
+// Schemaverletzung und für Fehler der Middleware. GraphQL-Fehler und Logs
+// bleiben deutsch.
+package handler
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// allowCandidates sind die Methoden, die für den Allow-Header geprüft werden.
+var allowCandidates = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// NotFound beantwortet unbekannte Pfade mit 404 im einheitlichen Fehlerformat.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, i18n.New(i18n.CodeResourceNotFound))
+}
+
+// MethodNotAllowed liefert einen Handler, der 405 im einheitlichen
+// Fehlerformat beantwortet. Der Allow-Header listet die Methoden, die für den
+// angefragten Pfad in routes registriert sind. Die Routen werden beim ersten
+// Aufruf eingelesen, damit der Handler vor ihrer Registrierung gesetzt werden kann.
+func MethodNotAllowed(routes chi.Routes) http.HandlerFunc {
+	var (
+		once sync.Once
+		flat *chi.Mux
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { flat = flattenRoutes(routes) })
+
+		if allowed := allowedMethods(flat, r.URL.Path, allowCandidates); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+		}
+		writeError(w, r, http.StatusMethodNotAllowed, i18n.New(i18n.CodeMethodNotAllowed))
+	}
+}
+
+// allowedMethods gibt die Methoden aus candidates zurück, für die flat eine
+// Route zu path kennt.
+func allowedMethods(flat *chi.Mux, path string, candidates []string) []string {
+	path = trimTrailingSlash(path)
+	var allowed []string
+	for _, m := range candidates {
+		if flat.Match(chi.NewRouteContext(), m, path) {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+// flattenRoutes bildet alle Routen ohne Subrouter auf einem einzelnen Mux ab.
+// Gemountete Subrouter akzeptieren auf oberster Ebene jede Methode, weshalb
+// Match auf dem ursprünglichen Router für den Allow-Header zu ungenau ist.
+func flattenRoutes(routes chi.Routes) *chi.Mux {
+	flat := chi.NewMux()
+	noop := func(http.ResponseWriter, *http.Request) {}
+	_ = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		flat.MethodFunc(method, trimTrailingSlash(route), noop)
+		return nil
+	})
+	return flat
+}
+
+func trimTrailingSlash(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return "/"
+}
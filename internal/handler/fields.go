@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/i18n"
+	"assecor-assessment-backend/internal/jsonapi"
+)
+
+// personFields sind die Felder einer Person in ihrer JSON-Darstellung und
+// damit die gültigen Werte von ?fields=.
+var personFields = jsonNames(reflect.TypeFor[domain.Person]())
+
+// jsonName gibt den Namen des Feldes f in der JSON-Darstellung zurück, ""
+// für nicht exportierte oder mit json:"-" ausgelassene Felder.
+func jsonName(f reflect.StructField) string {
+	if !f.IsExported() || f.Anonymous {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// jsonNames gibt die JSON-Namen der Felder des Structs t zurück.
+func jsonNames(t reflect.Type) []string {
+	var names []string
+	for _, f := range reflect.VisibleFields(t) {
+		if name := jsonName(f); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fieldSet ist die per ?fields= gewählte Auswahl an Feldern einer Person.
+// nil steht für alle Felder.
+type fieldSet map[string]bool
+
+// parseFields liest ?fields=id,name,color. Fehlt der Parameter oder nennt er
+// kein Feld (etwa fields=), ist das Ergebnis nil und Personen werden
+// vollständig geliefert. id ist immer enthalten, damit Clients projizierte
+// Personen zuordnen können. Ein unbekanntes Feld ergibt einen Fehler, der die
+// gültigen Felder nennt.
+func parseFields(r *http.Request) (fieldSet, error) {
+	var fields fieldSet
+	for _, name := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(personFields, name) {
+			return nil, i18n.New(i18n.CodeUnknownField, name, strings.Join(personFields, ", "))
+		}
+		if fields == nil {
+			fields = fieldSet{"id": true}
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// personView beschreibt, wie Personen in einer Antwort erscheinen: die per
+// ?fields= gewählten Felder und per ?include=hex zusätzlich color_hex. Der
+// Nullwert liefert Personen unverändert.
+type personView struct {
+	fields fieldSet
+	hex    bool
+}
+
+// parsePersonView liest ?fields= (siehe parseFields) und ?include=.
+// include nimmt eine kommagetrennte Liste; bekannt ist nur hex.
+func parsePersonView(r *http.Request) (personView, error) {
+	fields, err := parseFields(r)
+	if err != nil {
+		return personView{}, err
+	}
+	view := personView{fields: fields}
+	for _, name := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case "hex":
+			view.hex = true
+		default:
+			return personView{}, i18n.New(i18n.CodeUnknownInclude, name)
+		}
+	}
+	return view, nil
+}
+
+// plain meldet, ob Personen unverändert geliefert werden.
+func (v personView) plain() bool {
+	return v.fields == nil && !v.hex
+}
+
+// person gibt p für die Antwort zurück, sofern nötig als projectedPerson.
+func (v personView) person(p domain.Person) any {
+	if v.plain() {
+		return p
+	}
+	return projectedPerson{Person: p, view: v}
+}
+
+// persons wie person, für eine Liste. Eine leere Liste bleibt ein Array.
+func (v personView) persons(persons []domain.Person) any {
+	if v.plain() {
+		return persons
+	}
+	out := make([]projectedPerson, len(persons))
+	for i, p := range persons {
+		out[i] = projectedPerson{Person: p, view: v}
+	}
+	return out
+}
+
+// apply passt die Attribute von res an, die zu p gehört.
+func (v personView) apply(res *jsonapi.Resource, p domain.Person) {
+	if v.fields != nil {
+		for name := range res.Attributes {
+			if !v.fields[name] {
+				delete(res.Attributes, name)
+			}
+		}
+	}
+	if hex := p.Color.Hex(); v.hex && hex != "" {
+		res.Attributes["color_hex"], _ = json.Marshal(hex)
+	}
+}
+
+// projectedPerson kodiert eine Person nach view: nur die gewählten Felder,
+// in der Reihenfolge ihrer vollständigen Darstellung, und color_hex direkt
+// hinter color. Leere Zeitstempel fehlen wie dort auch, ebenso color_hex für
+// eine Farbe ohne Farbwert.
+type projectedPerson struct {
+	domain.Person
+	view personView
+}
+
+func (p projectedPerson) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal(p.Person)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(name string, value []byte) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	for _, name := range personFields {
+		if value, ok := all[name]; ok && (p.view.fields == nil || p.view.fields[name]) {
+			write(name, value)
+		}
+		if hex := p.Color.Hex(); name == "color" && p.view.hex && hex != "" {
+			value, _ := json.Marshal(hex)
+			write("color_hex", value)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
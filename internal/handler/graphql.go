@@ -0,0 +1,531 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// graphQLRequest ist der Body von POST /graphql. GET trägt dieselben Felder
+// als Query-Parameter, variables dann als JSON-Text.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// operation parst die Query und wählt die auszuführende Operation.
+func (req graphQLRequest) operation() (*ast.OperationDefinition, error) {
+	if req.Query == "" {
+		return nil, i18n.New(i18n.CodeQueryMissing)
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return nil, err
+	}
+	var ops []*ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok {
+			ops = append(ops, op)
+		}
+	}
+	switch {
+	case len(ops) == 0:
+		return nil, i18n.New(i18n.CodeNoOperation)
+	case req.OperationName == "" && len(ops) > 1:
+		return nil, i18n.New(i18n.CodeOperationAmbiguous, len(ops))
+	case req.OperationName == "":
+		return ops[0], nil
+	}
+	for _, op := range ops {
+		if op.Name != nil && op.Name.Value == req.OperationName {
+			return op, nil
+		}
+	}
+	return nil, i18n.New(i18n.CodeUnknownOperation, req.OperationName)
+}
+
+// checkVariables prüft die Werte der Variablen von op strenger als
+// graphql-go: Das kürzt 1.5 für Int auf 1 und macht aus jedem Wert einen
+// String. Ein Int muss hier eine ganze Zahl im 32-Bit-Bereich sein, ein
+// String ein JSON-String, auch in Listen und Feldern von Input-Objekten.
+// Fehlende Pflichtwerte und unbekannte Felder meldet graphql-go selbst.
+func checkVariables(schema graphql.Schema, op *ast.OperationDefinition, vars map[string]any) error {
+	for _, def := range op.VariableDefinitions {
+		name := "$" + def.Variable.Name.Value
+		if err := checkValue(name, graphQLType(schema, def.Type), vars[def.Variable.Name.Value]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// graphQLType löst den Typ einer Variablendefinition im Schema auf; nil,
+// wenn es ihn nicht gibt.
+func graphQLType(schema graphql.Schema, t ast.Type) graphql.Type {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		if inner := graphQLType(schema, t.Type); inner != nil {
+			return graphql.NewNonNull(inner)
+		}
+	case *ast.List:
+		if inner := graphQLType(schema, t.Type); inner != nil {
+			return graphql.NewList(inner)
+		}
+	case *ast.Named:
+		return schema.Type(t.Name.Value)
+	}
+	return nil
+}
+
+// checkValue prüft v gegen typ; path nennt die Stelle für den Fehler.
+func checkValue(path string, typ graphql.Type, v any) error {
+	if v == nil {
+		return nil
+	}
+	switch typ := typ.(type) {
+	case *graphql.NonNull:
+		return checkValue(path, typ.OfType, v)
+	case *graphql.List:
+		items, ok := v.([]any)
+		if !ok {
+			return checkValue(path, typ.OfType, v)
+		}
+		for i, item := range items {
+			if err := checkValue(fmt.Sprintf("%s[%d]", path, i), typ.OfType, item); err != nil {
+				return err
+			}
+		}
+	case *graphql.InputObject:
+		fields, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for name, field := range typ.Fields() {
+			if err := checkValue(path+"."+name, field.Type, fields[name]); err != nil {
+				return err
+			}
+		}
+	case *graphql.Scalar:
+		var ok bool
+		switch typ.Name() {
+		case "Int":
+			n, isNumber := v.(float64)
+			ok = isNumber && n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32
+		case "String":
+			_, ok = v.(string)
+		default:
+			ok = true
+		}
+		if !ok {
+			raw, _ := json.Marshal(v)
+			return i18n.New(i18n.CodeVariableType, path, typ.Name(), string(raw))
+		}
+	}
+	return nil
+}
+
+// graphQLResponse ist die Antwort von /graphql. Data fehlt, wenn die Anfrage
+// gar nicht ausgeführt wurde, etwa wegen eines unbekannten Feldes.
+type graphQLResponse struct {
+	Data   any                 `json:"data,omitempty"`
+	Errors []graphQLErrorEntry `json:"errors,omitempty"`
+}
+
+// graphQLErrorEntry ist ein Eintrag in graphQLResponse.Errors. Path nennt
+// bei Fehlern eines Resolvers den Schlüssel des Feldes in der Antwort.
+type graphQLErrorEntry struct {
+	Message    string                    `json:"message"`
+	Locations  []location.SourceLocation `json:"locations,omitempty"`
+	Path       []any                     `json:"path,omitempty"`
+	Extensions graphQLExtensions         `json:"extensions"`
+}
+
+// graphQLExtensions trägt den Fehlercode wie errorBody.Code bei REST.
+type graphQLExtensions struct {
+	Code i18n.Code `json:"code"`
+}
+
+// graphQLErrors übernimmt Fehler von graphql-go in graphQLResponse.Errors.
+// Fehler des Handlers und der Resolver erscheinen wie bei REST in lang mit
+// ihrem Code. Die Meldungen von graphql-go selbst, etwa Syntax- und
+// Validierungsfehler, gibt es nur auf Englisch; sie tragen
+// i18n.CodeGraphQLError.
+func graphQLErrors(lang i18n.Lang, errs []gqlerrors.FormattedError) []graphQLErrorEntry {
+	out := make([]graphQLErrorEntry, len(errs))
+	for i, e := range errs {
+		entry := graphQLErrorEntry{Message: e.Message, Locations: e.Locations, Path: e.Path}
+		entry.Extensions.Code = i18n.CodeGraphQLError
+		var l i18n.Localized
+		if errors.As(graphQLCause(e.OriginalError()), &l) {
+			entry.Extensions.Code, entry.Message = i18n.Render(lang, l)
+		}
+		out[i] = entry
+	}
+	return out
+}
+
+// graphQLCause gibt den Fehler zurück, den ein Resolver gemeldet hat.
+// graphql-go umschließt ihn in gqlerrors.Error, das kein Unwrap kennt.
+func graphQLCause(err error) error {
+	if gerr, ok := err.(*gqlerrors.Error); ok && gerr.OriginalError != nil {
+		return gerr.OriginalError
+	}
+	return err
+}
+
+// graphQLErrorResponse erstellt eine graphQLResponse ohne Data mit dem
+// einzelnen Fehler err in lang.
+func graphQLErrorResponse(lang i18n.Lang, err error) graphQLResponse {
+	return graphQLResponse{Errors: graphQLErrors(lang, gqlerrors.FormatErrors(err))}
+}
+
+// GraphQL beantwortet GET und POST /graphql mit den Queries persons,
+// person und personsByColor sowie der Mutation createPerson. Alle Felder
+// rufen denselben PersonService wie die REST-Endpunkte; eine Person hat die
+// Felder ihrer JSON-Darstellung (siehe graphQLFields). Parser, Validierung,
+// Ausführung und Introspektion übernimmt graphql-go.
+//
+// Eine Anfrage, die sich nicht ausführen lässt (Syntaxfehler, unbekanntes
+// Feld, Variable vom falschen Typ), ergibt 400 ohne data. Fehler einzelner
+// Felder stehen mit Pfad in errors, die Antwort bleibt 200. Mutationen gibt
+// es nur per POST; GET mit einer Mutation ergibt 405. Meldungen folgen wie
+// bei REST dem Accept-Language-Header, der Code steht in extensions.code.
+func (h *PersonHandler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	lang := requestLang(r)
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeJSON(w, http.StatusBadRequest, graphQLErrorResponse(lang, i18n.New(i18n.CodeInvalidVariables)))
+				return
+			}
+		}
+	} else {
+		if _, ok := requireMediaType(w, r, "application/json"); !ok {
+			return
+		}
+		if !decodeJSON(w, r, h.opts.MaxBodyBytes, &req) {
+			return
+		}
+	}
+
+	op, err := req.operation()
+	if err == nil {
+		err = checkVariables(h.graphQL, op, req.Variables)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, graphQLErrorResponse(lang, err))
+		return
+	}
+	if r.Method == http.MethodGet && op.Operation == ast.OperationTypeMutation {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, graphQLErrorResponse(lang, i18n.New(i18n.CodeMutationMethod)))
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.graphQL,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+	resp := graphQLResponse{Data: result.Data, Errors: graphQLErrors(lang, result.Errors)}
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, resp)
+}
+
+// IsGraphQLMutation meldet, ob eine Anfrage an /graphql eine Mutation
+// ausführen will. Die Routen sichern Mutationen damit wie schreibende
+// REST-Routen ab. Den Body liest es bis Options.MaxBodyBytes, wie später
+// GraphQL selbst, und stellt ihn für den Handler wieder her; lässt er sich
+// nicht lesen oder parsen, gilt die Anfrage im Zweifel als Mutation.
+func (h *PersonHandler) IsGraphQLMutation(r *http.Request) bool {
+	if r.Method == http.MethodGet {
+		// GraphQL lehnt Mutationen per GET selbst ab.
+		return false
+	}
+	raw, err := io.ReadAll(io.LimitReader(r.Body, h.opts.MaxBodyBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(raw), r.Body), r.Body}
+	if err != nil {
+		return true
+	}
+	var req graphQLRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return true
+	}
+	op, err := req.operation()
+	return err != nil || op.Operation == ast.OperationTypeMutation
+}
+
+// graphQLSchema beschreibt die Felder von /graphql. Ein ungültiges Schema
+// ist ein Programmierfehler und führt zur Panic.
+func (h *PersonHandler) graphQLSchema() graphql.Schema {
+	person := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Person",
+		Fields: graphQLFields(reflect.TypeFor[domain.Person]()),
+	})
+	personInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PersonInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":     {Type: graphql.String},
+			"lastname": {Type: graphql.String},
+			"zipcode":  {Type: graphql.String},
+			"city":     {Type: graphql.String},
+			"color":    {Type: graphql.String},
+		},
+	})
+	page := graphql.FieldConfigArgument{
+		"limit":  {Type: graphql.Int},
+		"offset": {Type: graphql.Int},
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"persons": {
+					Type: graphql.NewList(graphql.NewNonNull(person)),
+					Args: page,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						limit, offset, err := h.graphQLPage(p.Args)
+						if err != nil {
+							return nil, err
+						}
+						persons, err := h.service.Find(p.Context, domain.PersonFilter{Limit: limit, Offset: offset})
+						return persons, h.graphQLError("personen abrufen", err)
+					},
+				},
+				"person": {
+					Type: person,
+					Args: graphql.FieldConfigArgument{
+						"id": {Type: graphql.NewNonNull(graphql.Int)},
+					},
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						person, err := h.service.GetByID(p.Context, p.Args["id"].(int))
+						if errors.Is(err, domain.ErrNotFound) {
+							// Eine unbekannte ID ist in GraphQL null, kein Fehler.
+							return nil, nil
+						}
+						if err != nil {
+							return nil, h.graphQLError("person abrufen", err)
+						}
+						return person, nil
+					},
+				},
+				"personsByColor": {
+					Type: graphql.NewList(graphql.NewNonNull(person)),
+					Args: graphql.FieldConfigArgument{
+						"color":  {Type: graphql.NewNonNull(graphql.String)},
+						"sort":   {Type: graphql.String},
+						"limit":  page["limit"],
+						"offset": page["offset"],
+					},
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						limit, offset, err := h.graphQLPage(p.Args)
+						if err != nil {
+							return nil, err
+						}
+						sort, _ := p.Args["sort"].(string)
+						persons, err := h.service.GetByColor(p.Context, p.Args["color"].(string), sort, limit, offset)
+						if errors.Is(err, domain.ErrNoPersonsWithColor) {
+							return []domain.Person{}, nil
+						}
+						return persons, h.graphQLError("personen nach farbe abrufen", err)
+					},
+				},
+			},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"createPerson": {
+					Type: person,
+					Args: graphql.FieldConfigArgument{
+						"input": {Type: graphql.NewNonNull(personInput)},
+					},
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						input, err := decodePersonInput(p.Args["input"].(map[string]any))
+						if err != nil {
+							return nil, err
+						}
+						created, err := h.service.Add(p.Context, input)
+						if err != nil {
+							return nil, h.graphQLError("person erstellen", err)
+						}
+						return created, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(fmt.Sprintf("graphql-schema: %v", err))
+	}
+	return schema
+}
+
+// graphQLFields beschreibt die Felder des Structs t unter ihren JSON-Namen
+// (siehe jsonName). So bleibt der Domain-Typ die einzige Beschreibung einer
+// Person, für REST wie für GraphQL. Zeitstempel sind Strings im Format von
+// encoding/json und null, wo die JSON-Darstellung sie weglässt.
+func graphQLFields(t reflect.Type) graphql.Fields {
+	fields := graphql.Fields{}
+	for _, f := range reflect.VisibleFields(t) {
+		name := jsonName(f)
+		if name == "" {
+			continue
+		}
+		var typ graphql.Output
+		switch {
+		case f.Type == reflect.TypeFor[time.Time]():
+			typ = graphql.String
+		case f.Type.Kind() == reflect.Int:
+			typ = graphql.NewNonNull(graphql.Int)
+		case f.Type.Kind() == reflect.String:
+			typ = graphql.NewNonNull(graphql.String)
+		default:
+			panic(fmt.Sprintf("graphql-schema: feld %s.%s vom typ %s", t.Name(), f.Name, f.Type))
+		}
+		fields[name] = &graphql.Field{
+			Type: typ,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				v := reflect.Indirect(reflect.ValueOf(p.Source)).FieldByIndex(f.Index)
+				switch v := v.Interface().(type) {
+				case time.Time:
+					if v.IsZero() {
+						return nil, nil
+					}
+					return v.Format(time.RFC3339Nano), nil
+				default:
+					return v, nil
+				}
+			},
+		}
+	}
+	return fields
+}
+
+// graphQLPage liest limit und offset wie die Query-Parameter der
+// REST-Listen, einschließlich Options.MaxPageSize.
+func (h *PersonHandler) graphQLPage(args map[string]any) (limit, offset int, err error) {
+	limit, _ = args["limit"].(int)
+	offset, _ = args["offset"].(int)
+	if err := checkPaginationValue("limit", limit); err != nil {
+		return 0, 0, err
+	}
+	if err := checkPaginationValue("offset", offset); err != nil {
+		return 0, 0, err
+	}
+	limit, err = h.capPageSize(limit)
+	return limit, offset, err
+}
+
+// decodePersonInput prüft input gegen dasselbe Schema wie POST /persons und
+// dekodiert es als Person.
+func decodePersonInput(input map[string]any) (domain.Person, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return domain.Person{}, &inputError{err: err}
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return domain.Person{}, &inputError{err: err}
+	}
+	if err := personSchema.Validate(doc); err != nil {
+		var verr *jsonschema.ValidationError
+		if !errors.As(err, &verr) {
+			return domain.Person{}, &inputError{err: err}
+		}
+		return domain.Person{}, &inputError{err: err, verr: verr}
+	}
+	var p domain.Person
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return domain.Person{}, &inputError{err: err}
+	}
+	return p, nil
+}
+
+// inputError meldet ein ungültiges Argument "input" von createPerson. Verr
+// sind die Verletzungen des Personen-Schemas, sonst nennt die Meldung err.
+// Beides erscheint erst in Localize in der Sprache des Clients.
+type inputError struct {
+	err  error
+	verr *jsonschema.ValidationError
+}
+
+func (e *inputError) Error() string {
+	return e.Localize(i18n.Default)
+}
+
+func (e *inputError) Unwrap() error {
+	return e.err
+}
+
+// ErrorCode gibt den Code von e zurück.
+func (e *inputError) ErrorCode() i18n.Code {
+	if e.verr != nil {
+		return i18n.CodeInputSchema
+	}
+	return i18n.CodeInvalidInputArg
+}
+
+// Localize formuliert die Meldung von e in lang.
+func (e *inputError) Localize(lang i18n.Lang) string {
+	if e.verr == nil {
+		_, msg := i18n.Render(lang, e.err)
+		return i18n.Message(lang, i18n.CodeInvalidInputArg, msg)
+	}
+	var details []string
+	for _, v := range schemaViolations(lang, e.verr) {
+		details = append(details, strings.TrimPrefix(v.Field+": ", ": ")+v.Error)
+	}
+	return i18n.Message(lang, i18n.CodeInputSchema, strings.Join(details, "; "))
+}
+
+// graphQLError übersetzt einen Fehler des Service für errors[].message.
+// Fachliche Fehler gehen wie bei REST unverändert an den Client; alles
+// andere wird unter op geloggt und nur als interner Fehler gemeldet. Sprache
+// und Code wählt graphQLErrors.
+func (h *PersonHandler) graphQLError(op string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, domain.ErrInvalidInput), errors.Is(err, domain.ErrCapacityReached):
+		return err
+	case errors.Is(err, context.Canceled):
+		return i18n.New(i18n.CodeRequestCanceled)
+	case errors.Is(err, context.DeadlineExceeded):
+		return i18n.New(i18n.CodeTimeout)
+	default:
+		h.logger.Error(op, zap.Error(err))
+		return i18n.New(i18n.CodeInternal)
+	}
+}
@@ -2,128 +2,1323 @@ package handler
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/graphql-go/graphql"
 	"go.uber.org/zap"
 
 	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/i18n"
+	"assecor-assessment-backend/internal/jsonapi"
 )
 
-// maxRequestBody begrenzt die POST-Body-Größe auf 1 MegaByte
-const maxRequestBody = 1 << 20
+// defaultMaxRequestBody begrenzt die POST-Body-Größe auf 1 MegaByte, wenn
+// Options.MaxBodyBytes nicht gesetzt ist.
+const defaultMaxRequestBody = 1 << 20
+
+// defaultMaxIDs begrenzt die Anzahl der IDs in ?ids=, wenn Options.MaxIDs nicht gesetzt ist.
+const defaultMaxIDs = 100
+
+// defaultCursorLimit ist die Seitengröße bei Cursor-Paginierung ohne ?limit=.
+const defaultCursorLimit = 100
+
+// headerNextCursor trägt den Cursor der folgenden Seite; er fehlt auf der letzten Seite.
+const headerNextCursor = "X-Next-Cursor"
+
+// contentTypeNDJSON ist der Medientyp für zeilenweise JSON-Ausgabe.
+const contentTypeNDJSON = "application/x-ndjson"
+
+// contentTypeEnvelope wählt per Accept-Header die Liste in listResponse,
+// gleichwertig zu ?envelope=true.
+const contentTypeEnvelope = "application/vnd.assecor.v2+json"
+
+// ndjsonFlushEvery legt fest, nach wie vielen Zeilen der Stream geflusht wird.
+const ndjsonFlushEvery = 100
 
 // PersonService definiert den Vertrag, den der Handler von der Service-Schicht erwartet.
 type PersonService interface {
-	GetAll(ctx context.Context) ([]domain.Person, error)
+	Find(ctx context.Context, filter domain.PersonFilter) ([]domain.Person, error)
+	Count(ctx context.Context, filter domain.PersonFilter) (int, error)
+	StreamAll(ctx context.Context, fn func(domain.Person) error) error
 	GetByID(ctx context.Context, id int) (domain.Person, error)
-	GetByColor(ctx context.Context, color string) ([]domain.Person, error)
+	GetByIDs(ctx context.Context, ids []int) ([]domain.Person, error)
+	ListAfter(ctx context.Context, afterID, limit int) ([]domain.Person, int, error)
+	GetByColor(ctx context.Context, color, sort string, limit, offset int) ([]domain.Person, error)
+	GroupByColor(ctx context.Context, colors []string, limit, offset int) (map[domain.Color][]domain.Person, error)
+	GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) ([]domain.Person, error)
+	SameColorAs(ctx context.Context, id, limit, offset int) ([]domain.Person, error)
 	Add(ctx context.Context, person domain.Person) (domain.Person, error)
+	Update(ctx context.Context, id int, person domain.Person, expectedVersion int) (domain.Person, error)
+	Upsert(ctx context.Context, id int, person domain.Person, expectedVersion int) (domain.Person, bool, error)
+	CountsByColor(ctx context.Context) ([]domain.ColorCount, error)
+	Stats(ctx context.Context) (domain.PersonStats, error)
+	DistinctCities(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (domain.Person, error)
+	DeleteAll(ctx context.Context) error
+	DeleteByColor(ctx context.Context, color string) (int, error)
+	Capacity(ctx context.Context) (domain.Capacity, error)
+	LastModified(ctx context.Context) (time.Time, error)
+}
+
+// Options steuert optionales Verhalten des PersonHandler.
+type Options struct {
+	// AllowDestructive erlaubt Endpunkte, die Daten massenhaft löschen.
+	// Ist der Wert false, antworten diese mit 403.
+	AllowDestructive bool
+
+	// MaxBodyBytes begrenzt die Größe von Anfrage-Bodys einzelner Datensätze.
+	// 0 bedeutet defaultMaxRequestBody.
+	MaxBodyBytes int64
+
+	// MaxIDs begrenzt die Anzahl der IDs in GET /persons?ids=…
+	// 0 bedeutet defaultMaxIDs.
+	MaxIDs int
+
+	// RequireIfMatch verlangt bei PUT und PATCH einen If-Match-Header; ohne
+	// ihn wird mit 428 geantwortet. Ist der Wert false, überschreibt eine
+	// Änderung ohne If-Match ohne Versionsprüfung.
+	RequireIfMatch bool
+
+	// PutUpsert lässt PUT /persons/{id} eine fehlende Person unter genau
+	// dieser ID anlegen (201) statt mit 404 zu antworten. Angelegt wird nur
+	// ohne Versionsprüfung, also ohne If-Match oder mit If-Match: *.
+	PutUpsert bool
+
+	// ShowGone lässt GET /persons/{id} für vorläufig gelöschte Personen mit
+	// 410 statt 404 antworten.
+	ShowGone bool
+
+	// MaxPageSize begrenzt ?limit= bei allen Listen-Endpunkten; 0 bedeutet
+	// unbegrenzt. Ohne ?limit= bleibt es bei der vollständigen Liste.
+	MaxPageSize int
+
+	// RejectOversizedPage lehnt ein limit über MaxPageSize mit 400 ab, statt
+	// es auf MaxPageSize zu kappen.
+	RejectOversizedPage bool
+
+	// BasePath steht vor dem Pfad im Location-Header neu angelegter
+	// Personen, wenn die Routen unter einem Präfix liegen (BASE_PATH).
+	BasePath string
+
+	// StreamWriteTimeout ist die Schreibfrist je Zeile eines NDJSON-Streams.
+	// Sie ersetzt das WriteTimeout des Servers, das sonst einen langen
+	// Export mitten im Stream abbrechen würde; 0 hebt die Frist ganz auf.
+	StreamWriteTimeout time.Duration
+
+	// LoadReporter liefert den Ladebericht für GET /admin/load-report; nil,
+	// wenn die Datenquelle keinen hat.
+	LoadReporter LoadReporter
+	// Reloader lädt die Datenquelle für POST /admin/reload neu; nil, wenn
+	// die Datenquelle das nicht kann.
+	Reloader Reloader
 }
 
 // PersonHandler stellt Personen-Endpunkte über HTTP bereit.
 type PersonHandler struct {
 	service PersonService
 	logger  *zap.Logger
+	opts    Options
+	graphQL graphql.Schema
 }
 
 // NewPersonHandler erstellt einen neuen PersonHandler.
-func NewPersonHandler(svc PersonService, logger *zap.Logger) *PersonHandler {
-	return &PersonHandler{service: svc, logger: logger}
+func NewPersonHandler(svc PersonService, logger *zap.Logger, opts Options) *PersonHandler {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxRequestBody
+	}
+	if opts.MaxIDs <= 0 {
+		opts.MaxIDs = defaultMaxIDs
+	}
+	h := &PersonHandler{service: svc, logger: logger, opts: opts}
+	h.graphQL = h.graphQLSchema()
+	return h
 }
 
-// GetAll gibt alle Personen zurück.
+// GetAll gibt alle Personen zurück, optional eingeschränkt über die
+// Query-Parameter color, createdAfter (RFC3339), limit und offset. Fehler
+// haben in jedem Modus die Form errorBody.
+//
+// Mit ?ids=1,5,8 werden gezielt diese Personen geladen (siehe getByIDs), mit
+// ?cursor= seitenweise per Keyset-Paginierung (siehe listAfter).
+//
+// Bei "Accept: application/x-ndjson" oder ?format=ndjson wird jede nicht
+// gelöschte Person als eigene JSON-Zeile gestreamt, statt ein Array zu
+// puffern; Filter und Paginierung ergeben dann 400 (siehe streamAll).
+//
+// Mit ?envelope=true oder "Accept: application/vnd.assecor.v2+json" wird die
+// Liste samt Paginierungsangaben in listResponse verpackt; ohne bleibt es beim
+// Array.
+//
+// Mit "Accept: application/vnd.api+json" ist die Antwort ein
+// JSON:API-Dokument (siehe writePersons).
+//
+// ?fields= beschränkt jede Person auf die genannten Felder, ?include=hex
+// ergänzt color_hex (siehe personView).
+//
+// ?includeDeleted=true liefert auch vorläufig gelöschte Personen. Die Route
+// verlangt dafür Basic-Auth oder einen API-Schlüssel und antwortet 403, wenn
+// keines von beiden konfiguriert ist (siehe routes.Setup).
 func (h *PersonHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	persons, err := h.service.GetAll(r.Context())
+	// Form und Format der Antwort hängen auch vom Accept-Header ab.
+	varyAccept(w)
+	if r.URL.Query().Get("format") == "ndjson" || accepts(r, contentTypeNDJSON) {
+		h.streamAll(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Has("ids") {
+		h.getByIDs(w, r)
+		return
+	}
+	if q.Has("cursor") {
+		h.listAfter(w, r)
+		return
+	}
+	limit, offset, err := h.pagination(r)
 	if err != nil {
-		h.logger.Error("alle personen abrufen", zap.Error(err))
-		writeJSON(w, http.StatusInternalServerError, errorBody{"interner serverfehler"})
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, persons)
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	filter := domain.PersonFilter{
+		Color:          domain.Color(q.Get("color")),
+		Limit:          limit,
+		Offset:         offset,
+		IncludeDeleted: IncludeDeleted(r),
+	}
+	if v := q.Get("createdAfter"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidCreatedAfter))
+			return
+		}
+		filter.CreatedAfter = t
+	}
+
+	persons, err := h.service.Find(r.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "personen abrufen", err)
+		}
+		return
+	}
+	if !wantsEnvelope(r) {
+		h.writePersons(w, r, http.StatusOK, view, persons)
+		return
+	}
+
+	meta, err := h.listMeta(r.Context(), filter)
+	if err != nil {
+		h.serverError(w, r, "personen zählen", err)
+		return
+	}
+	if wantsJSONAPI(r) {
+		doc, err := personsDocument(view, persons)
+		if err != nil {
+			h.serverError(w, r, "personen kodieren", err)
+			return
+		}
+		doc.Meta = meta
+		writeJSONAs(w, http.StatusOK, jsonapi.MediaType, doc)
+		return
+	}
+	body := listResponse{Data: view.persons(persons), Meta: meta}
+	if accepts(r, contentTypeEnvelope) {
+		writeJSONAs(w, http.StatusOK, contentTypeEnvelope, body)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// listResponse ist die Antwort einer Personenliste mit ?envelope=true oder
+// contentTypeEnvelope.
+type listResponse struct {
+	// Data ist []domain.Person oder deren Darstellung nach personView.
+	Data any      `json:"data"`
+	Meta listMeta `json:"meta"`
+}
+
+// listMeta beschreibt die gelieferte Seite. Total zählt alle Treffer des
+// Filters ohne Limit und Offset; Limit 0 bedeutet unbegrenzt.
+type listMeta struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+}
+
+// listMeta ermittelt die Paginierungsangaben zu filter.
+func (h *PersonHandler) listMeta(ctx context.Context, filter domain.PersonFilter) (listMeta, error) {
+	total, err := h.service.Count(ctx, filter)
+	if err != nil {
+		return listMeta{}, err
+	}
+	return listMeta{Limit: filter.Limit, Offset: filter.Offset, Total: total}, nil
+}
+
+// IncludeDeleted meldet, ob die Anfrage ?includeDeleted=true (oder 1) enthält.
+// Die Routen nutzen es, um solche Anfragen zusätzlich abzusichern.
+func IncludeDeleted(r *http.Request) bool {
+	ok, err := strconv.ParseBool(r.URL.Query().Get("includeDeleted"))
+	return err == nil && ok
+}
+
+// wantsEnvelope meldet, ob der Client ?envelope=true (oder 1) oder per
+// Accept contentTypeEnvelope angefragt hat.
+func wantsEnvelope(r *http.Request) bool {
+	ok, err := strconv.ParseBool(r.URL.Query().Get("envelope"))
+	return err == nil && ok || accepts(r, contentTypeEnvelope)
+}
+
+// getByIDs beantwortet GET /persons?ids=1,5,8 in der angefragten Reihenfolge.
+// Unbekannte IDs werden ausgelassen, doppelte nur einmal geliefert. Eine
+// leere, fehlerhafte oder zu lange Liste sowie die Kombination mit color,
+// limit oder offset ergeben 400.
+func (h *PersonHandler) getByIDs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Has("color") || q.Has("limit") || q.Has("offset") {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeIDsCombined))
+		return
+	}
+
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	raw := strings.Split(q.Get("ids"), ",")
+	if len(raw) > h.opts.MaxIDs {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeTooManyIDs, h.opts.MaxIDs))
+		return
+	}
+	ids := make([]int, 0, len(raw))
+	for _, s := range raw {
+		id, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidIDs))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	persons, err := h.service.GetByIDs(r.Context(), ids)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "personen nach ids abrufen", err)
+		}
+		return
+	}
+	h.writePersons(w, r, http.StatusOK, view, persons)
+}
+
+// listAfter beantwortet GET /persons?cursor=…&limit=… per Keyset-Paginierung.
+// Ein leerer Cursor beginnt bei der ersten Person; der Cursor der nächsten
+// Seite steht im Header X-Next-Cursor. Filter und offset lassen sich nicht
+// mit einem Cursor kombinieren.
+func (h *PersonHandler) listAfter(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	for _, key := range []string{"color", "createdAfter", "offset"} {
+		if q.Has(key) {
+			writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeCursorCombined, key))
+			return
+		}
+	}
+
+	afterID, err := decodeCursor(q.Get("cursor"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidCursor))
+		return
+	}
+	limit, _, err := h.pagination(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if !q.Has("limit") {
+		limit = defaultCursorLimit
+		if h.opts.MaxPageSize > 0 {
+			limit = min(limit, h.opts.MaxPageSize)
+		}
+	}
+
+	persons, next, err := h.service.ListAfter(r.Context(), afterID, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "personen seitenweise abrufen", err)
+		}
+		return
+	}
+	if next > 0 {
+		w.Header().Set(headerNextCursor, encodeCursor(next))
+	}
+	h.writePersons(w, r, http.StatusOK, view, persons)
+}
+
+// encodeCursor kodiert die zuletzt gelieferte ID als undurchsichtigen Cursor,
+// damit Clients ihn nicht als Seitennummer missverstehen.
+func encodeCursor(afterID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(afterID)))
+}
+
+// decodeCursor ist die Umkehrung von encodeCursor; "" steht für den Anfang.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("cursor %q: %w", cursor, domain.ErrInvalidInput)
+	}
+	return id, nil
+}
+
+// ndjsonCombined sind die Query-Parameter der Liste, die der NDJSON-Stream
+// nicht auswertet. Er liefert immer alle nicht gelöschten Personen; statt
+// einen Filter stillschweigend zu übergehen, lehnt streamAll ihn ab.
+var ndjsonCombined = []string{"color", "createdAfter", "limit", "offset", "cursor", "ids", "includeDeleted"}
+
+// streamAll schreibt alle nicht gelöschten Personen als NDJSON und flusht
+// alle ndjsonFlushEvery Zeilen. Ein Parameter aus ndjsonCombined ergibt 400,
+// gleich ob NDJSON per ?format= oder per Accept gewählt wurde. Tritt ein
+// Fehler auf, bevor die erste Zeile geschrieben wurde, folgt eine reguläre
+// 500-Antwort; danach kann der Status nicht mehr geändert werden und der
+// Fehler wird nur geloggt. Trennt der Client die Verbindung, bricht die
+// Iteration über den Request-Kontext ab.
+func (h *PersonHandler) streamAll(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	for _, key := range ndjsonCombined {
+		if q.Has(key) {
+			writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeNDJSONCombined, key))
+			return
+		}
+	}
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	enc := json.NewEncoder(w)
+	rc := http.NewResponseController(w)
+	started := false
+	written := 0
+
+	err = h.service.StreamAll(r.Context(), func(p domain.Person) error {
+		if !started {
+			w.Header().Set("Content-Type", contentTypeNDJSON)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		h.extendWriteDeadline(rc)
+		if err := enc.Encode(view.person(p)); err != nil {
+			return err
+		}
+		written++
+		if written%ndjsonFlushEvery == 0 {
+			_ = rc.Flush()
+		}
+		return nil
+	})
+	if r.Context().Err() != nil {
+		h.logger.Debug("personen-stream vom client abgebrochen", zap.Int("zeilen", written))
+		return
+	}
+	if err != nil {
+		h.logger.Error("personen streamen", zap.Error(err), zap.Bool("begonnen", started))
+		if !started {
+			writeError(w, r, http.StatusInternalServerError, i18n.New(i18n.CodeInternal))
+		}
+		return
+	}
+	if !started {
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// extendWriteDeadline setzt die Schreibfrist auf StreamWriteTimeout ab jetzt
+// oder hebt sie ohne StreamWriteTimeout auf. Unterstützt der ResponseWriter
+// keine Fristen (etwa im httptest.ResponseRecorder), gilt weiter die des
+// Servers.
+func (h *PersonHandler) extendWriteDeadline(rc *http.ResponseController) {
+	var deadline time.Time
+	if h.opts.StreamWriteTimeout > 0 {
+		deadline = time.Now().Add(h.opts.StreamWriteTimeout)
+	}
+	_ = rc.SetWriteDeadline(deadline)
 }
 
 // GetByID gibt eine einzelne Person anhand ihrer ID zurück.
 func (h *PersonHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	h.getByID(w, r, true)
+}
+
+// HeadByID beantwortet HEAD /persons/{id} mit denselben Headern wie GetByID
+// (Status, ETag, Content-Length), aber ohne Body. So prüfen Clients Existenz
+// und Version, ohne die Person zu übertragen.
+func (h *PersonHandler) HeadByID(w http.ResponseWriter, r *http.Request) {
+	h.getByID(w, r, false)
+}
+
+// getByID lädt die Person {id} und setzt die Antwort-Header; den Body schreibt
+// es nur mit withBody. Content-Length wird aus dem kodierten Body berechnet,
+// damit GET und HEAD denselben Wert melden.
+func (h *PersonHandler) getByID(w http.ResponseWriter, r *http.Request, withBody bool) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, errorBody{"id muss eine ganzzahl sein"})
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeIDNotInteger))
+		return
+	}
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
 	person, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
+		err = h.personError(r, err, id, i18n.CodePersonNotFound)
 		switch {
+		case h.opts.ShowGone && errors.Is(err, domain.ErrGone):
+			writeError(w, r, http.StatusGone, err)
 		case errors.Is(err, domain.ErrNotFound):
-			writeJSON(w, http.StatusNotFound, errorBody{err.Error()})
+			writeError(w, r, http.StatusNotFound, err)
 		case errors.Is(err, domain.ErrInvalidInput):
-			writeJSON(w, http.StatusBadRequest, errorBody{err.Error()})
+			writeError(w, r, http.StatusBadRequest, err)
 		default:
-			h.logger.Error("person nach id abrufen", zap.Error(err))
-			writeJSON(w, http.StatusInternalServerError, errorBody{"interner serverfehler"})
+			h.serverError(w, r, "person nach id abrufen", err)
 		}
 		return
 	}
-	writeJSON(w, http.StatusOK, person)
+
+	contentType, v, err := personBody(r, view, person)
+	if err != nil {
+		h.serverError(w, r, "person kodieren", err)
+		return
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		h.serverError(w, r, "person kodieren", err)
+		return
+	}
+	body = append(body, '\n')
+	varyAccept(w)
+	w.Header().Set("ETag", etag(person.Version))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if withBody {
+		_, _ = w.Write(body)
+	}
 }
 
-// GetByColor gibt alle Personen mit passender Lieblingsfarbe zurück.
+// GetByColor gibt alle Personen mit passender Lieblingsfarbe zurück. Eine
+// unbekannte Farbe ergibt 400.
+//
+// Unterstützt die Query-Parameter limit, offset und sort, etwa sort=lastname
+// oder sort=-created_at (siehe domain.ParseOrder); ohne sort ist die
+// Reihenfolge die der IDs. Ein unbekanntes Sortierfeld ergibt 400.
+//
+// Passt eine gültige Farbe auf keine Person, ist die Antwort 200 mit [].
+// Mit ?strict=true ist sie stattdessen 404 mit colorErrorBody, damit Clients
+// "noch keine Personen" nicht erst an der Länge der Liste erkennen. Eine
+// Seite hinter dem letzten Treffer bleibt in beiden Fällen [].
 func (h *PersonHandler) GetByColor(w http.ResponseWriter, r *http.Request) {
-	color := chi.URLParam(r, "color")
+	h.getByColor(w, r, chi.URLParam(r, "color"))
+}
+
+// GetByColorID wie GetByColor, aber mit der numerischen Farb-ID aus der CSV
+// statt des Namens. Nicht numerische oder unbekannte IDs ergeben 400.
+func (h *PersonHandler) GetByColorID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeColorIDNotInteger))
+		return
+	}
+	color, err := domain.ColorByID(id)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	h.getByColor(w, r, color.String())
+}
+
+func (h *PersonHandler) getByColor(w http.ResponseWriter, r *http.Request, color string) {
+	limit, offset, err := h.pagination(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
 
-	persons, err := h.service.GetByColor(r.Context(), color)
+	persons, err := h.service.GetByColor(r.Context(), color, r.URL.Query().Get("sort"), limit, offset)
 	if err != nil {
 		switch {
+		case errors.Is(err, domain.ErrNoPersonsWithColor):
+			if !strictColor(r) {
+				h.writePersons(w, r, http.StatusOK, view, []domain.Person{})
+				return
+			}
+			parsed, _ := domain.ParseColor(color)
+			writeJSON(w, http.StatusNotFound, colorErrorBody{
+				errorBody: newErrorBody(r, err),
+				Color:     parsed,
+			})
 		case errors.Is(err, domain.ErrInvalidInput):
-			writeJSON(w, http.StatusBadRequest, errorBody{err.Error()})
+			writeError(w, r, http.StatusBadRequest, err)
 		default:
-			h.logger.Error("personen nach farbe abrufen", zap.Error(err))
-			writeJSON(w, http.StatusInternalServerError, errorBody{"interner serverfehler"})
+			h.serverError(w, r, "personen nach farbe abrufen", err)
 		}
 		return
 	}
-	writeJSON(w, http.StatusOK, persons)
+	h.writePersons(w, r, http.StatusOK, view, persons)
 }
 
-// Create fügt einen neuen Personendatensatz hinzu.
-// Der Request-Body wird auf maxRequestBody begrenzt (Exploit 1).
-func (h *PersonHandler) Create(w http.ResponseWriter, r *http.Request) {
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+// strictColor meldet, ob die Anfrage ?strict=true (oder 1) enthält.
+func strictColor(r *http.Request) bool {
+	ok, err := strconv.ParseBool(r.URL.Query().Get("strict"))
+	return err == nil && ok
+}
+
+// GroupByColor gibt alle Personen nach Farbe gruppiert in einem Objekt zurück,
+// etwa {"blau":[...],"grün":[]}. Jede bekannte Farbe erscheint, auch ohne
+// Personen. ?colors=blau,rot schränkt die Farben ein; limit und offset
+// begrenzen wie bei GetAll die Gesamtzahl der Personen.
+func (h *PersonHandler) GroupByColor(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := h.pagination(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	var colors []string
+	for _, c := range strings.Split(r.URL.Query().Get("colors"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			colors = append(colors, c)
+		}
+	}
+
+	groups, err := h.service.GroupByColor(r.Context(), colors, limit, offset)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "personen nach farben gruppieren", err)
+		}
+		return
+	}
+	if view.plain() {
+		writeJSON(w, http.StatusOK, groups)
+		return
+	}
+	projected := make(map[domain.Color]any, len(groups))
+	for color, persons := range groups {
+		projected[color] = view.persons(persons)
+	}
+	writeJSON(w, http.StatusOK, projected)
+}
+
+// GetByZipcode gibt alle Personen mit der Postleitzahl {zip} zurück.
+// Unterstützt die Query-Parameter limit und offset.
+func (h *PersonHandler) GetByZipcode(w http.ResponseWriter, r *http.Request) {
+	h.getByZipcode(w, r, false)
+}
+
+// GetByZipcodePrefix gibt alle Personen zurück, deren Postleitzahl mit {zip}
+// beginnt, etwa alle aus der Region 677. Unterstützt limit und offset.
+func (h *PersonHandler) GetByZipcodePrefix(w http.ResponseWriter, r *http.Request) {
+	h.getByZipcode(w, r, true)
+}
+
+func (h *PersonHandler) getByZipcode(w http.ResponseWriter, r *http.Request, prefix bool) {
+	limit, offset, err := h.pagination(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	persons, err := h.service.GetByZipcode(r.Context(), chi.URLParam(r, "zip"), prefix, limit, offset)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "personen nach postleitzahl abrufen", err)
+		}
+		return
+	}
+	h.writePersons(w, r, http.StatusOK, view, persons)
+}
+
+// SameColor gibt alle anderen Personen mit derselben Lieblingsfarbe wie die
+// Person {id} zurück. Unterstützt die Query-Parameter limit und offset.
+func (h *PersonHandler) SameColor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeIDNotInteger))
+		return
+	}
+	limit, offset, err := h.pagination(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	view, err := parsePersonView(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	persons, err := h.service.SameColorAs(r.Context(), id, limit, offset)
+	if err != nil {
+		err = h.personError(r, err, id, i18n.CodePersonNotFound)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			writeError(w, r, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "personen mit gleicher farbe abrufen", err)
+		}
+		return
+	}
+	h.writePersons(w, r, http.StatusOK, view, persons)
+}
+
+// colorInfo beschreibt eine Farbe in der Antwort von Colors. Hex fehlt für
+// Farben ohne Farbwert.
+type colorInfo struct {
+	ID   int          `json:"id"`
+	Name domain.Color `json:"name"`
+	Hex  string       `json:"hex,omitempty"`
+}
+
+// Colors gibt alle bekannten Farben nach ID sortiert mit ihrem Farbwert
+// zurück, damit Clients die Farbfelder einmal laden können, statt sie mit
+// ?include=hex an jeder Person abzufragen.
+func (h *PersonHandler) Colors(w http.ResponseWriter, r *http.Request) {
+	colors := domain.AllColors()
+	out := make([]colorInfo, 0, len(colors))
+	for _, c := range colors {
+		out = append(out, colorInfo{ID: c.ID(), Name: c, Hex: c.Hex()})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
 
+// ColorCounts gibt für jede bekannte Farbe die Anzahl der Personen zurück,
+// auch für Farben ohne Personen.
+func (h *PersonHandler) ColorCounts(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.service.CountsByColor(r.Context())
+	if err != nil {
+		h.serverError(w, r, "personen je farbe zählen", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, counts)
+}
+
+// Stats gibt Gesamtzahl sowie Anzahl der Personen je Farbe und je Stadt zurück.
+func (h *PersonHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.Stats(r.Context())
+	if err != nil {
+		h.serverError(w, r, "statistik berechnen", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// Cities gibt alle bekannten Städte sortiert und ohne Duplikate zurück, damit
+// Clients eine Autovervollständigung ohne Laden aller Personen anbieten können.
+func (h *PersonHandler) Cities(w http.ResponseWriter, r *http.Request) {
+	cities, err := h.service.DistinctCities(r.Context())
+	if err != nil {
+		h.serverError(w, r, "städte abrufen", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cities)
+}
+
+// Create fügt einen neuen Personendatensatz hinzu und verweist per Location-Header
+// auf die angelegte Ressource.
+// Der Request-Body wird auf Options.MaxBodyBytes begrenzt (Exploit 1) und vor
+// dem Dekodieren gegen person.schema.json geprüft. Neben JSON nimmt Create
+// für ältere Clients application/x-www-form-urlencoded mit denselben Feldern
+// an; ein solcher Body durchläuft als JSON-Objekt dieselben Prüfungen (siehe
+// formAsJSON). Jeder andere oder ein fehlender Content-Type ergibt 415.
+func (h *PersonHandler) Create(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := requireMediaType(w, r, "application/json", "application/x-www-form-urlencoded")
+	if !ok {
+		return
+	}
+	raw, ok := readBody(w, r, h.opts.MaxBodyBytes)
+	if !ok {
+		return
+	}
+	if mediaType == "application/x-www-form-urlencoded" {
+		var err error
+		if raw, err = formAsJSON(raw); err != nil {
+			writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidFormBody))
+			return
+		}
+	}
+	if !validateSchema(w, r, personSchema, raw) {
+		return
+	}
 	var p domain.Person
-	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorBody{"ungültiger anfrage-body"})
+	if err := json.Unmarshal(raw, &p); err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidBody))
 		return
 	}
 
 	created, err := h.service.Add(r.Context(), p)
 	if err != nil {
+		err = h.capacityError(r, err)
 		switch {
 		case errors.Is(err, domain.ErrCapacityReached):
-			writeJSON(w, http.StatusServiceUnavailable, errorBody{err.Error()})
+			// Kein Retry-After: Gelöschte Personen zählen nicht zur Grenze,
+			// daher schafft auch Purge keinen Platz. Nur ein DELETE hilft.
+			writeError(w, r, http.StatusServiceUnavailable, err)
 		case errors.Is(err, domain.ErrInvalidInput):
-			writeJSON(w, http.StatusBadRequest, errorBody{err.Error()})
+			writeError(w, r, http.StatusBadRequest, err)
 		default:
-			h.logger.Error("person erstellen", zap.Error(err))
-			writeJSON(w, http.StatusInternalServerError, errorBody{"interner serverfehler"})
+			h.serverError(w, r, "person erstellen", err)
 		}
 		return
 	}
-	writeJSON(w, http.StatusCreated, created)
+	w.Header().Set("Location", h.opts.BasePath+"/persons/"+strconv.Itoa(created.ID))
+	h.setCapacityRemaining(w, r)
+	h.writePerson(w, r, http.StatusCreated, personView{}, created)
 }
 
-// errorBody ist die einheitliche Fehlerantwort-Struktur.
+// Update ersetzt die Person {id} vollständig durch den Request-Body (PUT).
+// Die erwartete Version steht im If-Match-Header (siehe ifMatchVersion). Mit
+// Options.PutUpsert wird eine fehlende Person angelegt.
+func (h *PersonHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeIDNotInteger))
+		return
+	}
+	version, ok := h.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var p domain.Person
+	if !decodeJSON(w, r, h.opts.MaxBodyBytes, &p) {
+		return
+	}
+	if h.opts.PutUpsert {
+		h.upsert(w, r, id, p, version)
+		return
+	}
+	h.update(w, r, id, p, version)
+}
+
+// Patch ändert nur die im Request-Body enthaltenen Felder der Person {id}
+// (PATCH). Ohne If-Match bezieht sich die Versionsprüfung auf den zuvor
+// gelesenen Stand, sodass parallele Änderungen nicht verloren gehen.
+func (h *PersonHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeIDNotInteger))
+		return
+	}
+	version, ok := h.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	current, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		err = h.personError(r, err, id, i18n.CodePersonNotFound)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			writeError(w, r, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "person nach id abrufen", err)
+		}
+		return
+	}
+	if version == 0 {
+		version = current.Version
+	}
+
+	// Der Body wird auf den aktuellen Stand dekodiert; fehlende Felder bleiben erhalten.
+	if !decodeJSON(w, r, h.opts.MaxBodyBytes, &current) {
+		return
+	}
+	h.update(w, r, id, current, version)
+}
+
+// update führt Update und Patch zusammen.
+func (h *PersonHandler) update(w http.ResponseWriter, r *http.Request, id int, p domain.Person, version int) {
+	updated, err := h.service.Update(r.Context(), id, p, version)
+	if err != nil {
+		h.updateError(w, r, id, err)
+		return
+	}
+	w.Header().Set("ETag", etag(updated.Version))
+	h.writePerson(w, r, http.StatusOK, personView{}, updated)
+}
+
+// upsert antwortet wie update, für eine neu angelegte Person aber mit 201
+// und Location-Header.
+func (h *PersonHandler) upsert(w http.ResponseWriter, r *http.Request, id int, p domain.Person, version int) {
+	person, created, err := h.service.Upsert(r.Context(), id, p, version)
+	if err != nil {
+		h.updateError(w, r, id, err)
+		return
+	}
+	w.Header().Set("ETag", etag(person.Version))
+	if !created {
+		h.writePerson(w, r, http.StatusOK, personView{}, person)
+		return
+	}
+	w.Header().Set("Location", h.opts.BasePath+"/persons/"+strconv.Itoa(person.ID))
+	h.setCapacityRemaining(w, r)
+	h.writePerson(w, r, http.StatusCreated, personView{}, person)
+}
+
+// updateError bildet die Fehler von update und upsert auf Statuscodes ab. Ein
+// Versionskonflikt ergibt 412 mit der aktuellen Version im Body.
+func (h *PersonHandler) updateError(w http.ResponseWriter, r *http.Request, id int, err error) {
+	err = h.personError(r, err, id, i18n.CodePersonNotFound)
+	var conflict *domain.VersionConflictError
+	switch {
+	case errors.As(err, &conflict):
+		w.Header().Set("ETag", etag(conflict.Current))
+		writeJSON(w, http.StatusPreconditionFailed, conflictBody{
+			errorBody:      newErrorBody(r, err),
+			CurrentVersion: conflict.Current,
+		})
+	case errors.Is(err, domain.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrCapacityReached):
+		writeError(w, r, http.StatusServiceUnavailable, err)
+	case errors.Is(err, domain.ErrInvalidInput):
+		writeError(w, r, http.StatusBadRequest, err)
+	default:
+		h.serverError(w, r, "person aktualisieren", err)
+	}
+}
+
+// ifMatchVersion liest die erwartete Version aus dem If-Match-Header. Fehlt
+// der Header, ist das Ergebnis 0 (keine Prüfung) – sofern Options.RequireIfMatch
+// ihn nicht verlangt. "*" gilt ebenfalls als 0. Im Fehlerfall wurde bereits
+// geantwortet und ok ist false.
+func (h *PersonHandler) ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		if h.opts.RequireIfMatch {
+			writeError(w, r, http.StatusPreconditionRequired, i18n.New(i18n.CodeIfMatchRequired))
+			return 0, false
+		}
+		return 0, true
+	}
+	if header == "*" {
+		return 0, true
+	}
+	version, err := parseETag(header)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidIfMatch))
+		return 0, false
+	}
+	return version, true
+}
+
+// etag bildet eine Version auf ein schwaches ETag ab, z. B. W/"3". Es ist
+// schwach, weil die JSON-Darstellung nicht Byte für Byte garantiert ist.
+func etag(version int) string {
+	return `W/"` + strconv.Itoa(version) + `"`
+}
+
+// parseETag ist die Umkehrung von etag und akzeptiert auch die starke Form "3".
+func parseETag(tag string) (int, error) {
+	tag = strings.TrimPrefix(tag, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, fmt.Errorf("etag %q: %w", tag, domain.ErrInvalidInput)
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("etag %q: %w", tag, domain.ErrInvalidInput)
+	}
+	return version, nil
+}
+
+// Delete löscht die Person {id} vorläufig (siehe Restore).
+func (h *PersonHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeIDNotInteger))
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		err = h.personError(r, err, id, i18n.CodePersonNotFound)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			writeError(w, r, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "person löschen", err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Restore stellt die vorläufig gelöschte Person {id} wieder her.
+func (h *PersonHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeIDNotInteger))
+		return
+	}
+
+	restored, err := h.service.Restore(r.Context(), id)
+	if err != nil {
+		err = h.personError(r, err, id, i18n.CodeDeletedPersonNotFound)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			writeError(w, r, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrCapacityReached):
+			writeError(w, r, http.StatusServiceUnavailable, err)
+		case errors.Is(err, domain.ErrInvalidInput):
+			writeError(w, r, http.StatusBadRequest, err)
+		default:
+			h.serverError(w, r, "person wiederherstellen", err)
+		}
+		return
+	}
+	w.Header().Set("ETag", etag(restored.Version))
+	h.writePerson(w, r, http.StatusOK, personView{}, restored)
+}
+
+// DeleteAll entfernt alle Personen. Nur verfügbar, wenn destruktive
+// Operationen erlaubt sind (ALLOW_DESTRUCTIVE), sonst 403.
+func (h *PersonHandler) DeleteAll(w http.ResponseWriter, r *http.Request) {
+	if !h.opts.AllowDestructive {
+		writeError(w, r, http.StatusForbidden, i18n.New(i18n.CodeDestructiveDisabled))
+		return
+	}
+
+	if err := h.service.DeleteAll(r.Context()); err != nil {
+		h.serverError(w, r, "alle personen löschen", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteByColorResponse ist die Antwort von DELETE /persons/color/{color}.
+type deleteByColorResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// DeleteByColor löscht alle Personen einer Farbe vorläufig und antwortet mit
+// {"deleted": n}. Wie DeleteAll nur mit Options.AllowDestructive; eine
+// unbekannte Farbe ergibt 400.
+func (h *PersonHandler) DeleteByColor(w http.ResponseWriter, r *http.Request) {
+	if !h.opts.AllowDestructive {
+		writeError(w, r, http.StatusForbidden, i18n.New(i18n.CodeDestructiveDisabled))
+		return
+	}
+
+	n, err := h.service.DeleteByColor(r.Context(), chi.URLParam(r, "color"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		h.serverError(w, r, "personen nach farbe löschen", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deleteByColorResponse{Deleted: n})
+}
+
+// decodeJSON liest höchstens limit Bytes aus dem Body und dekodiert sie in dst.
+// Im Fehlerfall wird bereits geantwortet – 413 bei zu großem Body, sonst 400 –
+// und false zurückgegeben. Endpunkte mit größeren Bodys (z. B. Massenimporte)
+// übergeben ein eigenes limit.
+func decodeJSON(w http.ResponseWriter, r *http.Request, limit int64, dst any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, i18n.New(i18n.CodeBodyTooLarge, tooLarge.Limit))
+			return false
+		}
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidBody))
+		return false
+	}
+	return true
+}
+
+// errorBody ist die einheitliche Fehlerantwort-Struktur. Code ist der
+// stabile Fehlercode aus i18n, Error die Meldung in der per Accept-Language
+// gewählten Sprache.
 type errorBody struct {
-	Error string `json:"error"`
+	Error     string    `json:"error"`
+	Code      i18n.Code `json:"code"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// newErrorBody formuliert err in der Sprache aus dem Accept-Language-Header
+// von r.
+func newErrorBody(r *http.Request, err error) errorBody {
+	code, msg := i18n.Render(requestLang(r), err)
+	return errorBody{Error: msg, Code: code, RequestID: chimw.GetReqID(r.Context())}
+}
+
+// requestLang ist die per Accept-Language gewählte Sprache für Meldungen.
+func requestLang(r *http.Request) i18n.Lang {
+	return i18n.Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// conflictBody ist die Antwort auf einen Versionskonflikt (412).
+type conflictBody struct {
+	errorBody
+	CurrentVersion int `json:"current_version"`
+}
+
+// colorErrorBody ist die Antwort von GetByColor mit ?strict=true, wenn eine
+// gültige Farbe auf keine Person passt (404).
+type colorErrorBody struct {
+	errorBody
+	Color domain.Color `json:"color"`
+}
+
+// writeError schreibt eine Fehlerantwort inklusive der Request-ID, damit
+// Client-Meldungen den Logeinträgen zugeordnet werden können.
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	writeJSON(w, status, newErrorBody(r, err))
+}
+
+// personError vergibt für einen Fehler zur Person id, der als Code nur ein
+// Sentinel aus domain trägt, den genauen Code samt Parametern. Repositories
+// kennen keine Codes; die Zuordnung geschieht erst hier, wo Route und Person
+// bekannt sind. notFound ist der Code für domain.ErrNotFound, etwa
+// i18n.CodeDeletedPersonNotFound beim Wiederherstellen. Fehler mit genauerem
+// Code bleiben unverändert, der deutsche Wortlaut in jedem Fall.
+func (h *PersonHandler) personError(r *http.Request, err error, id int, notFound i18n.Code) error {
+	switch code, _ := i18n.Render(i18n.Default, err); code {
+	case i18n.CodeGone:
+		return i18n.Annotate(err, i18n.CodePersonGone, id)
+	case i18n.CodeNotFound:
+		return i18n.Annotate(err, notFound, id)
+	case i18n.CodeCapacityReached:
+		return h.capacityError(r, err)
+	}
+	return err
+}
+
+// capacityError ergänzt domain.ErrCapacityReached wie personError um den
+// Code mit der Kapazitätsgrenze. Ist sie nicht zu ermitteln, bleibt err
+// unverändert.
+func (h *PersonHandler) capacityError(r *http.Request, err error) error {
+	if code, _ := i18n.Render(i18n.Default, err); code != i18n.CodeCapacityReached {
+		return err
+	}
+	capacity, cerr := h.service.Capacity(r.Context())
+	if cerr != nil || capacity.Max <= 0 {
+		return err
+	}
+	return i18n.Annotate(err, i18n.CodeMaxPersons, capacity.Max)
+}
+
+// statusClientClosedRequest ist der von nginx eingeführte, nicht
+// standardisierte Status für Anfragen, die der Client vor der Antwort abbricht.
+const statusClientClosedRequest = 499
+
+// serverError beantwortet einen Fehler, den der Handler keiner fachlichen
+// Ursache zuordnen kann. Ein abgebrochener Request-Kontext ergibt 499, eine
+// abgelaufene Frist 504; beides ist kein Serverfehler und wird nicht als
+// Error geloggt. Alles andere wird unter op geloggt und mit 500 beantwortet.
+func (h *PersonHandler) serverError(w http.ResponseWriter, r *http.Request, op string, err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		h.logger.Debug("anfrage vom client abgebrochen", zap.String("vorgang", op))
+		writeError(w, r, statusClientClosedRequest, i18n.New(i18n.CodeRequestCanceled))
+	case errors.Is(err, context.DeadlineExceeded):
+		h.logger.Warn("zeitlimit überschritten", zap.String("vorgang", op))
+		writeError(w, r, http.StatusGatewayTimeout, i18n.New(i18n.CodeTimeout))
+	default:
+		h.logger.Error(op, zap.Error(err))
+		writeError(w, r, http.StatusInternalServerError, i18n.New(i18n.CodeInternal))
+	}
+}
+
+// maxPaginationValue begrenzt limit und offset, damit Summen wie offset+limit
+// auch auf 32-Bit-Plattformen und in SQLite nicht überlaufen.
+const maxPaginationValue = 1<<31 - 1
+
+// parsePagination liest die Query-Parameter limit und offset. Fehlende oder
+// leere Parameter ergeben 0 (limit 0 = unbegrenzt). Werte, die keine Ganzzahl,
+// negativ oder größer als maxPaginationValue sind, ergeben einen Fehler mit
+// domain.ErrInvalidInput, dessen Text direkt an den Client gehen kann.
+func parsePagination(r *http.Request) (limit, offset int, err error) {
+	if limit, err = paginationParam(r, "limit"); err != nil {
+		return 0, 0, err
+	}
+	if offset, err = paginationParam(r, "offset"); err != nil {
+		return 0, 0, err
+	}
+	return limit, offset, nil
+}
+
+// pagination liest limit und offset (siehe parsePagination) und begrenzt ein
+// limit über Options.MaxPageSize: Es wird gekappt oder, mit
+// Options.RejectOversizedPage, als ungültige Eingabe abgelehnt.
+func (h *PersonHandler) pagination(r *http.Request) (limit, offset int, err error) {
+	if limit, offset, err = parsePagination(r); err != nil {
+		return 0, 0, err
+	}
+	if limit, err = h.capPageSize(limit); err != nil {
+		return 0, 0, err
+	}
+	return limit, offset, nil
+}
+
+// capPageSize kappt limit auf Options.MaxPageSize oder lehnt es mit
+// RejectOversizedPage ab.
+func (h *PersonHandler) capPageSize(limit int) (int, error) {
+	if max := h.opts.MaxPageSize; max > 0 && limit > max {
+		if h.opts.RejectOversizedPage {
+			return 0, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeLimitTooLarge, max)
+		}
+		limit = max
+	}
+	return limit, nil
+}
+
+// paginationParam liest einen einzelnen Paginierungsparameter (siehe parsePagination).
+func paginationParam(r *http.Request, key string) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, errPaginationRange(key)
+	}
+	return n, checkPaginationValue(key, n)
+}
+
+// checkPaginationValue meldet, ob n außerhalb von 0 bis maxPaginationValue liegt.
+func checkPaginationValue(key string, n int) error {
+	if n < 0 || n > maxPaginationValue {
+		return errPaginationRange(key)
+	}
+	return nil
+}
+
+func errPaginationRange(key string) error {
+	return i18n.Wrap(domain.ErrInvalidInput, i18n.CodeInvalidPagination, key, maxPaginationValue)
+}
+
+// accepts meldet, ob der Accept-Header der Anfrage mediaType ausdrücklich nennt.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mt == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// requireMediaType gibt den Medientyp des Request-Bodys zurück, sofern er
+// einer von allowed ist; sonst antwortet es mit 415 und gibt false zurück.
+// Parameter wie charset sind erlaubt; eine Anfrage ohne Content-Type wird
+// ebenfalls mit 415 abgewiesen.
+func requireMediaType(w http.ResponseWriter, r *http.Request, allowed ...string) (string, bool) {
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && slices.Contains(allowed, mt) {
+		return mt, true
+	}
+	writeError(w, r, http.StatusUnsupportedMediaType, i18n.New(i18n.CodeUnsupportedMediaType, i18n.Alternatives(allowed)))
+	return "", false
+}
+
+// formAsJSON wandelt einen Formular-Body in ein JSON-Objekt mit String-Werten
+// um, damit er dieselbe Schema- und Service-Prüfung durchläuft wie JSON. Ein
+// mehrfach angegebenes Feld wird zur Liste und verletzt so das Schema.
+func formAsJSON(raw []byte) ([]byte, error) {
+	values, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any, len(values))
+	for key, v := range values {
+		if len(v) == 1 {
+			doc[key] = v[0]
+		} else {
+			doc[key] = v
+		}
+	}
+	return json.Marshal(doc)
 }
 
 // writeJSON setzt den Content-Type-Header und schreibt v als JSON in w.
 func writeJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
+	writeJSONAs(w, status, "application/json", v)
+}
+
+// writeJSONAs wie writeJSON, mit einem JSON-basierten Medientyp wie
+// contentTypeEnvelope oder jsonapi.MediaType.
+func writeJSONAs(w http.ResponseWriter, status int, contentType string, v any) {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(v)
 }
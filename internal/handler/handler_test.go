@@ -1,60 +1,205 @@
 package handler
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/i18n"
 )
 
 // mockService implementiert PersonService für Handler-Tests.
 type mockService struct {
-	persons []domain.Person
-	nextID  int
+	persons    []domain.Person
+	nextID     int
+	maxPersons int
+	modified   time.Time
 }
 
 func newMockService(persons []domain.Person) *mockService {
 	return &mockService{persons: persons, nextID: len(persons) + 1}
 }
 
-func (m *mockService) GetAll(_ context.Context) ([]domain.Person, error) {
-	out := make([]domain.Person, len(m.persons))
-	copy(out, m.persons)
-	return out, nil
+func (m *mockService) Find(_ context.Context, filter domain.PersonFilter) ([]domain.Person, error) {
+	if filter.Color != "" && filter.Color.ID() == 0 {
+		return nil, domain.ErrInvalidColor
+	}
+	out := make([]domain.Person, 0)
+	for _, p := range m.persons {
+		if filter.Matches(p) {
+			out = append(out, p)
+		}
+	}
+	return domain.Paginate(out, filter.Limit, filter.Offset), nil
+}
+
+func (m *mockService) Count(ctx context.Context, filter domain.PersonFilter) (int, error) {
+	filter.Limit, filter.Offset = 0, 0
+	persons, err := m.Find(ctx, filter)
+	return len(persons), err
+}
+
+func (m *mockService) StreamAll(_ context.Context, fn func(domain.Person) error) error {
+	for _, p := range m.persons {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (m *mockService) GetByID(_ context.Context, id int) (domain.Person, error) {
 	if id <= 0 {
-		return domain.Person{}, fmt.Errorf("id muss positiv sein: %w", domain.ErrInvalidInput)
+		return domain.Person{}, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeIDNotPositive)
 	}
 	for _, p := range m.persons {
 		if p.ID == id {
+			if p.Deleted() {
+				return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrGone)
+			}
 			return p, nil
 		}
 	}
 	return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
 }
 
-func (m *mockService) GetByColor(_ context.Context, color string) ([]domain.Person, error) {
-	if _, ok := domain.ColorNameID[color]; !ok {
-		return nil, fmt.Errorf("ungültige farbe: %w", domain.ErrInvalidInput)
+func (m *mockService) ListAfter(_ context.Context, afterID, limit int) ([]domain.Person, int, error) {
+	if limit <= 0 {
+		return nil, 0, fmt.Errorf("limit muss positiv sein: %w", domain.ErrInvalidInput)
+	}
+	out := make([]domain.Person, 0)
+	next := 0
+	for _, p := range m.persons {
+		if p.ID <= afterID {
+			continue
+		}
+		if len(out) == limit {
+			next = out[limit-1].ID
+			break
+		}
+		out = append(out, p)
+	}
+	return out, next, nil
+}
+
+func (m *mockService) GetByIDs(ctx context.Context, ids []int) ([]domain.Person, error) {
+	out := make([]domain.Person, 0)
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if p, err := m.GetByID(ctx, id); err == nil {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockService) GetByColor(_ context.Context, color, sort string, limit, offset int) ([]domain.Person, error) {
+	order, err := domain.ParseOrder(sort)
+	if err != nil {
+		return nil, err
+	}
+	if domain.Color(color).ID() == 0 {
+		return nil, domain.ErrInvalidColor
+	}
+	out := make([]domain.Person, 0)
+	for _, p := range m.persons {
+		if p.Color == domain.Color(color) {
+			out = append(out, p)
+		}
+	}
+	if len(out) == 0 {
+		return nil, domain.ErrNoPersonsWithColor
+	}
+	domain.SortPersons(out, order)
+	return domain.Paginate(out, limit, offset), nil
+}
+
+func (m *mockService) GroupByColor(_ context.Context, colors []string, limit, offset int) (map[domain.Color][]domain.Person, error) {
+	wanted := domain.AllColors()
+	if len(colors) > 0 {
+		wanted = nil
+		for _, c := range colors {
+			color, err := domain.ParseColor(c)
+			if err != nil {
+				return nil, err
+			}
+			wanted = append(wanted, color)
+		}
+	}
+	var matched []domain.Person
+	for _, p := range m.persons {
+		if slices.Contains(wanted, p.Color) {
+			matched = append(matched, p)
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b domain.Person) int { return strings.Compare(string(a.Color), string(b.Color)) })
+	groups := make(map[domain.Color][]domain.Person)
+	for _, c := range wanted {
+		groups[c] = []domain.Person{}
+	}
+	for _, p := range domain.Paginate(matched, limit, offset) {
+		groups[p.Color] = append(groups[p.Color], p)
+	}
+	return groups, nil
+}
+
+func (m *mockService) GetByZipcode(_ context.Context, zip string, prefix bool, limit, offset int) ([]domain.Person, error) {
+	if strings.TrimSpace(zip) == "" {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeZipcodeRequired)
+	}
+	out := make([]domain.Person, 0)
+	for _, p := range m.persons {
+		if p.Zipcode == zip || prefix && strings.HasPrefix(p.Zipcode, zip) {
+			out = append(out, p)
+		}
+	}
+	return domain.Paginate(out, limit, offset), nil
+}
+
+func (m *mockService) SameColorAs(ctx context.Context, id, limit, offset int) ([]domain.Person, error) {
+	person, err := m.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 	out := make([]domain.Person, 0)
 	for _, p := range m.persons {
-		if p.Color == color {
+		if p.Color == person.Color && p.ID != id {
 			out = append(out, p)
 		}
 	}
+	if offset >= len(out) {
+		return []domain.Person{}, nil
+	}
+	out = out[offset:]
+	if limit > 0 && limit < len(out) {
+		out = out[:limit]
+	}
 	return out, nil
 }
 
@@ -62,32 +207,188 @@ func (m *mockService) Add(_ context.Context, person domain.Person) (domain.Perso
 	if person.Name == "" || person.Lastname == "" {
 		return domain.Person{}, fmt.Errorf("name und nachname sind erforderlich: %w", domain.ErrInvalidInput)
 	}
-	if _, ok := domain.ColorNameID[person.Color]; !ok {
-		return domain.Person{}, fmt.Errorf("ungültige farbe: %w", domain.ErrInvalidInput)
+	if person.Color.ID() == 0 {
+		return domain.Person{}, domain.ErrInvalidColor
+	}
+	if c, _ := m.Capacity(context.Background()); c.Remaining() == 0 {
+		return domain.Person{}, fmt.Errorf("max %d personen: %w", m.maxPersons, domain.ErrCapacityReached)
 	}
 	person.ID = m.nextID
+	person.Version = 1
 	m.nextID++
 	m.persons = append(m.persons, person)
 	return person, nil
 }
 
+func (m *mockService) Update(_ context.Context, id int, person domain.Person, expectedVersion int) (domain.Person, error) {
+	if person.Name == "" || person.Lastname == "" {
+		return domain.Person{}, fmt.Errorf("name und nachname sind erforderlich: %w", domain.ErrInvalidInput)
+	}
+	for i, p := range m.persons {
+		if p.ID != id {
+			continue
+		}
+		if expectedVersion > 0 && p.Version != expectedVersion {
+			return domain.Person{}, &domain.VersionConflictError{ID: id, Current: p.Version}
+		}
+		person.ID = id
+		person.Version = p.Version + 1
+		m.persons[i] = person
+		return person, nil
+	}
+	return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
+}
+
+func (m *mockService) Upsert(ctx context.Context, id int, person domain.Person, expectedVersion int) (domain.Person, bool, error) {
+	updated, err := m.Update(ctx, id, person, expectedVersion)
+	if !errors.Is(err, domain.ErrNotFound) || expectedVersion > 0 {
+		return updated, false, err
+	}
+	person.ID = id
+	person.Version = 1
+	m.nextID = max(m.nextID, id+1)
+	m.persons = append(m.persons, person)
+	return person, true, nil
+}
+
+func (m *mockService) LastModified(_ context.Context) (time.Time, error) {
+	return m.modified, nil
+}
+
+func (m *mockService) CountsByColor(_ context.Context) ([]domain.ColorCount, error) {
+	out := make([]domain.ColorCount, 0)
+	for _, color := range domain.AllColors() {
+		c := domain.ColorCount{Color: color}
+		for _, p := range m.persons {
+			if p.Color == c.Color {
+				c.Count++
+			}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (m *mockService) Stats(_ context.Context) (domain.PersonStats, error) {
+	stats := domain.PersonStats{
+		Total:   len(m.persons),
+		ByColor: make(map[domain.Color]int),
+		ByCity:  make(map[string]int),
+	}
+	for _, c := range domain.AllColors() {
+		stats.ByColor[c] = 0
+	}
+	for _, p := range m.persons {
+		stats.ByColor[p.Color]++
+		stats.ByCity[p.City]++
+	}
+	return stats, nil
+}
+
+func (m *mockService) DistinctCities(_ context.Context) ([]string, error) {
+	cities := []string{}
+	for _, p := range m.persons {
+		if p.City != "" && !slices.Contains(cities, p.City) {
+			cities = append(cities, p.City)
+		}
+	}
+	slices.Sort(cities)
+	return cities, nil
+}
+
+func (m *mockService) Delete(_ context.Context, id int) error {
+	for i, p := range m.persons {
+		if p.ID == id && !p.Deleted() {
+			m.persons[i].DeletedAt = time.Now()
+			return nil
+		}
+	}
+	return fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
+}
+
+func (m *mockService) Restore(_ context.Context, id int) (domain.Person, error) {
+	for i, p := range m.persons {
+		if p.ID == id && p.Deleted() {
+			m.persons[i].DeletedAt = time.Time{}
+			m.persons[i].Version++
+			return m.persons[i], nil
+		}
+	}
+	return domain.Person{}, fmt.Errorf("gelöschte person mit id %d: %w", id, domain.ErrNotFound)
+}
+
+func (m *mockService) DeleteAll(_ context.Context) error {
+	m.persons = nil
+	m.nextID = 1
+	return nil
+}
+
+func (m *mockService) DeleteByColor(_ context.Context, color string) (int, error) {
+	if domain.Color(color).ID() == 0 {
+		return 0, domain.ErrInvalidColor
+	}
+	n := 0
+	for i, p := range m.persons {
+		if p.Color == domain.Color(color) && !p.Deleted() {
+			m.persons[i].DeletedAt = time.Now()
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockService) Capacity(_ context.Context) (domain.Capacity, error) {
+	used := 0
+	for _, p := range m.persons {
+		if !p.Deleted() {
+			used++
+		}
+	}
+	return domain.Capacity{Used: used, Max: m.maxPersons}, nil
+}
+
 func setupRouter(h *PersonHandler) *chi.Mux {
 	r := chi.NewRouter()
 	r.Get("/persons", h.GetAll)
 	r.Post("/persons", h.Create)
+	r.Delete("/persons", h.DeleteAll)
+	r.Get("/persons/stats", h.Stats)
+	r.Get("/persons/by-color", h.GroupByColor)
+	r.Get("/persons/zipcode/{zip}", h.GetByZipcode)
+	r.Get("/persons/zipcode/{zip}/prefix", h.GetByZipcodePrefix)
 	r.Get("/persons/{id}", h.GetByID)
+	r.Head("/persons/{id}", h.HeadByID)
+	r.Put("/persons/{id}", h.Update)
+	r.Patch("/persons/{id}", h.Patch)
+	r.Delete("/persons/{id}", h.Delete)
+	r.Post("/persons/{id}/restore", h.Restore)
+	r.Get("/persons/{id}/same-color", h.SameColor)
 	r.Get("/persons/color/{color}", h.GetByColor)
+	r.Delete("/persons/color/{color}", h.DeleteByColor)
+	r.Get("/persons/color/id/{id}", h.GetByColorID)
+	r.Get("/colors", h.Colors)
+	r.Get("/colors/counts", h.ColorCounts)
+	r.Get("/cities", h.Cities)
+	r.Get("/admin/load-report", h.LoadReport)
+	r.Get("/admin/capacity", h.Capacity)
+	r.Post("/admin/reload", h.Reload)
+	r.Get("/graphql", h.GraphQL)
+	r.Post("/graphql", h.GraphQL)
 	return r
 }
 
 func neuerTestHandler() (*PersonHandler, *chi.Mux) {
+	return neuerTestHandlerMit(Options{})
+}
+
+func neuerTestHandlerMit(opts Options) (*PersonHandler, *chi.Mux) {
 	logger, _ := zap.NewDevelopment()
 	svc := newMockService([]domain.Person{
-		{ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"},
-		{ID: 2, Name: "Peter", Lastname: "Petersen", Zipcode: "18439", City: "Stralsund", Color: "grün"},
-		{ID: 3, Name: "Johnny", Lastname: "Johnson", Zipcode: "88888", City: "made up", Color: "violett"},
+		{ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau", Version: 1},
+		{ID: 2, Name: "Peter", Lastname: "Petersen", Zipcode: "18439", City: "Stralsund", Color: "grün", Version: 1},
+		{ID: 3, Name: "Johnny", Lastname: "Johnson", Zipcode: "88888", City: "made up", Color: "violett", Version: 1},
 	})
-	h := NewPersonHandler(svc, logger)
+	h := NewPersonHandler(svc, logger, opts)
 	return h, setupRouter(h)
 }
 
@@ -106,114 +407,904 @@ func TestGetAll_GibtPersonenZurueck(t *testing.T) {
 	assert.Len(t, persons, 3)
 }
 
-func TestGetByID_Gefunden(t *testing.T) {
-	_, router := neuerTestHandler()
-	req := httptest.NewRequest(http.MethodGet, "/persons/1", nil)
-	rec := httptest.NewRecorder()
+func TestGetAll_QueryFilter(t *testing.T) {
+	h, router := neuerTestHandler()
+	_, _ = h.service.(*mockService).Add(context.Background(), domain.Person{Name: "Anna", Lastname: "Blau", Color: "blau"})
 
-	router.ServeHTTP(rec, req)
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []int
+	}{
+		{"farbe", "?color=blau", http.StatusOK, []int{1, 4}},
+		{"farbe mit limit und offset", "?color=blau&limit=1&offset=1", http.StatusOK, []int{4}},
+		{"nur limit", "?limit=2", http.StatusOK, []int{1, 2}},
+		{"unbekannte farbe", "?color=pink", http.StatusBadRequest, nil},
+	}
 
-	assert.Equal(t, http.StatusOK, rec.Code)
-	var p domain.Person
-	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
-	assert.Equal(t, "Hans", p.Name)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons"+tt.query, nil))
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantIDs == nil {
+				return
+			}
+			var persons []domain.Person
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+			ids := make([]int, 0, len(persons))
+			for _, p := range persons {
+				ids = append(ids, p.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
 }
 
-func TestGetByID_NichtGefunden(t *testing.T) {
-	_, router := neuerTestHandler()
-	req := httptest.NewRequest(http.MethodGet, "/persons/999", nil)
-	rec := httptest.NewRecorder()
+// ─── Paginierung ──────────────────────────────────────────────────────────────
 
-	router.ServeHTTP(rec, req)
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantLimit  int
+		wantOffset int
+		wantErr    bool
+	}{
+		{"ohne parameter", "", 0, 0, false},
+		{"leere werte", "?limit=&offset=", 0, 0, false},
+		{"gültige werte", "?limit=10&offset=20", 10, 20, false},
+		{"obergrenze", "?limit=2147483647", maxPaginationValue, 0, false},
+		{"limit keine zahl", "?limit=abc", 0, 0, true},
+		{"offset keine zahl", "?offset=1.5", 0, 0, true},
+		{"negativer offset", "?offset=-5", 0, 0, true},
+		{"negatives limit", "?limit=-1", 0, 0, true},
+		{"über der obergrenze", "?limit=2147483648", 0, 0, true},
+		{"absurd groß", "?offset=99999999999999999999999", 0, 0, true},
+	}
 
-	assert.Equal(t, http.StatusNotFound, rec.Code)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, offset, err := parsePagination(httptest.NewRequest(http.MethodGet, "/persons"+tt.query, nil))
+			if tt.wantErr {
+				require.ErrorIs(t, err, domain.ErrInvalidInput)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLimit, limit)
+			assert.Equal(t, tt.wantOffset, offset)
+		})
+	}
 }
 
-func TestGetByID_UngueltigeID(t *testing.T) {
+func TestPaginierung_UngueltigeWerteErgeben400(t *testing.T) {
 	_, router := neuerTestHandler()
-	req := httptest.NewRequest(http.MethodGet, "/persons/abc", nil)
+
+	for _, target := range []string{
+		"/persons?limit=abc",
+		"/persons?offset=-5",
+		"/persons?limit=99999999999999999999",
+		"/persons/1/same-color?limit=abc",
+		"/persons/1/same-color?offset=-1",
+		"/persons?cursor=&limit=abc",
+		"/persons/color/blau?limit=abc",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		assert.Contains(t, rec.Body.String(), "ganzzahl zwischen 0 und", target)
+	}
+}
+
+func TestPaginierung_MaxPageSizeKappt(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{MaxPageSize: 2})
+
+	tests := []struct {
+		target  string
+		wantLen int
+	}{
+		{"/persons?limit=1000000", 2},
+		{"/persons?limit=1", 1},
+		{"/persons", 3}, // ohne limit bleibt es bei der vollständigen Liste
+		{"/persons/color/blau?limit=1000000", 1},
+		{"/persons/1/same-color?limit=1000000", 0},
+		{"/persons?cursor=", 2},
+		{"/persons?cursor=&limit=50", 2},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		require.Equal(t, http.StatusOK, rec.Code, tt.target)
+		var persons []domain.Person
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons), tt.target)
+		assert.Len(t, persons, tt.wantLen, tt.target)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons?limit=500&envelope=true", nil))
+	var body listResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, 2, body.Meta.Limit, "meta zeigt das gekappte limit")
+}
+
+func TestPaginierung_MaxPageSizeAbgelehnt(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{MaxPageSize: 2, RejectOversizedPage: true})
+
+	for _, target := range []string{
+		"/persons?limit=3",
+		"/persons/color/blau?limit=3",
+		"/persons/1/same-color?limit=3",
+		"/persons?cursor=&limit=3",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		assert.Contains(t, rec.Body.String(), "limit darf höchstens 2 sein", target)
+	}
+
 	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons?cursor=", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "standard-seitengröße wird nicht abgelehnt")
+}
 
-	router.ServeHTTP(rec, req)
+func TestGetAll_Envelope(t *testing.T) {
+	h, router := neuerTestHandler()
+	_, _ = h.service.(*mockService).Add(context.Background(), domain.Person{Name: "Anna", Lastname: "Blau", Color: "blau"})
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
 
+	// Ohne Parameter bleibt es beim Array.
+	rec := get("/persons?color=blau&limit=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var bare []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&bare))
+	assert.Len(t, bare, 1)
+
+	rec = get("/persons?color=blau&limit=1&offset=1&envelope=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var env struct {
+		Data []domain.Person `json:"data"`
+		Meta struct {
+			Limit  int `json:"limit"`
+			Offset int `json:"offset"`
+			Total  int `json:"total"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&env))
+	require.Len(t, env.Data, 1)
+	assert.Equal(t, 4, env.Data[0].ID)
+	assert.Equal(t, 1, env.Meta.Limit)
+	assert.Equal(t, 1, env.Meta.Offset)
+	assert.Equal(t, 2, env.Meta.Total, "total zählt die gefilterten treffer ohne paginierung")
+
+	rec = get("/persons?envelope=true&color=pink")
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
-func TestGetByID_NegativeID(t *testing.T) {
+func TestGetAll_EnvelopePerAccept(t *testing.T) {
+	h, router := neuerTestHandler()
+	_, _ = h.service.(*mockService).Add(context.Background(), domain.Person{Name: "Anna", Lastname: "Blau", Color: "blau"})
+
+	tests := []struct {
+		name            string
+		target          string
+		accept          string
+		wantContentType string
+		wantEnvelope    bool
+	}{
+		{"ohne auswahl", "/persons?color=blau&limit=1", "", "application/json", false},
+		{"accept json", "/persons?color=blau&limit=1", "application/json", "application/json", false},
+		{"query-parameter", "/persons?color=blau&limit=1&envelope=true", "", "application/json", true},
+		{"accept v2", "/persons?color=blau&limit=1", contentTypeEnvelope, contentTypeEnvelope, true},
+		{"accept v2 unter mehreren", "/persons?color=blau&limit=1", "text/html, application/vnd.assecor.v2+json;q=0.9", contentTypeEnvelope, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantContentType, rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Header().Values("Vary"), "Accept")
+			if !tt.wantEnvelope {
+				var bare []domain.Person
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&bare))
+				assert.Len(t, bare, 1)
+				return
+			}
+			var body listResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Len(t, body.Data, 1)
+			assert.Equal(t, listMeta{Limit: 1, Offset: 0, Total: 2}, body.Meta, "total zählt nur die gefilterten treffer")
+		})
+	}
+}
+
+func TestGetAll_EnvelopeFehlerformat(t *testing.T) {
 	_, router := neuerTestHandler()
-	req := httptest.NewRequest(http.MethodGet, "/persons/-1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/persons?color=pink", nil)
+	req.Header.Set("Accept", contentTypeEnvelope)
 	rec := httptest.NewRecorder()
-
 	router.ServeHTTP(rec, req)
 
-	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, []string{"code", "error"}, slices.Sorted(maps.Keys(body)), "fehler bleiben errorBody, ohne data und meta")
 }
 
-func TestGetByColor_Gefunden(t *testing.T) {
+func TestGetAll_IDs(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{MaxIDs: 3})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []int
+	}{
+		{"reihenfolge der anfrage", "?ids=3,1", http.StatusOK, []int{3, 1}},
+		{"duplikate nur einmal", "?ids=2,2,1", http.StatusOK, []int{2, 1}},
+		{"unbekannte ids ausgelassen", "?ids=99,2", http.StatusOK, []int{2}},
+		{"mit leerzeichen", "?ids=1,%202", http.StatusOK, []int{1, 2}},
+		{"leere liste", "?ids=", http.StatusBadRequest, nil},
+		{"keine zahl", "?ids=1,abc", http.StatusBadRequest, nil},
+		{"leerer eintrag", "?ids=1,,2", http.StatusBadRequest, nil},
+		{"zu viele ids", "?ids=1,2,3,1", http.StatusBadRequest, nil},
+		{"mit color kombiniert", "?ids=1&color=blau", http.StatusBadRequest, nil},
+		{"mit limit kombiniert", "?ids=1&limit=1", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons"+tt.query, nil))
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantIDs == nil {
+				return
+			}
+			var persons []domain.Person
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+			ids := make([]int, 0, len(persons))
+			for _, p := range persons {
+				ids = append(ids, p.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestGetAll_Cursor(t *testing.T) {
 	_, router := neuerTestHandler()
-	req := httptest.NewRequest(http.MethodGet, "/persons/color/blau", nil)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	var seen []int
+	target := "/persons?cursor=&limit=2"
+	for pages := 0; target != ""; pages++ {
+		require.Less(t, pages, 5, "paginierung endet nicht")
+		rec := get(target)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var persons []domain.Person
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+		for _, p := range persons {
+			seen = append(seen, p.ID)
+		}
+
+		target = ""
+		if next := rec.Header().Get("X-Next-Cursor"); next != "" {
+			target = "/persons?limit=2&cursor=" + url.QueryEscape(next)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3}, seen)
+
+	for _, bad := range []string{
+		"/persons?cursor=!!!",
+		"/persons?cursor=" + base64.RawURLEncoding.EncodeToString([]byte("abc")),
+		"/persons?cursor=&offset=1",
+		"/persons?cursor=&color=blau",
+		"/persons?cursor=&limit=0",
+	} {
+		assert.Equal(t, http.StatusBadRequest, get(bad).Code, bad)
+	}
+}
+
+func TestGetAll_NDJSON(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/persons", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusOK, rec.Code)
-	var persons []domain.Person
-	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
-	assert.Len(t, persons, 1)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(t, lines, 3)
+	for i, line := range lines {
+		var p domain.Person
+		require.NoError(t, json.Unmarshal([]byte(line), &p))
+		assert.Equal(t, i+1, p.ID)
+	}
 }
 
-func TestGetByColor_Leer(t *testing.T) {
+func TestGetAll_NDJSONPerQueryParameter(t *testing.T) {
 	_, router := neuerTestHandler()
-	req := httptest.NewRequest(http.MethodGet, "/persons/color/gelb", nil)
+	req := httptest.NewRequest(http.MethodGet, "/persons?format=ndjson", nil)
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusOK, rec.Code)
-	var persons []domain.Person
-	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
-	assert.Empty(t, persons)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Len(t, strings.Split(strings.TrimSpace(rec.Body.String()), "\n"), 3)
 }
 
-func TestGetByColor_UnbekannteFarbe(t *testing.T) {
+func TestGetAll_NDJSONLehntFilterAb(t *testing.T) {
 	_, router := neuerTestHandler()
-	req := httptest.NewRequest(http.MethodGet, "/persons/color/pink", nil)
-	rec := httptest.NewRecorder()
 
-	router.ServeHTTP(rec, req)
+	for _, query := range []string{
+		"color=blau", "createdAfter=2024-01-01T00:00:00Z", "limit=1", "offset=1",
+		"cursor=", "ids=1,2", "includeDeleted=true",
+	} {
+		// NDJSON per Accept muss dieselben Parameter ablehnen wie ?format=ndjson.
+		for name, req := range map[string]*http.Request{
+			"format": httptest.NewRequest(http.MethodGet, "/persons?format=ndjson&"+query, nil),
+			"accept": httptest.NewRequest(http.MethodGet, "/persons?"+query, nil),
+		} {
+			if name == "accept" {
+				req.Header.Set("Accept", "application/x-ndjson")
+			}
+			t.Run(name+"/"+query, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
 
-	assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				var body errorBody
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+				assert.Equal(t, i18n.CodeNDJSONCombined, body.Code)
+			})
+		}
+	}
 }
 
-func TestCreate_Gueltig(t *testing.T) {
+// endlosStreamService liefert beim Streamen so lange Personen, bis der
+// Kontext abgebrochen wird, und meldet das Ende über done.
+type endlosStreamService struct {
+	*mockService
+	done chan error
+}
+
+func (s *endlosStreamService) StreamAll(ctx context.Context, fn func(domain.Person) error) error {
+	var err error
+	for id := 1; err == nil; id++ {
+		if err = ctx.Err(); err == nil {
+			err = fn(domain.Person{ID: id, Name: "Hans", Lastname: "Müller", Color: "blau"})
+		}
+	}
+	s.done <- err
+	return err
+}
+
+func TestGetAll_NDJSONInkrementellLesen(t *testing.T) {
+	persons := make([]domain.Person, 250)
+	for i := range persons {
+		persons[i] = domain.Person{ID: i + 1, Name: "Hans", Lastname: "Müller", Color: "blau"}
+	}
+	logger, _ := zap.NewDevelopment()
+	srv := httptest.NewServer(setupRouter(NewPersonHandler(newMockService(persons), logger, Options{})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/persons?format=ndjson")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	ids := 0
+	for scanner.Scan() {
+		var p domain.Person
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &p))
+		ids++
+		assert.Equal(t, ids, p.ID)
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, 250, ids)
+}
+
+func TestGetAll_NDJSONAbbruchDurchClient(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	svc := &endlosStreamService{mockService: newMockService(nil), done: make(chan error, 1)}
+	srv := httptest.NewServer(setupRouter(NewPersonHandler(svc, logger, Options{})))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/persons?format=ndjson", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for i := 0; i < 5; i++ {
+		require.True(t, scanner.Scan())
+	}
+	cancel()
+	_ = resp.Body.Close()
+
+	select {
+	case err := <-svc.done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream wurde nach abbruch durch den client nicht beendet")
+	}
+}
+
+// langsamStreamService liefert n Personen mit delay zwischen je zwei Zeilen.
+type langsamStreamService struct {
+	*mockService
+	n     int
+	delay time.Duration
+}
+
+func (s *langsamStreamService) StreamAll(ctx context.Context, fn func(domain.Person) error) error {
+	for id := 1; id <= s.n; id++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.delay):
+		}
+		if err := fn(domain.Person{ID: id, Name: "Hans", Lastname: "Müller", Color: "blau"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGetAll_NDJSONUeberdauertWriteTimeout(t *testing.T) {
+	const writeTimeout = 50 * time.Millisecond
+	tests := []struct {
+		name          string
+		streamTimeout time.Duration
+	}{
+		{"frist je zeile", writeTimeout},
+		{"ohne frist", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &langsamStreamService{mockService: newMockService(nil), n: 8, delay: writeTimeout / 2}
+			h := NewPersonHandler(svc, zap.NewNop(), Options{StreamWriteTimeout: tt.streamTimeout})
+			srv := httptest.NewUnstartedServer(setupRouter(h))
+			srv.Config.WriteTimeout = writeTimeout
+			srv.Start()
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL + "/persons?format=ndjson")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err, "stream wurde nach WriteTimeout abgebrochen")
+			assert.Len(t, strings.Split(strings.TrimSpace(string(body)), "\n"), 8)
+		})
+	}
+}
+
+func TestGetByID_Gefunden(t *testing.T) {
 	_, router := neuerTestHandler()
-	body := `{"name":"Neu","lastname":"Person","zipcode":"00000","city":"Stadt","color":"rot"}`
-	req := httptest.NewRequest(http.MethodPost, "/persons", bytes.NewBufferString(body))
-	req.Header.Set("Content-Type", "application/json")
+	req := httptest.NewRequest(http.MethodGet, "/persons/1", nil)
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
 
-	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, http.StatusOK, rec.Code)
 	var p domain.Person
 	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
-	assert.Equal(t, 4, p.ID)
-	assert.Equal(t, "rot", p.Color)
+	assert.Equal(t, "Hans", p.Name)
 }
 
-func TestCreate_FehlenderName(t *testing.T) {
+func TestGetByID_OhneZeitstempelKeineFelder(t *testing.T) {
 	_, router := neuerTestHandler()
-	body := `{"lastname":"Person","color":"rot"}`
-	req := httptest.NewRequest(http.MethodPost, "/persons", bytes.NewBufferString(body))
-	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	router.ServeHTTP(rec, req)
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/1", nil))
 
-	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "created_at")
+	assert.NotContains(t, rec.Body.String(), "updated_at")
 }
 
-func TestCreate_UngueltigesJSON(t *testing.T) {
+func TestGetAll_CreatedAfter(t *testing.T) {
+	h, router := neuerTestHandler()
+	svc := h.service.(*mockService)
+	created, _ := svc.Add(context.Background(), domain.Person{
+		Name: "Neu", Lastname: "Person", Color: "rot",
+		CreatedAt: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons?createdAfter=2024-04-30T00:00:00%2B02:00", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"created_at":"2024-05-01T08:00:00Z"`)
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	require.Len(t, persons, 1)
+	assert.Equal(t, created.ID, persons[0].ID)
+	assert.True(t, created.CreatedAt.Equal(persons[0].CreatedAt))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons?createdAfter=gestern", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHeadByID(t *testing.T) {
+	_, router := neuerTestHandler()
+	get := httptest.NewRecorder()
+	router.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/persons/1", nil))
+	require.Equal(t, http.StatusOK, get.Code)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{"vorhandene Person", "/persons/1", http.StatusOK},
+		{"unbekannte id", "/persons/999", http.StatusNotFound},
+		{"ungültige id", "/persons/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, tt.target, nil))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Empty(t, rec.Body.String())
+			assert.Equal(t, get.Header().Get("ETag"), rec.Header().Get("ETag"))
+			assert.Equal(t, strconv.Itoa(get.Body.Len()), rec.Header().Get("Content-Length"))
+			assert.Equal(t, get.Header().Get("Content-Length"), rec.Header().Get("Content-Length"))
+		})
+	}
+}
+
+func TestGetByID_NichtGefunden(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/persons/999", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetByID_UngueltigeID(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/persons/abc", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetByID_NegativeID(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/persons/-1", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetByColor_Gefunden(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/persons/color/blau", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	assert.Len(t, persons, 1)
+}
+
+func TestGetByColor_Leer(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/persons/color/gelb", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	assert.Empty(t, persons)
+}
+
+func TestGetByColor_UnbekannteFarbe(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/persons/color/pink", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetByColor_Strict(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	tests := []struct {
+		name     string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"gefunden", "/persons/color/grün?fields=name", http.StatusOK, `[{"id":2,"name":"Peter"}]`},
+		{"gefunden strict", "/persons/color/grün?fields=name&strict=true", http.StatusOK, `[{"id":2,"name":"Peter"}]`},
+		{"gültig ohne personen", "/persons/color/gelb", http.StatusOK, `[]`},
+		{"gültig ohne personen strict", "/persons/color/gelb?strict=true", http.StatusNotFound,
+			`{"error":"keine personen mit dieser farbe","code":"no_persons_with_color","color":"gelb"}`},
+		{"gültig ohne personen strict=1", "/persons/color/gelb?strict=1", http.StatusNotFound,
+			`{"error":"keine personen mit dieser farbe","code":"no_persons_with_color","color":"gelb"}`},
+		{"gültig ohne personen strict=false", "/persons/color/gelb?strict=false", http.StatusOK, `[]`},
+		{"farb-id ohne personen strict", "/persons/color/id/5?strict=true", http.StatusNotFound,
+			`{"error":"keine personen mit dieser farbe","code":"no_persons_with_color","color":"gelb"}`},
+		{"seite hinter dem letzten treffer strict", "/persons/color/grün?offset=5&strict=true", http.StatusOK, `[]`},
+		{"unbekannte farbe", "/persons/color/pink", http.StatusBadRequest,
+			`{"error":"ungültige farbe: ungültige eingabe","code":"invalid_color"}`},
+		{"unbekannte farbe strict", "/persons/color/pink?strict=true", http.StatusBadRequest,
+			`{"error":"ungültige farbe: ungültige eingabe","code":"invalid_color"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestGetByColor_Sortierung(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	h := NewPersonHandler(newMockService([]domain.Person{
+		{ID: 1, Name: "Hans", Lastname: "Müller", Color: "blau"},
+		{ID: 2, Name: "Lena", Lastname: "Klein", Color: "blau"},
+		{ID: 3, Name: "Anna", Lastname: "Schmidt", Color: "blau"},
+		{ID: 4, Name: "Peter", Lastname: "Petersen", Color: "grün"},
+	}), logger, Options{})
+	router := setupRouter(h)
+
+	tests := []struct {
+		name     string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"erste seite", "/persons/color/blau?sort=lastname&limit=2&fields=lastname", http.StatusOK,
+			`[{"id":2,"lastname":"Klein"},{"id":1,"lastname":"Müller"}]`},
+		{"zweite seite", "/persons/color/blau?sort=lastname&limit=2&offset=2&fields=lastname", http.StatusOK,
+			`[{"id":3,"lastname":"Schmidt"}]`},
+		{"absteigend", "/persons/color/blau?sort=-lastname&fields=lastname", http.StatusOK,
+			`[{"id":3,"lastname":"Schmidt"},{"id":1,"lastname":"Müller"},{"id":2,"lastname":"Klein"}]`},
+		{"farb-id", "/persons/color/id/1?sort=-id&limit=1&fields=id", http.StatusOK, `[{"id":3}]`},
+		{"unbekanntes feld", "/persons/color/blau?sort=color", http.StatusBadRequest,
+			`{"error":"unbekanntes sortierfeld \"color\", erlaubt sind: id, name, lastname, zipcode, city, created_at, updated_at: ungültige eingabe","code":"unknown_sort_field"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestGetByColorID(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantLen  int
+	}{
+		{"/persons/color/id/1", http.StatusOK, 1},
+		{"/persons/color/id/5", http.StatusOK, 0},
+		{"/persons/color/id/0", http.StatusBadRequest, 0},
+		{"/persons/color/id/8", http.StatusBadRequest, 0},
+		{"/persons/color/id/blau", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode == http.StatusOK {
+				var persons []domain.Person
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+				assert.Len(t, persons, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestGroupByColor(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	tests := []struct {
+		target     string
+		wantStatus int
+		wantColors []string
+	}{
+		{"/persons/by-color", http.StatusOK, []string{"blau", "gelb", "grün", "rot", "türkis", "violett", "weiß"}},
+		{"/persons/by-color?colors=rot,blau,,rot", http.StatusOK, []string{"blau", "rot"}},
+		{"/persons/by-color?colors=blau,pink", http.StatusBadRequest, nil},
+		{"/persons/by-color?limit=-1", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var groups map[string][]domain.Person
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&groups))
+			colors := slices.Sorted(maps.Keys(groups))
+			assert.Equal(t, tt.wantColors, colors)
+		})
+	}
+}
+
+func TestGetByZipcode(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/persons/zipcode/67742", http.StatusOK, ""},
+		{"/persons/zipcode/677", http.StatusOK, "[]\n"},
+		{"/persons/zipcode/677/prefix", http.StatusOK, ""},
+		{"/persons/zipcode/999/prefix", http.StatusOK, "[]\n"},
+		{"/persons/zipcode/%20/prefix", http.StatusBadRequest, ""},
+		{"/persons/zipcode/677?limit=x", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSameColor(t *testing.T) {
+	h, router := neuerTestHandler()
+	svc := h.service.(*mockService)
+	_, _ = svc.Add(context.Background(), domain.Person{Name: "Anna", Lastname: "Blau", Color: "blau"})
+	_, _ = svc.Add(context.Background(), domain.Person{Name: "Lena", Lastname: "Blau", Color: "blau"})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"treffer", "/persons/1/same-color", http.StatusOK, ""},
+		{"niemand sonst", "/persons/2/same-color", http.StatusOK, "[]\n"},
+		{"unbekannte id", "/persons/99/same-color", http.StatusNotFound, ""},
+		{"keine ganzzahl", "/persons/abc/same-color", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/1/same-color?limit=1&offset=1", nil))
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	require.Len(t, persons, 1)
+	assert.Equal(t, 5, persons[0].ID)
+}
+
+func TestCreate_Gueltig(t *testing.T) {
+	_, router := neuerTestHandler()
+	body := `{"name":"Neu","lastname":"Person","zipcode":"00000","city":"Stadt","color":"rot"}`
+	req := httptest.NewRequest(http.MethodPost, "/persons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var p domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	assert.Equal(t, 4, p.ID)
+	assert.Equal(t, domain.Color("rot"), p.Color)
+	assert.Equal(t, "/persons/4", rec.Header().Get("Location"))
+}
+
+func TestCreate_KapazitaetImHeader(t *testing.T) {
+	h, router := neuerTestHandler()
+	svc := h.service.(*mockService)
+	post := func() *httptest.ResponseRecorder {
+		body := `{"name":"Neu","lastname":"Person","zipcode":"00000","city":"Stadt","color":"rot"}`
+		req := httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post()
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("X-Capacity-Remaining"), "ohne grenze kein header")
+
+	svc.maxPersons = 6
+	rec = post()
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Capacity-Remaining"))
+	rec = post()
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-Capacity-Remaining"))
+
+	rec = post()
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Empty(t, rec.Header().Get("Retry-After"), "purge schafft keinen platz")
+	assert.Empty(t, rec.Header().Get("X-Capacity-Remaining"))
+}
+
+func TestCreate_FehlenderName(t *testing.T) {
+	_, router := neuerTestHandler()
+	body := `{"lastname":"Person","color":"rot"}`
+	req := httptest.NewRequest(http.MethodPost, "/persons", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCreate_UngueltigesJSON(t *testing.T) {
 	_, router := neuerTestHandler()
 	req := httptest.NewRequest(http.MethodPost, "/persons", bytes.NewBufferString("{bad"))
 	req.Header.Set("Content-Type", "application/json")
@@ -224,9 +1315,76 @@ func TestCreate_UngueltigesJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
+func TestCreate_ContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		wantStatus  int
+	}{
+		{"json", "application/json", http.StatusCreated},
+		{"json mit charset", "application/json; charset=utf-8", http.StatusCreated},
+		{"großschreibung", "Application/JSON", http.StatusCreated},
+		{"ohne header", "", http.StatusUnsupportedMediaType},
+		{"text", "text/plain", http.StatusUnsupportedMediaType},
+		{"multipart", "multipart/form-data; boundary=x", http.StatusUnsupportedMediaType},
+		{"ungültiger header", "application/json;;", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandler()
+			body := `{"name":"Neu","lastname":"Person","zipcode":"00000","city":"Stadt","color":"rot"}`
+			req := httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader(body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusUnsupportedMediaType {
+				assert.Contains(t, rec.Body.String(), "content-type muss application/json oder application/x-www-form-urlencoded sein")
+			}
+		})
+	}
+}
+
+func TestCreate_Formular(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"gültig", "name=Neu&lastname=Person&zipcode=00000&city=Bad+Homburg&color=rot", http.StatusCreated, `"city":"Bad Homburg"`},
+		{"umlaute kodiert", "name=J%C3%BCrgen&lastname=M%C3%BCller&zipcode=12345&city=K%C3%B6ln&color=gr%C3%BCn", http.StatusCreated, `"name":"Jürgen"`},
+		{"pflichtfeld fehlt", "name=Neu&lastname=Person&zipcode=00000&color=rot", http.StatusBadRequest, `{"field":"/city","error":"pflichtfeld fehlt"}`},
+		{"unbekanntes feld", "name=Neu&lastname=Person&zipcode=00000&city=Stadt&color=rot&age=3", http.StatusBadRequest, `{"field":"/age","error":"unbekanntes feld"}`},
+		{"feld doppelt", "name=Neu&name=Alt&lastname=Person&zipcode=00000&city=Stadt&color=rot", http.StatusBadRequest, `{"field":"/name","error":"erwartet string, erhalten array"}`},
+		{"service-validierung", "name=Neu&lastname=Person&zipcode=00000&city=Stadt&color=neon", http.StatusBadRequest, "ungültige farbe"},
+		{"kaputte kodierung", "name=%zz", http.StatusBadRequest, "ungültiger formular-body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandler()
+			req := httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "die antwort bleibt json")
+		})
+	}
+}
+
 func TestCreate_UnbekannteFarbe(t *testing.T) {
 	_, router := neuerTestHandler()
-	body := `{"name":"A","lastname":"B","color":"neon"}`
+	body := `{"name":"Neu","lastname":"Person","zipcode":"00000","city":"Stadt","color":"neon"}`
 	req := httptest.NewRequest(http.MethodPost, "/persons", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
@@ -235,3 +1393,1459 @@ func TestCreate_UnbekannteFarbe(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestCreate_Schema(t *testing.T) {
+	type violation struct {
+		Field string `json:"field"`
+		Error string `json:"error"`
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want []violation
+	}{
+		{
+			"fehlende pflichtfelder",
+			`{"name":"A","lastname":"B","color":"rot"}`,
+			[]violation{{"/city", "pflichtfeld fehlt"}, {"/zipcode", "pflichtfeld fehlt"}},
+		},
+		{
+			"falscher typ",
+			`{"name":"Neu","lastname":"Person","zipcode":12345,"city":"Stadt","color":"rot"}`,
+			[]violation{{"/zipcode", "erwartet string, erhalten number"}},
+		},
+		{
+			"unbekanntes feld",
+			`{"name":"Neu","lastname":"Person","zipcode":"00000","city":"Stadt","color":"rot","alter":3}`,
+			[]violation{{"/alter", "unbekanntes feld"}},
+		},
+		{
+			"kein objekt",
+			`["Neu"]`,
+			[]violation{{"", "erwartet object, erhalten array"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandler()
+			req := httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			var got struct {
+				Error   string      `json:"error"`
+				Details []violation `json:"details"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+			assert.Equal(t, "anfrage-body entspricht nicht dem schema", got.Error)
+			assert.Equal(t, tt.want, got.Details)
+		})
+	}
+}
+
+func TestCreate_BodyUeberLimit(t *testing.T) {
+	const limit = 128
+	_, router := neuerTestHandlerMit(Options{MaxBodyBytes: limit})
+
+	// Der Name wird so aufgefüllt, dass der Body das Limit genau trifft bzw. um
+	// ein Byte überschreitet; das Überschreiten liegt damit im JSON-Objekt.
+	body := func(size int) string {
+		tmpl := `{"name":"%s","lastname":"Person","zipcode":"00000","city":"Stadt","color":"rot"}`
+		return fmt.Sprintf(tmpl, strings.Repeat("a", size-len(tmpl)+2))
+	}
+
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int
+	}{
+		{"genau am limit", limit, http.StatusCreated},
+		{"ein byte darüber", limit + 1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := body(tt.size)
+			require.Len(t, b, tt.size)
+			req := httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader(b))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusRequestEntityTooLarge {
+				assert.Contains(t, rec.Body.String(), "128 bytes")
+			}
+		})
+	}
+}
+
+// ─── Update / Patch ───────────────────────────────────────────────────────────
+
+// sendeMitIfMatch schickt body per method an target und setzt If-Match, falls nicht leer.
+func sendeMitIfMatch(router http.Handler, method, target, ifMatch, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetByID_ETag(t *testing.T) {
+	_, router := neuerTestHandler()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/1", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `W/"1"`, rec.Header().Get("ETag"))
+}
+
+func TestUpdate_IfMatch(t *testing.T) {
+	const body = `{"name":"Hans","lastname":"Meier","zipcode":"67742","city":"Lauterecken","color":"rot"}`
+
+	tests := []struct {
+		name       string
+		target     string
+		ifMatch    string
+		wantStatus int
+		wantETag   string
+	}{
+		{"ohne if-match", "/persons/1", "", http.StatusPreconditionRequired, ""},
+		{"aktuelle version", "/persons/1", `W/"1"`, http.StatusOK, `W/"2"`},
+		{"starke form", "/persons/1", `"1"`, http.StatusOK, `W/"2"`},
+		{"stern", "/persons/1", "*", http.StatusOK, `W/"2"`},
+		{"veraltete version", "/persons/2", `W/"7"`, http.StatusPreconditionFailed, `W/"1"`},
+		{"ungültiger header", "/persons/1", "eins", http.StatusBadRequest, ""},
+		{"unbekannte id", "/persons/99", `W/"1"`, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandlerMit(Options{RequireIfMatch: true})
+			rec := sendeMitIfMatch(router, http.MethodPut, tt.target, tt.ifMatch, body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantETag, rec.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestUpdate_OhneIfMatchErlaubt(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{RequireIfMatch: false})
+	rec := sendeMitIfMatch(router, http.MethodPut, "/persons/1", "",
+		`{"name":"Hans","lastname":"Meier","zipcode":"67742","city":"Lauterecken","color":"rot"}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var p domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	assert.Equal(t, "Meier", p.Lastname)
+	assert.Equal(t, 2, p.Version)
+}
+
+func TestUpdate_PutUpsert(t *testing.T) {
+	const body = `{"name":"Hans","lastname":"Meier","zipcode":"67742","city":"Lauterecken","color":"rot"}`
+
+	tests := []struct {
+		name         string
+		opts         Options
+		target       string
+		ifMatch      string
+		wantStatus   int
+		wantLocation string
+		wantETag     string
+	}{
+		{"abgeschaltet", Options{}, "/persons/42", "", http.StatusNotFound, "", ""},
+		{"legt an", Options{PutUpsert: true}, "/persons/42", "", http.StatusCreated, "/persons/42", `W/"1"`},
+		{"legt mit stern an", Options{PutUpsert: true, RequireIfMatch: true}, "/persons/42", "*", http.StatusCreated, "/persons/42", `W/"1"`},
+		{"ersetzt vorhandene", Options{PutUpsert: true}, "/persons/1", "", http.StatusOK, "", `W/"2"`},
+		{"konkrete version legt nicht an", Options{PutUpsert: true}, "/persons/42", `W/"1"`, http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandlerMit(tt.opts)
+			rec := sendeMitIfMatch(router, http.MethodPut, tt.target, tt.ifMatch, body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantLocation, rec.Header().Get("Location"))
+			assert.Equal(t, tt.wantETag, rec.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestPatch_BehaeltFehlendeFelder(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{RequireIfMatch: true})
+	rec := sendeMitIfMatch(router, http.MethodPatch, "/persons/2", `W/"1"`, `{"city":"Rostock"}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var p domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	assert.Equal(t, "Rostock", p.City)
+	assert.Equal(t, "Petersen", p.Lastname)
+	assert.Equal(t, domain.Color("grün"), p.Color)
+}
+
+// Zwei Clients lesen dieselbe Version und ändern sie nacheinander: Die zweite
+// Änderung darf die erste nicht überschreiben.
+func TestPatch_VerlorenesUpdate(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{RequireIfMatch: true})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/3", nil))
+	gelesen := rec.Header().Get("ETag")
+	require.Equal(t, `W/"1"`, gelesen)
+
+	erster := sendeMitIfMatch(router, http.MethodPatch, "/persons/3", gelesen, `{"city":"Berlin"}`)
+	require.Equal(t, http.StatusOK, erster.Code)
+
+	zweiter := sendeMitIfMatch(router, http.MethodPatch, "/persons/3", gelesen, `{"city":"Hamburg"}`)
+	require.Equal(t, http.StatusPreconditionFailed, zweiter.Code)
+	var conflict struct {
+		Error          string `json:"error"`
+		CurrentVersion int    `json:"current_version"`
+	}
+	require.NoError(t, json.NewDecoder(zweiter.Body).Decode(&conflict))
+	assert.Equal(t, 2, conflict.CurrentVersion)
+	assert.Contains(t, conflict.Error, "versionskonflikt")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/3", nil))
+	var p domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	assert.Equal(t, "Berlin", p.City)
+}
+
+// ─── Delete / Restore ─────────────────────────────────────────────────────────
+
+func TestDelete_UndRestore(t *testing.T) {
+	_, router := neuerTestHandler()
+	send := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/persons/2").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/persons/2").Code, "bereits gelöscht")
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/persons/2").Code)
+
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(send(http.MethodGet, "/persons").Body).Decode(&persons))
+	assert.Len(t, persons, 2)
+	require.NoError(t, json.NewDecoder(send(http.MethodGet, "/persons?includeDeleted=true").Body).Decode(&persons))
+	assert.Len(t, persons, 3)
+
+	rec := send(http.MethodPost, "/persons/2/restore")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `W/"2"`, rec.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/persons/2").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "/persons/2/restore").Code, "nicht gelöscht")
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodDelete, "/persons/abc").Code)
+}
+
+func TestGetByID_ShowGone(t *testing.T) {
+	tests := []struct {
+		name       string
+		showGone   bool
+		wantStatus int
+	}{
+		{"standard", false, http.StatusNotFound},
+		{"show gone", true, http.StatusGone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandlerMit(Options{ShowGone: tt.showGone})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/persons/1", nil))
+			require.Equal(t, http.StatusNoContent, rec.Code)
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/1", nil))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/99", nil))
+			assert.Equal(t, http.StatusNotFound, rec.Code, "unbekannte id bleibt 404")
+		})
+	}
+}
+
+func TestDeleteAll_Deaktiviert(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodDelete, "/persons", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	assert.Len(t, persons, 3)
+}
+
+func TestDeleteAll_Erlaubt(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{AllowDestructive: true})
+	req := httptest.NewRequest(http.MethodDelete, "/persons", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	assert.Empty(t, persons)
+}
+
+func TestDeleteByColor(t *testing.T) {
+	tests := []struct {
+		name        string
+		destructive bool
+		color       string
+		wantStatus  int
+		wantBody    string
+		wantLeft    int
+	}{
+		{"deaktiviert", false, "blau", http.StatusForbidden, "", 3},
+		{"löscht treffer", true, "blau", http.StatusOK, `{"deleted":1}`, 2},
+		{"farbe ohne personen", true, "weiß", http.StatusOK, `{"deleted":0}`, 3},
+		{"unbekannte farbe", true, "lila", http.StatusBadRequest, "", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandlerMit(Options{AllowDestructive: tt.destructive})
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/persons/color/"+url.PathEscape(tt.color), nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+			var persons []domain.Person
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+			assert.Len(t, persons, tt.wantLeft)
+		})
+	}
+}
+
+func TestColorCounts(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodGet, "/colors/counts", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var counts []domain.ColorCount
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&counts))
+	require.Len(t, counts, 7)
+	assert.Equal(t, domain.ColorCount{Color: "blau", Count: 1}, counts[0])
+	assert.Equal(t, domain.ColorCount{Color: "weiß", Count: 0}, counts[6])
+}
+
+func TestCities(t *testing.T) {
+	_, router := neuerTestHandler()
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cities", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `["Lauterecken","Stralsund","made up"]`, rec.Body.String())
+}
+
+func TestStats(t *testing.T) {
+	_, router := neuerTestHandler()
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/stats", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Total   int            `json:"total"`
+		ByColor map[string]int `json:"byColor"`
+		ByCity  map[string]int `json:"byCity"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, 3, body.Total)
+	assert.Len(t, body.ByColor, 7)
+	assert.Equal(t, 1, body.ByColor["blau"])
+	assert.Equal(t, 0, body.ByColor["weiß"])
+	assert.Equal(t, 1, body.ByCity["Stralsund"])
+}
+
+// ─── Ladebericht ──────────────────────────────────────────────────────────────
+
+type festerBericht domain.LoadReport
+
+func (b festerBericht) LoadReport() domain.LoadReport { return domain.LoadReport(b) }
+
+func TestLoadReport_MitReporter(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{LoadReporter: festerBericht{
+		Source: "persons.csv",
+		Loaded: 10,
+		Skipped: []domain.SkippedRecord{
+			{Line: 4, Fields: []string{"Müller", "Hans", "67742 Lauterecken", "99"}, Reason: "unbekannte farb-id 99"},
+		},
+	}})
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/load-report", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body domain.LoadReport
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "persons.csv", body.Source)
+	assert.Equal(t, 10, body.Loaded)
+	require.Len(t, body.Skipped, 1)
+	assert.Equal(t, 4, body.Skipped[0].Line)
+	assert.Equal(t, "99", body.Skipped[0].Fields[3])
+}
+
+func TestLoadReport_OhneReporter(t *testing.T) {
+	_, router := neuerTestHandler()
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/load-report", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// festerReloader liefert beim Neuladen immer report und err.
+type festerReloader struct {
+	report domain.LoadReport
+	err    error
+}
+
+func (f festerReloader) Reload(context.Context) (domain.LoadReport, error) { return f.report, f.err }
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name     string
+		reloader Reloader
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "erfolgreich",
+			reloader: festerReloader{report: domain.LoadReport{
+				Loaded:   8,
+				Overflow: 1,
+				Skipped:  []domain.SkippedRecord{{Line: 3}, {Line: 5}},
+			}},
+			wantCode: http.StatusOK,
+			wantBody: `"loaded":8,"skipped":2,"overflow":1`,
+		},
+		{
+			name:     "ungültige daten",
+			reloader: festerReloader{err: fmt.Errorf("zeile 3: %w", domain.ErrInvalidInput)},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "zeile 3",
+		},
+		{
+			name:     "quelle nicht lesbar",
+			reloader: festerReloader{err: errors.New("datei fehlt")},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "ohne reloader",
+			wantCode: http.StatusNotImplemented,
+			wantBody: "nicht unterstützt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandlerMit(Options{Reloader: tt.reloader})
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	h, router := neuerTestHandler()
+	h.service.(*mockService).maxPersons = 10
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/capacity", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"used":3,"max":10}`, rec.Body.String())
+}
+
+// ─── Kontextabbruch ───────────────────────────────────────────────────────────
+
+// abbruchService meldet bei Lesezugriffen einen abgebrochenen Request-Kontext
+// oder, falls der Kontext noch aktiv ist, err – jeweils umschlossen wie aus
+// einem Repository.
+type abbruchService struct {
+	*mockService
+	err error
+}
+
+func (s *abbruchService) fehler(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("csv-repository: %w", err)
+	}
+	return fmt.Errorf("csv-repository: %w", s.err)
+}
+
+func (s *abbruchService) Find(ctx context.Context, _ domain.PersonFilter) ([]domain.Person, error) {
+	return nil, s.fehler(ctx)
+}
+
+func (s *abbruchService) GetByID(ctx context.Context, _ int) (domain.Person, error) {
+	return domain.Person{}, s.fehler(ctx)
+}
+
+func (s *abbruchService) GetByColor(ctx context.Context, _, _ string, _, _ int) ([]domain.Person, error) {
+	return nil, s.fehler(ctx)
+}
+
+func (s *abbruchService) Stats(ctx context.Context) (domain.PersonStats, error) {
+	return domain.PersonStats{}, s.fehler(ctx)
+}
+
+func TestServerError_KontextfehlerErgebenEigenenStatus(t *testing.T) {
+	pfade := []string{"/persons", "/persons/1", "/persons/color/blau", "/persons/stats"}
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantError  bool // wird als Error geloggt
+	}{
+		{"abgebrochen", context.Canceled, statusClientClosedRequest, false},
+		{"zeitlimit", context.DeadlineExceeded, http.StatusGatewayTimeout, false},
+		{"sonstiger fehler", errors.New("platte voll"), http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		for _, pfad := range pfade {
+			t.Run(tt.name+" "+pfad, func(t *testing.T) {
+				core, logs := observer.New(zapcore.DebugLevel)
+				svc := &abbruchService{mockService: newMockService(nil), err: tt.err}
+				router := setupRouter(NewPersonHandler(svc, zap.New(core), Options{}))
+				rec := httptest.NewRecorder()
+
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pfad, nil))
+
+				assert.Equal(t, tt.wantStatus, rec.Code)
+				assert.Equal(t, tt.wantError, logs.FilterLevelExact(zapcore.ErrorLevel).Len() > 0)
+			})
+		}
+	}
+}
+
+func TestServerError_AbgebrochenerRequestKontext(t *testing.T) {
+	svc := &abbruchService{mockService: newMockService(nil), err: errors.New("nicht erreicht")}
+	logger, _ := zap.NewDevelopment()
+	router := setupRouter(NewPersonHandler(svc, logger, Options{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil).WithContext(ctx))
+
+	assert.Equal(t, statusClientClosedRequest, rec.Code)
+	var body errorBody
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "anfrage abgebrochen", body.Error)
+}
+
+// ─── Last-Modified ────────────────────────────────────────────────────────────
+
+func TestLastModified_BedingteAnfrage(t *testing.T) {
+	geaendert := time.Date(2024, 3, 1, 12, 0, 0, 500_000_000, time.UTC)
+	const header = "Fri, 01 Mar 2024 12:00:00 GMT"
+
+	tests := []struct {
+		name         string
+		method       string
+		header       map[string]string
+		wantStatus   int
+		wantModified string
+	}{
+		{"ohne bedingung", http.MethodGet, nil, http.StatusOK, header},
+		{"unverändert, auf sekunden gekürzt", http.MethodGet, map[string]string{"If-Modified-Since": header}, http.StatusNotModified, header},
+		{"später angefragt", http.MethodGet, map[string]string{"If-Modified-Since": "Sat, 02 Mar 2024 00:00:00 GMT"}, http.StatusNotModified, header},
+		{"seitdem geändert", http.MethodGet, map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 11:59:59 GMT"}, http.StatusOK, header},
+		{"ungültiges datum", http.MethodGet, map[string]string{"If-Modified-Since": "gestern"}, http.StatusOK, header},
+		{"if-none-match hat vorrang", http.MethodGet, map[string]string{"If-Modified-Since": header, "If-None-Match": `W/"1"`}, http.StatusOK, header},
+		{"head", http.MethodHead, map[string]string{"If-Modified-Since": header}, http.StatusNotModified, header},
+		{"schreibende anfrage", http.MethodDelete, map[string]string{"If-Modified-Since": header}, http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newMockService([]domain.Person{{ID: 1, Name: "Hans", Lastname: "Müller", Color: "blau", Version: 1}})
+			svc.modified = geaendert
+			h := NewPersonHandler(svc, zap.NewNop(), Options{})
+			r := chi.NewRouter()
+			r.Use(h.LastModified)
+			r.Get("/persons/{id}", h.GetByID)
+			r.Head("/persons/{id}", h.HeadByID)
+			r.Delete("/persons/{id}", h.Delete)
+
+			req := httptest.NewRequest(tt.method, "/persons/1", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantModified, rec.Header().Get("Last-Modified"))
+			if tt.wantStatus == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLastModified_OhneZeitpunkt(t *testing.T) {
+	h, _ := neuerTestHandler()
+	r := chi.NewRouter()
+	r.Use(h.LastModified)
+	r.Get("/persons/{id}", h.GetByID)
+
+	req := httptest.NewRequest(http.MethodGet, "/persons/1", nil)
+	req.Header.Set("If-Modified-Since", "Fri, 01 Mar 2024 12:00:00 GMT")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Last-Modified"))
+}
+
+// ─── GraphQL ──────────────────────────────────────────────────────────────────
+
+// graphQL schickt query mit variables per POST an /graphql.
+func graphQL(router http.Handler, query string, variables map[string]any) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGraphQL_Queries(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{MaxPageSize: 2})
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      string
+	}{
+		{"seite", `{ persons(limit: 1, offset: 1) { id name } }`, nil,
+			`{"data":{"persons":[{"id":2,"name":"Peter"}]}}`},
+		{"limit wird gekappt", `{ persons(limit: 50) { id } }`, nil,
+			`{"data":{"persons":[{"id":1},{"id":2}]}}`},
+		{"person per variable", `query($id: Int!) { person(id: $id) { lastname color version } }`, map[string]any{"id": 3},
+			`{"data":{"person":{"lastname":"Johnson","color":"violett","version":1}}}`},
+		{"leere zeitstempel sind null", `{ person(id: 1) { id deleted_at } }`, nil,
+			`{"data":{"person":{"id":1,"deleted_at":null}}}`},
+		{"unbekannte person ist null", `{ person(id: 99) { id } }`, nil,
+			`{"data":{"person":null}}`},
+		{"nach farbe", `{ personsByColor(color: "grün") { id city } }`, nil,
+			`{"data":{"personsByColor":[{"id":2,"city":"Stralsund"}]}}`},
+		{"unicode-escape", `{ personsByColor(color: "gr\u00fcn") { id } }`, nil,
+			`{"data":{"personsByColor":[{"id":2}]}}`},
+		{"escapes im string", `{ personsByColor(color: "\"gr\\ün\"\n") { id } }`, nil,
+			`{"data":{"personsByColor":null},"errors":[{"message":"ungültige farbe: ungültige eingabe","locations":[{"line":1,"column":3}],"path":["personsByColor"],"extensions":{"code":"invalid_color"}}]}`},
+		{"leere liste", `{ personsByColor(color: "rot") { id } }`, nil,
+			`{"data":{"personsByColor":[]}}`},
+		{"nach farbe sortiert", `{ personsByColor(color: "grün", sort: "-lastname") { id } }`, nil,
+			`{"data":{"personsByColor":[{"id":2}]}}`},
+		{"mehrere felder mit alias", `{ a: person(id: 1) { name } b: person(id: 2) { name } }`, nil,
+			`{"data":{"a":{"name":"Hans"},"b":{"name":"Peter"}}}`},
+		{"fragment", `{ person(id: 2) { ...Name } } fragment Name on Person { name __typename }`, nil,
+			`{"data":{"person":{"name":"Peter","__typename":"Person"}}}`},
+		{"direktive", `query($mitStadt: Boolean!) { person(id: 2) { id city @include(if: $mitStadt) } }`, map[string]any{"mitStadt": false},
+			`{"data":{"person":{"id":2}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := graphQL(router, tt.query, tt.variables)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
+
+func TestGraphQL_Introspektion(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	rec := graphQL(router, `{ __schema { queryType { name } mutationType { name } } __type(name: "Person") { fields { name } } }`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var body struct {
+		Data struct {
+			Schema struct {
+				QueryType    struct{ Name string } `json:"queryType"`
+				MutationType struct{ Name string } `json:"mutationType"`
+			} `json:"__schema"`
+			Type struct {
+				Fields []struct{ Name string } `json:"fields"`
+			} `json:"__type"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "Query", body.Data.Schema.QueryType.Name)
+	assert.Equal(t, "Mutation", body.Data.Schema.MutationType.Name)
+	var fields []string
+	for _, f := range body.Data.Type.Fields {
+		fields = append(fields, f.Name)
+	}
+	assert.ElementsMatch(t, personFields, fields, "Person hat die felder der json-darstellung")
+}
+
+func TestGraphQL_FeldfehlerMitPfad(t *testing.T) {
+	_, router := neuerTestHandlerMit(Options{MaxPageSize: 2, RejectOversizedPage: true})
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"unbekannte farbe", `{ personsByColor(color: "pink") { id } }`, "ungültige farbe: ungültige eingabe"},
+		{"unbekanntes sortierfeld", `{ personsByColor(color: "grün", sort: "farbe") { id } }`,
+			"unbekanntes sortierfeld \"farbe\", erlaubt sind: id, name, lastname, zipcode, city, created_at, updated_at: ungültige eingabe"},
+		{"limit über maximum", `{ persons(limit: 3) { id } }`, "limit darf höchstens 2 sein: ungültige eingabe"},
+		{"negativer offset", `{ persons(offset: -1) { id } }`, "offset muss eine ganzzahl zwischen 0 und 2147483647 sein: ungültige eingabe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := graphQL(router, tt.query, nil)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			var body struct {
+				Data   map[string]any `json:"data"`
+				Errors []struct {
+					Message string `json:"message"`
+					Path    []any  `json:"path"`
+				} `json:"errors"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			require.Len(t, body.Errors, 1)
+			assert.Equal(t, tt.want, body.Errors[0].Message)
+			key := body.Errors[0].Path[0].(string)
+			assert.Contains(t, body.Data, key)
+			assert.Nil(t, body.Data[key])
+		})
+	}
+}
+
+func TestGraphQL_CreatePerson(t *testing.T) {
+	h, router := neuerTestHandler()
+	const mutation = `mutation($p: PersonInput!) { createPerson(input: $p) { id name color } }`
+
+	rec := graphQL(router, mutation, map[string]any{"p": map[string]any{
+		"name": "Anna", "lastname": "Blau", "zipcode": "12345", "city": "Berlin", "color": "rot",
+	}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":{"createPerson":{"id":4,"name":"Anna","color":"rot"}}}`, rec.Body.String())
+	assert.Len(t, h.service.(*mockService).persons, 4)
+
+	rec = graphQL(router, mutation, map[string]any{"p": map[string]any{"name": "Anna"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(),
+		`argument \"input\" entspricht nicht dem schema: /city: pflichtfeld fehlt; /color: pflichtfeld fehlt; /lastname: pflichtfeld fehlt; /zipcode: pflichtfeld fehlt`)
+
+	rec = graphQL(router, mutation, map[string]any{"p": map[string]any{"name": "Anna", "id": 7}})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `In field \"id\": Unknown field.`)
+	assert.Len(t, h.service.(*mockService).persons, 4, "ungültige eingabe legt nichts an")
+}
+
+// TestGraphQL_Validierung prüft Anfragen, die graphql-go oder checkVariables
+// vor der Ausführung ablehnen: 400 ohne data.
+func TestGraphQL_Validierung(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantError string
+	}{
+		// Syntax
+		{"offene auswahl", `{ persons {`, nil, "Syntax Error GraphQL (1:12) Expected Name, found EOF"},
+		{"offener string", `{ personsByColor(color: "grün) { id } }`, nil, "Syntax Error GraphQL (1:40) Unterminated string."},
+		{"zeilenumbruch im string", "{ personsByColor(color: \"gr\nün\") { id } }", nil, "Syntax Error GraphQL (1:28) Unterminated string."},
+		{"ungültiges escape", `{ personsByColor(color: "gr\x") { id } }`, nil, `Syntax Error GraphQL (1:29) Invalid character escape sequence: \\x.`},
+		{"ungültiges unicode-escape", `{ personsByColor(color: "gr\u00zz") { id } }`, nil, `Syntax Error GraphQL (1:29) Invalid character escape sequence: \u00zz`},
+		{"ungültige zahl", `{ persons(limit: 01) { id } }`, nil, "Invalid number"},
+		// Verschachtelte Auswahl
+		{"auswahl fehlt", `{ persons }`, nil, `Field "persons" of type "[Person!]" must have a sub selection.`},
+		{"auswahl auf skalar", `{ persons { id { wert } } }`, nil, `Field "id" of type "Int!" must not have a sub selection.`},
+		{"tief verschachtelt", `{ person(id: 1) { name { vorname { kurz } } } }`, nil, `Field "name" of type "String!" must not have a sub selection.`},
+		{"unbekanntes feld", `{ persons { passwort } }`, nil, `Cannot query field "passwort" on type "Person".`},
+		{"unbekanntes wurzelfeld", `{ personen { id } }`, nil, `Cannot query field "personen" on type "Query".`},
+		// Argumente
+		{"id fehlt", `{ person { id } }`, nil, `Field "person" argument "id" of type "Int!" is required but not provided.`},
+		{"id als string", `{ person(id: "1") { id } }`, nil, `Argument "id" has invalid value "1".`},
+		{"limit als kommazahl", `{ persons(limit: 1.5) { id } }`, nil, `Argument "limit" has invalid value 1.5.`},
+		// Variablen
+		{"variable fehlt", `query($id: Int!) { person(id: $id) { id } }`, nil, `Variable "$id" of required type "Int!" was not provided.`},
+		{"variable falsch deklariert", `query($id: String!) { person(id: $id) { id } }`, map[string]any{"id": "1"},
+			`Variable "$id" of type "String!" used in position expecting type "Int!".`},
+		{"kommazahl für int", `query($id: Int!) { person(id: $id) { id } }`, map[string]any{"id": 1.5}, `variable "$id": erwartet Int, erhalten 1.5`},
+		{"string für int", `query($id: Int!) { person(id: $id) { id } }`, map[string]any{"id": "1"}, `variable "$id": erwartet Int, erhalten "1"`},
+		{"zahl für string", `query($c: String!) { personsByColor(color: $c) { id } }`, map[string]any{"c": 5}, `variable "$c": erwartet String, erhalten 5`},
+		{"feld im input-objekt", `mutation($p: PersonInput!) { createPerson(input: $p) { id } }`, map[string]any{"p": map[string]any{"name": true}},
+			`variable "$p.name": erwartet String, erhalten true`},
+		{"kein objekt für input", `mutation($p: PersonInput!) { createPerson(input: $p) { id } }`, map[string]any{"p": "Anna"},
+			`Expected "PersonInput", found not an object.`},
+		// Operationen
+		{"subscription", `subscription { persons { id } }`, nil, "Schema is not configured for subscriptions"},
+		{"mehrere ohne operationName", `query A { persons { id } } query B { persons { id } }`, nil, "dokument enthält 2 operationen, operationName fehlt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := graphQL(router, tt.query, tt.variables)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var body graphQLResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Nil(t, body.Data)
+			require.Len(t, body.Errors, 1)
+			assert.Contains(t, body.Errors[0].Message, tt.wantError)
+		})
+	}
+}
+
+func TestGraphQL_FehlerNachAcceptLanguage(t *testing.T) {
+	_, router := neuerTestHandler()
+	const create = `mutation($p: PersonInput!) { createPerson(input: $p) { id } }`
+
+	tests := []struct {
+		name      string
+		lang      string
+		query     string
+		variables map[string]any
+		wantCode  i18n.Code
+		wantError string
+	}{
+		{"resolver deutsch", "de", `{ personsByColor(color: "pink") { id } }`, nil,
+			i18n.CodeInvalidColor, "ungültige farbe: ungültige eingabe"},
+		{"resolver englisch", "en", `{ personsByColor(color: "pink") { id } }`, nil,
+			i18n.CodeInvalidColor, "invalid color"},
+		{"input englisch", "en", create, map[string]any{"p": map[string]any{"name": "Anna"}},
+			i18n.CodeInputSchema, `argument "input" does not match the schema: /city: required field is missing`},
+		{"variable englisch", "en", `query($id: Int!) { person(id: $id) { id } }`, map[string]any{"id": 1.5},
+			i18n.CodeVariableType, `variable "$id": expected Int, got 1.5`},
+		{"operation englisch", "en", `query A { persons { id } } query B { persons { id } }`, nil,
+			i18n.CodeOperationAmbiguous, "document contains 2 operations, operationName is missing"},
+		{"graphql-go bleibt englisch", "de", `{ persons { passwort } }`, nil,
+			i18n.CodeGraphQLError, `Cannot query field "passwort" on type "Person".`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]any{"query": tt.query, "variables": tt.variables})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.lang)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			var resp graphQLResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, tt.wantCode, resp.Errors[0].Extensions.Code)
+			assert.Contains(t, resp.Errors[0].Message, tt.wantError)
+		})
+	}
+}
+
+func TestGraphQL_UngueltigeAnfragen(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantError   string
+	}{
+		{"query fehlt", http.MethodPost, "/graphql", "application/json", `{}`,
+			http.StatusBadRequest, "query fehlt"},
+		{"falscher content-type", http.MethodPost, "/graphql", "text/plain", `{ persons { id } }`,
+			http.StatusUnsupportedMediaType, "content-type muss application/json sein"},
+		{"mutation per get", http.MethodGet, "/graphql?query=" + url.QueryEscape(`mutation { createPerson(input: {}) { id } }`), "", "",
+			http.StatusMethodNotAllowed, "mutationen nur per POST"},
+		{"variables kein json", http.MethodGet, "/graphql?query=%7Bpersons%7Bid%7D%7D&variables=x", "", "",
+			http.StatusBadRequest, "variables ist kein json-objekt"},
+		{"unbekannte operation", http.MethodPost, "/graphql", "application/json", `{"query":"query A { persons { id } }","operationName":"B"}`,
+			http.StatusBadRequest, `operation \"B\" gibt es nicht`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantError)
+			assert.NotContains(t, rec.Body.String(), `"data"`)
+		})
+	}
+}
+
+func TestGraphQL_QueryPerGet(t *testing.T) {
+	_, router := neuerTestHandler()
+	target := "/graphql?query=" + url.QueryEscape(`query($id: Int!) { person(id: $id) { name } }`) +
+		"&variables=" + url.QueryEscape(`{"id":2}`)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":{"person":{"name":"Peter"}}}`, rec.Body.String())
+}
+
+func TestIsGraphQLMutation(t *testing.T) {
+	h, _ := neuerTestHandler()
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"query", `{"query":"{ persons { id } }"}`, false},
+		{"mutation", `{"query":"mutation { createPerson(input: {}) { id } }"}`, true},
+		{"benannte query unter mehreren", `{"query":"query A { persons { id } } mutation B { createPerson(input: {}) { id } }","operationName":"A"}`, false},
+		{"benannte mutation unter mehreren", `{"query":"query A { persons { id } } mutation B { createPerson(input: {}) { id } }","operationName":"B"}`, true},
+		{"kein json", `{ persons { id } }`, true},
+		{"syntaxfehler", `{"query":"{ persons {"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body))
+
+			assert.Equal(t, tt.want, h.IsGraphQLMutation(req))
+			rest, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(rest), "body bleibt für den handler lesbar")
+		})
+	}
+}
+
+func TestIsGraphQLMutation_BodyGrenzeAusOptions(t *testing.T) {
+	// Eine Query mit mehr als defaultMaxRequestBody an Variablen.
+	body := `{"variables":{"pad":"` + strings.Repeat("x", defaultMaxRequestBody) + `"},"query":"{ persons { id } }"}`
+
+	tests := []struct {
+		name  string
+		limit int64
+		want  bool
+	}{
+		{"grenze aus options", 4 << 20, false},
+		{"standardgrenze gilt im zweifel als mutation", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := neuerTestHandlerMit(Options{MaxBodyBytes: tt.limit})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+
+			assert.Equal(t, tt.want, h.IsGraphQLMutation(req))
+			rest, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Len(t, rest, len(body), "body bleibt für den handler lesbar")
+		})
+	}
+}
+
+// ─── Feldauswahl ──────────────────────────────────────────────────────────────
+
+func TestParseFields_JedesFeld(t *testing.T) {
+	zeit := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	person := domain.Person{
+		ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau",
+		CreatedAt: zeit, UpdatedAt: zeit, Version: 2, DeletedAt: zeit,
+	}
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"id", `{"id":1}`},
+		{"name", `{"id":1,"name":"Hans"}`},
+		{"lastname", `{"id":1,"lastname":"Müller"}`},
+		{"zipcode", `{"id":1,"zipcode":"67742"}`},
+		{"city", `{"id":1,"city":"Lauterecken"}`},
+		{"color", `{"id":1,"color":"blau"}`},
+		{"created_at", `{"id":1,"created_at":"2024-05-01T12:00:00Z"}`},
+		{"updated_at", `{"id":1,"updated_at":"2024-05-01T12:00:00Z"}`},
+		{"version", `{"id":1,"version":2}`},
+		{"deleted_at", `{"id":1,"deleted_at":"2024-05-01T12:00:00Z"}`},
+	}
+	var covered []string
+	for _, tt := range tests {
+		covered = append(covered, tt.field)
+		t.Run(tt.field, func(t *testing.T) {
+			fields, err := parseFields(httptest.NewRequest(http.MethodGet, "/persons?fields="+tt.field, nil))
+			require.NoError(t, err)
+			got, err := json.Marshal(personView{fields: fields}.person(person))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+	assert.Equal(t, personFields, covered, "jedes feld einer person ist abgedeckt")
+}
+
+func TestParseFields_ReihenfolgeUndLeereZeitstempel(t *testing.T) {
+	fields, err := parseFields(httptest.NewRequest(http.MethodGet, "/persons?fields=color,%20created_at,,name,name", nil))
+	require.NoError(t, err)
+
+	got, err := json.Marshal(personView{fields: fields}.person(domain.Person{ID: 7, Name: "Anna", Color: "rot"}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":7,"name":"Anna","color":"rot"}`, string(got), "reihenfolge wie in der vollständigen darstellung, leere zeitstempel fehlen")
+}
+
+func TestFields_Endpunkte(t *testing.T) {
+	h, router := neuerTestHandler()
+	_, _ = h.service.(*mockService).Add(context.Background(), domain.Person{Name: "Anna", Lastname: "Blau", Zipcode: "67700", City: "Kaiserslautern", Color: "blau"})
+
+	// personen liest alle Personen aus den unterschiedlichen Antwortformen.
+	personen := func(t *testing.T, form string, body []byte) []map[string]any {
+		t.Helper()
+		var out []map[string]any
+		switch form {
+		case "objekt":
+			var p map[string]any
+			require.NoError(t, json.Unmarshal(body, &p))
+			out = append(out, p)
+		case "liste":
+			require.NoError(t, json.Unmarshal(body, &out))
+		case "envelope":
+			var env struct {
+				Data []map[string]any `json:"data"`
+				Meta listMeta         `json:"meta"`
+			}
+			require.NoError(t, json.Unmarshal(body, &env))
+			assert.Equal(t, listMeta{Limit: 1, Offset: 1, Total: 2}, env.Meta, "paginierung bleibt unverändert")
+			out = env.Data
+		case "gruppen":
+			var groups map[string][]map[string]any
+			require.NoError(t, json.Unmarshal(body, &groups))
+			for _, color := range slices.Sorted(maps.Keys(groups)) {
+				out = append(out, groups[color]...)
+			}
+		case "ndjson":
+			dec := json.NewDecoder(bytes.NewReader(body))
+			for dec.More() {
+				var p map[string]any
+				require.NoError(t, dec.Decode(&p))
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		form    string
+		wantIDs []float64
+	}{
+		{"einzelne person", "/persons/1?fields=name", "objekt", []float64{1}},
+		{"liste", "/persons?fields=name", "liste", []float64{1, 2, 3, 4}},
+		{"liste paginiert", "/persons?fields=name&limit=2&offset=1", "liste", []float64{2, 3}},
+		{"envelope", "/persons?fields=name&color=blau&limit=1&offset=1&envelope=true", "envelope", []float64{4}},
+		{"ids", "/persons?fields=name&ids=3,1", "liste", []float64{3, 1}},
+		{"cursor", "/persons?fields=name&cursor=&limit=2", "liste", []float64{1, 2}},
+		{"ndjson", "/persons?fields=name&format=ndjson", "ndjson", []float64{1, 2, 3, 4}},
+		{"farbe", "/persons/color/blau?fields=name", "liste", []float64{1, 4}},
+		{"farb-id", "/persons/color/id/1?fields=name", "liste", []float64{1, 4}},
+		{"postleitzahl", "/persons/zipcode/67742?fields=name", "liste", []float64{1}},
+		{"postleitzahl-präfix", "/persons/zipcode/677/prefix?fields=name", "liste", []float64{1, 4}},
+		{"gleiche farbe", "/persons/1/same-color?fields=name", "liste", []float64{4}},
+		{"farbgruppen", "/persons/by-color?fields=name&colors=blau,grün", "gruppen", []float64{1, 4, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var ids []float64
+			for _, p := range personen(t, tt.form, rec.Body.Bytes()) {
+				assert.Equal(t, []string{"id", "name"}, slices.Sorted(maps.Keys(p)))
+				ids = append(ids, p["id"].(float64))
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestFields_HeadMeldetLaengeDerProjektion(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	get := httptest.NewRecorder()
+	router.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/persons/1?fields=city", nil))
+	head := httptest.NewRecorder()
+	router.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/persons/1?fields=city", nil))
+
+	require.Equal(t, http.StatusOK, get.Code)
+	assert.Equal(t, `{"id":1,"city":"Lauterecken"}`+"\n", get.Body.String())
+	assert.Equal(t, strconv.Itoa(get.Body.Len()), get.Header().Get("Content-Length"))
+	assert.Equal(t, get.Header().Get("Content-Length"), head.Header().Get("Content-Length"))
+	assert.Equal(t, `W/"1"`, get.Header().Get("ETag"))
+}
+
+func TestFields_UnbekanntUndLeer(t *testing.T) {
+	_, router := neuerTestHandler()
+
+	tests := []struct {
+		name      string
+		target    string
+		wantCode  int
+		wantError string
+		wantKeys  []string
+	}{
+		{"unbekanntes feld", "/persons/1?fields=name,geburtstag", http.StatusBadRequest,
+			`unbekanntes feld "geburtstag" in fields, erlaubt sind: id, name, lastname, zipcode, city, color, created_at, updated_at, version, deleted_at`, nil},
+		{"unbekanntes feld in liste", "/persons?fields=Name", http.StatusBadRequest,
+			`unbekanntes feld "Name" in fields, erlaubt sind: id, name, lastname, zipcode, city, color, created_at, updated_at, version, deleted_at`, nil},
+		{"unbekanntes feld im stream", "/persons?fields=x&format=ndjson", http.StatusBadRequest,
+			`unbekanntes feld "x" in fields, erlaubt sind: id, name, lastname, zipcode, city, color, created_at, updated_at, version, deleted_at`, nil},
+		{"leer liefert alle felder", "/persons/1?fields=", http.StatusOK, "", []string{"city", "color", "id", "lastname", "name", "version", "zipcode"}},
+		{"nur kommas", "/persons/1?fields=,,", http.StatusOK, "", []string{"city", "color", "id", "lastname", "name", "version", "zipcode"}},
+		{"nur id", "/persons/1?fields=id", http.StatusOK, "", []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			require.Equal(t, tt.wantCode, rec.Code)
+
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			if tt.wantError != "" {
+				assert.Equal(t, tt.wantError, body["error"])
+				return
+			}
+			assert.Equal(t, tt.wantKeys, slices.Sorted(maps.Keys(body)))
+		})
+	}
+}
+
+// ─── JSON:API ─────────────────────────────────────────────────────────────────
+
+func TestJSONAPI_Antworten(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   string
+	}{
+		{"einzelne person", http.MethodGet, "/persons/1", "",
+			`{"data":{"type":"persons","id":"1","attributes":{"city":"Lauterecken","color":"blau","lastname":"Müller","name":"Hans","version":1,"zipcode":"67742"}}}`},
+		{"liste", http.MethodGet, "/persons/color/grün", "",
+			`{"data":[{"type":"persons","id":"2","attributes":{"city":"Stralsund","color":"grün","lastname":"Petersen","name":"Peter","version":1,"zipcode":"18439"}}]}`},
+		{"leere liste", http.MethodGet, "/persons/zipcode/00000", "", `{"data":[]}`},
+		{"mit feldauswahl", http.MethodGet, "/persons?fields=name&limit=2", "",
+			`{"data":[{"type":"persons","id":"1","attributes":{"name":"Hans"}},{"type":"persons","id":"2","attributes":{"name":"Peter"}}]}`},
+		{"envelope mit meta", http.MethodGet, "/persons?fields=color&limit=1&offset=2&envelope=true", "",
+			`{"data":[{"type":"persons","id":"3","attributes":{"color":"violett"}}],"meta":{"limit":1,"offset":2,"total":3}}`},
+		{"angelegt", http.MethodPost, "/persons", `{"name":"Anna","lastname":"Blau","zipcode":"12345","city":"Berlin","color":"blau"}`,
+			`{"data":{"type":"persons","id":"4","attributes":{"city":"Berlin","color":"blau","lastname":"Blau","name":"Anna","version":1,"zipcode":"12345"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandler()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/vnd.api+json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Less(t, rec.Code, 300, rec.Body.String())
+			assert.Equal(t, "application/vnd.api+json", rec.Header().Get("Content-Type"))
+			assert.Equal(t, []string{"Accept"}, rec.Header().Values("Vary"))
+			assert.JSONEq(t, tt.want, rec.Body.String())
+		})
+	}
+}
+
+func TestJSONAPI_OhneAcceptBleibtJSON(t *testing.T) {
+	_, router := neuerTestHandler()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons/1", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"Accept"}, rec.Header().Values("Vary"), "caches unterscheiden die darstellungen")
+	var p domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&p))
+	assert.Equal(t, "Hans", p.Name)
+}
+
+func TestJSONAPI_HeadUndFehler(t *testing.T) {
+	_, router := neuerTestHandler()
+	sendeJSONAPI := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Accept", "application/vnd.api+json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	get := sendeJSONAPI(http.MethodGet, "/persons/1")
+	head := sendeJSONAPI(http.MethodHead, "/persons/1")
+	assert.Equal(t, strconv.Itoa(get.Body.Len()), head.Header().Get("Content-Length"))
+	assert.Equal(t, "application/vnd.api+json", head.Header().Get("Content-Type"))
+
+	rec := sendeJSONAPI(http.MethodGet, "/persons/99")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "fehler bleiben errorBody")
+}
+
+// ─── Farbwerte ────────────────────────────────────────────────────────────────
+
+func TestInclude_Hex(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+	}{
+		{"ohne include unverändert", "/persons/2", "",
+			`{"id":2,"name":"Peter","lastname":"Petersen","zipcode":"18439","city":"Stralsund","color":"grün","version":1}`},
+		{"einzelne person", "/persons/2?include=hex", "",
+			`{"id":2,"name":"Peter","lastname":"Petersen","zipcode":"18439","city":"Stralsund","color":"grün","color_hex":"#00FF00","version":1}`},
+		{"liste", "/persons/color/blau?include=hex&fields=color", "",
+			`[{"id":1,"color":"blau","color_hex":"#0000FF"}]`},
+		{"ohne color in fields", "/persons?include=hex&fields=name&limit=1", "",
+			`[{"id":1,"name":"Hans","color_hex":"#0000FF"}]`},
+		{"envelope", "/persons?include=hex&fields=name&limit=1&envelope=true", "",
+			`{"data":[{"id":1,"name":"Hans","color_hex":"#0000FF"}],"meta":{"limit":1,"offset":0,"total":3}}`},
+		{"json:api", "/persons/3?include=hex&fields=name", "application/vnd.api+json",
+			`{"data":{"type":"persons","id":"3","attributes":{"color_hex":"#EE82EE","name":"Johnny"}}}`},
+		{"farbgruppen", "/persons/by-color?include=hex&fields=name&colors=grün", "",
+			`{"grün":[{"id":2,"name":"Peter","color_hex":"#00FF00"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandler()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.want, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestInclude_Ungueltig(t *testing.T) {
+	_, router := neuerTestHandler()
+	for _, target := range []string{"/persons/1?include=farbe", "/persons?include=hex,farbe", "/persons?include=x&format=ndjson"} {
+		t.Run(target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), "in include, erlaubt ist: hex")
+		})
+	}
+}
+
+func TestColors(t *testing.T) {
+	_, router := neuerTestHandler()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/colors", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[
+		{"id":1,"name":"blau","hex":"#0000FF"},
+		{"id":2,"name":"grün","hex":"#00FF00"},
+		{"id":3,"name":"violett","hex":"#EE82EE"},
+		{"id":4,"name":"rot","hex":"#FF0000"},
+		{"id":5,"name":"gelb","hex":"#FFFF00"},
+		{"id":6,"name":"türkis","hex":"#40E0D0"},
+		{"id":7,"name":"weiß","hex":"#FFFFFF"}
+	]`, rec.Body.String())
+}
+
+// ─── Fehlermeldungen nach Sprache ─────────────────────────────────────────────
+
+func TestFehler_SpracheNachAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		contentType    string
+		acceptLanguage string
+		wantStatus     int
+		wantBody       string
+	}{
+		{"ohne header deutsch", http.MethodGet, "/persons/abc", "", "", http.StatusBadRequest,
+			`{"error":"id muss eine ganzzahl sein","code":"id_not_integer"}`},
+		{"deutsch", http.MethodGet, "/persons/99", "", "de", http.StatusNotFound,
+			`{"error":"person mit id 99: nicht gefunden","code":"person_not_found"}`},
+		{"englisch", http.MethodGet, "/persons/99", "", "en", http.StatusNotFound,
+			`{"error":"person with id 99 not found","code":"person_not_found"}`},
+		{"englisch mit region und q-werten", http.MethodGet, "/persons?limit=-1", "", "en-US,en;q=0.9,de;q=0.8", http.StatusBadRequest,
+			`{"error":"limit must be an integer between 0 and 2147483647","code":"invalid_pagination"}`},
+		{"deutsch bevorzugt", http.MethodGet, "/persons?limit=-1", "", "en;q=0.5,de", http.StatusBadRequest,
+			`{"error":"limit muss eine ganzzahl zwischen 0 und 2147483647 sein: ungültige eingabe","code":"invalid_pagination"}`},
+		{"nicht unterstützt fällt auf deutsch zurück", http.MethodGet, "/persons/99", "", "fr-FR,fr;q=0.9", http.StatusNotFound,
+			`{"error":"person mit id 99: nicht gefunden","code":"person_not_found"}`},
+		{"nicht unterstützt mit englisch als ausweichsprache", http.MethodGet, "/persons/99", "", "fr,en;q=0.5", http.StatusNotFound,
+			`{"error":"person with id 99 not found","code":"person_not_found"}`},
+		{"aufzählung englisch", http.MethodPost, "/persons", "text/plain", "en", http.StatusUnsupportedMediaType,
+			`{"error":"content-type must be application/json or application/x-www-form-urlencoded","code":"unsupported_media_type"}`},
+		{"unbekannte farbe englisch", http.MethodGet, "/persons/color/pink", "", "en", http.StatusBadRequest,
+			`{"error":"invalid color","code":"invalid_color"}`},
+		{"unbekanntes feld englisch", http.MethodGet, "/persons?fields=alter", "", "en", http.StatusBadRequest,
+			`{"error":"unknown field \"alter\" in fields, allowed are: id, name, lastname, zipcode, city, color, created_at, updated_at, version, deleted_at","code":"unknown_field"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, router := neuerTestHandler()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader("{}"))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+// TestFehler_CodesFuerRepositoryFehler prüft die Zuordnung in personError:
+// Repositories melden nur domain-Sentinels mit der ID im Text, den genauen
+// Code samt englischer Meldung vergibt der Handler.
+func TestFehler_CodesFuerRepositoryFehler(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		acceptLanguage string
+		wantStatus     int
+		wantBody       string
+	}{
+		{"löschen englisch", http.MethodDelete, "/persons/99", "en", http.StatusNotFound,
+			`{"error":"person with id 99 not found","code":"person_not_found"}`},
+		{"wiederherstellen deutsch", http.MethodPost, "/persons/99/restore", "de", http.StatusNotFound,
+			`{"error":"gelöschte person mit id 99: nicht gefunden","code":"deleted_person_not_found"}`},
+		{"wiederherstellen englisch", http.MethodPost, "/persons/99/restore", "en", http.StatusNotFound,
+			`{"error":"deleted person with id 99 not found","code":"deleted_person_not_found"}`},
+		{"kapazität deutsch", http.MethodPost, "/persons", "de", http.StatusServiceUnavailable,
+			`{"error":"max 3 personen: kapazitätsgrenze erreicht","code":"max_persons"}`},
+		{"kapazität englisch", http.MethodPost, "/persons", "en", http.StatusServiceUnavailable,
+			`{"error":"capacity of 3 persons reached","code":"max_persons"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, router := neuerTestHandler()
+			h.service.(*mockService).maxPersons = 3
+			body := `{"name":"Neu","lastname":"Person","zipcode":"00000","city":"Stadt","color":"rot"}`
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+// TestFehler_DeutschUnveraendert hält fest, dass die Codes den Wortlaut der
+// Meldungen nicht verändert haben: Mit Accept-Language: de ist der Body bis
+// auf das neue Feld code Byte für Byte der bisherige.
+func TestFehler_DeutschUnveraendert(t *testing.T) {
+	tests := []struct {
+		method   string
+		target   string
+		wantBody string
+	}{
+		{http.MethodGet, "/persons/0", `{"error":"id muss positiv sein: ungültige eingabe","code":"id_not_positive"}`},
+		{http.MethodGet, "/persons/abc", `{"error":"id muss eine ganzzahl sein","code":"id_not_integer"}`},
+		{http.MethodGet, "/persons/color/pink", `{"error":"ungültige farbe: ungültige eingabe","code":"invalid_color"}`},
+		{http.MethodGet, "/persons/color/id/x", `{"error":"farb-id muss eine ganzzahl sein","code":"color_id_not_integer"}`},
+		{http.MethodGet, "/persons/color/id/99", `{"error":"unbekannte farb-id 99: ungültige eingabe","code":"unknown_color_id"}`},
+		{http.MethodGet, "/persons?limit=x", `{"error":"limit muss eine ganzzahl zwischen 0 und 2147483647 sein: ungültige eingabe","code":"invalid_pagination"}`},
+		{http.MethodGet, "/persons?ids=1,x", `{"error":"ids muss eine kommagetrennte liste von ganzzahlen sein","code":"invalid_ids"}`},
+		{http.MethodGet, "/persons?ids=1&color=blau", `{"error":"ids kann nicht mit color, limit oder offset kombiniert werden","code":"ids_combined"}`},
+		{http.MethodGet, "/persons?cursor=&offset=1", `{"error":"cursor kann nicht mit offset kombiniert werden","code":"cursor_combined"}`},
+		{http.MethodGet, "/persons?cursor=!", `{"error":"ungültiger cursor","code":"invalid_cursor"}`},
+		{http.MethodGet, "/persons?createdAfter=gestern", `{"error":"createdAfter muss ein RFC3339-zeitstempel sein","code":"invalid_created_after"}`},
+		{http.MethodGet, "/persons?include=bild", `{"error":"unbekannter wert \"bild\" in include, erlaubt ist: hex","code":"unknown_include"}`},
+		{http.MethodPost, "/persons/99/restore", `{"error":"gelöschte person mit id 99: nicht gefunden","code":"deleted_person_not_found"}`},
+		{http.MethodGet, "/admin/load-report", `{"error":"kein ladebericht für diese datenquelle","code":"no_load_report"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			_, router := neuerTestHandler()
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Accept-Language", "de")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantBody+"\n", rec.Body.String())
+		})
+	}
+}
+
+func TestFehler_SchemaDetailsAufEnglisch(t *testing.T) {
+	_, router := neuerTestHandler()
+	req := httptest.NewRequest(http.MethodPost, "/persons",
+		strings.NewReader(`{"name":"Neu","lastname":"Person","zipcode":12345,"color":"rot","alter":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{
+		"error": "request body does not match the schema",
+		"code": "schema_violation",
+		"details": [
+			{"field": "/alter", "error": "unknown field"},
+			{"field": "/city", "error": "required field is missing"},
+			{"field": "/zipcode", "error": "expected string, got number"}
+		]
+	}`, rec.Body.String())
+}
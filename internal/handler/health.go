@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"assecor-assessment-backend/internal/buildinfo"
+)
+
+// Healthz meldet, dass der Prozess läuft und Anfragen annimmt.
+func Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// versionResponse ergänzt die Versionsangaben um die konfigurierte Datenquelle.
+type versionResponse struct {
+	buildinfo.Info
+	DataSource string `json:"data_source"`
+}
+
+// Version meldet Version, Commit und Bauzeitpunkt des laufenden Binaries
+// sowie die Datenquelle, damit sich bei Fehlern klären lässt, was läuft.
+func Version(dataSource string) http.HandlerFunc {
+	body := versionResponse{Info: buildinfo.Get(), DataSource: dataSource}
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, body)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/jsonapi"
+)
+
+// wantsJSONAPI meldet, ob der Client per Accept jsonapi.MediaType angefragt
+// hat. Ohne bleibt es bei einfachem JSON.
+func wantsJSONAPI(r *http.Request) bool {
+	return accepts(r, jsonapi.MediaType)
+}
+
+// writePerson schreibt p mit status als JSON oder, falls angefragt, als
+// JSON:API-Dokument. view bestimmt die Felder beziehungsweise Attribute.
+func (h *PersonHandler) writePerson(w http.ResponseWriter, r *http.Request, status int, view personView, p domain.Person) {
+	contentType, body, err := personBody(r, view, p)
+	if err != nil {
+		h.serverError(w, r, "person kodieren", err)
+		return
+	}
+	varyAccept(w)
+	writeJSONAs(w, status, contentType, body)
+}
+
+// writePersons wie writePerson, für eine Liste.
+func (h *PersonHandler) writePersons(w http.ResponseWriter, r *http.Request, status int, view personView, persons []domain.Person) {
+	varyAccept(w)
+	if !wantsJSONAPI(r) {
+		writeJSON(w, status, view.persons(persons))
+		return
+	}
+	doc, err := personsDocument(view, persons)
+	if err != nil {
+		h.serverError(w, r, "personen kodieren", err)
+		return
+	}
+	writeJSONAs(w, status, jsonapi.MediaType, doc)
+}
+
+// personBody gibt Content-Type und Body für p zurück, wie writePerson sie
+// schreibt.
+func personBody(r *http.Request, view personView, p domain.Person) (contentType string, body any, err error) {
+	if !wantsJSONAPI(r) {
+		return "application/json", view.person(p), nil
+	}
+	res, err := jsonapi.Person(p)
+	if err != nil {
+		return "", nil, err
+	}
+	view.apply(&res, p)
+	return jsonapi.MediaType, jsonapi.Document{Data: res}, nil
+}
+
+// personsDocument verpackt persons als JSON:API-Dokument.
+func personsDocument(view personView, persons []domain.Person) (jsonapi.Document, error) {
+	resources, err := jsonapi.Persons(persons)
+	if err != nil {
+		return jsonapi.Document{}, err
+	}
+	for i := range resources {
+		view.apply(&resources[i], persons[i])
+	}
+	return jsonapi.Document{Data: resources}, nil
+}
+
+// varyAccept vermerkt, dass die Darstellung vom Accept-Header abhängt.
+func varyAccept(w http.ResponseWriter) {
+	if !slices.Contains(w.Header().Values("Vary"), "Accept") {
+		w.Header().Add("Vary", "Accept")
+	}
+}
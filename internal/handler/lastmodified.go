@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LastModified ist eine Middleware für lesende Anfragen. Sie setzt den
+// Änderungszeitpunkt des Bestands als Last-Modified und beantwortet eine
+// Anfrage mit If-Modified-Since, seit dem sich nichts geändert hat, mit 304
+// ohne Body. Der Zeitpunkt gilt für den gesamten Bestand, da jede Änderung
+// auch Listen, Statistiken und Farbgruppen betreffen kann.
+//
+// Der Zeitpunkt wird vor dem Handler gelesen. Eine gleichzeitige Änderung kann
+// daher neuere Daten unter dem älteren Zeitpunkt ausliefern; ein Cache prüft
+// dann erneut, hält aber nie einen veralteten Stand für aktuell.
+func (h *PersonHandler) LastModified(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		modified, err := h.service.LastModified(r.Context())
+		if err != nil {
+			// Ohne Zeitpunkt wird ohne Bedingung geantwortet, statt die
+			// Anfrage scheitern zu lassen.
+			h.logger.Warn("änderungszeitpunkt nicht verfügbar", zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if modified.IsZero() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// HTTP-Datumsangaben kennen nur ganze Sekunden.
+		modified = modified.UTC().Truncate(time.Second)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		if notModifiedSince(r, modified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// notModifiedSince wertet If-Modified-Since gegen modified aus. Nach RFC 9110
+// wird der Header neben If-None-Match ignoriert; ein ungültiges Datum gilt als
+// nicht angegeben.
+func notModifiedSince(r *http.Request, modified time.Time) bool {
+	if r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// readMethods sind die Methoden, die auch im Schreibschutz erlaubt bleiben.
+var readMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+// ReadOnlyMode ist ein zur Laufzeit umschaltbarer Schreibschutz. Ist er
+// aktiv, lehnt Guard alle nicht lesenden Anfragen ab.
+type ReadOnlyMode struct {
+	enabled atomic.Bool
+	logger  *zap.Logger
+}
+
+// NewReadOnlyMode legt den Schreibschutz mit dem Startwert enabled an.
+func NewReadOnlyMode(enabled bool, logger *zap.Logger) *ReadOnlyMode {
+	m := &ReadOnlyMode{logger: logger}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled meldet, ob der Schreibschutz aktiv ist.
+func (m *ReadOnlyMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Guard liefert eine Middleware, die bei aktivem Schreibschutz alle Anfragen
+// außer GET, HEAD und OPTIONS mit 405 beantwortet. Der Allow-Header nennt die
+// lesenden Methoden, die in routes für den Pfad registriert sind.
+func (m *ReadOnlyMode) Guard(routes chi.Routes) func(http.Handler) http.Handler {
+	var (
+		once sync.Once
+		flat *chi.Mux
+	)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !m.Enabled() || isReadMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			once.Do(func() { flat = flattenRoutes(routes) })
+			if allowed := allowedMethods(flat, r.URL.Path, readMethods); len(allowed) > 0 {
+				w.Header().Set("Allow", strings.Join(allowed, ", "))
+			}
+			writeError(w, r, http.StatusMethodNotAllowed, i18n.New(i18n.CodeReadOnly))
+		})
+	}
+}
+
+// readOnlyBody ist Anfrage und Antwort von POST /admin/readonly.
+type readOnlyBody struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// Toggle schaltet den Schreibschutz per {"enabled":true|false} um und
+// antwortet mit dem neuen Zustand.
+func (m *ReadOnlyMode) Toggle(w http.ResponseWriter, r *http.Request) {
+	var body readOnlyBody
+	if !decodeJSON(w, r, defaultMaxRequestBody, &body) {
+		return
+	}
+	if body.Enabled == nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeEnabledMissing))
+		return
+	}
+	if m.enabled.Swap(*body.Enabled) != *body.Enabled {
+		m.logger.Info("schreibschutz umgeschaltet", zap.Bool("aktiv", *body.Enabled))
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func isReadMethod(method string) bool {
+	for _, m := range readMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
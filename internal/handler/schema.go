@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// personSchemaJSON beschreibt den Body von POST /persons. Das Schema prüft nur
+// die Struktur; Längen und Farben bleiben Sache des Service, weil COLORS den
+// Farbsatz zur Laufzeit ersetzen kann.
+//
+//go:embed person.schema.json
+var personSchemaJSON []byte
+
+// personSchema ist das beim Paketstart kompilierte personSchemaJSON.
+var personSchema = mustCompileSchema("person.schema.json", personSchemaJSON)
+
+// mustCompileSchema kompiliert ein eingebettetes Schema. Ein Fehler ist ein
+// Programmierfehler und führt zur Panic.
+func mustCompileSchema(name string, raw []byte) *jsonschema.Schema {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		panic(fmt.Sprintf("schema %s ist kein gültiges json: %v", name, err))
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(name, doc); err != nil {
+		panic(fmt.Sprintf("schema %s: %v", name, err))
+	}
+	return c.MustCompile(name)
+}
+
+// schemaViolation ist ein einzelner Schemaverstoß. Field ist der JSON-Pointer
+// des betroffenen Feldes und fehlt, wenn der Body als Ganzes ungültig ist.
+type schemaViolation struct {
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// schemaErrorBody ist die Antwort auf einen Body, der das Schema verletzt (400).
+type schemaErrorBody struct {
+	errorBody
+	Details []schemaViolation `json:"details"`
+}
+
+// readBody liest den Request-Body bis zur Größe limit. Bei Überschreitung
+// antwortet er mit 413 und gibt false zurück.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, i18n.New(i18n.CodeBodyTooLarge, tooLarge.Limit))
+			return nil, false
+		}
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeBodyUnreadable))
+		return nil, false
+	}
+	return raw, true
+}
+
+// validateSchema prüft raw gegen schema. Bei einem Verstoß antwortet es mit
+// 400 und listet alle verletzten Felder auf.
+func validateSchema(w http.ResponseWriter, r *http.Request, schema *jsonschema.Schema, raw []byte) bool {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidBody))
+		return false
+	}
+
+	err = schema.Validate(doc)
+	if err == nil {
+		return true
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		writeError(w, r, http.StatusBadRequest, i18n.New(i18n.CodeInvalidBody))
+		return false
+	}
+	writeJSON(w, http.StatusBadRequest, schemaErrorBody{
+		errorBody: newErrorBody(r, i18n.New(i18n.CodeSchemaViolation)),
+		Details:   schemaViolations(requestLang(r), verr),
+	})
+	return false
+}
+
+// schemaViolations sammelt die Blätter des Fehlerbaums in der Sprache lang,
+// sortiert nach Feld, damit die Antwort unabhängig von der Prüfreihenfolge
+// des Schemas ist.
+func schemaViolations(lang i18n.Lang, verr *jsonschema.ValidationError) []schemaViolation {
+	var out []schemaViolation
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, c := range e.Causes {
+				walk(c)
+			}
+			return
+		}
+		out = append(out, describeViolation(lang, e)...)
+	}
+	walk(verr)
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
+
+// describeViolation übersetzt einen Schemafehler in Meldungen je Feld. Fehlende
+// und unbekannte Felder werden einzeln aufgeführt.
+func describeViolation(lang i18n.Lang, e *jsonschema.ValidationError) []schemaViolation {
+	field := func(name ...string) string {
+		path := append(append([]string(nil), e.InstanceLocation...), name...)
+		if len(path) == 0 {
+			return ""
+		}
+		return "/" + strings.Join(path, "/")
+	}
+
+	switch k := e.ErrorKind.(type) {
+	case *kind.Required:
+		out := make([]schemaViolation, 0, len(k.Missing))
+		for _, name := range k.Missing {
+			out = append(out, schemaViolation{Field: field(name), Error: i18n.Message(lang, i18n.CodeSchemaRequired)})
+		}
+		return out
+	case *kind.AdditionalProperties:
+		out := make([]schemaViolation, 0, len(k.Properties))
+		for _, name := range k.Properties {
+			out = append(out, schemaViolation{Field: field(name), Error: i18n.Message(lang, i18n.CodeSchemaUnknownField)})
+		}
+		return out
+	case *kind.Type:
+		return []schemaViolation{{
+			Field: field(),
+			Error: i18n.Message(lang, i18n.CodeSchemaType, i18n.Alternatives(k.Want), k.Got),
+		}}
+	default:
+		return []schemaViolation{{Field: field(), Error: i18n.Message(lang, i18n.CodeSchemaInvalid)}}
+	}
+}
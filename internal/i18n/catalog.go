@@ -0,0 +1,187 @@
+package i18n
+
+// Codes der Fehlermeldungen. Sie sind Teil der API: Clients werten sie aus,
+// daher werden sie nicht umbenannt, sondern höchstens ergänzt.
+const (
+	// CodeUnknown steht für einen Fehler ohne Code; er sollte nicht an
+	// Clients gelangen.
+	CodeUnknown Code = "unknown"
+
+	// ─── Domäne ───────────────────────────────────────────────────────────────
+
+	CodeNotFound              Code = "not_found"
+	CodeInvalidInput          Code = "invalid_input"
+	CodeCapacityReached       Code = "capacity_reached"
+	CodeVersionConflict       Code = "version_conflict"
+	CodeGone                  Code = "gone"
+	CodeInvalidColor          Code = "invalid_color"
+	CodeNoPersonsWithColor    Code = "no_persons_with_color"
+	CodeUnknownColor          Code = "unknown_color"
+	CodeUnknownColorID        Code = "unknown_color_id"
+	CodePersonVersionConflict Code = "person_version_conflict"
+
+	// ─── Personen ─────────────────────────────────────────────────────────────
+
+	// Diese Codes vergibt der Handler für Fehler aus den Repositories, die
+	// selbst nur die Sentinels aus domain melden (siehe Annotate).
+
+	CodePersonNotFound        Code = "person_not_found"
+	CodePersonGone            Code = "person_gone"
+	CodeDeletedPersonNotFound Code = "deleted_person_not_found"
+	CodeMaxPersons            Code = "max_persons"
+
+	// ─── Service ──────────────────────────────────────────────────────────────
+
+	CodeNegativePagination Code = "negative_pagination"
+	CodeInvalidCursorLimit Code = "invalid_cursor_limit"
+	CodeIDNotPositive      Code = "id_not_positive"
+	CodeIDsRequired        Code = "ids_required"
+	CodeZipcodeRequired    Code = "zipcode_required"
+	CodeZipcodeTooLong     Code = "zipcode_too_long"
+	CodeNegativeVersion    Code = "negative_version"
+	CodeFieldTooShort      Code = "field_too_short"
+	CodeFieldTooLong       Code = "field_too_long"
+	CodeUnknownSortField   Code = "unknown_sort_field"
+
+	// ─── HTTP ─────────────────────────────────────────────────────────────────
+
+	CodeIDNotInteger         Code = "id_not_integer"
+	CodeColorIDNotInteger    Code = "color_id_not_integer"
+	CodeInvalidCreatedAfter  Code = "invalid_created_after"
+	CodeInvalidPagination    Code = "invalid_pagination"
+	CodeLimitTooLarge        Code = "limit_too_large"
+	CodeUnknownField         Code = "unknown_field"
+	CodeUnknownInclude       Code = "unknown_include"
+	CodeIDsCombined          Code = "ids_combined"
+	CodeTooManyIDs           Code = "too_many_ids"
+	CodeInvalidIDs           Code = "invalid_ids"
+	CodeCursorCombined       Code = "cursor_combined"
+	CodeNDJSONCombined       Code = "ndjson_combined"
+	CodeInvalidCursor        Code = "invalid_cursor"
+	CodeInvalidBody          Code = "invalid_body"
+	CodeInvalidFormBody      Code = "invalid_form_body"
+	CodeBodyTooLarge         Code = "body_too_large"
+	CodeBodyUnreadable       Code = "body_unreadable"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeSchemaViolation      Code = "schema_violation"
+	CodeSchemaRequired       Code = "schema_required"
+	CodeSchemaUnknownField   Code = "schema_unknown_field"
+	CodeSchemaType           Code = "schema_type"
+	CodeSchemaInvalid        Code = "schema_invalid"
+	CodeGraphQLError         Code = "graphql_error"
+	CodeQueryMissing         Code = "query_missing"
+	CodeNoOperation          Code = "no_operation"
+	CodeOperationAmbiguous   Code = "operation_ambiguous"
+	CodeUnknownOperation     Code = "unknown_operation"
+	CodeInvalidVariables     Code = "invalid_variables"
+	CodeVariableType         Code = "variable_type"
+	CodeMutationMethod       Code = "mutation_method"
+	CodeInvalidInputArg      Code = "invalid_input_argument"
+	CodeInputSchema          Code = "input_schema_violation"
+	CodeIfMatchRequired      Code = "if_match_required"
+	CodeInvalidIfMatch       Code = "invalid_if_match"
+	CodeDestructiveDisabled  Code = "destructive_disabled"
+	CodeResourceNotFound     Code = "resource_not_found"
+	CodeMethodNotAllowed     Code = "method_not_allowed"
+	CodeReadOnly             Code = "read_only"
+	CodeEnabledMissing       Code = "enabled_missing"
+	CodeNoLoadReport         Code = "no_load_report"
+	CodeReloadUnsupported    Code = "reload_unsupported"
+	CodeReloadRejected       Code = "reload_rejected"
+	CodeRequestCanceled      Code = "request_canceled"
+	CodeTimeout              Code = "timeout"
+	CodeInternal             Code = "internal_error"
+
+	// ─── Middleware ───────────────────────────────────────────────────────────
+
+	CodeTooManyRequests        Code = "too_many_requests"
+	CodeServerBusy             Code = "server_busy"
+	CodeInvalidAPIKey          Code = "invalid_api_key"
+	CodeAuthenticationRequired Code = "authentication_required"
+	CodeNoCredentials          Code = "no_credentials"
+)
+
+// catalog enthält je Code die Meldung als fmt-Format. Die deutschen Texte
+// entsprechen Zeichen für Zeichen den Meldungen vor Einführung der Codes;
+// beide Sprachen erwarten dieselben Parameter in derselben Reihenfolge.
+var catalog = map[Code]struct{ de, en string }{
+	CodeNotFound:              {"nicht gefunden", "not found"},
+	CodeInvalidInput:          {"ungültige eingabe", "invalid input"},
+	CodeCapacityReached:       {"kapazitätsgrenze erreicht", "capacity reached"},
+	CodeVersionConflict:       {"versionskonflikt", "version conflict"},
+	CodeGone:                  {"gelöscht: nicht gefunden", "deleted: not found"},
+	CodeInvalidColor:          {"ungültige farbe: ungültige eingabe", "invalid color"},
+	CodeNoPersonsWithColor:    {"keine personen mit dieser farbe", "no persons with this color"},
+	CodeUnknownColor:          {"unbekannte farbe: ungültige eingabe", "unknown color"},
+	CodeUnknownColorID:        {"unbekannte farb-id %d: ungültige eingabe", "unknown color id %d"},
+	CodePersonVersionConflict: {"person mit id %d hat version %d: versionskonflikt", "person with id %d has version %d: version conflict"},
+
+	CodePersonNotFound:        {"person mit id %d: nicht gefunden", "person with id %d not found"},
+	CodePersonGone:            {"person mit id %d: gelöscht: nicht gefunden", "person with id %d has been deleted"},
+	CodeDeletedPersonNotFound: {"gelöschte person mit id %d: nicht gefunden", "deleted person with id %d not found"},
+	CodeMaxPersons:            {"max %d personen: kapazitätsgrenze erreicht", "capacity of %d persons reached"},
+
+	CodeNegativePagination: {"limit und offset dürfen nicht negativ sein: ungültige eingabe", "limit and offset must not be negative"},
+	CodeInvalidCursorLimit: {"cursor und limit müssen positiv sein: ungültige eingabe", "cursor and limit must be positive"},
+	CodeIDNotPositive:      {"id muss positiv sein: ungültige eingabe", "id must be positive"},
+	CodeIDsRequired:        {"mindestens eine id erforderlich: ungültige eingabe", "at least one id is required"},
+	CodeZipcodeRequired:    {"postleitzahl ist erforderlich: ungültige eingabe", "zipcode is required"},
+	CodeZipcodeTooLong:     {"postleitzahl darf maximal %d zeichen lang sein: ungültige eingabe", "zipcode must be at most %d characters long"},
+	CodeNegativeVersion:    {"version darf nicht negativ sein: ungültige eingabe", "version must not be negative"},
+	CodeFieldTooShort:      {"%s muss mindestens %d zeichen lang sein: ungültige eingabe", "%s must be at least %d characters long"},
+	CodeFieldTooLong:       {"%s darf maximal %d zeichen lang sein: ungültige eingabe", "%s must be at most %d characters long"},
+	CodeUnknownSortField:   {"unbekanntes sortierfeld %q, erlaubt sind: %s: ungültige eingabe", "unknown sort field %q, allowed are: %s"},
+
+	CodeIDNotInteger:         {"id muss eine ganzzahl sein", "id must be an integer"},
+	CodeColorIDNotInteger:    {"farb-id muss eine ganzzahl sein", "color id must be an integer"},
+	CodeInvalidCreatedAfter:  {"createdAfter muss ein RFC3339-zeitstempel sein", "createdAfter must be an RFC3339 timestamp"},
+	CodeInvalidPagination:    {"%s muss eine ganzzahl zwischen 0 und %d sein: ungültige eingabe", "%s must be an integer between 0 and %d"},
+	CodeLimitTooLarge:        {"limit darf höchstens %d sein: ungültige eingabe", "limit must be at most %d"},
+	CodeUnknownField:         {"unbekanntes feld %q in fields, erlaubt sind: %s", "unknown field %q in fields, allowed are: %s"},
+	CodeUnknownInclude:       {"unbekannter wert %q in include, erlaubt ist: hex", "unknown value %q in include, allowed is: hex"},
+	CodeIDsCombined:          {"ids kann nicht mit color, limit oder offset kombiniert werden", "ids cannot be combined with color, limit or offset"},
+	CodeTooManyIDs:           {"höchstens %d ids pro anfrage erlaubt", "at most %d ids per request allowed"},
+	CodeInvalidIDs:           {"ids muss eine kommagetrennte liste von ganzzahlen sein", "ids must be a comma-separated list of integers"},
+	CodeCursorCombined:       {"cursor kann nicht mit %s kombiniert werden", "cursor cannot be combined with %s"},
+	CodeNDJSONCombined:       {"ndjson kann nicht mit %s kombiniert werden", "ndjson cannot be combined with %s"},
+	CodeInvalidCursor:        {"ungültiger cursor", "invalid cursor"},
+	CodeInvalidBody:          {"ungültiger anfrage-body", "invalid request body"},
+	CodeInvalidFormBody:      {"ungültiger formular-body", "invalid form body"},
+	CodeBodyTooLarge:         {"anfrage-body überschreitet das limit von %d bytes", "request body exceeds the limit of %d bytes"},
+	CodeBodyUnreadable:       {"anfrage-body konnte nicht gelesen werden", "request body could not be read"},
+	CodeUnsupportedMediaType: {"content-type muss %s sein", "content-type must be %s"},
+	CodeSchemaViolation:      {"anfrage-body entspricht nicht dem schema", "request body does not match the schema"},
+	CodeSchemaRequired:       {"pflichtfeld fehlt", "required field is missing"},
+	CodeSchemaUnknownField:   {"unbekanntes feld", "unknown field"},
+	CodeSchemaType:           {"erwartet %s, erhalten %s", "expected %s, got %s"},
+	CodeSchemaInvalid:        {"verletzt das schema", "violates the schema"},
+	CodeGraphQLError:         {"graphql-fehler", "graphql error"},
+	CodeQueryMissing:         {"query fehlt", "query is missing"},
+	CodeNoOperation:          {"dokument enthält keine operation", "document contains no operation"},
+	CodeOperationAmbiguous:   {"dokument enthält %d operationen, operationName fehlt", "document contains %d operations, operationName is missing"},
+	CodeUnknownOperation:     {"operation %q gibt es nicht", "operation %q does not exist"},
+	CodeInvalidVariables:     {"variables ist kein json-objekt", "variables is not a json object"},
+	CodeVariableType:         {"variable %q: erwartet %s, erhalten %s", "variable %q: expected %s, got %s"},
+	CodeMutationMethod:       {"mutationen nur per POST", "mutations only via POST"},
+	CodeInvalidInputArg:      {"argument \"input\": %s", "argument \"input\": %s"},
+	CodeInputSchema:          {"argument \"input\" entspricht nicht dem schema: %s", "argument \"input\" does not match the schema: %s"},
+	CodeIfMatchRequired:      {"if-match-header erforderlich", "if-match header required"},
+	CodeInvalidIfMatch:       {"ungültiger if-match-header", "invalid if-match header"},
+	CodeDestructiveDisabled:  {"destruktive operationen sind deaktiviert", "destructive operations are disabled"},
+	CodeResourceNotFound:     {"ressource nicht gefunden", "resource not found"},
+	CodeMethodNotAllowed:     {"methode nicht erlaubt", "method not allowed"},
+	CodeReadOnly:             {"schreibschutz aktiv: nur lesende anfragen sind erlaubt", "read-only mode active: only read requests are allowed"},
+	CodeEnabledMissing:       {"feld enabled fehlt", "field enabled is missing"},
+	CodeNoLoadReport:         {"kein ladebericht für diese datenquelle", "no load report for this data source"},
+	CodeReloadUnsupported:    {"neu laden wird von dieser datenquelle nicht unterstützt", "this data source does not support reloading"},
+	CodeReloadRejected:       {"%v", "reload rejected, previous data kept: %v"},
+	CodeRequestCanceled:      {"anfrage abgebrochen", "request canceled"},
+	CodeTimeout:              {"zeitlimit überschritten", "timeout exceeded"},
+	CodeInternal:             {"interner serverfehler", "internal server error"},
+
+	CodeTooManyRequests:        {"zu viele anfragen", "too many requests"},
+	CodeServerBusy:             {"server ausgelastet", "server busy"},
+	CodeInvalidAPIKey:          {"fehlender oder ungültiger api-schlüssel", "missing or invalid api key"},
+	CodeAuthenticationRequired: {"authentifizierung erforderlich", "authentication required"},
+	CodeNoCredentials:          {"ohne konfigurierte zugangsdaten nicht erlaubt", "not allowed without configured credentials"},
+}
@@ -0,0 +1,180 @@
+// Package i18n übersetzt Fehlermeldungen an Clients. Jeder Fehler hat einen
+// stabilen Code (siehe catalog.go), nach dem Clients unterscheiden; die
+// Meldung dazu gibt es auf Deutsch (Standard) und Englisch. Fehler werden mit
+// Code und Parametern erzeugt und erst beim Schreiben der Antwort in der
+// Sprache aus Accept-Language formuliert. Logs bleiben deutsch.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lang ist eine unterstützte Sprache.
+type Lang string
+
+const (
+	German  Lang = "de"
+	English Lang = "en"
+)
+
+// Default gilt, wenn Accept-Language fehlt oder keine unterstützte Sprache
+// nennt.
+const Default = German
+
+// Negotiate wählt anhand eines Accept-Language-Headers wie
+// "en-US,en;q=0.9,de;q=0.8" die Sprache mit dem höchsten q-Wert. Verglichen
+// wird nur die Hauptsprache, "*" steht für Default. Bei gleichem q-Wert
+// gewinnt der frühere Eintrag, q=0 schließt eine Sprache aus.
+func Negotiate(header string) Lang {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		var lang Lang
+		switch primary {
+		case "*":
+			lang = Default
+		case string(German), string(English):
+			lang = Lang(primary)
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Code kennzeichnet eine Fehlerursache dauerhaft, unabhängig von Sprache und
+// Wortlaut.
+type Code string
+
+// Localized ist ein Fehler mit Code, dessen Meldung es in jeder Sprache gibt.
+type Localized interface {
+	error
+	ErrorCode() Code
+	Localize(lang Lang) string
+}
+
+// Error ist ein Fehler aus dem Katalog. Error() liefert die deutsche Meldung;
+// Err ist die umschlossene Ursache, meist ein Sentinel wie
+// domain.ErrInvalidInput, und geht nicht in die Meldung ein.
+type Error struct {
+	Code Code
+	Args []any
+	Err  error
+}
+
+// New erzeugt einen Fehler mit code und den Parametern seiner Meldung.
+func New(code Code, args ...any) *Error {
+	return &Error{Code: code, Args: args}
+}
+
+// Wrap wie New, mit err als Ursache für errors.Is und errors.As.
+func Wrap(err error, code Code, args ...any) *Error {
+	return &Error{Code: code, Args: args, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Localize(Default)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode gibt den Code von e zurück.
+func (e *Error) ErrorCode() Code {
+	return e.Code
+}
+
+// Localize formuliert die Meldung von e in lang.
+func (e *Error) Localize(lang Lang) string {
+	return Message(lang, e.Code, e.Args...)
+}
+
+// Message formuliert die Meldung zu code in lang. Einen unbekannten Code gibt
+// es unverändert zurück.
+func Message(lang Lang, code Code, args ...any) string {
+	m, ok := catalog[code]
+	if !ok {
+		return string(code)
+	}
+	format := m.de
+	if lang == English {
+		format = m.en
+	}
+	if len(args) == 0 {
+		return format
+	}
+	localized := make([]any, len(args))
+	for i, arg := range args {
+		if alt, ok := arg.(Alternatives); ok {
+			arg = alt.join(lang)
+		}
+		localized[i] = arg
+	}
+	return fmt.Sprintf(format, localized...)
+}
+
+// Annotate ordnet err nachträglich code mit den Parametern args zu. Anders
+// als bei Wrap bleibt die deutsche Meldung der Text von err; nur die übrigen
+// Sprachen kommen aus dem Katalog. So vergibt etwa der Handler Codes für
+// Fehler aus Schichten, die selbst keine kennen.
+func Annotate(err error, code Code, args ...any) Localized {
+	return &annotated{err: err, code: code, args: args}
+}
+
+type annotated struct {
+	err  error
+	code Code
+	args []any
+}
+
+func (a *annotated) Error() string   { return a.err.Error() }
+func (a *annotated) Unwrap() error   { return a.err }
+func (a *annotated) ErrorCode() Code { return a.code }
+func (a *annotated) Localize(lang Lang) string {
+	if lang == German {
+		return a.err.Error()
+	}
+	return Message(lang, a.code, a.args...)
+}
+
+// Render gibt Code und Meldung von err für einen Client in lang zurück.
+// Maßgeblich ist der äußerste Localized-Fehler in der Kette. Auf Deutsch ist
+// die Meldung immer err.Error(), also genau der bisherige Wortlaut. Ohne
+// Localized-Fehler ist der Code CodeUnknown und die Meldung err.Error().
+func Render(lang Lang, err error) (Code, string) {
+	var l Localized
+	if !errors.As(err, &l) {
+		return CodeUnknown, err.Error()
+	}
+	if lang == German {
+		return l.ErrorCode(), err.Error()
+	}
+	return l.ErrorCode(), l.Localize(lang)
+}
+
+// Alternatives ist ein Parameter, der als Aufzählung mit "oder"
+// beziehungsweise "or" in die Meldung eingeht, etwa "a oder b".
+type Alternatives []string
+
+func (a Alternatives) join(lang Lang) string {
+	if lang == English {
+		return strings.Join(a, " or ")
+	}
+	return strings.Join(a, " oder ")
+}
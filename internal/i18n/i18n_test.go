@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// verbs findet die fmt-Verben eines Formats, ohne %%.
+var verbs = regexp.MustCompile(`%[-+# 0]*[a-zA-Z]`)
+
+func TestCatalog_JedeMeldungInBeidenSprachen(t *testing.T) {
+	for code, m := range catalog {
+		assert.NotEmpty(t, m.de, "code %s ohne deutsche meldung", code)
+		assert.NotEmpty(t, m.en, "code %s ohne englische meldung", code)
+		assert.Equal(t, verbs.FindAllString(m.de, -1), verbs.FindAllString(m.en, -1),
+			"code %s: beide sprachen müssen dieselben parameter erwarten", code)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Lang
+	}{
+		{"", German},
+		{"de", German},
+		{"en", English},
+		{"EN-us", English},
+		{"en-US,en;q=0.9,de;q=0.8", English},
+		{"de-CH, en;q=0.5", German},
+		{"fr, en;q=0.8, de;q=0.9", German},
+		{"de;q=0.3, en;q=0.7", English},
+		{"en;q=0.5, de;q=0.5", English},
+		{"en;q=0, de;q=0.1", German},
+		{"en;q=0", German},
+		{"fr", German},
+		{"fr, ja;q=0.5", German},
+		{"fr, en;q=0.1", English},
+		{"*", German},
+		{"en;q=abc", German},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.want, Negotiate(tt.header))
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	sentinel := New(CodeInvalidInput)
+	coded := Wrap(sentinel, CodeFieldTooShort, "name", 2)
+
+	tests := []struct {
+		name     string
+		err      error
+		lang     Lang
+		wantCode Code
+		wantMsg  string
+	}{
+		{"deutsch", coded, German, CodeFieldTooShort, "name muss mindestens 2 zeichen lang sein: ungültige eingabe"},
+		{"englisch", coded, English, CodeFieldTooShort, "name must be at least 2 characters long"},
+		{"deutsch mit kontext bleibt unverändert", fmt.Errorf("zeile 3: %w", coded), German,
+			CodeFieldTooShort, "zeile 3: name muss mindestens 2 zeichen lang sein: ungültige eingabe"},
+		{"englisch ohne deutschen kontext", fmt.Errorf("zeile 3: %w", coded), English,
+			CodeFieldTooShort, "name must be at least 2 characters long"},
+		{"ohne code", errors.New("kaputt"), English, CodeUnknown, "kaputt"},
+		{"nachträglich deutsch", Annotate(fmt.Errorf("person mit id 7: %w", sentinel), CodePersonNotFound, 7), German,
+			CodePersonNotFound, "person mit id 7: ungültige eingabe"},
+		{"nachträglich englisch", Annotate(fmt.Errorf("person mit id 7: %w", sentinel), CodePersonNotFound, 7), English,
+			CodePersonNotFound, "person with id 7 not found"},
+		{"aufzählung deutsch", New(CodeUnsupportedMediaType, Alternatives{"a", "b"}), German,
+			CodeUnsupportedMediaType, "content-type muss a oder b sein"},
+		{"aufzählung englisch", New(CodeUnsupportedMediaType, Alternatives{"a", "b"}), English,
+			CodeUnsupportedMediaType, "content-type must be a or b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, msg := Render(tt.lang, tt.err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantMsg, msg)
+		})
+	}
+	assert.ErrorIs(t, coded, sentinel)
+	assert.ErrorIs(t, Annotate(coded, CodeUnknown), sentinel)
+}
@@ -0,0 +1,78 @@
+// Package jsonapi kodiert Personen als JSON:API-Dokumente
+// (https://jsonapi.org), etwa {"data":{"type":"persons","id":"1",
+// "attributes":{...}}}. Die Attribute sind die Felder der JSON-Darstellung
+// ohne id; JSON:API verlangt die ID als String.
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// MediaType ist der Medientyp von JSON:API-Dokumenten.
+const MediaType = "application/vnd.api+json"
+
+// TypePersons ist der Ressourcentyp einer Person.
+const TypePersons = "persons"
+
+// Document ist ein JSON:API-Dokument. Data ist eine Resource oder eine Liste
+// davon; Meta fehlt, wenn es nil ist.
+type Document struct {
+	Data any `json:"data"`
+	Meta any `json:"meta,omitempty"`
+}
+
+// Resource ist ein Ressourcenobjekt.
+type Resource struct {
+	Type       string                     `json:"type"`
+	ID         string                     `json:"id"`
+	Attributes map[string]json.RawMessage `json:"attributes"`
+}
+
+// NewResource wandelt v in eine Ressource vom Typ typ. v muss als JSON-Objekt
+// mit dem Feld id kodieren; eine numerische ID wird zum String.
+func NewResource(typ string, v any) (Resource, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return Resource{}, err
+	}
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &attributes); err != nil {
+		return Resource{}, fmt.Errorf("%s: kein json-objekt: %w", typ, err)
+	}
+	rawID, ok := attributes["id"]
+	if !ok {
+		return Resource{}, errors.New(typ + ": feld id fehlt")
+	}
+	delete(attributes, "id")
+
+	id := string(rawID)
+	if err := json.Unmarshal(rawID, &id); err != nil {
+		// Keine Zeichenkette: die ID steht so im Dokument, wie sie kodiert
+		// wurde, etwa 1 als "1".
+		id = string(rawID)
+	}
+	return Resource{Type: typ, ID: id, Attributes: attributes}, nil
+}
+
+// Person wandelt p in eine Ressource vom Typ persons.
+func Person(p domain.Person) (Resource, error) {
+	return NewResource(TypePersons, p)
+}
+
+// Persons wie Person, für eine Liste. Eine leere Liste bleibt ein leeres
+// Array, wie JSON:API es für leere Sammlungen vorsieht.
+func Persons(persons []domain.Person) ([]Resource, error) {
+	out := make([]Resource, 0, len(persons))
+	for _, p := range persons {
+		res, err := Person(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+func TestPerson(t *testing.T) {
+	res, err := Person(domain.Person{
+		ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau",
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Version: 2,
+	})
+	require.NoError(t, err)
+
+	got, err := json.Marshal(Document{Data: res})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data": {
+		"type": "persons",
+		"id": "1",
+		"attributes": {
+			"name": "Hans", "lastname": "Müller", "zipcode": "67742", "city": "Lauterecken",
+			"color": "blau", "created_at": "2024-05-01T12:00:00Z", "version": 2
+		}
+	}}`, string(got))
+}
+
+func TestPersons_LeereListeBleibtArray(t *testing.T) {
+	resources, err := Persons(nil)
+	require.NoError(t, err)
+
+	got, err := json.Marshal(Document{Data: resources})
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":[]}`, string(got))
+}
+
+func TestNewResource(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       any
+		wantID  string
+		wantErr string
+	}{
+		{"numerische id", map[string]any{"id": 7, "name": "Anna"}, "7", ""},
+		{"string-id", map[string]any{"id": "a-1"}, "a-1", ""},
+		{"id fehlt", map[string]any{"name": "Anna"}, "", "persons: feld id fehlt"},
+		{"kein objekt", []int{1}, "", "persons: kein json-objekt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewResource(TypePersons, tt.v)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, TypePersons, res.Type)
+			assert.Equal(t, tt.wantID, res.ID)
+			assert.NotContains(t, res.Attributes, "id")
+		})
+	}
+}
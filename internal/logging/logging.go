@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config beschreibt Level und Ausgabeformat des Anwendungsloggers.
+type Config struct {
+	Level  string // "debug", "info", "warn", "error"; leer bedeutet "info"
+	Format string // "json" oder "console"; leer bedeutet "json"
+}
+
+// New erstellt einen zap-Logger gemäß cfg. Basis ist die Produktionskonfiguration;
+// im Format "console" wird der menschenlesbare Development-Encoder verwendet.
+func New(cfg Config) (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("log-level %q: %w", cfg.Level, err)
+	}
+
+	zc := zap.NewProductionConfig()
+	zc.Level = zap.NewAtomicLevelAt(level)
+
+	switch cfg.Format {
+	case "", "json":
+	case "console":
+		zc.Encoding = "console"
+		zc.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	default:
+		return nil, fmt.Errorf("unbekanntes log-format %q", cfg.Format)
+	}
+
+	return zc.Build()
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNew_LevelFilter(t *testing.T) {
+	tests := []struct {
+		level       string
+		wantDebug   bool
+		wantInfo    bool
+		wantWarning bool
+	}{
+		{"", false, true, true},
+		{"debug", true, true, true},
+		{"info", false, true, true},
+		{"warn", false, false, true},
+		{"ERROR", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			logger, err := New(Config{Level: tt.level})
+			require.NoError(t, err)
+			core := logger.Core()
+			assert.Equal(t, tt.wantDebug, core.Enabled(zap.DebugLevel))
+			assert.Equal(t, tt.wantInfo, core.Enabled(zap.InfoLevel))
+			assert.Equal(t, tt.wantWarning, core.Enabled(zap.WarnLevel))
+		})
+	}
+}
+
+func TestNew_Formate(t *testing.T) {
+	for _, format := range []string{"", "json", "console"} {
+		_, err := New(Config{Format: format})
+		assert.NoError(t, err, format)
+	}
+}
+
+func TestNew_UngueltigeWerte(t *testing.T) {
+	_, err := New(Config{Level: "laut"})
+	require.Error(t, err)
+
+	_, err = New(Config{Format: "xml"})
+	require.Error(t, err)
+}
@@ -0,0 +1,27 @@
+// Package metrics stellt die Prometheus-Metriken des Dienstes bereit. Sie
+// liegen in der Standard-Registry von client_golang, die neben den eigenen
+// Metriken auch die Go- und Prozesskennzahlen enthält.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler liefert die Metriken der Standard-Registry im Prometheus-Textformat.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// NewPersonsGauge legt das Gauge persons_total an und registriert es bei
+// reg, in main also prometheus.DefaultRegisterer.
+func NewPersonsGauge(reg prometheus.Registerer) prometheus.Gauge {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "persons_total",
+		Help: "Anzahl der nicht gelöschten Personen im Bestand.",
+	})
+	reg.MustRegister(g)
+	return g
+}
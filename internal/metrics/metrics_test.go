@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPersonsGauge(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	g := NewPersonsGauge(reg)
+	g.Set(3)
+	g.Add(-1)
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP persons_total Anzahl der nicht gelöschten Personen im Bestand.
+# TYPE persons_total gauge
+persons_total 2
+`), "persons_total")
+	require.NoError(t, err)
+
+	assert.Panics(t, func() { NewPersonsGauge(reg) }, "doppelte registrierung")
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"assecor-assessment-backend/internal/audit"
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// APIKeyHeader ist der Header, in dem der API-Schlüssel erwartet wird.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey gibt eine Middleware zurück, die einen gültigen Schlüssel im Header
+// X-API-Key verlangt. Leere Schlüssel werden ignoriert; bleibt keiner übrig,
+// ist die Middleware wirkungslos. Anfragen auf exemptPaths (exakte Pfade,
+// z. B. "/healthz") werden ohne Prüfung durchgelassen. Die Kennung eines
+// akzeptierten Schlüssels legt sie für das Audit-Log im Kontext ab (siehe
+// audit.KeyID).
+func APIKey(keys []string, exemptPaths ...string) func(http.Handler) http.Handler {
+	var hashes [][sha256.Size]byte
+	for _, k := range keys {
+		if k != "" {
+			hashes = append(hashes, sha256.Sum256([]byte(k)))
+		}
+	}
+	if len(hashes) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, p := range exemptPaths {
+		exempt[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(APIKeyHeader)
+			got := sha256.Sum256([]byte(key))
+			// Alle Schlüssel werden verglichen, damit die Laufzeit nicht verrät,
+			// der wievielte Schlüssel getroffen wurde.
+			match := 0
+			for _, want := range hashes {
+				match |= subtle.ConstantTimeCompare(got[:], want[:])
+			}
+			if key == "" || match != 1 {
+				writeError(w, r, http.StatusUnauthorized, i18n.CodeInvalidAPIKey)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(audit.WithKeyID(r.Context(), audit.KeyIDFor(key))))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"assecor-assessment-backend/internal/audit"
+)
+
+func TestAPIKey_OhneSchluesselWirkungslos(t *testing.T) {
+	h := APIKey([]string{"", ""})(statusHandler(http.StatusOK))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAPIKey(t *testing.T) {
+	h := APIKey([]string{"schluessel-a", "schluessel-b"}, "/healthz")(statusHandler(http.StatusOK))
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		wantStatus int
+	}{
+		{"ohne schlüssel", "/persons", "", http.StatusUnauthorized},
+		{"unbekannter schlüssel", "/persons", "schluessel-c", http.StatusUnauthorized},
+		{"erster schlüssel", "/persons", "schluessel-a", http.StatusOK},
+		{"zweiter schlüssel", "/persons", "schluessel-b", http.StatusOK},
+		{"ausgenommener pfad ohne schlüssel", "/healthz", "", http.StatusOK},
+		{"unterpfad nicht ausgenommen", "/healthz/x", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestAPIKey_LegtSchluesselkennungAb(t *testing.T) {
+	var got string
+	h := APIKey([]string{"schluessel-a"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = audit.KeyID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/persons", nil)
+	req.Header.Set(APIKeyHeader, "schluessel-a")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, audit.KeyIDFor("schluessel-a"), got)
+	assert.NotContains(t, got, "schluessel-a", "der schlüssel selbst darf nicht erscheinen")
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// BasicAuth gibt eine Middleware zurück, die HTTP-Basic-Authentifizierung
+// verlangt. Sind username und password leer, ist die Middleware wirkungslos.
+// Die Zugangsdaten werden vor dem Vergleich gehasht und in konstanter Zeit
+// verglichen, sodass weder Inhalt noch Länge über Laufzeitunterschiede
+// erkennbar sind.
+func BasicAuth(username, password string) func(http.Handler) http.Handler {
+	if username == "" && password == "" {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	wantUser := sha256.Sum256([]byte(username))
+	wantPass := sha256.Sum256([]byte(password))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			gotUser := sha256.Sum256([]byte(user))
+			gotPass := sha256.Sum256([]byte(pass))
+
+			userOK := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+			passOK := subtle.ConstantTimeCompare(gotPass[:], wantPass[:])
+			if !ok || userOK&passOK != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="persons", charset="UTF-8"`)
+				writeError(w, r, http.StatusUnauthorized, i18n.CodeAuthenticationRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Deny gibt eine Middleware zurück, die jede Anfrage mit 403 und code
+// ablehnt. Die Routen setzen sie statt BasicAuth ein, wo ohne konfigurierte
+// Zugangsdaten niemand berechtigt sein soll.
+func Deny(code i18n.Code) func(http.Handler) http.Handler {
+	return func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, http.StatusForbidden, code)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+func TestBasicAuth_OhneZugangsdatenWirkungslos(t *testing.T) {
+	h := BasicAuth("", "")(statusHandler(http.StatusCreated))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/persons", nil))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestBasicAuth(t *testing.T) {
+	h := BasicAuth("admin", "geheim")(statusHandler(http.StatusCreated))
+
+	tests := []struct {
+		name       string
+		user, pass string
+		setAuth    bool
+		wantStatus int
+	}{
+		{"ohne zugangsdaten", "", "", false, http.StatusUnauthorized},
+		{"falsches passwort", "admin", "falsch", true, http.StatusUnauthorized},
+		{"falscher benutzer", "root", "geheim", true, http.StatusUnauthorized},
+		{"präfix des passworts", "admin", "geh", true, http.StatusUnauthorized},
+		{"korrekte zugangsdaten", "admin", "geheim", true, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/persons", nil)
+			if tt.setAuth {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Basic")
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestDeny(t *testing.T) {
+	h := Deny(i18n.CodeNoCredentials)(statusHandler(http.StatusOK))
+
+	req := httptest.NewRequest(http.MethodGet, "/persons?includeDeleted=true", nil)
+	req.SetBasicAuth("admin", "geheim")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"code":"no_credentials"`)
+}
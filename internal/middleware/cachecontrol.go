@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CacheControl gibt eine Middleware zurück, die Cache-Control nach dem Status
+// der Antwort setzt, sobald der Handler die Header schreibt:
+//
+//   - Erfolgreiche GET- und HEAD-Antworten (2xx und 304) erhalten
+//     "public, max-age=N" mit maxAge in ganzen Sekunden. Bei Anfragen mit
+//     Authorization oder X-API-Key steht "private" statt "public", damit ein
+//     geteilter Cache sie nicht an Clients ohne Zugang ausliefert.
+//   - Alle übrigen Antworten, also Fehler und Änderungen, erhalten "no-store"
+//     und verlieren einen zuvor gesetzten Last-Modified-Header.
+//
+// maxAge < 0 schließt auch erfolgreiche Lesezugriffe per "no-store" aus. Ein
+// bereits gesetztes Cache-Control bleibt unverändert; so hat eine innere
+// Registrierung Vorrang vor einer äußeren wie NoStore.
+func CacheControl(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&cacheControlWriter{ResponseWriter: w, r: r, maxAge: maxAge}, r)
+		})
+	}
+}
+
+// NoStore gibt eine Middleware zurück, die jede Antwort mit "no-store"
+// versieht, sofern keine innere CacheControl-Middleware etwas anderes setzt.
+func NoStore() func(http.Handler) http.Handler {
+	return CacheControl(-1)
+}
+
+// cacheControlWriter setzt Cache-Control unmittelbar vor dem Senden der Header.
+type cacheControlWriter struct {
+	http.ResponseWriter
+	r      *http.Request
+	maxAge time.Duration
+	done   bool
+}
+
+func (cw *cacheControlWriter) WriteHeader(status int) {
+	// Informationsantworten (1xx) gehen den eigentlichen Headern voraus.
+	if status >= http.StatusOK {
+		cw.apply(status)
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheControlWriter) Write(p []byte) (int, error) {
+	cw.apply(http.StatusOK)
+	return cw.ResponseWriter.Write(p)
+}
+
+func (cw *cacheControlWriter) Flush() {
+	cw.apply(http.StatusOK)
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap erlaubt http.ResponseController den Zugriff auf den inneren Writer.
+func (cw *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// apply setzt den Header beim ersten Aufruf passend zu status.
+func (cw *cacheControlWriter) apply(status int) {
+	if cw.done {
+		return
+	}
+	cw.done = true
+	h := cw.ResponseWriter.Header()
+	if h.Get("Cache-Control") != "" {
+		return
+	}
+	if !cacheable(cw.r.Method, status) || cw.maxAge < 0 {
+		h.Set("Cache-Control", "no-store")
+		if status >= http.StatusBadRequest {
+			h.Del("Last-Modified")
+		}
+		return
+	}
+	scope := "public"
+	if cw.r.Header.Get("Authorization") != "" || cw.r.Header.Get(APIKeyHeader) != "" {
+		scope = "private"
+	}
+	h.Set("Cache-Control", scope+", max-age="+strconv.Itoa(int(cw.maxAge/time.Second)))
+}
+
+// cacheable meldet, ob eine Antwort mit status auf method gespeichert werden darf.
+func cacheable(method string, status int) bool {
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	return status < http.StatusMultipleChoices || status == http.StatusNotModified
+}
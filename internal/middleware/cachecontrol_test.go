@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl_NachMethodeUndStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		method string
+		header string
+		status int
+		want   string
+	}{
+		{"get erfolgreich", 30 * time.Second, http.MethodGet, "", http.StatusOK, "public, max-age=30"},
+		{"head erfolgreich", 30 * time.Second, http.MethodHead, "", http.StatusOK, "public, max-age=30"},
+		{"nicht geändert", 30 * time.Second, http.MethodGet, "", http.StatusNotModified, "public, max-age=30"},
+		{"max-age null", 0, http.MethodGet, "", http.StatusOK, "public, max-age=0"},
+		{"sekundenbruchteile abgeschnitten", 1500 * time.Millisecond, http.MethodGet, "", http.StatusOK, "public, max-age=1"},
+		{"mit api-schlüssel privat", 30 * time.Second, http.MethodGet, APIKeyHeader, http.StatusOK, "private, max-age=30"},
+		{"mit authorization privat", 30 * time.Second, http.MethodGet, "Authorization", http.StatusOK, "private, max-age=30"},
+		{"abgeschaltet", -1, http.MethodGet, "", http.StatusOK, "no-store"},
+		{"nicht gefunden", 30 * time.Second, http.MethodGet, "", http.StatusNotFound, "no-store"},
+		{"serverfehler", 30 * time.Second, http.MethodGet, "", http.StatusInternalServerError, "no-store"},
+		{"post", 30 * time.Second, http.MethodPost, "", http.StatusCreated, "no-store"},
+		{"delete", 30 * time.Second, http.MethodDelete, "", http.StatusNoContent, "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CacheControl(tt.maxAge)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Last-Modified", "Fri, 01 Mar 2024 12:00:00 GMT")
+				w.WriteHeader(tt.status)
+			}))
+			req := httptest.NewRequest(tt.method, "/persons", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, "geheim")
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.want, rec.Header().Get("Cache-Control"))
+			assert.Equal(t, tt.status < http.StatusBadRequest, rec.Header().Get("Last-Modified") != "",
+				"fehlerantworten ohne last-modified")
+		})
+	}
+}
+
+func TestCacheControl_InnereRegistrierungHatVorrang(t *testing.T) {
+	h := NoStore()(CacheControl(time.Minute)(bodyHandler(http.StatusOK, "application/json", "{}")))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+
+	assert.Equal(t, "public, max-age=60", rec.Header().Get("Cache-Control"))
+}
+
+func TestCacheControl_OhneWriteHeader(t *testing.T) {
+	h := CacheControl(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+
+	assert.Equal(t, "public, max-age=60", rec.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressibleTypes sind die Medientypen, deren Antworten komprimiert werden.
+var compressibleTypes = map[string]bool{
+	"application/json":     true,
+	"application/x-ndjson": true,
+	"text/csv":             true,
+}
+
+var gzipPool = sync.Pool{
+	New: func() any {
+		gz, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return gz
+	},
+}
+
+// Compress gibt eine Middleware zurück, die Antworten per gzip komprimiert,
+// sofern der Client dies über Accept-Encoding erlaubt, der Content-Type
+// komprimierbar ist und der Body mindestens minSize Bytes umfasst.
+// Antworten mit 204/304, HEAD-Anfragen und bereits kodierte Antworten bleiben
+// unverändert. Die Middleware muss innerhalb von Logging registriert werden,
+// damit dort die komprimierte Größe protokolliert wird.
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, minSize: minSize}
+			defer func() {
+				// Bei einer Panic werden noch gepufferte Daten verworfen, damit
+				// Recovery eine saubere Fehlerantwort schreiben kann.
+				if rec := recover(); rec != nil {
+					cw.buf = nil
+					panic(rec)
+				}
+				cw.finish()
+			}()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter puffert den Anfang der Antwort, bis feststeht, ob sich eine
+// Kompression lohnt, und schreibt danach entweder gzip oder unverändert.
+type compressWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	// Informationsantworten und Antworten ohne Body werden sofort gesendet.
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		_ = cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.decided {
+		if cw.gz != nil {
+			return cw.gz.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) >= cw.minSize {
+		if err := cw.decide(cw.eligible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush entscheidet spätestens jetzt über die Kompression und leert alle Puffer.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		_ = cw.decide(cw.eligible() && len(cw.buf) >= cw.minSize)
+	}
+	if cw.gz != nil {
+		_ = cw.gz.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap erlaubt http.ResponseController den Zugriff auf den inneren Writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// eligible prüft Status, Content-Type und eine eventuell vorhandene Kodierung.
+func (cw *compressWriter) eligible() bool {
+	if cw.ResponseWriter.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	return cw.eligibleType()
+}
+
+// decide legt fest, ob komprimiert wird, sendet die Header und schreibt den
+// bisher gepufferten Inhalt.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	h := cw.ResponseWriter.Header()
+	if cw.eligibleType() {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if compress {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		cw.gz = gzipPool.Get().(*gzip.Writer)
+		cw.gz.Reset(cw.ResponseWriter)
+	}
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.gz != nil {
+		_, err := cw.gz.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// eligibleType meldet, ob der Content-Type grundsätzlich komprimierbar ist;
+// nur dann variiert die Antwort mit Accept-Encoding.
+func (cw *compressWriter) eligibleType() bool {
+	mt, _, err := mime.ParseMediaType(cw.ResponseWriter.Header().Get("Content-Type"))
+	return err == nil && compressibleTypes[mt]
+}
+
+// finish schreibt verbleibende Pufferinhalte und schließt den gzip-Stream.
+func (cw *compressWriter) finish() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return
+		}
+		_ = cw.decide(false)
+	}
+	if cw.gz != nil {
+		_ = cw.gz.Close()
+		cw.gz.Reset(nil)
+		gzipPool.Put(cw.gz)
+		cw.gz = nil
+	}
+}
+
+// acceptsGzip wertet Accept-Encoding inklusive q-Werten aus.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func bodyHandler(status int, contentType, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	})
+}
+
+func gzipRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/persons", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	return req
+}
+
+func gunzip(t *testing.T, r io.Reader) string {
+	t.Helper()
+	gz, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	out, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestCompress_GrosseJSONAntwortWirdKomprimiert(t *testing.T) {
+	body := strings.Repeat(`{"name":"Hans"},`, 200)
+	h := Compress(1024)(bodyHandler(http.StatusOK, "application/json", body))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, gzipRequest())
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	assert.Less(t, rec.Body.Len(), len(body))
+	assert.Equal(t, body, gunzip(t, rec.Body))
+}
+
+func TestCompress_KeineKompression(t *testing.T) {
+	large := strings.Repeat("x", 4096)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		req     *http.Request
+	}{
+		{"unter mindestgröße", bodyHandler(http.StatusOK, "application/json", `{"id":1}`), gzipRequest()},
+		{"nicht komprimierbarer typ", bodyHandler(http.StatusOK, "image/png", large), gzipRequest()},
+		{"status 204", bodyHandler(http.StatusNoContent, "application/json", ""), gzipRequest()},
+		{"status 304", bodyHandler(http.StatusNotModified, "application/json", ""), gzipRequest()},
+		{"client ohne gzip", bodyHandler(http.StatusOK, "application/json", large), httptest.NewRequest(http.MethodGet, "/", nil)},
+		{"gzip mit q=0", bodyHandler(http.StatusOK, "application/json", large), func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", "gzip;q=0")
+			return r
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Compress(1024)(tt.handler).ServeHTTP(rec, tt.req)
+			assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		})
+	}
+}
+
+func TestCompress_StatusBleibtErhalten(t *testing.T) {
+	body := strings.Repeat("a", 2048)
+	h := Compress(1024)(bodyHandler(http.StatusCreated, "application/json; charset=utf-8", body))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, gzipRequest())
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, body, gunzip(t, rec.Body))
+}
+
+func TestCompress_LoggingProtokolliertKomprimierteGroesse(t *testing.T) {
+	body := strings.Repeat(`{"name":"Hans"},`, 500)
+	core, logs := observer.New(zap.InfoLevel)
+	h := Logging(zap.New(core), 1, nil, nil)(Compress(1024)(bodyHandler(http.StatusOK, "application/json", body)))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, gzipRequest())
+
+	entries := logs.FilterMessage("anfrage").All()
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, rec.Body.Len(), entries[0].ContextMap()["bytes"])
+	assert.Less(t, rec.Body.Len(), len(body))
+}
+
+func TestCompress_PanicVorDemSendenErlaubtSaubereFehlerantwort(t *testing.T) {
+	h := Recovery(zap.NewNop())(Compress(1024)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[{"id":1`)
+		panic("kaputt")
+	})))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, gzipRequest())
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "interner serverfehler")
+	assert.NotContains(t, rec.Body.String(), `[{"id":1`)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// ConcurrencyLimit gibt eine Middleware zurück, die höchstens max Anfragen
+// gleichzeitig in die Handler-Kette lässt. Weitere Anfragen warten bis zu
+// queueTimeout auf einen freien Platz und erhalten danach 503 mit Retry-After.
+// Bei queueTimeout <= 0 wird sofort abgelehnt, bei max <= 0 ist die Middleware
+// wirkungslos. Plätze werden auch bei einer Panic im Handler freigegeben.
+func ConcurrencyLimit(max int, queueTimeout time.Duration) func(http.Handler) http.Handler {
+	if max <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	slots := make(chan struct{}, max)
+	retryAfter := strconv.Itoa(int(math.Max(1, math.Ceil(queueTimeout.Seconds()))))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !acquire(r, slots, queueTimeout) {
+				w.Header().Set("Retry-After", retryAfter)
+				writeError(w, r, http.StatusServiceUnavailable, i18n.CodeServerBusy)
+				return
+			}
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire belegt einen Platz und wartet dafür höchstens timeout bzw. bis der
+// Client die Anfrage abbricht.
+func acquire(r *http.Request, slots chan struct{}, timeout time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
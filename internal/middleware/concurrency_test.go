@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// blockingHandler meldet jeden Eintritt über entered und blockiert, bis release geschlossen wird.
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serveAsync(h http.Handler) <-chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+		done <- rec
+	}()
+	return done
+}
+
+func TestConcurrencyLimit_OhneLimitWirkungslos(t *testing.T) {
+	h := ConcurrencyLimit(0, 0)(statusHandler(http.StatusOK))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestConcurrencyLimit_LehntUeberzaehligeSofortAb(t *testing.T) {
+	entered, release := make(chan struct{}, 2), make(chan struct{})
+	h := ConcurrencyLimit(2, 0)(blockingHandler(entered, release))
+
+	first, second := serveAsync(h), serveAsync(h)
+	<-entered
+	<-entered
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-second).Code)
+}
+
+func TestConcurrencyLimit_WartendeAnfrageRueckt(t *testing.T) {
+	entered, release := make(chan struct{}, 2), make(chan struct{})
+	h := ConcurrencyLimit(1, 5*time.Second)(blockingHandler(entered, release))
+
+	first := serveAsync(h)
+	<-entered
+	queued := serveAsync(h)
+
+	select {
+	case <-entered:
+		t.Fatal("zweite anfrage darf vor freigabe nicht eintreten")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-first).Code)
+	assert.Equal(t, http.StatusOK, (<-queued).Code)
+}
+
+func TestConcurrencyLimit_WarteschlangeLaeuftAb(t *testing.T) {
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	h := ConcurrencyLimit(1, 20*time.Millisecond)(blockingHandler(entered, release))
+
+	serveAsync(h)
+	<-entered
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestConcurrencyLimit_PanicGibtPlatzFrei(t *testing.T) {
+	calls := 0
+	inner := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("kaputt")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	h := Recovery(zap.NewNop())(ConcurrencyLimit(1, 0)(inner))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "platz muss nach panic wieder frei sein")
+}
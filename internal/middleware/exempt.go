@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Exempt liefert ein Prädikat für Anfragen, die Rate-Limit und Zugriffslog
+// auslassen, z. B. Liveness-Probes und Metrik-Abfragen.
+//
+// Verglichen wird das Routenmuster, das routes für die Anfrage findet (etwa
+// "/persons/{id}"), nicht der rohe Pfad; nur Pfade ohne Route werden direkt
+// verglichen. Einträge mit abschließendem "*" gelten als Präfix, "/admin/*"
+// nimmt also alle Admin-Endpunkte aus.
+func Exempt(routes chi.Routes, patterns ...string) func(*http.Request) bool {
+	exact := make(map[string]bool, len(patterns))
+	var prefixes []string
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			prefixes = append(prefixes, prefix)
+		} else {
+			exact[p] = true
+		}
+	}
+
+	return func(r *http.Request) bool {
+		pattern := routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+		if pattern == "" {
+			pattern = r.URL.Path
+		}
+		if exact[pattern] {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(pattern, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
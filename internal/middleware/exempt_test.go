@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExempt(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/healthz", func(http.ResponseWriter, *http.Request) {})
+	r.Route("/persons", func(r chi.Router) {
+		r.Get("/", func(http.ResponseWriter, *http.Request) {})
+		r.Get("/{id}", func(http.ResponseWriter, *http.Request) {})
+	})
+	r.Get("/admin/capacity", func(http.ResponseWriter, *http.Request) {})
+	exempt := Exempt(r, "/healthz", "/persons/{id}", "/admin/*", "/metrics")
+
+	tests := []struct {
+		method, target string
+		want           bool
+	}{
+		{http.MethodGet, "/healthz", true},
+		{http.MethodGet, "/persons/42", true},
+		{http.MethodGet, "/persons", false},
+		{http.MethodGet, "/admin/capacity", true},
+		{http.MethodGet, "/metrics", true},
+		{http.MethodGet, "/metrics/foo", false},
+		{http.MethodGet, "/{id}", false},
+	}
+	for _, tt := range tests {
+		got := exempt(httptest.NewRequest(tt.method, tt.target, nil))
+		assert.Equal(t, tt.want, got, tt.method+" "+tt.target)
+	}
+}
@@ -1,15 +1,31 @@
 package middleware
 
 import (
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 )
 
-// Logging gibt eine Middleware zurück, die jede Anfrage mit Methode, Path, Statuscode, Dauer und Request-ID
-func Logging(logger *zap.Logger) func(http.Handler) http.Handler {
+// combinedTimeLayout ist das Zeitformat des NCSA-Combined-Logformats.
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// Logging gibt eine Middleware zurück, die jede Anfrage mit Methode, Path, Statuscode, Dauer, Request-ID,
+// Client-IP und Antwortgröße protokolliert. Mit sampleRate > 1 wird nur jede n-te erfolgreiche Anfrage geloggt;
+// Antworten mit Status 4xx/5xx werden immer geloggt. Ist access gesetzt, wird statt des zap-Eintrags eine
+// Zeile im NCSA-Combined-Format (wie Apache/nginx) nach access geschrieben. Anfragen, für die quiet
+// (optional, siehe Exempt) true meldet, landen unabhängig davon nur als Debug-Eintrag im zap-Log.
+func Logging(logger *zap.Logger, sampleRate int, access io.Writer, quiet func(*http.Request) bool) func(http.Handler) http.Handler {
+	var seen atomic.Uint64
+	var mu sync.Mutex // serialisiert Zeilen nach access
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -17,13 +33,75 @@ func Logging(logger *zap.Logger) func(http.Handler) http.Handler {
 
 			next.ServeHTTP(ww, r)
 
-			logger.Info("anfrage",
+			level := zap.InfoLevel
+			if quiet != nil && quiet(r) {
+				level = zap.DebugLevel
+				if !logger.Core().Enabled(level) {
+					return
+				}
+			} else if ww.Status() < http.StatusBadRequest && sampleRate > 1 &&
+				(seen.Add(1)-1)%uint64(sampleRate) != 0 {
+				return
+			}
+
+			if access != nil && level == zap.InfoLevel {
+				line := combinedLine(r, start, ww.Status(), ww.BytesWritten())
+				mu.Lock()
+				_, _ = io.WriteString(access, line)
+				mu.Unlock()
+				return
+			}
+
+			logger.Log(level, "anfrage",
 				zap.String("request_id", chimw.GetReqID(r.Context())),
 				zap.String("methode", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.Int("status", ww.Status()),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.String("remote_ip", clientIP(r)),
 				zap.Duration("dauer", time.Since(start)),
 			)
 		})
 	}
 }
+
+// combinedLine formatiert eine Anfrage als Zeile im NCSA-Combined-Format:
+// ip - user [zeit] "METHODE pfad protokoll" status bytes "referer" "user-agent".
+// Fehlende Werte werden wie bei Apache als "-" geschrieben.
+func combinedLine(r *http.Request, start time.Time, status, bytes int) string {
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = escapeCombined(u)
+	}
+	size := "-"
+	if bytes > 0 {
+		size = fmt.Sprint(bytes)
+	}
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"\n",
+		clientIP(r), user, start.Format(combinedTimeLayout),
+		escapeCombined(r.Method), escapeCombined(r.URL.RequestURI()), escapeCombined(r.Proto),
+		status, size, orDash(escapeCombined(r.Referer())), orDash(escapeCombined(r.UserAgent())))
+}
+
+// combinedEscaper maskiert Anführungszeichen, Backslashes und Zeilenumbrüche,
+// damit Clients keine eigenen Felder oder Zeilen ins Log schreiben können.
+var combinedEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func escapeCombined(s string) string {
+	return combinedEscaper.Replace(s)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// clientIP gibt den Host-Anteil von r.RemoteAddr zurück.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func statusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	})
+}
+
+func serve(h http.Handler, n int) {
+	for i := 0; i < n; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/persons", nil))
+	}
+}
+
+func TestLogging_OhneSamplingWirdJedeAnfrageGeloggt(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	h := Logging(zap.New(core), 1, nil, nil)(statusHandler(http.StatusOK))
+
+	serve(h, 5)
+
+	assert.Equal(t, 5, logs.FilterMessage("anfrage").Len())
+}
+
+func TestLogging_SamplingFuer2xx(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	h := Logging(zap.New(core), 10, nil, nil)(statusHandler(http.StatusOK))
+
+	serve(h, 25)
+
+	// Anfragen 1, 11 und 21 werden geloggt.
+	assert.Equal(t, 3, logs.FilterMessage("anfrage").Len())
+}
+
+func TestLogging_FehlerWerdenImmerGeloggt(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		core, logs := observer.New(zap.InfoLevel)
+		h := Logging(zap.New(core), 100, nil, nil)(statusHandler(status))
+
+		serve(h, 7)
+
+		entries := logs.FilterMessage("anfrage").All()
+		assert.Len(t, entries, 7)
+		assert.EqualValues(t, status, entries[0].ContextMap()["status"])
+	}
+}
+
+func TestLogging_LevelFilter(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	h := Logging(zap.New(core), 1, nil, nil)(statusHandler(http.StatusOK))
+
+	serve(h, 3)
+
+	assert.Zero(t, logs.Len())
+}
+
+func TestLogging_ZaehltGeschriebeneBytes(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	h := Logging(zap.New(core), 1, nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hallo "))
+		_, _ = w.Write([]byte("welt"))
+	}))
+
+	serve(h, 1)
+
+	entries := logs.FilterMessage("anfrage").All()
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, 10, entries[0].ContextMap()["bytes"])
+	assert.EqualValues(t, http.StatusOK, entries[0].ContextMap()["status"])
+}
+
+// combinedPattern zerlegt eine Zeile im NCSA-Combined-Format.
+var combinedPattern = regexp.MustCompile(`^(\S+) - (\S+) \[([^\]]+)\] "(.*)" (\d{3}) (\S+) "(.*)" "(.*)"$`)
+
+func TestLogging_CombinedFormat(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	var out bytes.Buffer
+	h := Logging(zap.New(core), 1, &out, nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hallo"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/persons?color=blau", nil)
+	req.Header.Set("Referer", "https://example.org/liste")
+	req.Header.Set("User-Agent", `curl/8.0 "test"`)
+	req.SetBasicAuth("admin", "geheim")
+	before := time.Now().Truncate(time.Second)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Zero(t, logs.Len(), "kein zap-eintrag im combined-format")
+	line := out.String()
+	require.True(t, strings.HasSuffix(line, "\n"))
+	m := combinedPattern.FindStringSubmatch(strings.TrimSuffix(line, "\n"))
+	require.NotNil(t, m, line)
+	assert.Equal(t, "192.0.2.1", m[1])
+	assert.Equal(t, "admin", m[2])
+	ts, err := time.Parse(combinedTimeLayout, m[3])
+	require.NoError(t, err)
+	assert.False(t, ts.Before(before))
+	assert.Equal(t, "GET /persons?color=blau HTTP/1.1", m[4])
+	assert.Equal(t, "200", m[5])
+	assert.Equal(t, "5", m[6])
+	assert.Equal(t, "https://example.org/liste", m[7])
+	assert.Equal(t, `curl/8.0 \"test\"`, m[8])
+}
+
+func TestLogging_CombinedFormatOhneOptionaleFelder(t *testing.T) {
+	var out bytes.Buffer
+	h := Logging(zap.NewNop(), 1, &out, nil)(statusHandler(http.StatusNoContent))
+
+	req := httptest.NewRequest(http.MethodDelete, "/persons/1", nil)
+	req.Header.Del("User-Agent")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	m := combinedPattern.FindStringSubmatch(strings.TrimSuffix(out.String(), "\n"))
+	require.NotNil(t, m, out.String())
+	assert.Equal(t, []string{"-", "204", "-", "-", "-"}, []string{m[2], m[5], m[6], m[7], m[8]})
+}
+
+func TestLogging_CombinedFormatMitSampling(t *testing.T) {
+	var out bytes.Buffer
+	h := Logging(zap.NewNop(), 10, &out, nil)(statusHandler(http.StatusOK))
+
+	serve(h, 25)
+
+	assert.Equal(t, 3, strings.Count(out.String(), "\n"))
+}
+
+func TestLogging_AusgenommenePfadeNurAlsDebug(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	var out bytes.Buffer
+	quiet := func(r *http.Request) bool { return r.URL.Path == "/healthz" }
+	h := Logging(zap.New(core), 1, &out, quiet)(statusHandler(http.StatusOK))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/persons", nil))
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, zap.DebugLevel, logs.All()[0].Level)
+	assert.Equal(t, "/healthz", logs.All()[0].ContextMap()["path"])
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"), "nur /persons im zugriffslog")
+	assert.Contains(t, out.String(), "GET /persons ")
+}
@@ -2,27 +2,101 @@ package middleware
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
+
+	"assecor-assessment-backend/internal/i18n"
 )
 
 // RateLimit gibt eine Middleware zurück, die eingehende Anfragen auf
-func RateLimit(requestsPerSecond float64, logger *zap.Logger) func(http.Handler) http.Handler {
-	limiter := rate.NewLimiter(rate.Limit(requestsPerSecond), int(requestsPerSecond))
+// requestsPerSecond begrenzt. Alle Anfragen teilen sich einen Bucket; bei
+// requestsPerSecond <= 0 ist die Middleware wirkungslos. Anfragen, für die
+// exempt (optional, siehe Exempt) true meldet, umgehen die Grenze.
+func RateLimit(requestsPerSecond float64, logger *zap.Logger, exempt func(*http.Request) bool) func(http.Handler) http.Handler {
+	if requestsPerSecond <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	all := newBucket("", requestsPerSecond)
+	return rateLimit(func(*http.Request) *bucket { return all }, logger, exempt)
+}
 
+// RateLimitPerGroup begrenzt lesende (GET, HEAD, OPTIONS) und schreibende
+// Anfragen getrennt, damit viele günstige Lesezugriffe keine Schreibzugriffe
+// verdrängen und umgekehrt. Die 429-Antwort nennt den erschöpften Bucket.
+// Eine Rate <= 0 hebt die Grenze für ihre Gruppe auf; exempt wie bei RateLimit.
+func RateLimitPerGroup(read, write float64, logger *zap.Logger, exempt func(*http.Request) bool) func(http.Handler) http.Handler {
+	if read <= 0 && write <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	readBucket, writeBucket := newBucket("read", read), newBucket("write", write)
+	return rateLimit(func(r *http.Request) *bucket {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return readBucket
+		default:
+			return writeBucket
+		}
+	}, logger, exempt)
+}
+
+// bucket ist ein benannter Token-Bucket.
+type bucket struct {
+	name    string
+	limiter *rate.Limiter
+}
+
+// newBucket legt einen Bucket mit requestsPerSecond an. Der Burst ist die
+// aufgerundete Rate, mindestens aber 1: Bei gebrochenen Raten wie 0,5/s
+// ergäbe int(0.5) sonst einen Burst von 0, der jede Anfrage ablehnt.
+// requestsPerSecond <= 0 ergibt einen unbegrenzten Bucket.
+func newBucket(name string, requestsPerSecond float64) *bucket {
+	if requestsPerSecond <= 0 {
+		return &bucket{name: name, limiter: rate.NewLimiter(rate.Inf, 0)}
+	}
+	burst := max(1, int(math.Ceil(requestsPerSecond)))
+	return &bucket{name: name, limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), burst)}
+}
+
+// take entnimmt ein Token. Ist keines frei, bleibt der Bucket unverändert
+// und wait nennt die Zeit, bis wieder eines verfügbar ist.
+func (b *bucket) take() (wait time.Duration, ok bool) {
+	res := b.limiter.Reserve()
+	if !res.OK() {
+		return time.Second, false
+	}
+	if wait = res.Delay(); wait > 0 {
+		res.Cancel()
+		return wait, false
+	}
+	return 0, true
+}
+
+func rateLimit(pick func(*http.Request) *bucket, logger *zap.Logger, exempt func(*http.Request) bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !limiter.Allow() {
+			if exempt != nil && exempt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			b := pick(r)
+			if wait, ok := b.take(); !ok {
 				logger.Warn("rate-limit überschritten",
 					zap.String("remote", r.RemoteAddr),
+					zap.String("bucket", b.name),
 				)
+				body := errorBody(r, i18n.CodeTooManyRequests)
+				if b.name != "" {
+					body["bucket"] = b.name
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds())))))
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusTooManyRequests)
-				_ = json.NewEncoder(w).Encode(map[string]string{
-					"error": "zu viele anfragen",
-				})
+				_ = json.NewEncoder(w).Encode(body)
 				return
 			}
 			next.ServeHTTP(w, r)
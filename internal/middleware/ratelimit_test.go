@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewBucket_Burst(t *testing.T) {
+	tests := []struct {
+		rate      float64
+		wantBurst int
+	}{
+		{rate: 0.5, wantBurst: 1},
+		{rate: 1, wantBurst: 1},
+		{rate: 1.5, wantBurst: 2},
+		{rate: 100, wantBurst: 100},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.wantBurst, newBucket("", tt.rate).limiter.Burst(), "rate %v", tt.rate)
+	}
+}
+
+func TestRateLimit_ErsteAnfrageImmerZugelassen(t *testing.T) {
+	tests := []struct {
+		rate        float64
+		wantLimited bool
+	}{
+		{rate: 0, wantLimited: false},
+		{rate: -1, wantLimited: false},
+		{rate: 0.5, wantLimited: true},
+		{rate: 1, wantLimited: true},
+		{rate: 100, wantLimited: true},
+	}
+	for _, tt := range tests {
+		h := RateLimit(tt.rate, zap.NewNop(), nil)(statusHandler(http.StatusOK))
+
+		limited := false
+		for i := 0; i < 101; i++ {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+			if i == 0 {
+				require.Equal(t, http.StatusOK, rec.Code, "rate %v: erste anfrage", tt.rate)
+			}
+			limited = limited || rec.Code == http.StatusTooManyRequests
+		}
+		assert.Equal(t, tt.wantLimited, limited, "rate %v", tt.rate)
+	}
+}
+
+func TestRateLimitPerGroup_NullHebtGrenzeDerGruppeAuf(t *testing.T) {
+	h := RateLimitPerGroup(-1, 0.5, zap.NewNop(), nil)(statusHandler(http.StatusOK))
+	serve := func(method string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/persons", nil))
+		return rec.Code
+	}
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, http.StatusOK, serve(http.MethodGet))
+	}
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost))
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodPost))
+}
+
+func TestRateLimit_GebrocheneRateLaesstErsteAnfrageDurch(t *testing.T) {
+	h := RateLimit(0.5, zap.NewNop(), nil)(statusHandler(http.StatusOK))
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve().Code)
+	rec := serve()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.JSONEq(t, `{"error":"zu viele anfragen","code":"too_many_requests"}`, rec.Body.String())
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"), "nächstes token bei 0,5/s in zwei sekunden")
+}
+
+func TestRateLimit_MeldungNachAcceptLanguage(t *testing.T) {
+	h := RateLimit(0.5, zap.NewNop(), nil)(statusHandler(http.StatusOK))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/persons", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/persons", nil)
+	req.Header.Set("Accept-Language", "en-GB,en;q=0.9")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.JSONEq(t, `{"error":"too many requests","code":"too_many_requests"}`, rec.Body.String())
+}
+
+func TestRateLimitPerGroup_LesenTrotzErschoepftemSchreibBucket(t *testing.T) {
+	h := RateLimitPerGroup(1000, 0.5, zap.NewNop(), nil)(statusHandler(http.StatusOK))
+	serve := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/persons", nil))
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, serve(http.MethodPost).Code)
+	rec := serve(http.MethodDelete)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "write", body["bucket"])
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		assert.Equal(t, http.StatusOK, serve(method).Code, method)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodPut).Code)
+}
+
+func TestRateLimitPerGroup_MeldetLeseBucket(t *testing.T) {
+	h := RateLimitPerGroup(0.5, 1000, zap.NewNop(), nil)(statusHandler(http.StatusOK))
+	serve := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/persons", nil))
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, serve(http.MethodGet).Code)
+	rec := serve(http.MethodGet)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"bucket":"read"`)
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost).Code)
+}
+
+func TestRateLimit_AbgelehnteAnfrageVerbrauchtKeinToken(t *testing.T) {
+	h := RateLimit(10, zap.NewNop(), nil)(statusHandler(http.StatusOK))
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+		return rec
+	}
+	for i := 0; i < 10; i++ {
+		require.Equal(t, http.StatusOK, serve().Code)
+	}
+
+	for i := 0; i < 50; i++ {
+		rec := serve()
+		if rec.Code == http.StatusOK {
+			continue
+		}
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"), "aufgerundet auf ganze sekunden")
+	}
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, serve().Code, "abgelehnte anfragen schieben das nächste token nicht hinaus")
+}
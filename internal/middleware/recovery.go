@@ -5,27 +5,52 @@ import (
 	"net/http"
 	"runtime/debug"
 
+	chimw "github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/i18n"
 )
 
-// Recovery gibt eine Middleware zurück, die Panics abfängt
+// Recovery gibt eine Middleware zurück, die Panics abfängt.
+// Wurde noch nichts geschrieben, folgt eine saubere 500-JSON-Antwort. Hat der
+// Handler Header oder Teile des Bodys bereits gesendet, wird die Verbindung
+// über http.ErrAbortHandler abgebrochen, statt die Teilantwort mit einem
+// Fehlerobjekt zu vermischen. http.ErrAbortHandler selbst wird unverändert
+// weitergereicht, wie es net/http erwartet.
 func Recovery(logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
 			defer func() {
-				if rec := recover(); rec != nil {
-					logger.Error("panic abgefangen",
-						zap.Any("fehler", rec),
-						zap.ByteString("stack", debug.Stack()),
-					)
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusInternalServerError)
-					_ = json.NewEncoder(w).Encode(map[string]string{
-						"error": "interner serverfehler",
-					})
+				rec := recover()
+				if rec == nil {
+					return
 				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				reqID := chimw.GetReqID(r.Context())
+				written := ww.Status() != 0 || ww.BytesWritten() > 0
+				logger.Error("panic abgefangen",
+					zap.String("request_id", reqID),
+					zap.Any("fehler", rec),
+					zap.Bool("antwort_begonnen", written),
+					zap.ByteString("stack", debug.Stack()),
+				)
+
+				if written {
+					panic(http.ErrAbortHandler)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				body := errorBody(r, i18n.CodeInternal)
+				body["request_id"] = reqID
+				_ = json.NewEncoder(w).Encode(body)
 			}()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(ww, r)
 		})
 	}
 }
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecovery_PanicVorDemSchreiben(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	h := Recovery(zap.New(core))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("kaputt")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "interner serverfehler", body["error"])
+	assert.Equal(t, 1, logs.FilterMessage("panic abgefangen").Len())
+}
+
+func TestRecovery_PanicNachDemSchreibenBrichtAb(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	h := Recovery(zap.New(core))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, `[{"id":1`)
+		panic("kaputt")
+	}))
+
+	rec := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `[{"id":1`, rec.Body.String(), "kein fehlerobjekt an teilantwort angehängt")
+	assert.Equal(t, 1, logs.FilterMessage("panic abgefangen").Len())
+}
+
+func TestRecovery_ErrAbortHandlerWirdWeitergereicht(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	h := Recovery(zap.New(core))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Zero(t, logs.Len())
+}
+
+func TestRecovery_ServerBrichtVerbindungAb(t *testing.T) {
+	h := Recovery(zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "teil")
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		panic("kaputt")
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	assert.Error(t, err, "abgebrochene verbindung statt vollständiger antwort erwartet")
+}
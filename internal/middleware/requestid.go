@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader gibt die von chimw.RequestID vergebene Request-ID im
+// Antwort-Header X-Request-Id zurück, damit Clients sie bei Fehlermeldungen
+// angeben können. Muss nach chimw.RequestID registriert werden.
+func RequestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := chimw.GetReqID(r.Context()); id != "" {
+			w.Header().Set(chimw.RequestIDHeader, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
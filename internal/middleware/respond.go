@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"assecor-assessment-backend/internal/i18n"
+)
+
+// writeError schreibt eine JSON-Fehlerantwort im selben Format wie die
+// Handler, inklusive Fehlercode und Request-ID.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code i18n.Code) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorBody(r, code))
+}
+
+// errorBody ist der Body einer Fehlerantwort zu code, mit der Meldung in der
+// Sprache aus dem Accept-Language-Header von r.
+func errorBody(r *http.Request, code i18n.Code) map[string]string {
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	body := map[string]string{"error": i18n.Message(lang, code), "code": string(code)}
+	if id := chimw.GetReqID(r.Context()); id != "" {
+		body["request_id"] = id
+	}
+	return body
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import "net/http"
+
+// SecureHeaders setzt auf jeder Antwort Header, die Browser-Angriffe wie
+// MIME-Sniffing und Clickjacking erschweren. Die Header werden vor dem
+// nachfolgenden Handler gesetzt und gelten damit auch für Fehlerantworten.
+func SecureHeaders() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "no-referrer")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
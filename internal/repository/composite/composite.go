@@ -0,0 +1,484 @@
+// Package composite stellt ein PersonRepository bereit, das eine
+// beschreibbare Schicht (etwa SQLite) über einen unveränderlichen
+// Grundbestand (etwa die CSV) legt.
+//
+// Lesezugriffe sehen beide Schichten zusammengeführt: Trägt die
+// Schreibschicht eine Person mit derselben ID wie der Grundbestand, verdeckt
+// sie diese. Schreibzugriffe gehen nur an die Schreibschicht. Ändert oder
+// löscht ein Aufruf eine Person des Grundbestands, wird sie zuvor dorthin
+// kopiert (copy-on-write); eine gelöschte Kopie verdeckt die Person des
+// Grundbestands als Grabstein. Neue Personen erhalten IDs oberhalb aller IDs
+// beider Schichten.
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository"
+	"assecor-assessment-backend/internal/repository/memory"
+)
+
+// PersonRepository führt Grundbestand und Schreibschicht zusammen und
+// implementiert repository.PersonRepository. Die Kapazitätsgrenze gilt für
+// den zusammengeführten Bestand; die Schreibschicht selbst sollte unbegrenzt
+// sein.
+//
+// Nebenläufigkeit: Schreibzugriffe sind unter mu serialisiert, weil ein
+// copy-on-write mehrere Zugriffe auf die Schreibschicht umfasst. Lesezugriffe
+// brauchen keine Sperre: Der Grundbestand ändert sich nie, und eine Kopie in
+// der Schreibschicht verdeckt ihr Original unabhängig davon, wann sie entsteht.
+type PersonRepository struct {
+	mu         sync.Mutex
+	seed       repository.PersonRepository
+	overlay    repository.PersonRepository
+	maxPersons int
+	seedMaxID  int
+	nextID     int
+}
+
+// New legt das Repository an. seed wird nur gelesen.
+func New(seed, overlay repository.PersonRepository, maxPersons int) (*PersonRepository, error) {
+	ctx := context.Background()
+	seeded, err := seed.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("composite-repository: grundbestand lesen: %w", err)
+	}
+	written, err := overlay.Find(ctx, domain.PersonFilter{IncludeDeleted: true})
+	if err != nil {
+		return nil, fmt.Errorf("composite-repository: schreibschicht lesen: %w", err)
+	}
+	r := &PersonRepository{seed: seed, overlay: overlay, maxPersons: maxPersons}
+	// Beide Ergebnisse sind nach ID sortiert.
+	if len(seeded) > 0 {
+		r.seedMaxID = seeded[len(seeded)-1].ID
+	}
+	r.nextID = r.seedMaxID + 1
+	if len(written) > 0 {
+		r.nextID = max(r.nextID, written[len(written)-1].ID+1)
+	}
+	return r, nil
+}
+
+// LoadReport liefert den Ladebericht des Grundbestands, sofern er einen hat.
+func (r *PersonRepository) LoadReport() domain.LoadReport {
+	if reporter, ok := r.seed.(interface{ LoadReport() domain.LoadReport }); ok {
+		return reporter.LoadReport()
+	}
+	return domain.LoadReport{Skipped: []domain.SkippedRecord{}}
+}
+
+// merged liefert alle Personen beider Schichten einschließlich gelöschter
+// nach ID sortiert; bei gleicher ID gilt die Schreibschicht.
+func (r *PersonRepository) merged(ctx context.Context) ([]domain.Person, error) {
+	seeded, err := r.seed.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("composite-repository: grundbestand lesen: %w", err)
+	}
+	written, err := r.overlay.Find(ctx, domain.PersonFilter{IncludeDeleted: true})
+	if err != nil {
+		return nil, fmt.Errorf("composite-repository: schreibschicht lesen: %w", err)
+	}
+
+	out := make([]domain.Person, 0, len(seeded)+len(written))
+	i, j := 0, 0
+	for i < len(seeded) && j < len(written) {
+		switch s, w := seeded[i], written[j]; {
+		case s.ID < w.ID:
+			out = append(out, s)
+			i++
+		case s.ID > w.ID:
+			out = append(out, w)
+			j++
+		default:
+			out = append(out, w)
+			i++
+			j++
+		}
+	}
+	out = append(out, seeded[i:]...)
+	return append(out, written[j:]...), nil
+}
+
+// view liefert den zusammengeführten Bestand als memory-Repository, an das
+// die Lesezugriffe ihre Filter, Sortierung und Paginierung abgeben.
+func (r *PersonRepository) view(ctx context.Context) (*memory.PersonRepository, error) {
+	persons, err := r.merged(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewPersonRepository(r.maxPersons, persons...), nil
+}
+
+// GetAll gibt alle nicht gelöschten Personen beider Schichten zurück.
+func (r *PersonRepository) GetAll(ctx context.Context) ([]domain.Person, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.GetAll(ctx)
+}
+
+// GetAllStream ruft fn für jede Person des beim Aufruf aktuellen Stands auf.
+func (r *PersonRepository) GetAllStream(ctx context.Context, fn func(domain.Person) error) error {
+	v, err := r.view(ctx)
+	if err != nil {
+		return err
+	}
+	return v.GetAllStream(ctx, fn)
+}
+
+// GetAllAfter liefert höchstens limit Personen mit einer ID größer als afterID.
+func (r *PersonRepository) GetAllAfter(ctx context.Context, afterID, limit int) ([]domain.Person, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.GetAllAfter(ctx, afterID, limit)
+}
+
+// GetByID fragt zuerst die Schreibschicht. Nur wenn sie die ID nicht kennt,
+// auch nicht als gelöschte Person, gilt der Grundbestand.
+func (r *PersonRepository) GetByID(ctx context.Context, id int) (domain.Person, error) {
+	p, err := r.overlay.GetByID(ctx, id)
+	if !unknown(err) {
+		return p, err
+	}
+	return r.seed.GetByID(ctx, id)
+}
+
+// unknown meldet, ob err bedeutet, dass die Schreibschicht die ID gar nicht
+// kennt. domain.ErrGone umschließt domain.ErrNotFound, heißt hier aber, dass
+// ein Grabstein das Original verdeckt.
+func unknown(err error) bool {
+	return errors.Is(err, domain.ErrNotFound) && !errors.Is(err, domain.ErrGone)
+}
+
+// GetByIDs liefert die nicht gelöschten Personen zu ids.
+func (r *PersonRepository) GetByIDs(ctx context.Context, ids []int) ([]domain.Person, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.GetByIDs(ctx, ids)
+}
+
+// GetByColor berücksichtigt, dass eine Kopie in der Schreibschicht eine
+// andere Farbe tragen kann als ihr Original.
+func (r *PersonRepository) GetByColor(ctx context.Context, color domain.Color, order domain.Order, limit, offset int) ([]domain.Person, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.GetByColor(ctx, color, order, limit, offset)
+}
+
+// GetByColors liefert die Treffer nach Farbe und ID sortiert.
+func (r *PersonRepository) GetByColors(ctx context.Context, colors []domain.Color, limit, offset int) ([]domain.Person, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.GetByColors(ctx, colors, limit, offset)
+}
+
+// GetByZipcode vergleicht die Postleitzahlen exakt oder als Präfix.
+func (r *PersonRepository) GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) ([]domain.Person, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.GetByZipcode(ctx, zip, prefix, limit, offset)
+}
+
+// Find filtert den zusammengeführten Bestand und paginiert ihn.
+func (r *PersonRepository) Find(ctx context.Context, filter domain.PersonFilter) ([]domain.Person, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.Find(ctx, filter)
+}
+
+// Count zählt die Treffer von filter.
+func (r *PersonRepository) Count(ctx context.Context, filter domain.PersonFilter) (int, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return v.Count(ctx, filter)
+}
+
+// CountsByColor zählt die Personen je Farbe.
+func (r *PersonRepository) CountsByColor(ctx context.Context) (map[domain.Color]int, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.CountsByColor(ctx)
+}
+
+// Stats zählt Farben und Städte im selben Stand.
+func (r *PersonRepository) Stats(ctx context.Context) (domain.PersonStats, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return domain.PersonStats{}, err
+	}
+	return v.Stats(ctx)
+}
+
+// DistinctCities liefert die Städte beider Schichten ohne Duplikate.
+func (r *PersonRepository) DistinctCities(ctx context.Context) ([]string, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.DistinctCities(ctx)
+}
+
+// Capacity zählt die nicht gelöschten Personen beider Schichten.
+func (r *PersonRepository) Capacity(ctx context.Context) (int, int, error) {
+	v, err := r.view(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return v.Capacity(ctx)
+}
+
+// LastModified liefert die jüngere der beiden letzten Änderungen.
+func (r *PersonRepository) LastModified(ctx context.Context) (time.Time, error) {
+	seeded, err := r.seed.LastModified(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	written, err := r.overlay.LastModified(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if written.After(seeded) {
+		return written, nil
+	}
+	return seeded, nil
+}
+
+// checkCapacity prüft die Grenze gegen den zusammengeführten Bestand. Der
+// Aufrufer hält mu.
+func (r *PersonRepository) checkCapacity(ctx context.Context) error {
+	if r.maxPersons <= 0 {
+		return nil
+	}
+	used, _, err := r.Capacity(ctx)
+	if err != nil {
+		return err
+	}
+	if used >= r.maxPersons {
+		return fmt.Errorf("max %d personen: %w", r.maxPersons, domain.ErrCapacityReached)
+	}
+	return nil
+}
+
+// shadow kopiert die Person id aus dem Grundbestand in die Schreibschicht,
+// sofern diese die ID noch nicht kennt. Kennt keine Schicht die ID, ergibt
+// das domain.ErrNotFound. Der Aufrufer hält mu.
+func (r *PersonRepository) shadow(ctx context.Context, id int) error {
+	_, err := r.overlay.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrGone) {
+		return nil
+	}
+	if !unknown(err) {
+		return err
+	}
+	p, err := r.seed.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := r.overlay.Insert(ctx, p); err != nil {
+		return fmt.Errorf("composite-repository: person %d kopieren: %w", id, err)
+	}
+	return nil
+}
+
+// Add vergibt die nächste ID oberhalb beider Schichten.
+func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (domain.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkCapacity(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	for {
+		person.ID = r.nextID
+		r.nextID++
+		added, err := r.overlay.Insert(ctx, person)
+		var conflict *domain.VersionConflictError
+		if errors.As(err, &conflict) {
+			// Die ID wurde an nextID vorbei vergeben, etwa von einem früheren
+			// Prozess mit derselben Datenbank; die nächste versuchen.
+			continue
+		}
+		return added, err
+	}
+}
+
+// Insert legt die Person unter ihrer ID an. Eine ID des Grundbestands gilt
+// als belegt, auch wenn ihre Person gelöscht ist.
+func (r *PersonRepository) Insert(ctx context.Context, person domain.Person) (domain.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Belegt die Schreibschicht die ID, meldet ihr Insert den Konflikt.
+	if _, err := r.overlay.GetByID(ctx, person.ID); unknown(err) {
+		seeded, err := r.seed.GetByID(ctx, person.ID)
+		if err == nil {
+			return domain.Person{}, &domain.VersionConflictError{ID: person.ID, Current: seeded.Version}
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return domain.Person{}, err
+		}
+	}
+	if err := r.checkCapacity(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	inserted, err := r.overlay.Insert(ctx, person)
+	if err != nil {
+		return domain.Person{}, err
+	}
+	r.nextID = max(r.nextID, inserted.ID+1)
+	return inserted, nil
+}
+
+// Update kopiert eine Person des Grundbestands vor dem Ändern in die
+// Schreibschicht. Die Kopie beginnt mit Version 1 wie jede frisch geladene
+// Person, sodass expectedVersion zur zuvor gelesenen Version passt.
+func (r *PersonRepository) Update(ctx context.Context, person domain.Person, expectedVersion int) (domain.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.shadow(ctx, person.ID); err != nil {
+		return domain.Person{}, err
+	}
+	return r.overlay.Update(ctx, person, expectedVersion)
+}
+
+// Delete löscht die Person in der Schreibschicht; für eine Person des
+// Grundbestands bleibt die gelöschte Kopie als Grabstein.
+func (r *PersonRepository) Delete(ctx context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.shadow(ctx, id); err != nil {
+		return err
+	}
+	return r.overlay.Delete(ctx, id, at)
+}
+
+// DeleteByColor kopiert die passenden Personen des Grundbestands und löscht
+// dann alle Treffer der Schreibschicht. Eine Kopie, deren Farbe geändert
+// wurde, bleibt dabei unberührt.
+func (r *PersonRepository) DeleteByColor(ctx context.Context, color domain.Color, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seeded, err := r.seed.GetByColor(ctx, color, domain.Order{}, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range seeded {
+		if err := r.shadow(ctx, p.ID); err != nil {
+			return 0, err
+		}
+	}
+	return r.overlay.DeleteByColor(ctx, color, at)
+}
+
+// Restore hebt die Löschung in der Schreibschicht auf, sofern die
+// Kapazitätsgrenze des zusammengeführten Bestands es zulässt.
+func (r *PersonRepository) Restore(ctx context.Context, id int) (domain.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.overlay.GetByID(ctx, id); !errors.Is(err, domain.ErrGone) {
+		return domain.Person{}, fmt.Errorf("gelöschte person mit id %d: %w", id, domain.ErrNotFound)
+	}
+	if err := r.checkCapacity(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	return r.overlay.Restore(ctx, id)
+}
+
+// Purge entfernt gelöschte Personen endgültig aus der Schreibschicht.
+// Grabsteine von Personen des Grundbestands werden danach neu angelegt, da
+// sonst das Original wieder sichtbar würde; sie zählen nicht mit. Bis dahin
+// können Leser das Original kurz sehen.
+func (r *PersonRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	written, err := r.overlay.Find(ctx, domain.PersonFilter{IncludeDeleted: true})
+	if err != nil {
+		return 0, err
+	}
+	var tombstones []domain.Person
+	for _, p := range written {
+		if p.Deleted() && p.DeletedAt.Before(before) && p.ID <= r.seedMaxID {
+			if _, err := r.seed.GetByID(ctx, p.ID); err == nil {
+				tombstones = append(tombstones, p)
+			}
+		}
+	}
+	n, err := r.overlay.Purge(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.bury(ctx, tombstones); err != nil {
+		return 0, err
+	}
+	return n - len(tombstones), nil
+}
+
+// DeleteAll leert die Schreibschicht und legt für jede Person des
+// Grundbestands einen Grabstein an. Neue IDs beginnen wieder oberhalb des
+// Grundbestands.
+func (r *PersonRepository) DeleteAll(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seeded, err := r.seed.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.overlay.DeleteAll(ctx); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	tombstones := make([]domain.Person, len(seeded))
+	for i, p := range seeded {
+		p.DeletedAt = now
+		tombstones[i] = p
+	}
+	if err := r.bury(ctx, tombstones); err != nil {
+		return err
+	}
+	r.nextID = r.seedMaxID + 1
+	return nil
+}
+
+// bury legt für jede Person einen Grabstein zu ihrem DeletedAt an. Der
+// Aufrufer hält mu.
+func (r *PersonRepository) bury(ctx context.Context, tombstones []domain.Person) error {
+	for _, p := range tombstones {
+		alive := p
+		alive.DeletedAt = time.Time{}
+		if _, err := r.overlay.Insert(ctx, alive); err != nil {
+			return fmt.Errorf("composite-repository: grabstein %d anlegen: %w", p.ID, err)
+		}
+		if err := r.overlay.Delete(ctx, p.ID, p.DeletedAt); err != nil {
+			return fmt.Errorf("composite-repository: grabstein %d anlegen: %w", p.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package composite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository"
+	"assecor-assessment-backend/internal/repository/memory"
+	sqliterepo "assecor-assessment-backend/internal/repository/sqlite"
+)
+
+var geladen = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// grundbestand liefert Personen wie nach dem Laden einer CSV.
+func grundbestand() []domain.Person {
+	persons := []domain.Person{
+		{ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"},
+		{ID: 2, Name: "Peter", Lastname: "Petersen", Zipcode: "18439", City: "Stralsund", Color: "grün"},
+		{ID: 4, Name: "Johnny", Lastname: "Johnson", Zipcode: "88888", City: "made up", Color: "blau"},
+	}
+	for i := range persons {
+		persons[i].CreatedAt, persons[i].UpdatedAt, persons[i].Version = geladen, geladen, 1
+	}
+	return persons
+}
+
+func neuesRepo(t *testing.T, maxPersons int, overlay repository.PersonRepository) (*PersonRepository, repository.PersonRepository) {
+	t.Helper()
+	seed := memory.NewPersonRepository(0, grundbestand()...)
+	if overlay == nil {
+		overlay = memory.NewPersonRepository(0)
+	}
+	r, err := New(seed, overlay, maxPersons)
+	require.NoError(t, err)
+	return r, seed
+}
+
+func ids(persons []domain.Person) []int {
+	out := make([]int, 0, len(persons))
+	for _, p := range persons {
+		out = append(out, p.ID)
+	}
+	return out
+}
+
+// ─── Lesen ────────────────────────────────────────────────────────────────────
+
+func TestGetAll_SchreibschichtVerdecktGrundbestand(t *testing.T) {
+	ctx := context.Background()
+	overlay := memory.NewPersonRepository(0,
+		domain.Person{ID: 2, Name: "Paul", Lastname: "Petersen", Color: "rot", Version: 3},
+		domain.Person{ID: 7, Name: "Neu", Color: "gelb", Version: 1},
+	)
+	r, _ := neuesRepo(t, 0, overlay)
+
+	all, err := r.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 4, 7}, ids(all))
+	assert.Equal(t, "Paul", all[1].Name)
+
+	p, err := r.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, p.Version)
+	p, err = r.GetByID(ctx, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "Johnny", p.Name)
+	_, err = r.GetByID(ctx, 3)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	again, err := r.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, all, again, "reihenfolge stabil")
+}
+
+func TestFind_PaginiertUeberBeideSchichten(t *testing.T) {
+	ctx := context.Background()
+	r, _ := neuesRepo(t, 0, nil)
+	_, err := r.Add(ctx, domain.Person{Name: "A", Color: "blau", CreatedAt: geladen.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = r.Add(ctx, domain.Person{Name: "B", Color: "rot", CreatedAt: geladen.Add(time.Hour)})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter domain.PersonFilter
+		want   []int
+	}{
+		{"erste seite", domain.PersonFilter{Limit: 2}, []int{1, 2}},
+		{"über die grenze", domain.PersonFilter{Limit: 2, Offset: 2}, []int{4, 5}},
+		{"letzte seite", domain.PersonFilter{Limit: 2, Offset: 4}, []int{6}},
+		{"farbe", domain.PersonFilter{Color: "blau"}, []int{1, 4, 5}},
+		{"nach dem laden angelegt", domain.PersonFilter{CreatedAfter: geladen}, []int{5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Find(ctx, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(got))
+		})
+	}
+
+	count, err := r.Count(ctx, domain.PersonFilter{Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	after, err := r.GetAllAfter(ctx, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5}, ids(after))
+}
+
+func TestGetByColor_UeberBeideSchichten(t *testing.T) {
+	ctx := context.Background()
+	r, _ := neuesRepo(t, 0, nil)
+
+	hans, err := r.GetByID(ctx, 1)
+	require.NoError(t, err)
+	hans.Color = "rot"
+	_, err = r.Update(ctx, hans, 1)
+	require.NoError(t, err)
+	_, err = r.Add(ctx, domain.Person{Name: "Neu", Color: "blau"})
+	require.NoError(t, err)
+
+	blau, err := r.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5}, ids(blau), "die kopie mit neuer farbe verdeckt das original")
+	rot, err := r.GetByColor(ctx, "rot", domain.Order{}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids(rot))
+
+	both, err := r.GetByColors(ctx, []domain.Color{"rot", "blau"}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 1}, ids(both))
+
+	counts, err := r.CountsByColor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[domain.Color]int{"blau": 2, "grün": 1, "rot": 1}, counts)
+
+	n, err := r.DeleteByColor(ctx, "blau", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	all, err := r.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids(all))
+}
+
+// ─── Schreiben ────────────────────────────────────────────────────────────────
+
+func TestAdd_IDsOberhalbDesGrundbestands(t *testing.T) {
+	ctx := context.Background()
+
+	r, _ := neuesRepo(t, 0, nil)
+	p, err := r.Add(ctx, domain.Person{Name: "Neu", Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.ID)
+	assert.Equal(t, 1, p.Version)
+
+	// Eine Schreibschicht mit höheren IDs, etwa aus einem früheren Lauf.
+	r, _ = neuesRepo(t, 0, memory.NewPersonRepository(0, domain.Person{ID: 10, Name: "Alt", Color: "rot", Version: 1}))
+	p, err = r.Add(ctx, domain.Person{Name: "Neu", Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, 11, p.ID)
+}
+
+func TestInsert_IDKollision(t *testing.T) {
+	ctx := context.Background()
+	r, _ := neuesRepo(t, 0, nil)
+
+	_, err := r.Insert(ctx, domain.Person{ID: 2, Name: "Doppelt", Color: "rot"})
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 2, conflict.ID)
+
+	// Lücken im Grundbestand sind frei.
+	p, err := r.Insert(ctx, domain.Person{ID: 3, Name: "Lücke", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, p.ID)
+	_, err = r.Insert(ctx, domain.Person{ID: 3, Name: "Nochmal", Color: "rot"})
+	require.ErrorAs(t, err, &conflict)
+
+	_, err = r.Insert(ctx, domain.Person{ID: 9, Name: "Weit", Color: "rot"})
+	require.NoError(t, err)
+	p, err = r.Add(ctx, domain.Person{Name: "Danach", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 10, p.ID)
+
+	// Auch eine gelöschte Person des Grundbestands belegt ihre ID.
+	require.NoError(t, r.Delete(ctx, 1, time.Now()))
+	_, err = r.Insert(ctx, domain.Person{ID: 1, Name: "Ersatz", Color: "rot"})
+	require.ErrorAs(t, err, &conflict)
+}
+
+func TestUpdate_KopiertGrundbestand(t *testing.T) {
+	ctx := context.Background()
+	r, seed := neuesRepo(t, 0, nil)
+
+	peter := grundbestand()[1]
+	peter.City = "Rostock"
+	_, err := r.Update(ctx, peter, 2)
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 1, conflict.Current)
+
+	updated, err := r.Update(ctx, peter, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, geladen, updated.CreatedAt)
+
+	original, err := seed.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Stralsund", original.City, "grundbestand bleibt unverändert")
+	got, err := r.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Rostock", got.City)
+
+	_, err = r.Update(ctx, domain.Person{ID: 99, Name: "X"}, 0)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestDelete_GrabsteinUeberstehtPurge(t *testing.T) {
+	ctx := context.Background()
+	r, _ := neuesRepo(t, 0, nil)
+	deletedAt := time.Now().Add(-time.Hour)
+
+	require.NoError(t, r.Delete(ctx, 1, deletedAt))
+	assert.ErrorIs(t, r.Delete(ctx, 1, deletedAt), domain.ErrNotFound)
+	_, err := r.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrGone)
+
+	restored, err := r.Restore(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Hans", restored.Name)
+	_, err = r.Restore(ctx, 2)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	added, err := r.Add(ctx, domain.Person{Name: "Neu", Color: "blau"})
+	require.NoError(t, err)
+	require.NoError(t, r.Delete(ctx, 1, deletedAt))
+	require.NoError(t, r.Delete(ctx, added.ID, deletedAt))
+
+	n, err := r.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "nur die neue person ist endgültig entfernt")
+	_, err = r.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrGone, "das original taucht nicht wieder auf")
+	_, err = r.GetByID(ctx, added.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	all, err := r.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, ids(all))
+}
+
+func TestCapacity_ZaehltZusammengefuehrtenBestand(t *testing.T) {
+	ctx := context.Background()
+	r, _ := neuesRepo(t, 4, nil)
+
+	_, err := r.Add(ctx, domain.Person{Name: "Vierte", Color: "blau"})
+	require.NoError(t, err)
+	used, limit, err := r.Capacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, used)
+	assert.Equal(t, 4, limit)
+
+	_, err = r.Add(ctx, domain.Person{Name: "Fünfte", Color: "blau"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+	_, err = r.Insert(ctx, domain.Person{ID: 3, Name: "Fünfte", Color: "blau"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+
+	// Ein geänderter Datensatz des Grundbestands zählt nur einmal.
+	hans := grundbestand()[0]
+	hans.City = "Kaiserslautern"
+	_, err = r.Update(ctx, hans, 1)
+	require.NoError(t, err)
+	used, _, err = r.Capacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, used)
+
+	require.NoError(t, r.Delete(ctx, 2, time.Now()))
+	_, err = r.Add(ctx, domain.Person{Name: "Fünfte", Color: "blau"})
+	require.NoError(t, err)
+	_, err = r.Restore(ctx, 2)
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+}
+
+func TestDeleteAll_VerdecktGrundbestand(t *testing.T) {
+	ctx := context.Background()
+	r, _ := neuesRepo(t, 0, nil)
+	_, err := r.Add(ctx, domain.Person{Name: "Neu", Color: "blau"})
+	require.NoError(t, err)
+
+	require.NoError(t, r.DeleteAll(ctx))
+
+	all, err := r.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	p, err := r.Add(ctx, domain.Person{Name: "Erste", Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.ID)
+}
+
+// ─── Mit SQLite als Schreibschicht ────────────────────────────────────────────
+
+func TestSQLiteSchreibschicht(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliterepo.NewPersonRepository(":memory:", 0, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	r, _ := neuesRepo(t, 0, db)
+
+	peter := grundbestand()[1]
+	peter.Color = "blau"
+	_, err = r.Update(ctx, peter, 1)
+	require.NoError(t, err)
+	require.NoError(t, r.Delete(ctx, 4, time.Now().Add(-time.Hour)))
+	added, err := r.Add(ctx, domain.Person{Name: "Neu", Color: "rot", CreatedAt: geladen, UpdatedAt: geladen})
+	require.NoError(t, err)
+	assert.Equal(t, 5, added.ID)
+
+	n, err := r.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	blau, err := r.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids(blau))
+	_, err = r.GetByID(ctx, 4)
+	assert.ErrorIs(t, err, domain.ErrGone)
+	stats, err := r.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+
+	// 4 ist schon gelöscht und zählt nicht mit.
+	n, err = r.DeleteByColor(ctx, "blau", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	all, err := r.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{5}, ids(all))
+}
@@ -1,131 +1,551 @@
 package csv
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	stdcsv "encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/gocarina/gocsv"
 	"go.uber.org/zap"
 
 	"assecor-assessment-backend/internal/domain"
 )
 
-// personDTO ist das Zwischen-DTO, das gocsv aus der normalisierten CSV befüllt.
+// personDTO ist ein aus einer oder mehreren Quellzeilen zusammengesetzter
+// Datensatz, bevor er zu einer domain.Person wird.
 type personDTO struct {
-	Lastname string `csv:"lastname"`
-	Name     string `csv:"name"`
-	ZipCity  string `csv:"zipcity"`
-	ColorID  string `csv:"colorid"`
+	Lastname string
+	Name     string
+	ZipCity  string
+	ColorID  string
 }
 
+// fields gibt die Felder in Dateireihenfolge zurück.
+func (d *personDTO) fields() []string {
+	return []string{d.Lastname, d.Name, d.ZipCity, d.ColorID}
+}
+
+// defaultDelimiter ist das Feldtrennzeichen, wenn keines konfiguriert wurde.
+const defaultDelimiter = ','
+
+// defaultFetchTimeout begrenzt das Laden über HTTP, wenn kein eigenes Timeout gesetzt ist.
+const defaultFetchTimeout = 30 * time.Second
+
+// stdinPath ist der Pfad, unter dem die CSV von der Standardeingabe gelesen wird.
+const stdinPath = "-"
+
 // PersonRepository hält alle Personen im Arbeitsspeicher und implementiert repository.PersonRepository.
+//
+// ID-Semantik: Eine aus der Datei geladene Person erhält als ID die Position
+// ihres Datensatzes in der Datei (1-basiert, nach Zusammenführen mehrzeiliger
+// Datensätze). Übersprungene ungültige Datensätze hinterlassen Lücken, sodass
+// eine Person bei erneutem Laden derselben Datei dieselbe ID behält. Neue
+// Personen erhalten fortlaufend IDs ab der höchsten gültigen ID + 1.
+//
+// Nebenläufigkeit: Der Bestand liegt in einem unveränderlichen snapshot.
+// Lesezugriffe laden ihn ohne Sperre; Schreibzugriffe bauen unter mu einen
+// neuen auf und tauschen ihn atomar aus. Zurückgegebene Slices teilen sich
+// daher den Speicher mit dem Snapshot und dürfen nicht verändert werden; ihre
+// Kapazität ist auf die Länge begrenzt, sodass append stets kopiert.
 type PersonRepository struct {
-	mu         sync.RWMutex
-	persons    []domain.Person
-	nextID     int
-	maxPersons int
-	logger     *zap.Logger
+	mu            sync.Mutex // serialisiert Schreibzugriffe und Ladevorgänge
+	snap          atomic.Pointer[snapshot]
+	source        string
+	nextID        int
+	maxPersons    int
+	delimiter     rune
+	fetchTimeout  time.Duration
+	fetchMaxBytes int64
+	bearerToken   string
+	s3            S3Config
+	cachePath     string
+	strict        bool
+	overflowErr   bool
+	allowMissing  bool
+	unknownColor  UnknownColorPolicy
+	report        domain.LoadReport // unter mu gesetzt, danach unverändert
+	logger        *zap.Logger
 }
 
-// NewPersonRepository legt ein neues PersonRepository
-func NewPersonRepository(filePath string, maxPersons int, logger *zap.Logger) (*PersonRepository, error) {
-	r := &PersonRepository{maxPersons: maxPersons, logger: logger}
-	if err := r.load(filePath); err != nil {
-		return nil, fmt.Errorf("csv-repository: %w", err)
+// snapshot ist ein unveränderlicher Stand des Bestands, beide Slices nach ID
+// sortiert. Vorläufig gelöschte Personen stehen nur in all, bis Purge sie
+// entfernt. Gibt es keine gelöschten Personen, ist live dasselbe Slice wie all.
+//
+// Add hängt an all und live an, ohne sie zu kopieren: Ältere Snapshots sehen
+// die neuen Elemente wegen ihrer kürzeren Länge nicht. Alle anderen
+// Änderungen bauen neue Slices auf.
+//
+// modified ist der Zeitpunkt, zu dem der Snapshot den vorigen ersetzt hat
+// (siehe LastModified).
+type snapshot struct {
+	all      []domain.Person
+	live     []domain.Person
+	modified time.Time
+}
+
+// newSnapshot bildet den Snapshot zu all und filtert live daraus.
+func newSnapshot(all []domain.Person) *snapshot {
+	deleted := 0
+	for _, p := range all {
+		if p.Deleted() {
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return &snapshot{all: all, live: all}
+	}
+	live := make([]domain.Person, 0, len(all)-deleted)
+	for _, p := range all {
+		if !p.Deleted() {
+			live = append(live, p)
+		}
+	}
+	return &snapshot{all: all, live: live}
+}
+
+// Option konfiguriert optionale Eigenschaften des PersonRepository.
+type Option func(*PersonRepository)
+
+// WithDelimiter setzt das Feldtrennzeichen der Quelldatei, z. B. ';' für
+// Exporte aus deutschem Excel.
+func WithDelimiter(d rune) Option {
+	return func(r *PersonRepository) {
+		if d != 0 {
+			r.delimiter = d
+		}
+	}
+}
+
+// WithFetchTimeout begrenzt die Gesamtdauer des Ladens über HTTP(S) oder S3.
+func WithFetchTimeout(d time.Duration) Option {
+	return func(r *PersonRepository) {
+		if d > 0 {
+			r.fetchTimeout = d
+		}
+	}
+}
+
+// WithStrict lässt das Laden mit einem Fehler scheitern, sobald ein Datensatz
+// ungültig ist, statt ihn zu überspringen.
+func WithStrict(strict bool) Option {
+	return func(r *PersonRepository) {
+		r.strict = strict
+	}
+}
+
+// WithOverflowError lässt das Laden mit einem Fehler scheitern, wenn die
+// Quelle mehr gültige Datensätze enthält als maxPersons, statt nur die ersten
+// maxPersons zu laden. Im strikten Modus gilt das immer.
+func WithOverflowError(enabled bool) Option {
+	return func(r *PersonRepository) {
+		r.overflowErr = enabled
+	}
+}
+
+// WithAllowMissing lässt das Repository beim Anlegen leer starten, wenn die
+// Quelldatei nicht existiert. Andere Fehler wie fehlende Leserechte und ein
+// Reload ohne Datei scheitern weiterhin.
+func WithAllowMissing(enabled bool) Option {
+	return func(r *PersonRepository) {
+		r.allowMissing = enabled
+	}
+}
+
+// Aktionen für Datensätze mit unbekannter Farb-ID, siehe UnknownColorPolicy.
+const (
+	UnknownColorSkip    = "skip"
+	UnknownColorDefault = "default"
+	UnknownColorError   = "error"
+)
+
+// UnknownColorPolicy legt fest, wie load Datensätze behandelt, deren Farb-ID
+// keine bekannte Farbe ist. Der Nullwert überspringt sie. Eine Farb-ID, die
+// keine Ganzzahl ist, gilt nicht als unbekannte Farbe; solche Datensätze
+// werden unabhängig von der Regel übersprungen.
+type UnknownColorPolicy struct {
+	Action   string       // UnknownColorSkip, UnknownColorDefault oder UnknownColorError
+	Fallback domain.Color // Ersatzfarbe bei UnknownColorDefault
+}
+
+// ParseUnknownColorPolicy liest eine Regel der Form "skip", "error" oder
+// "default:<farbe>". Die Ersatzfarbe muss im aktiven Farbsatz bekannt sein.
+func ParseUnknownColorPolicy(s string) (UnknownColorPolicy, error) {
+	switch action, name, _ := strings.Cut(s, ":"); action {
+	case UnknownColorSkip, UnknownColorError:
+		if name != "" {
+			break
+		}
+		return UnknownColorPolicy{Action: action}, nil
+	case UnknownColorDefault:
+		color, err := domain.ParseColor(name)
+		if err != nil {
+			return UnknownColorPolicy{}, fmt.Errorf("ersatzfarbe %q: %w", name, err)
+		}
+		return UnknownColorPolicy{Action: action, Fallback: color}, nil
+	}
+	return UnknownColorPolicy{}, fmt.Errorf("regel %q: erwartet skip, error oder default:<farbe>: %w", s, domain.ErrInvalidInput)
+}
+
+// WithUnknownColor setzt die Regel für Datensätze mit unbekannter Farb-ID.
+func WithUnknownColor(p UnknownColorPolicy) Option {
+	return func(r *PersonRepository) {
+		r.unknownColor = p
+	}
+}
+
+// NewPersonRepository legt ein neues PersonRepository an und lädt die Quelle.
+// filePath ist ein lokaler Pfad, eine http://-, https://- oder
+// s3://bucket/key-URL oder "-" für die Standardeingabe.
+func NewPersonRepository(filePath string, maxPersons int, logger *zap.Logger, opts ...Option) (*PersonRepository, error) {
+	r := &PersonRepository{
+		source:        filePath,
+		maxPersons:    maxPersons,
+		delimiter:     defaultDelimiter,
+		fetchTimeout:  defaultFetchTimeout,
+		fetchMaxBytes: defaultFetchMaxBytes,
+		logger:        logger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if _, err := r.load(context.Background()); err != nil {
+		return nil, err
 	}
 	return r, nil
 }
 
-// load liest die CSV-Datei und befüllt r.persons über gocsv.
-func (r *PersonRepository) load(filePath string) error {
+// load liest die CSV-Quelle als Strom und wandelt jeden zusammengesetzten
+// Datensatz sofort in eine Person um; die Quelle liegt dabei nie vollständig
+// im Speicher. Verworfene Datensätze landen im Ladebericht. Im strikten Modus
+// ist jeder verworfene Datensatz ein Fehler.
+//
+// Kapazitätsgrenze: Enthält die Quelle mehr als maxPersons gültige
+// Datensätze, werden nur die ersten maxPersons geladen und der Rest im
+// Ladebericht als Overflow gezählt. Mit WithOverflowError oder im strikten
+// Modus scheitert das Laden stattdessen. maxPersons 0 heißt unbegrenzt.
+//
+// Der neue Bestand ersetzt den alten erst nach vollständigem Lesen in einem
+// Schritt; schlägt das Laden fehl, bleibt der alte unverändert. Fehler tragen
+// wie alle des Repositorys das Präfix csv-repository.
+func (r *PersonRepository) load(ctx context.Context) (domain.LoadReport, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	data, err := os.ReadFile(filePath)
+	filePath := r.source
+	src, size, sourceModified, err := r.openSource(ctx, filePath)
 	if err != nil {
-		return fmt.Errorf("datei lesen %s: %w", filePath, err)
+		if r.allowMissing && r.snap.Load() == nil && errors.Is(err, fs.ErrNotExist) {
+			r.logger.Warn("csv-datei fehlt, start mit leerem bestand", zap.String("datei", filePath))
+			return r.startEmpty(filePath), nil
+		}
+		if src, err = r.openCache(filePath, err); err != nil {
+			return domain.LoadReport{}, fmt.Errorf("csv-repository: %w", err)
+		}
+		filePath, size, sourceModified = r.cachePath, -1, time.Time{}
 	}
+	defer src.Close()
 
-	normalized, err := normalizeCSV(data, r.logger)
+	records, err := newRecordReader(src, r.delimiter, r.logger)
 	if err != nil {
-		return fmt.Errorf("csv normalisieren: %w", err)
-	}
-
-	var dtos []*personDTO
-	if err := gocsv.UnmarshalBytes(normalized, &dtos); err != nil {
-		return fmt.Errorf("csv parsen: %w", err)
+		return domain.LoadReport{}, fmt.Errorf("csv-repository: csv lesen: %w", err)
 	}
 
-	r.persons = make([]domain.Person, 0, len(dtos))
-	for i, dto := range dtos {
-		person, err := toPerson(i+1, dto)
+	// Die Datei enthält keine Zeitstempel; geladene Personen gelten als zum
+	// Ladezeitpunkt angelegt.
+	loadedAt := time.Now().UTC()
+	var persons []domain.Person
+	var skipped []domain.SkippedRecord
+	overflow := 0
+	maxID := 0
+	for n := 1; ; n++ {
+		if n%ctxCheckInterval == 0 {
+			if err := ctxErr(ctx); err != nil {
+				return domain.LoadReport{}, err
+			}
+		}
+		dto, line, err := records.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return domain.LoadReport{}, fmt.Errorf("csv-repository: csv lesen: %w", err)
+		}
+		person, err := toPerson(n, dto)
+		if errors.Is(err, domain.ErrUnknownColor) {
+			switch r.unknownColor.Action {
+			case UnknownColorDefault:
+				r.logger.Warn("unbekannte farb-id, ersatzfarbe wird verwendet",
+					zap.Int("datensatz", n), zap.Int("zeile", line),
+					zap.Stringer("farbe", r.unknownColor.Fallback), zap.Error(err))
+				person, err = newPerson(n, dto, r.unknownColor.Fallback), nil
+			case UnknownColorError:
+				return domain.LoadReport{}, fmt.Errorf("csv-repository: datensatz in zeile %d: %w", line, err)
+			}
+		}
 		if err != nil {
 			r.logger.Warn("ungültiger datensatz wird übersprungen",
-				zap.Int("datensatz", i+1), zap.Error(err))
+				zap.Int("datensatz", n), zap.Int("zeile", line), zap.Error(err))
+			skipped = append(skipped, domain.SkippedRecord{Line: line, Fields: dto.fields(), Reason: err.Error()})
+			continue
+		}
+		// Auch jenseits der Grenze wird weitergelesen, um die Zahl der nicht
+		// geladenen Datensätze melden zu können.
+		if r.maxPersons > 0 && len(persons) >= r.maxPersons {
+			overflow++
 			continue
 		}
-		r.persons = append(r.persons, person)
+		if n == sizeProbe && size > 0 {
+			remaining := estimateRemaining(size, records.offset(), n)
+			if r.maxPersons > 0 {
+				remaining = min(remaining, r.maxPersons-len(persons))
+			}
+			persons = slices.Grow(persons, remaining)
+		}
+		person.CreatedAt, person.UpdatedAt = loadedAt, loadedAt
+		person.Version = 1
+		persons = append(persons, person)
+		maxID = person.ID
+	}
+	skipped = append(skipped, records.dropped...)
+	slices.SortStableFunc(skipped, func(a, b domain.SkippedRecord) int { return a.Line - b.Line })
+	if r.strict && len(skipped) > 0 {
+		first := skipped[0]
+		return domain.LoadReport{}, fmt.Errorf("csv-repository: %d ungültige datensätze, erster in zeile %d (%s): %w",
+			len(skipped), first.Line, first.Reason, domain.ErrInvalidInput)
+	}
+	if overflow > 0 {
+		if r.strict || r.overflowErr {
+			return domain.LoadReport{}, fmt.Errorf("csv-repository: datei enthält %d gültige datensätze, erlaubt sind max %d personen: %w",
+				len(persons)+overflow, r.maxPersons, domain.ErrCapacityReached)
+		}
+		r.logger.Warn("kapazitätsgrenze erreicht, überzählige datensätze wurden nicht geladen",
+			zap.Int("max_persons", r.maxPersons), zap.Int("nicht_geladen", overflow))
+	}
+	if cache, ok := src.(*cachingBody); ok {
+		cache.commit()
+	}
+	// Beim ersten Laden gilt der Änderungszeitpunkt der Quelle, sofern
+	// bekannt. Ein Reload ersetzt den Bestand und ist selbst eine Änderung.
+	snap := newSnapshot(slices.Clip(persons))
+	snap.modified = loadedAt
+	if r.snap.Load() == nil && !sourceModified.IsZero() {
+		snap.modified = sourceModified.UTC()
+	}
+	r.snap.Store(snap)
+	r.report = domain.LoadReport{
+		Source: filePath, LoadedAt: loadedAt, Loaded: len(persons), Overflow: overflow, Skipped: skipped,
 	}
 
-	r.nextID = len(dtos) + 1
+	// IDs steigen mit der Position, daher ist die zuletzt vergebene die höchste.
+	r.nextID = maxID + 1
 
-	r.logger.Info("personen aus CSV geladen",
-		zap.Int("anzahl", len(r.persons)), zap.String("datei", filePath))
-	return nil
+	// Die Zusammenfassung ist bei verworfenen Datensätzen eine Warnung, damit
+	// eine fehlerhafte Datei nicht zwischen den Einzelmeldungen untergeht.
+	level := zap.InfoLevel
+	if len(skipped) > 0 {
+		level = zap.WarnLevel
+	}
+	r.logger.Log(level, "personen aus CSV geladen",
+		zap.Int("anzahl", len(persons)), zap.Int("verworfen", len(skipped)), zap.String("datei", filePath))
+	return r.report, nil
+}
+
+// startEmpty setzt einen leeren Bestand für eine fehlende Quelle. Der
+// Aufrufer hält mu.
+func (r *PersonRepository) startEmpty(filePath string) domain.LoadReport {
+	now := time.Now().UTC()
+	r.snap.Store(&snapshot{modified: now})
+	r.nextID = 1
+	r.report = domain.LoadReport{Source: filePath, LoadedAt: now}
+	return r.report
+}
+
+// Reload liest die beim Anlegen angegebene Quelle erneut und ersetzt den
+// gesamten Bestand, auch seit dem Start hinzugefügte oder geänderte Personen.
+// Gleichzeitige Reloads und Schreibzugriffe warten aufeinander; Leser sehen
+// bis zum Austausch den alten, danach den neuen Bestand, nie einen halben.
+// Die Standardeingabe lässt sich nicht erneut lesen.
+func (r *PersonRepository) Reload(ctx context.Context) (domain.LoadReport, error) {
+	if r.source == stdinPath {
+		return domain.LoadReport{}, fmt.Errorf("csv-repository: standardeingabe kann nicht erneut gelesen werden: %w", domain.ErrInvalidInput)
+	}
+	report, err := r.load(ctx)
+	if err != nil {
+		return domain.LoadReport{}, err
+	}
+	report.Skipped = slices.Clone(report.Skipped)
+	return report, nil
 }
 
-// normalizeCSV verarbeitet das mehrzeilige Datensatzformat der Quell-CSV.
-func normalizeCSV(data []byte, logger *zap.Logger) ([]byte, error) {
-	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+// LoadReport liefert den Bericht des letzten Ladens. Skipped ist nie nil.
+func (r *PersonRepository) LoadReport() domain.LoadReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := r.report
+	report.Skipped = append([]domain.SkippedRecord{}, report.Skipped...)
+	return report
+}
 
-	records := make([][]string, 0, len(lines)+1)
-	records = append(records, []string{"lastname", "name", "zipcity", "colorid"})
+// sizeProbe ist die Anzahl Datensätze, nach der load aus dem gelesenen Anteil
+// der Quelle hochrechnet, wie viele Personen noch folgen. So wächst das Slice
+// bei großen Dateien nicht schrittweise, was zeitweise fast die doppelte
+// Menge Speicher belegen würde.
+const sizeProbe = 1024
 
-	var accumulated []string
-	for _, line := range lines {
-		rawParts := strings.Split(line, ",")
-		nonEmpty := countNonEmpty(rawParts)
-		if len(accumulated) > 0 && nonEmpty >= 4 {
-			logger.Warn("fehlerhafter vorgänger-datensatz verworfen",
-				zap.Strings("felder", accumulated))
-			accumulated = nil
+// estimateRemaining schätzt, wie viele Datensätze nach den ersten n folgen,
+// wenn diese read von size Bytes belegt haben.
+func estimateRemaining(size, read int64, n int) int {
+	if read <= 0 || read >= size {
+		return 0
+	}
+	return int((size - read) * int64(n) / read)
+}
+
+// openSource öffnet eine URL, die Standardeingabe oder eine Datei zum Lesen
+// und gibt, soweit bekannt, ihre Größe in Bytes (sonst -1) und ihren
+// Änderungszeitpunkt (sonst die Nullzeit) zurück. Die Standardeingabe wird
+// beim Schließen nicht geschlossen.
+func (r *PersonRepository) openSource(ctx context.Context, source string) (io.ReadCloser, int64, time.Time, error) {
+	switch {
+	case source == stdinPath:
+		return io.NopCloser(os.Stdin), -1, time.Time{}, nil
+	case isRemote(source):
+		return r.fetch(ctx, source)
+	default:
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, 0, time.Time{}, fmt.Errorf("datei lesen %s: %w", source, err)
+		}
+		size := int64(-1)
+		var modified time.Time
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			size = info.Size()
+			modified = info.ModTime()
 		}
+		return f, size, modified, nil
+	}
+}
+
+// utf8BOM markiert manche Exporte (z. B. aus Excel) am Dateianfang.
+var utf8BOM = []byte("\uFEFF")
+
+// recordReader setzt Datensätze aus dem mehrzeiligen Format der Quell-CSV
+// zusammen. Die Zeilen werden mit encoding/csv gelesen, sodass Felder in
+// Anführungszeichen das Trennzeichen enthalten dürfen ("Meyer, Dr.").
+//
+// Eine Zeile mit mindestens vier Feldern ist ein eigener Datensatz. Kürzere
+// Zeilen werden gesammelt, bis mindestens vier Felder vorliegen und das
+// letzte eine Farb-ID ist (siehe isComplete); so darf ein Datensatz über
+// beliebig viele Zeilen umbrechen. Beginnt währenddessen eine vollständige
+// Zeile, war der gesammelte Vorgänger unvollständig und wird verworfen.
+type recordReader struct {
+	csv    *stdcsv.Reader
+	logger *zap.Logger
+
+	accumulated      []string
+	accumulatedLines []int
+	dropped          []domain.SkippedRecord // schon beim Zusammensetzen verworfene Fragmente
+}
 
-		for _, field := range rawParts {
-			if trimmed := strings.TrimSpace(field); trimmed != "" {
-				accumulated = append(accumulated, trimmed)
+// newRecordReader liest Datensätze aus src und überspringt ein UTF-8-BOM am Anfang.
+func newRecordReader(src io.Reader, delimiter rune, logger *zap.Logger) (*recordReader, error) {
+	br := bufio.NewReader(src)
+	if prefix, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	} else if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	reader := stdcsv.NewReader(br)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+	return &recordReader{csv: reader, logger: logger}, nil
+}
+
+// Next liefert den nächsten vollständigen Datensatz und seine erste Quellzeile
+// (1-basiert). Am Ende der Quelle ist der Fehler io.EOF.
+func (rr *recordReader) Next() (*personDTO, int, error) {
+	for {
+		raw, err := rr.csv.Read()
+		if err == io.EOF {
+			if len(rr.accumulated) > 0 {
+				rr.discard("unvollständiger datensatz am dateiende wird verworfen")
 			}
+			return nil, 0, io.EOF
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		line, _ := rr.csv.FieldPos(0)
+		// Mit LazyQuotes liest ein nicht geschlossenes Anführungszeichen bis
+		// zum nächsten; in der Quelldatei deutet das fast immer auf einen
+		// Tippfehler hin, der Folgezeilen verschluckt.
+		if slices.ContainsFunc(raw, func(f string) bool { return strings.Contains(f, "\n") }) {
+			rr.logger.Warn("feld in anführungszeichen umfasst mehrere zeilen", zap.Int("zeile", line))
 		}
 
-		if len(accumulated) >= 4 {
-			n := len(accumulated)
-			records = append(records, []string{
-				accumulated[0],
-				accumulated[1],
-				strings.Join(accumulated[2:n-1], " "),
-				accumulated[n-1],
-			})
-			accumulated = nil
+		fields := nonEmptyFields(raw)
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(rr.accumulated) == 0 && len(fields) >= 4:
+			return toRecord(fields), line, nil
+		case len(rr.accumulated) > 0 && isComplete(fields):
+			rr.discard("fehlerhafter vorgänger-datensatz verworfen")
+			return toRecord(fields), line, nil
 		}
-	}
 
-	if len(accumulated) > 0 {
-		logger.Warn("unvollständiger datensatz am dateiende wird verworfen",
-			zap.Strings("felder", accumulated))
+		rr.accumulated = append(rr.accumulated, fields...)
+		rr.accumulatedLines = append(rr.accumulatedLines, line)
+		if isComplete(rr.accumulated) {
+			rr.logger.Debug("mehrzeiliger datensatz zusammengeführt",
+				zap.Ints("zeilen", rr.accumulatedLines), zap.Strings("felder", rr.accumulated))
+			first := rr.accumulatedLines[0]
+			dto := toRecord(rr.accumulated)
+			rr.accumulated, rr.accumulatedLines = nil, nil
+			return dto, first, nil
+		}
 	}
+}
 
-	var buf bytes.Buffer
-	w := stdcsv.NewWriter(&buf)
-	if err := w.WriteAll(records); err != nil {
-		return nil, fmt.Errorf("csv schreiben: %w", err)
+// offset gibt die Anzahl bisher gelesener Bytes zurück.
+func (rr *recordReader) offset() int64 {
+	return rr.csv.InputOffset()
+}
+
+// discard verwirft die gesammelten Fragmente und hält sie in dropped fest.
+func (rr *recordReader) discard(msg string) {
+	rr.logger.Warn(msg, zap.Ints("zeilen", rr.accumulatedLines), zap.Strings("felder", rr.accumulated))
+	rr.dropped = append(rr.dropped, domain.SkippedRecord{
+		Line: rr.accumulatedLines[0], Fields: rr.accumulated, Reason: "unvollständiger datensatz",
+	})
+	rr.accumulated, rr.accumulatedLines = nil, nil
+}
+
+// isComplete meldet, ob fields einen vollständigen Datensatz bilden:
+// mindestens vier Felder, deren letztes wie eine Farb-ID aussieht.
+func isComplete(fields []string) bool {
+	if len(fields) < 4 {
+		return false
 	}
-	return buf.Bytes(), nil
+	_, err := strconv.Atoi(fields[len(fields)-1])
+	return err == nil
 }
 
 // toPerson wandelt ein personDTO in eine domain.Person um.
@@ -134,25 +554,43 @@ func toPerson(id int, dto *personDTO) (domain.Person, error) {
 	if err != nil {
 		return domain.Person{}, fmt.Errorf("ungültige farb-id %q: %w", dto.ColorID, err)
 	}
-	colorName, ok := domain.ColorMap[colorID]
-	if !ok {
-		return domain.Person{}, fmt.Errorf("unbekannte farb-id %d", colorID)
+	color, err := domain.ColorByID(colorID)
+	if err != nil {
+		return domain.Person{}, err
 	}
+	return newPerson(id, dto, color), nil
+}
+
+// newPerson baut die Person zu dto mit der angegebenen Farbe.
+func newPerson(id int, dto *personDTO, color domain.Color) domain.Person {
 	zipcode, city := splitZipcodeCity(dto.ZipCity)
 	return domain.Person{
 		ID: id, Name: dto.Name, Lastname: dto.Lastname,
-		Zipcode: zipcode, City: city, Color: colorName,
-	}, nil
+		Zipcode: zipcode, City: city, Color: color,
+	}
 }
 
-func countNonEmpty(parts []string) int {
-	n := 0
+// toRecord fasst die Felder zu lastname, name, zipcity und colorid zusammen.
+// Überzählige Felder zwischen Vorname und Farb-ID bilden gemeinsam zipcity.
+func toRecord(fields []string) *personDTO {
+	n := len(fields)
+	return &personDTO{
+		Lastname: fields[0],
+		Name:     fields[1],
+		ZipCity:  strings.Join(fields[2:n-1], " "),
+		ColorID:  fields[n-1],
+	}
+}
+
+// nonEmptyFields gibt die getrimmten, nicht-leeren Felder einer Zeile zurück.
+func nonEmptyFields(parts []string) []string {
+	out := make([]string, 0, len(parts))
 	for _, p := range parts {
-		if strings.TrimSpace(p) != "" {
-			n++
+		if trimmed := strings.TrimSpace(p); trimmed != "" {
+			out = append(out, trimmed)
 		}
 	}
-	return n
+	return out
 }
 
 // splitZipcodeCity trennt "PLZ Stadt" am ersten Leerzeichen.
@@ -164,54 +602,520 @@ func splitZipcodeCity(s string) (string, string) {
 	return s, ""
 }
 
-// GetAll gibt alle Personen zurück.
-func (r *PersonRepository) GetAll(_ context.Context) ([]domain.Person, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// ctxCheckInterval gibt an, nach wie vielen Personen lange Durchläufe den
+// Kontext erneut prüfen.
+const ctxCheckInterval = 1024
 
-	out := make([]domain.Person, len(r.persons))
-	copy(out, r.persons)
-	return out, nil
+// ctxErr meldet einen abgebrochenen Kontext als umschlossenen Fehler, den
+// Aufrufer per errors.Is auf context.Canceled bzw. DeadlineExceeded prüfen.
+func ctxErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("csv-repository: %w", err)
+	}
+	return nil
 }
 
-// GetByID sucht eine Person anhand ihrer positionsbasierten ID.
-func (r *PersonRepository) GetByID(_ context.Context, id int) (domain.Person, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// scan ruft fn für jede Person auf und prüft ctx alle ctxCheckInterval
+// Personen. Gibt fn false zurück, endet der Durchlauf ohne Fehler.
+func scan(ctx context.Context, persons []domain.Person, fn func(domain.Person) bool) error {
+	for i, p := range persons {
+		if i%ctxCheckInterval == 0 {
+			if err := ctxErr(ctx); err != nil {
+				return err
+			}
+		}
+		if !fn(p) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// GetAll gibt alle nicht gelöschten Personen zurück, ohne sie zu kopieren.
+func (r *PersonRepository) GetAll(ctx context.Context) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	return slices.Clip(r.snap.Load().live), nil
+}
 
-	for _, p := range r.persons {
-		if p.ID == id {
-			return p, nil
+// GetAllStream ruft fn für jede Person des beim Aufruf aktuellen Snapshots
+// auf. Ein langsamer Aufrufer blockiert daher keine Schreibzugriffe.
+func (r *PersonRepository) GetAllStream(ctx context.Context, fn func(domain.Person) error) error {
+	for _, p := range r.snap.Load().live {
+		if err := ctxErr(ctx); err != nil {
+			return err
 		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllAfter sucht die Startposition per Binärsuche und gibt einen
+// Ausschnitt des Snapshots zurück.
+func (r *PersonRepository) GetAllAfter(ctx context.Context, afterID, limit int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	live := r.snap.Load().live
+	start := sort.Search(len(live), func(i int) bool { return live[i].ID > afterID })
+	return slices.Clip(domain.Paginate(live[start:], limit, 0)), nil
+}
+
+// GetByID sucht eine Person anhand ihrer ID (siehe ID-Semantik am Typ).
+func (r *PersonRepository) GetByID(ctx context.Context, id int) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	all := r.snap.Load().all
+	i := indexOf(all, id)
+	if i < 0 {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
 	}
-	return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
+	if all[i].Deleted() {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrGone)
+	}
+	return all[i], nil
 }
 
-// GetByColor gibt alle Personen mit passender Lieblingsfarbe zurück.
-func (r *PersonRepository) GetByColor(_ context.Context, color string) ([]domain.Person, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// GetByIDs sucht jede angefragte ID per Binärsuche im selben Snapshot und
+// liefert die Treffer nach ID sortiert.
+func (r *PersonRepository) GetByIDs(ctx context.Context, ids []int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	sorted := slices.Compact(slices.Sorted(slices.Values(ids)))
 
+	live := r.snap.Load().live
+	out := make([]domain.Person, 0, len(sorted))
+	for _, id := range sorted {
+		if i := indexOf(live, id); i >= 0 {
+			out = append(out, live[i])
+		}
+	}
+	return out, nil
+}
+
+// GetByColor gibt die Personen mit passender Lieblingsfarbe in der
+// Reihenfolge order zurück, beschränkt auf die Seite aus limit und offset.
+func (r *PersonRepository) GetByColor(ctx context.Context, color domain.Color, order domain.Order, limit, offset int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
 	out := make([]domain.Person, 0)
-	for _, p := range r.persons {
+	err := scan(ctx, r.snap.Load().live, func(p domain.Person) bool {
 		if p.Color == color {
 			out = append(out, p)
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
-	return out, nil
+	if !order.IsZero() {
+		domain.SortPersons(out, order)
+	}
+	return domain.Paginate(out, limit, offset), nil
+}
+
+// GetByColors sammelt die Treffer in einem Durchlauf und sortiert sie
+// stabil nach Farbe, sodass die ID-Reihenfolge je Farbe erhalten bleibt.
+func (r *PersonRepository) GetByColors(ctx context.Context, colors []domain.Color, limit, offset int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]domain.Person, 0)
+	err := scan(ctx, r.snap.Load().live, func(p domain.Person) bool {
+		if slices.Contains(colors, p.Color) {
+			out = append(out, p)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(out, func(a, b domain.Person) int { return strings.Compare(string(a.Color), string(b.Color)) })
+	return domain.Paginate(out, limit, offset), nil
+}
+
+// GetByZipcode vergleicht die Postleitzahlen in einem Durchlauf.
+func (r *PersonRepository) GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	out := make([]domain.Person, 0)
+	err := scan(ctx, r.snap.Load().live, func(p domain.Person) bool {
+		if p.Zipcode == zip || prefix && strings.HasPrefix(p.Zipcode, zip) {
+			out = append(out, p)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return domain.Paginate(out, limit, offset), nil
 }
 
-// Add fügt eine neue Person hinzu.
-func (r *PersonRepository) Add(_ context.Context, person domain.Person) (domain.Person, error) {
+// Find filtert in einem Durchlauf und wendet anschließend Limit und Offset
+// an. Schränkt filter nur die Seite ein, ist das Ergebnis ein Ausschnitt des
+// Snapshots ohne Kopie.
+func (r *PersonRepository) Find(ctx context.Context, filter domain.PersonFilter) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	s := r.snap.Load()
+	if filter.Color == "" && filter.CreatedAfter.IsZero() {
+		return slices.Clip(domain.Paginate(s.visible(filter), filter.Limit, filter.Offset)), nil
+	}
+
+	out := make([]domain.Person, 0)
+	err := scan(ctx, s.visible(filter), func(p domain.Person) bool {
+		if filter.Matches(p) {
+			out = append(out, p)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return domain.Paginate(out, filter.Limit, filter.Offset), nil
+}
+
+// Count zählt die Treffer von filter in einem Durchlauf.
+func (r *PersonRepository) Count(ctx context.Context, filter domain.PersonFilter) (int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, err
+	}
+	s := r.snap.Load()
+	if filter.Color == "" && filter.CreatedAfter.IsZero() {
+		return len(s.visible(filter)), nil
+	}
+
+	n := 0
+	err := scan(ctx, s.visible(filter), func(p domain.Person) bool {
+		if filter.Matches(p) {
+			n++
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// visible liefert die für filter sichtbaren Personen vor allen weiteren Kriterien.
+func (s *snapshot) visible(filter domain.PersonFilter) []domain.Person {
+	if filter.IncludeDeleted {
+		return s.all
+	}
+	return s.live
+}
+
+// CountsByColor zählt die Personen je Farbe in einem Durchlauf.
+func (r *PersonRepository) CountsByColor(ctx context.Context) (map[domain.Color]int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	counts := make(map[domain.Color]int)
+	err := scan(ctx, r.snap.Load().live, func(p domain.Person) bool {
+		counts[p.Color]++
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// DistinctCities sammelt die Städte in einer Map und sortiert sie.
+func (r *PersonRepository) DistinctCities(ctx context.Context) ([]string, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	err := scan(ctx, r.snap.Load().live, func(p domain.Person) bool {
+		if p.City != "" {
+			seen[p.City] = struct{}{}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	cities := make([]string, 0, len(seen))
+	for city := range seen {
+		cities = append(cities, city)
+	}
+	slices.Sort(cities)
+	return cities, nil
+}
+
+// Capacity liest die Anzahl nicht gelöschter Personen aus dem aktuellen Snapshot.
+func (r *PersonRepository) Capacity(ctx context.Context) (int, int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, 0, err
+	}
+	return len(r.snap.Load().live), r.maxPersons, nil
+}
+
+// Stats zählt Farben und Städte in einem einzigen Durchlauf über denselben Snapshot.
+func (r *PersonRepository) Stats(ctx context.Context) (domain.PersonStats, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.PersonStats{}, err
+	}
+	live := r.snap.Load().live
+	stats := domain.PersonStats{
+		Total:   len(live),
+		ByColor: make(map[domain.Color]int),
+		ByCity:  make(map[string]int),
+	}
+	err := scan(ctx, live, func(p domain.Person) bool {
+		stats.ByColor[p.Color]++
+		stats.ByCity[p.City]++
+		return true
+	})
+	if err != nil {
+		return domain.PersonStats{}, err
+	}
+	return stats, nil
+}
+
+// LastModified liefert den Zeitpunkt, zu dem der aktuelle Snapshot entstand:
+// nach dem Start den Änderungszeitpunkt der Quelle, danach den der letzten
+// Änderung.
+func (r *PersonRepository) LastModified(ctx context.Context) (time.Time, error) {
+	if err := ctxErr(ctx); err != nil {
+		return time.Time{}, err
+	}
+	return r.snap.Load().modified, nil
+}
+
+// Add hängt eine neue Person an (siehe snapshot).
+func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.maxPersons > 0 && len(r.persons) >= r.maxPersons {
-		return domain.Person{}, fmt.Errorf("max %d personen: %w", r.maxPersons, domain.ErrCapacityReached)
+	s := r.snap.Load()
+	if err := r.checkCapacity(s); err != nil {
+		return domain.Person{}, err
 	}
 
 	person.ID = r.nextID
+	person.Version = 1
 	r.nextID++
-	r.persons = append(r.persons, person)
+	all := append(s.all, person)
+	live := all
+	if len(s.live) != len(s.all) {
+		live = append(s.live, person)
+	}
+	r.store(&snapshot{all: all, live: live})
 	return person, nil
 }
+
+// Insert fügt die Person an ihrer ID-Position in einen neuen Snapshot ein und
+// hebt nextID bei Bedarf über die vorgegebene ID.
+func (r *PersonRepository) Insert(ctx context.Context, person domain.Person) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.snap.Load()
+	if i := indexOf(s.all, person.ID); i >= 0 {
+		return domain.Person{}, &domain.VersionConflictError{ID: person.ID, Current: s.all[i].Version}
+	}
+	if err := r.checkCapacity(s); err != nil {
+		return domain.Person{}, err
+	}
+
+	person.Version = 1
+	i := sort.Search(len(s.all), func(i int) bool { return s.all[i].ID > person.ID })
+	r.store(newSnapshot(slices.Insert(slices.Clone(s.all), i, person)))
+	r.nextID = max(r.nextID, person.ID+1)
+	return person, nil
+}
+
+// Update vergleicht die Version und ersetzt die Person in einem neuen Snapshot.
+func (r *PersonRepository) Update(ctx context.Context, person domain.Person, expectedVersion int) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := r.snap.Load().all
+	i := indexOf(all, person.ID)
+	if i < 0 {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", person.ID, domain.ErrNotFound)
+	}
+	current := all[i]
+	if current.Deleted() {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", person.ID, domain.ErrGone)
+	}
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return domain.Person{}, &domain.VersionConflictError{ID: person.ID, Current: current.Version}
+	}
+
+	person.CreatedAt = current.CreatedAt
+	person.DeletedAt = time.Time{}
+	person.Version = current.Version + 1
+	r.store(newSnapshot(replaced(all, i, person)))
+	return person, nil
+}
+
+// Delete setzt DeletedAt der Person; sie bleibt bis zum Purge im Speicher.
+func (r *PersonRepository) Delete(ctx context.Context, id int, at time.Time) error {
+	if err := ctxErr(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := r.snap.Load().all
+	i := indexOf(all, id)
+	if i < 0 || all[i].Deleted() {
+		return fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
+	}
+	p := all[i]
+	p.DeletedAt = at
+	p.Version++
+	r.store(newSnapshot(replaced(all, i, p)))
+	return nil
+}
+
+// DeleteByColor löscht alle Treffer in einem einzigen neuen Snapshot.
+func (r *PersonRepository) DeleteByColor(ctx context.Context, color domain.Color, at time.Time) (int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := r.snap.Load().all
+	var out []domain.Person
+	n := 0
+	for i, p := range all {
+		if p.Color != color || p.Deleted() {
+			continue
+		}
+		if out == nil {
+			out = slices.Clone(all)
+		}
+		p.DeletedAt = at
+		p.Version++
+		out[i] = p
+		n++
+	}
+	if n > 0 {
+		r.store(newSnapshot(out))
+	}
+	return n, nil
+}
+
+// Restore hebt die Löschung auf, sofern die Kapazitätsgrenze es zulässt.
+func (r *PersonRepository) Restore(ctx context.Context, id int) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.snap.Load()
+	i := indexOf(s.all, id)
+	if i < 0 || !s.all[i].Deleted() {
+		return domain.Person{}, fmt.Errorf("gelöschte person mit id %d: %w", id, domain.ErrNotFound)
+	}
+	if err := r.checkCapacity(s); err != nil {
+		return domain.Person{}, err
+	}
+	p := s.all[i]
+	p.DeletedAt = time.Time{}
+	p.Version++
+	r.store(newSnapshot(replaced(s.all, i, p)))
+	return p, nil
+}
+
+// Purge baut ein neues Slice ohne die vor before gelöschten Personen auf.
+// nextID bleibt unverändert, sodass IDs nicht erneut vergeben werden. Ein
+// Abbruch während des Durchlaufs lässt die Daten unverändert.
+func (r *PersonRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.snap.Load()
+	kept := make([]domain.Person, 0, len(s.all))
+	err := scan(ctx, s.all, func(p domain.Person) bool {
+		if !p.Deleted() || !p.DeletedAt.Before(before) {
+			kept = append(kept, p)
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	purged := len(s.all) - len(kept)
+	if purged > 0 {
+		r.store(newSnapshot(kept))
+	}
+	return purged, nil
+}
+
+// store ersetzt den Snapshot durch s und vermerkt den Zeitpunkt der Änderung.
+// Der Aufrufer hält mu.
+func (r *PersonRepository) store(s *snapshot) {
+	s.modified = time.Now().UTC()
+	r.snap.Store(s)
+}
+
+// checkCapacity prüft die Kapazitätsgrenze; gelöschte Personen zählen nicht.
+// Der Aufrufer hält mu.
+func (r *PersonRepository) checkCapacity(s *snapshot) error {
+	if r.maxPersons > 0 && len(s.live) >= r.maxPersons {
+		return fmt.Errorf("max %d personen: %w", r.maxPersons, domain.ErrCapacityReached)
+	}
+	return nil
+}
+
+// indexOf sucht die Position von id in den nach ID sortierten persons per
+// Binärsuche oder liefert -1.
+func indexOf(persons []domain.Person, id int) int {
+	i := sort.Search(len(persons), func(i int) bool { return persons[i].ID >= id })
+	if i == len(persons) || persons[i].ID != id {
+		return -1
+	}
+	return i
+}
+
+// replaced gibt eine Kopie von persons zurück, in der Position i durch p
+// ersetzt ist; persons selbst bleibt unverändert.
+func replaced(persons []domain.Person, i int, p domain.Person) []domain.Person {
+	out := slices.Clone(persons)
+	out[i] = p
+	return out
+}
+
+// DeleteAll entfernt alle Personen; die nächste vergebene ID ist wieder 1.
+func (r *PersonRepository) DeleteAll(ctx context.Context) error {
+	if err := ctxErr(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.store(&snapshot{})
+	r.nextID = 1
+	return nil
+}
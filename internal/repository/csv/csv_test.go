@@ -1,16 +1,26 @@
 package csv
 
 import (
+	"bufio"
 	"bytes"
 	"context"
-	stdcsv "encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"assecor-assessment-backend/internal/domain"
 )
@@ -20,25 +30,38 @@ func testLogger() *zap.Logger {
 	return l
 }
 
-func tempCSV(t *testing.T, content string) string {
+// ohneZeitstempel entfernt die beim Laden gesetzten Zeitstempel für Vergleiche.
+func ohneZeitstempel(p domain.Person) domain.Person {
+	p.CreatedAt, p.UpdatedAt = time.Time{}, time.Time{}
+	return p
+}
+
+func tempCSV(t testing.TB, content string) string {
 	t.Helper()
 	path := filepath.Join(t.TempDir(), "test.csv")
 	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
 	return path
 }
 
-func parseCSVRows(t *testing.T, data []byte) [][]string {
+// readRecords liest alle Datensätze aus input mit einem recordReader.
+func readRecords(t *testing.T, input string, delimiter rune, logger *zap.Logger) (rows [][]string, lines []int, rr *recordReader) {
 	t.Helper()
-	r := stdcsv.NewReader(bytes.NewReader(data))
-	all, err := r.ReadAll()
+	rr, err := newRecordReader(strings.NewReader(input), delimiter, logger)
 	require.NoError(t, err)
-	require.NotEmpty(t, all, "mindestens eine Kopfzeile erwartet")
-	return all[1:]
+	for {
+		dto, line, err := rr.Next()
+		if err == io.EOF {
+			return rows, lines, rr
+		}
+		require.NoError(t, err)
+		rows = append(rows, dto.fields())
+		lines = append(lines, line)
+	}
 }
 
-// ─── normalizeCSV ─────────────────────────────────────────────────────────────
+// ─── recordReader ─────────────────────────────────────────────────────────────
 
-func TestNormalizeCSV(t *testing.T) {
+func TestRecordReader(t *testing.T) {
 	logger := testLogger()
 
 	tests := []struct {
@@ -96,6 +119,81 @@ func TestNormalizeCSV(t *testing.T) {
 				{"Müller", "Hans", "67742 Lauterecken", "1"},
 			},
 		},
+		{
+			name:     "abschließendes Komma erzeugt kein zusätzliches Feld",
+			input:    "Müller, Hans, 67742 Lauterecken, 1,\nPetersen, Peter, 18439 Stralsund, 2\n",
+			wantRows: 2,
+			wantCells: [][]string{
+				{"Müller", "Hans", "67742 Lauterecken", "1"},
+				{"Petersen", "Peter", "18439 Stralsund", "2"},
+			},
+		},
+		{
+			name:     "mehrere abschließende Kommas und Leerraum",
+			input:    "Müller, Hans, 67742 Lauterecken, 1, ,  ,\n",
+			wantRows: 1,
+			wantCells: [][]string{
+				{"Müller", "Hans", "67742 Lauterecken", "1"},
+			},
+		},
+		{
+			name:     "zusätzlicher Leerraum um Felder wird entfernt",
+			input:    "  Müller ,\tHans  ,   67742 Lauterecken ,  1   \n",
+			wantRows: 1,
+			wantCells: [][]string{
+				{"Müller", "Hans", "67742 Lauterecken", "1"},
+			},
+		},
+		{
+			name:     "vollständige Zeile nach unvollständigem Vorgänger",
+			input:    "Bart, Bertram,\nMüller, Hans, 67742 Lauterecken, 1,\n",
+			wantRows: 1,
+			wantCells: [][]string{
+				{"Müller", "Hans", "67742 Lauterecken", "1"},
+			},
+		},
+		{
+			name:     "Datensatz über drei Zeilen",
+			input:    "Bart,\nBertram,\n12313 Wasweißich, 1\n",
+			wantRows: 1,
+			wantCells: [][]string{
+				{"Bart", "Bertram", "12313 Wasweißich", "1"},
+			},
+		},
+		{
+			name:     "Stadt bricht auf eine dritte Zeile um",
+			input:    "Bart, Bertram, 12313\nBad\nHomburg, 1\n",
+			wantRows: 1,
+			wantCells: [][]string{
+				{"Bart", "Bertram", "12313 Bad Homburg", "1"},
+			},
+		},
+		{
+			name:     "zwei aufeinanderfolgende umbrochene Datensätze",
+			input:    "Bart, Bertram,\n12313 Wasweißich, 1\nGerber,\nGerda, 76535\nWoanders, 3\n",
+			wantRows: 2,
+			wantCells: [][]string{
+				{"Bart", "Bertram", "12313 Wasweißich", "1"},
+				{"Gerber", "Gerda", "76535 Woanders", "3"},
+			},
+		},
+		{
+			name:     "umbrochener Datensatz direkt vor normalem",
+			input:    "Bart, Bertram, 12313\nWasweißich, 1\nMüller, Hans, 67742 Lauterecken, 1\n",
+			wantRows: 2,
+			wantCells: [][]string{
+				{"Bart", "Bertram", "12313 Wasweißich", "1"},
+				{"Müller", "Hans", "67742 Lauterecken", "1"},
+			},
+		},
+		{
+			name:     "Datei endet mitten im Datensatz",
+			input:    "Müller, Hans, 67742 Lauterecken, 1\nBart, Bertram,\n12313 Wasweißich\n",
+			wantRows: 1,
+			wantCells: [][]string{
+				{"Müller", "Hans", "67742 Lauterecken", "1"},
+			},
+		},
 		{
 			name:     "leere Eingabe erzeugt keine Datenzeilen",
 			input:    "",
@@ -105,9 +203,7 @@ func TestNormalizeCSV(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out, err := normalizeCSV([]byte(tt.input), logger)
-			require.NoError(t, err)
-			rows := parseCSVRows(t, out)
+			rows, _, _ := readRecords(t, tt.input, defaultDelimiter, logger)
 			assert.Len(t, rows, tt.wantRows)
 			for i, want := range tt.wantCells {
 				require.Less(t, i, len(rows))
@@ -117,19 +213,143 @@ func TestNormalizeCSV(t *testing.T) {
 	}
 }
 
+func TestRecordReader_Semikolon(t *testing.T) {
+	input := "Meyer, Dr.; Anna; 10115 Berlin; 4\nBart; Bertram; \n12313 Wasweißich; 1\n"
+	rows, _, _ := readRecords(t, input, ';', testLogger())
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"Meyer, Dr.", "Anna", "10115 Berlin", "4"}, rows[0])
+	assert.Equal(t, []string{"Bart", "Bertram", "12313 Wasweißich", "1"}, rows[1])
+}
+
+func TestRecordReader_FelderInAnfuehrungszeichen(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  [][]string
+	}{
+		{
+			name:  "Komma im Nachnamen",
+			input: "\"Meyer, Dr.\", Anna, 10115 Berlin, 4\n",
+			want:  [][]string{{"Meyer, Dr.", "Anna", "10115 Berlin", "4"}},
+		},
+		{
+			name:  "Komma in der Stadt",
+			input: "Schulz, Eva, \"15230 Frankfurt, Oder\", 2\n",
+			want:  [][]string{{"Schulz", "Eva", "15230 Frankfurt, Oder", "2"}},
+		},
+		{
+			name:  "maskierte Anführungszeichen",
+			input: "\"Meyer \"\"Doc\"\"\", Anna, 10115 Berlin, 4\n",
+			want:  [][]string{{"Meyer \"Doc\"", "Anna", "10115 Berlin", "4"}},
+		},
+		{
+			name:  "Anführungszeichen im mehrzeiligen Datensatz",
+			input: "\"Meyer, Dr.\", Anna,\n\"15230 Frankfurt, Oder\", 4\nMüller, Hans, 67742 Lauterecken, 1\n",
+			want: [][]string{
+				{"Meyer, Dr.", "Anna", "15230 Frankfurt, Oder", "4"},
+				{"Müller", "Hans", "67742 Lauterecken", "1"},
+			},
+		},
+		{
+			name:  "UTF-8-BOM am Dateianfang",
+			input: "\uFEFFMüller, Hans, 67742 Lauterecken, 1\n",
+			want:  [][]string{{"Müller", "Hans", "67742 Lauterecken", "1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, _, _ := readRecords(t, tt.input, defaultDelimiter, testLogger())
+			assert.Equal(t, tt.want, rows)
+		})
+	}
+}
+
+func TestRecordReader_OffenesAnfuehrungszeichenWirdGemeldet(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	input := "Müller, Hans, 67742 Lauterecken, 1\n\"Meyer, Anna, 10115 Berlin, 4\nPetersen, Peter, 18439 Stralsund, 2\n"
+
+	readRecords(t, input, defaultDelimiter, zap.New(core))
+
+	entries := logs.FilterMessage("feld in anführungszeichen umfasst mehrere zeilen").All()
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, 2, entries[0].ContextMap()["zeile"])
+}
+
+func TestLoad_FelderInAnfuehrungszeichen(t *testing.T) {
+	const data = "\uFEFF\"Meyer, Dr.\", Anna, \"15230 Frankfurt, Oder\", 4\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	p, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Meyer, Dr.", p.Lastname)
+	assert.Equal(t, "15230", p.Zipcode)
+	assert.Equal(t, "Frankfurt, Oder", p.City)
+	assert.Equal(t, domain.Color("rot"), p.Color)
+}
+
 // ─── Bug 2: Akkumulationsschutz ─────────────────────────────────────────────
 
-func TestNormalizeCSV_AkkumulationsschutzBug2(t *testing.T) {
+func TestRecordReader_AkkumulationsschutzBug2(t *testing.T) {
 	input := "A, B, C\nD, E, F\nG, H, I\nMüller, Hans, 67742 Lauterecken, 1\n"
-	out, err := normalizeCSV([]byte(input), testLogger())
-	require.NoError(t, err)
+	rows, _, _ := readRecords(t, input, defaultDelimiter, testLogger())
 
-	rows := parseCSVRows(t, out)
 	require.GreaterOrEqual(t, len(rows), 1)
 	last := rows[len(rows)-1]
 	assert.Equal(t, "Müller", last[0])
 }
 
+func TestRecordReader_Zeilennummern(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	input := "Müller, Hans, 67742 Lauterecken, 1\n" + // 1
+		"\n" + // 2
+		"Bart, Bertram,\n" + // 3
+		"12313 Wasweißich, 1\n" + // 4
+		"Kaputt,\n" + // 5: verworfen
+		"Gerber, Gerda, 76535 Woanders, 3\n" + // 6
+		"Rest, Rita\n" // 7: verworfen
+
+	_, lines, rr := readRecords(t, input, defaultDelimiter, zap.New(core))
+	assert.Equal(t, []int{1, 3, 6}, lines)
+	assert.Equal(t, []domain.SkippedRecord{
+		{Line: 5, Fields: []string{"Kaputt"}, Reason: "unvollständiger datensatz"},
+		{Line: 7, Fields: []string{"Rest", "Rita"}, Reason: "unvollständiger datensatz"},
+	}, rr.dropped)
+
+	warnings := logs.FilterLevelExact(zap.WarnLevel).All()
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "fehlerhafter vorgänger-datensatz verworfen", warnings[0].Message)
+	assert.Equal(t, "[5]", fmt.Sprint(warnings[0].ContextMap()["zeilen"]))
+	assert.Equal(t, "unvollständiger datensatz am dateiende wird verworfen", warnings[1].Message)
+	assert.Equal(t, "[7]", fmt.Sprint(warnings[1].ContextMap()["zeilen"]))
+}
+
+func TestLoad_UngueltigerDatensatzMeldetQuellzeile(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	const data = "Bart, Bertram,\n12313 Wasweißich, 1\nA, B, 11111 X, 99\n"
+	_, err := NewPersonRepository(tempCSV(t, data), 0, zap.New(core))
+	require.NoError(t, err)
+
+	skipped := logs.FilterMessage("ungültiger datensatz wird übersprungen").All()
+	require.Len(t, skipped, 1)
+	assert.EqualValues(t, 2, skipped[0].ContextMap()["datensatz"])
+	assert.EqualValues(t, 3, skipped[0].ContextMap()["zeile"])
+}
+
+func TestRecordReader_ZusammenfuehrungLoggtAufDebug(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	input := "Bart, Bertram, \n12313 Wasweißich, 1\nMüller, Hans, 67742 Lauterecken, 1,\n"
+
+	readRecords(t, input, defaultDelimiter, zap.New(core))
+
+	assert.Zero(t, logs.FilterLevelExact(zap.WarnLevel).Len())
+	merged := logs.FilterMessage("mehrzeiliger datensatz zusammengeführt")
+	require.Equal(t, 1, merged.Len())
+	assert.Equal(t, zap.DebugLevel, merged.All()[0].Level)
+}
+
 // ─── toPerson ─────────────────────────────────────────────────────────────────
 
 func TestToPerson(t *testing.T) {
@@ -212,7 +432,7 @@ func TestLoad(t *testing.T) {
 			wantLen: 2,
 			wantFirst: domain.Person{
 				ID: 1, Name: "Hans", Lastname: "Müller",
-				Zipcode: "67742", City: "Lauterecken", Color: "blau",
+				Zipcode: "67742", City: "Lauterecken", Color: "blau", Version: 1,
 			},
 		},
 		{
@@ -221,7 +441,7 @@ func TestLoad(t *testing.T) {
 			wantLen: 1,
 			wantFirst: domain.Person{
 				ID: 1, Name: "Bertram", Lastname: "Bart",
-				Zipcode: "12313", City: "Wasweißich", Color: "blau",
+				Zipcode: "12313", City: "Wasweißich", Color: "blau", Version: 1,
 			},
 		},
 		{
@@ -235,7 +455,7 @@ func TestLoad(t *testing.T) {
 			wantLen: 1,
 			wantFirst: domain.Person{
 				ID: 2, Name: "Hans", Lastname: "Müller",
-				Zipcode: "67742", City: "Lauterecken", Color: "blau",
+				Zipcode: "67742", City: "Lauterecken", Color: "blau", Version: 1,
 			},
 		},
 	}
@@ -249,116 +469,1064 @@ func TestLoad(t *testing.T) {
 			require.NoError(t, err)
 			assert.Len(t, all, tt.wantLen)
 			if tt.wantLen > 0 {
-				assert.Equal(t, tt.wantFirst, all[0])
+				assert.Equal(t, tt.wantFirst, ohneZeitstempel(all[0]))
 			}
 		})
 	}
 }
 
+func TestLoad_SemikolonGetrennt(t *testing.T) {
+	const data = "Müller; Hans; 67742 Lauterecken; 1\nPetersen; Peter; 18439 Stralsund; 2;\nBart; Bertram;\n12313 Wasweißich; 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger(), WithDelimiter(';'))
+	require.NoError(t, err)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, domain.Person{
+		ID: 1, Name: "Hans", Lastname: "Müller",
+		Zipcode: "67742", City: "Lauterecken", Color: "blau", Version: 1,
+	}, ohneZeitstempel(all[0]))
+	assert.Equal(t, "12313", all[2].Zipcode)
+	assert.Equal(t, "Wasweißich", all[2].City)
+}
+
+func TestLoad_EigenerFarbsatz(t *testing.T) {
+	require.NoError(t, domain.LoadColors(domain.DefaultColorSpec+",8:orange"))
+	t.Cleanup(func() { require.NoError(t, domain.LoadColors(domain.DefaultColorSpec)) })
+
+	const data = "Müller, Hans, 67742 Lauterecken, 8\nPetersen, Peter, 18439 Stralsund, 9\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, domain.Color("orange"), all[0].Color)
+}
+
 func TestLoad_DateiNichtGefunden(t *testing.T) {
 	_, err := NewPersonRepository("/nicht/vorhanden/path.csv", 0, testLogger())
 	require.Error(t, err)
 }
 
-// ─── GetByID ──────────────────────────────────────────────────────────────────
+func TestLoad_FehlendeDateiLeerErlaubt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fehlt.csv")
+	core, logs := observer.New(zap.WarnLevel)
 
-func TestGetByID(t *testing.T) {
-	const data = "Müller, Hans, 67742 Lauterecken, 1\nPetersen, Peter, 18439 Stralsund, 2\n"
-	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	repo, err := NewPersonRepository(path, 0, zap.New(core), WithAllowMissing(true))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	assert.Equal(t, 1, logs.FilterMessage("csv-datei fehlt, start mit leerem bestand").Len())
+	report := repo.LoadReport()
+	assert.Equal(t, path, report.Source)
+	assert.Zero(t, report.Loaded)
+
+	added, err := repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added.ID)
+
+	_, err = repo.Reload(ctx)
+	require.ErrorIs(t, err, fs.ErrNotExist, "reload ohne datei scheitert weiterhin")
+	all, err = repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1, "der bestand bleibt beim fehlgeschlagenen reload erhalten")
+}
+
+func TestLoad_FehlendeDateiErlaubtKeineLesefehler(t *testing.T) {
+	// Ein Verzeichnis lässt sich öffnen, aber nicht lesen – anders als eine
+	// fehlende Datei ist das ein echter Fehler.
+	_, err := NewPersonRepository(t.TempDir(), 0, testLogger(), WithAllowMissing(true))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, fs.ErrNotExist)
+}
+
+// ─── PersonRepository – Laden über URL und stdin ──────────────────────────────
+
+func TestLoad_URL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "Müller, Hans, 67742 Lauterecken, 1\n")
+	}))
+	defer srv.Close()
+
+	repo, err := NewPersonRepository(srv.URL+"/persons.csv", 0, testLogger())
+	require.NoError(t, err)
+
+	p, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Hans", p.Name)
+}
+
+func TestLoad_URLNicht200(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := NewPersonRepository(srv.URL+"/fehlt.csv", 0, testLogger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestLoad_URLTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, err := NewPersonRepository(srv.URL, 0, testLogger(), WithFetchTimeout(50*time.Millisecond))
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestLoad_Stdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	orig := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = orig; _ = r.Close() })
+
+	_, err = io.WriteString(w, "Müller, Hans, 67742 Lauterecken, 1\nPetersen, Peter, 18439 Stralsund, 2\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	repo, err := NewPersonRepository("-", 0, testLogger())
+	require.NoError(t, err)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+// ─── Strikter Modus und Ladebericht ───────────────────────────────────────────
+
+// ungueltigeFarbeCSV enthält eine gültige Zeile, eine mit unbekannter Farb-ID
+// und ein unvollständiges Fragment am Dateiende.
+const ungueltigeFarbeCSV = "Müller, Hans, 67742 Lauterecken, 1\n" +
+	"A, B, 11111 X, 99\n" +
+	"Petersen, Peter, 18439 Stralsund, 2\n" +
+	"Rest, Rita\n"
+
+func TestLoad_StrictSchlaegtFehl(t *testing.T) {
+	_, err := NewPersonRepository(tempCSV(t, ungueltigeFarbeCSV), 0, testLogger(), WithStrict(true))
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+	assert.Contains(t, err.Error(), "2 ungültige datensätze, erster in zeile 2")
+	assert.Contains(t, err.Error(), "99")
+
+	_, err = NewPersonRepository(tempCSV(t, "Müller, Hans, 67742 Lauterecken, 1\n"), 0, testLogger(), WithStrict(true))
+	require.NoError(t, err, "gültige datei lädt auch im strikten modus")
+}
+
+func TestLoadReport_Nachsichtig(t *testing.T) {
+	path := tempCSV(t, ungueltigeFarbeCSV)
+	repo, err := NewPersonRepository(path, 0, testLogger())
 	require.NoError(t, err)
 
+	report := repo.LoadReport()
+	assert.Equal(t, path, report.Source)
+	assert.False(t, report.LoadedAt.IsZero())
+	assert.Equal(t, 2, report.Loaded)
+	require.Len(t, report.Skipped, 2)
+	assert.Equal(t, 2, report.Skipped[0].Line)
+	assert.Equal(t, []string{"A", "B", "11111 X", "99"}, report.Skipped[0].Fields)
+	assert.Contains(t, report.Skipped[0].Reason, "farb-id 99")
+	assert.Equal(t, domain.SkippedRecord{Line: 4, Fields: []string{"Rest", "Rita"}, Reason: "unvollständiger datensatz"}, report.Skipped[1])
+
+	report.Skipped[0].Line = 42
+	assert.Equal(t, 2, repo.LoadReport().Skipped[0].Line, "bericht ist eine kopie")
+}
+
+func TestLoadReport_OhneVerworfeneDatensaetze(t *testing.T) {
+	repo, err := NewPersonRepository(tempCSV(t, "Müller, Hans, 67742 Lauterecken, 1\n"), 0, testLogger())
+	require.NoError(t, err)
+
+	report := repo.LoadReport()
+	assert.Equal(t, 1, report.Loaded)
+	assert.NotNil(t, report.Skipped)
+	assert.Empty(t, report.Skipped)
+}
+
+// kapazitaetCSV enthält drei gültige Datensätze und einen ungültigen, der
+// nicht zur Kapazitätsgrenze zählt.
+const kapazitaetCSV = "Müller, Hans, 67742 Lauterecken, 1\n" +
+	"A, B, 11111 X, 99\n" +
+	"Petersen, Peter, 18439 Stralsund, 2\n" +
+	"Johnson, Johnny, 88888 made up, 3\n"
+
+func TestLoad_Zusammenfassung(t *testing.T) {
 	tests := []struct {
-		name     string
-		id       int
-		wantName string
-		wantErr  error
+		name          string
+		content       string
+		wantLevel     zapcore.Level
+		wantLoaded    int64
+		wantVerworfen int64
 	}{
-		{"erste Person", 1, "Hans", nil},
-		{"zweite Person", 2, "Peter", nil},
-		{"nicht vorhanden", 999, "", domain.ErrNotFound},
+		{"fehlerfrei", "A, B, 11111 X, 1\n", zap.InfoLevel, 1, 0},
+		{"mit verworfenen", "A, B, 11111 X, 1\nC, D, 22222 Y, 99\nkaputt\n", zap.WarnLevel, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			repo, err := NewPersonRepository(tempCSV(t, tt.content), 0, zap.New(core))
+			require.NoError(t, err)
+
+			summary := logs.FilterMessage("personen aus CSV geladen").All()
+			require.Len(t, summary, 1)
+			assert.Equal(t, tt.wantLevel, summary[0].Level)
+			assert.Equal(t, tt.wantLoaded, summary[0].ContextMap()["anzahl"])
+			assert.Equal(t, tt.wantVerworfen, summary[0].ContextMap()["verworfen"])
+			assert.Len(t, repo.LoadReport().Skipped, int(tt.wantVerworfen))
+		})
 	}
+}
 
+func TestLoad_MaxPersons(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxPersons   int
+		wantLoaded   int
+		wantOverflow int
+	}{
+		{"unbegrenzt", 0, 3, 0},
+		{"genau an der grenze", 3, 3, 0},
+		{"eine über der grenze", 2, 2, 1},
+		{"zwei über der grenze", 1, 1, 2},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p, err := repo.GetByID(context.Background(), tt.id)
-			if tt.wantErr != nil {
-				require.ErrorIs(t, err, tt.wantErr)
+			core, logs := observer.New(zap.WarnLevel)
+			repo, err := NewPersonRepository(tempCSV(t, kapazitaetCSV), tt.maxPersons, zap.New(core))
+			require.NoError(t, err)
+
+			all, err := repo.GetAll(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, all, tt.wantLoaded)
+			assert.Equal(t, 1, all[0].ID, "die ersten datensätze werden geladen")
+			report := repo.LoadReport()
+			assert.Equal(t, tt.wantLoaded, report.Loaded)
+			assert.Equal(t, tt.wantOverflow, report.Overflow)
+			assert.Len(t, report.Skipped, 1)
+
+			warnings := logs.FilterMessage("kapazitätsgrenze erreicht, überzählige datensätze wurden nicht geladen").All()
+			if tt.wantOverflow == 0 {
+				assert.Empty(t, warnings)
 				return
 			}
-			require.NoError(t, err)
-			assert.Equal(t, tt.wantName, p.Name)
+			require.Len(t, warnings, 1)
+			assert.EqualValues(t, tt.wantOverflow, warnings[0].ContextMap()["nicht_geladen"])
 		})
 	}
 }
 
-// ─── GetByColor ───────────────────────────────────────────────────────────────
+func TestLoad_MaxPersonsUeberlaufAlsFehler(t *testing.T) {
+	for name, opt := range map[string]Option{"overflow-error": WithOverflowError(true), "strikt": WithStrict(true)} {
+		t.Run(name, func(t *testing.T) {
+			const data = "Müller, Hans, 67742 Lauterecken, 1\nPetersen, Peter, 18439 Stralsund, 2\n"
+			_, err := NewPersonRepository(tempCSV(t, data), 1, testLogger(), opt)
+			require.ErrorIs(t, err, domain.ErrCapacityReached)
+			assert.Contains(t, err.Error(), "datei enthält 2 gültige datensätze, erlaubt sind max 1 personen")
 
-func TestGetByColor(t *testing.T) {
-	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
-	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
-	require.NoError(t, err)
+			_, err = NewPersonRepository(tempCSV(t, data), 2, testLogger(), opt)
+			require.NoError(t, err, "genau an der grenze")
+		})
+	}
+}
+
+// ─── Unbekannte Farb-IDs ──────────────────────────────────────────────────────
 
+func TestParseUnknownColorPolicy(t *testing.T) {
 	tests := []struct {
-		name    string
-		color   string
-		wantLen int
+		input   string
+		want    UnknownColorPolicy
+		wantErr bool
 	}{
-		{"zwei Treffer für blau", "blau", 2},
-		{"ein Treffer für grün", "grün", 1},
-		{"kein Treffer liefert leeres Slice (nicht nil)", "rot", 0},
+		{input: "skip", want: UnknownColorPolicy{Action: UnknownColorSkip}},
+		{input: "error", want: UnknownColorPolicy{Action: UnknownColorError}},
+		{input: "default:rot", want: UnknownColorPolicy{Action: UnknownColorDefault, Fallback: "rot"}},
+		{input: "default:Gruen", want: UnknownColorPolicy{Action: UnknownColorDefault, Fallback: "grün"}},
+		{input: "default:", wantErr: true},
+		{input: "default:magenta", wantErr: true},
+		{input: "skip:rot", wantErr: true},
+		{input: "ignore", wantErr: true},
+		{input: "", wantErr: true},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			persons, err := repo.GetByColor(context.Background(), tt.color)
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseUnknownColorPolicy(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
 			require.NoError(t, err)
-			assert.NotNil(t, persons)
-			assert.Len(t, persons, tt.wantLen)
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
 
-// ─── Add + Kapazitätsgrenze ───────────────────────────────────────────────────
-
-func TestAdd(t *testing.T) {
-	const data = "A, B, 11111 X, 1\n"
-	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+func TestLoad_UnbekannteFarbeMitErsatzfarbe(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	policy := UnknownColorPolicy{Action: UnknownColorDefault, Fallback: "weiß"}
+	repo, err := NewPersonRepository(tempCSV(t, ungueltigeFarbeCSV), 0, zap.New(core), WithUnknownColor(policy))
 	require.NoError(t, err)
 
-	created, err := repo.Add(context.Background(), domain.Person{
-		Name: "Neu", Lastname: "Person", Color: "rot",
-	})
+	p, err := repo.GetByID(context.Background(), 2)
 	require.NoError(t, err)
-	assert.Equal(t, 2, created.ID)
+	assert.Equal(t, "A", p.Lastname)
+	assert.Equal(t, "B", p.Name)
+	assert.Equal(t, domain.Color("weiß"), p.Color)
 
-	all, _ := repo.GetAll(context.Background())
-	assert.Len(t, all, 2)
+	report := repo.LoadReport()
+	assert.Equal(t, 3, report.Loaded)
+	require.Len(t, report.Skipped, 1, "nur das unvollständige fragment wird verworfen")
+
+	entries := logs.FilterMessage("unbekannte farb-id, ersatzfarbe wird verwendet").All()
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, 2, entries[0].ContextMap()["zeile"])
+	assert.Equal(t, "weiß", entries[0].ContextMap()["farbe"])
 }
 
-func TestAdd_KapazitaetsgrenzExploit3(t *testing.T) {
-	const data = "A, B, 11111 X, 1\n"
-	repo, err := NewPersonRepository(tempCSV(t, data), 2, testLogger())
-	require.NoError(t, err)
+func TestLoad_NichtNumerischeFarbeOhneErsatzfarbe(t *testing.T) {
+	const data = "Müller, Hans, 67742 Lauterecken, 1\n" +
+		"A, B, 11111 X, blau\n" +
+		"Petersen, Peter, 18439 Stralsund, 2\n"
 
-	_, err = repo.Add(context.Background(), domain.Person{Name: "N", Lastname: "P", Color: "rot"})
-	require.NoError(t, err)
+	for _, policy := range []UnknownColorPolicy{
+		{Action: UnknownColorDefault, Fallback: "weiß"},
+		{Action: UnknownColorError},
+	} {
+		t.Run(policy.Action, func(t *testing.T) {
+			repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger(), WithUnknownColor(policy))
+			require.NoError(t, err, "keine unbekannte farbe, sondern ein ungültiger datensatz")
 
-	_, err = repo.Add(context.Background(), domain.Person{Name: "Z", Lastname: "Q", Color: "blau"})
-	require.ErrorIs(t, err, domain.ErrCapacityReached)
+			report := repo.LoadReport()
+			assert.Equal(t, 2, report.Loaded)
+			require.Len(t, report.Skipped, 1)
+			assert.Equal(t, 2, report.Skipped[0].Line)
+			assert.Contains(t, report.Skipped[0].Reason, `ungültige farb-id "blau"`)
+		})
+	}
 }
 
-func TestAdd_KeineIDKollisionNachUebersprungeneEintraege(t *testing.T) {
-	const data = "A, B, 11111 X, 99\nMüller, Hans, 67742 Lauterecken, 1\n"
+func TestLoad_UnbekannteFarbeBrichtAb(t *testing.T) {
+	policy := UnknownColorPolicy{Action: UnknownColorError}
+	_, err := NewPersonRepository(tempCSV(t, ungueltigeFarbeCSV), 0, testLogger(), WithUnknownColor(policy))
+	require.ErrorIs(t, err, domain.ErrUnknownColor)
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+	assert.Contains(t, err.Error(), "datensatz in zeile 2: unbekannte farb-id 99")
+}
+
+// ─── GetByID ──────────────────────────────────────────────────────────────────
+
+func TestGetByID(t *testing.T) {
+	const data = "Müller, Hans, 67742 Lauterecken, 1\nPetersen, Peter, 18439 Stralsund, 2\n"
 	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
 	require.NoError(t, err)
 
-	created, err := repo.Add(context.Background(), domain.Person{
-		Name: "Neu", Lastname: "Person", Color: "rot",
-	})
+	tests := []struct {
+		name     string
+		id       int
+		wantName string
+		wantErr  error
+	}{
+		{"erste Person", 1, "Hans", nil},
+		{"zweite Person", 2, "Peter", nil},
+		{"nicht vorhanden", 999, "", domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := repo.GetByID(context.Background(), tt.id)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, p.Name)
+		})
+	}
+}
+
+func TestGetAllStream(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	var ids []int
+	err = repo.GetAllStream(context.Background(), func(p domain.Person) error {
+		ids = append(ids, p.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+}
+
+func TestGetAllStream_KontextAbbruch(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 3\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err = repo.GetAllStream(ctx, func(domain.Person) error {
+		calls++
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestGetByIDs(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	persons, err := repo.GetByIDs(context.Background(), []int{3, 99, 1})
+	require.NoError(t, err)
+	got := make([]int, 0, len(persons))
+	for _, p := range persons {
+		got = append(got, p.ID)
+	}
+	assert.ElementsMatch(t, []int{1, 3}, got)
+
+	none, err := repo.GetByIDs(context.Background(), []int{42})
+	require.NoError(t, err)
+	assert.NotNil(t, none)
+	assert.Empty(t, none)
+}
+
+func TestGetAllAfter(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		afterID int
+		limit   int
+		want    []int
+	}{
+		{"erste seite", 0, 2, []int{1, 2}},
+		{"folgeseite", 2, 2, []int{3}},
+		{"hinter dem ende leer", 3, 2, []int{}},
+		{"limit 0 bedeutet unbegrenzt", 1, 0, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := repo.GetAllAfter(context.Background(), tt.afterID, tt.limit)
+			require.NoError(t, err)
+			require.NotNil(t, persons)
+			got := make([]int, 0, len(persons))
+			for _, p := range persons {
+				got = append(got, p.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// ─── GetByColor ───────────────────────────────────────────────────────────────
+
+func TestGetByColor(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		color         domain.Color
+		limit, offset int
+		wantIDs       []int
+	}{
+		{"zwei Treffer für blau", "blau", 0, 0, []int{1, 3}},
+		{"ein Treffer für grün", "grün", 0, 0, []int{2}},
+		{"kein Treffer liefert leeres Slice (nicht nil)", "rot", 0, 0, []int{}},
+		{"limit begrenzt", "blau", 1, 0, []int{1}},
+		{"offset überspringt", "blau", 0, 1, []int{3}},
+		{"seite hinter dem letzten Treffer ist leer", "blau", 1, 2, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := repo.GetByColor(context.Background(), tt.color, domain.Order{}, tt.limit, tt.offset)
+			require.NoError(t, err)
+			assert.NotNil(t, persons)
+			got := make([]int, len(persons))
+			for i, p := range persons {
+				got[i] = p.ID
+			}
+			assert.Equal(t, tt.wantIDs, got)
+		})
+	}
+}
+
+// ─── Find ─────────────────────────────────────────────────────────────────────
+
+func TestFind(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		filter  domain.PersonFilter
+		wantIDs []int
+	}{
+		{"ohne filter", domain.PersonFilter{}, []int{1, 2, 3}},
+		{"nach farbe", domain.PersonFilter{Color: "blau"}, []int{1, 3}},
+		{"farbe mit limit", domain.PersonFilter{Color: "blau", Limit: 1}, []int{1}},
+		{"farbe mit offset", domain.PersonFilter{Color: "blau", Offset: 1}, []int{3}},
+		{"limit und offset", domain.PersonFilter{Limit: 1, Offset: 1}, []int{2}},
+		{"offset hinter dem ende", domain.PersonFilter{Offset: 10}, []int{}},
+		{"kein treffer", domain.PersonFilter{Color: "rot"}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := repo.Find(context.Background(), tt.filter)
+			require.NoError(t, err)
+			require.NotNil(t, persons)
+			ids := make([]int, 0, len(persons))
+			for _, p := range persons {
+				ids = append(ids, p.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		filter domain.PersonFilter
+		want   int
+	}{
+		{"ohne filter", domain.PersonFilter{}, 3},
+		{"nach farbe", domain.PersonFilter{Color: "blau"}, 2},
+		{"limit und offset werden ignoriert", domain.PersonFilter{Color: "blau", Limit: 1, Offset: 1}, 2},
+		{"kein treffer", domain.PersonFilter{Color: "rot"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := repo.Count(context.Background(), tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, n)
+		})
+	}
+}
+
+func TestFind_CreatedAfter(t *testing.T) {
+	const data = "A, B, 11111 X, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	later := time.Now().Add(time.Hour).UTC()
+	created, err := repo.Add(context.Background(), domain.Person{
+		Name: "Neu", Lastname: "Person", Color: "rot", CreatedAt: later, UpdatedAt: later,
+	})
+	require.NoError(t, err)
+
+	persons, err := repo.Find(context.Background(), domain.PersonFilter{CreatedAfter: later.Add(-time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, persons, 1)
+	assert.Equal(t, created.ID, persons[0].ID)
+	assert.True(t, later.Equal(persons[0].CreatedAt))
+}
+
+func TestLoad_ZeitstempelAufLadezeitpunkt(t *testing.T) {
+	before := time.Now()
+	repo, err := NewPersonRepository(tempCSV(t, "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"), 0, testLogger())
+	require.NoError(t, err)
+	after := time.Now()
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	for _, p := range all {
+		assert.False(t, p.CreatedAt.Before(before.Truncate(time.Microsecond)), "id %d", p.ID)
+		assert.False(t, p.CreatedAt.After(after), "id %d", p.ID)
+		assert.Equal(t, p.CreatedAt, p.UpdatedAt)
+	}
+	assert.Equal(t, all[0].CreatedAt, all[1].CreatedAt, "alle datensätze teilen den ladezeitpunkt")
+}
+
+func TestDistinctCities(t *testing.T) {
+	const data = "Müller, Hans, 67742 Lauterecken, 1\n" +
+		"Petersen, Peter, 18439 Stralsund, 2\n" +
+		"Meyer, Anna, 18439 Stralsund, 3\n" +
+		"Ohne, Stadt, 11111, 4\n" +
+		"Bald, Weg, 10115 Berlin, 5\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, repo.Delete(ctx, 5, time.Now()))
+
+	cities, err := repo.DistinctCities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Lauterecken", "Stralsund"}, cities)
+
+	empty, err := NewPersonRepository(tempCSV(t, ""), 0, testLogger())
+	require.NoError(t, err)
+	cities, err = empty.DistinctCities(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, cities)
+	assert.Empty(t, cities)
+}
+
+// ─── Add + Kapazitätsgrenze ───────────────────────────────────────────────────
+
+func TestAdd(t *testing.T) {
+	const data = "A, B, 11111 X, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	created, err := repo.Add(context.Background(), domain.Person{
+		Name: "Neu", Lastname: "Person", Color: "rot",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, created.ID)
+
+	all, _ := repo.GetAll(context.Background())
+	assert.Len(t, all, 2)
+}
+
+func TestAdd_KapazitaetsgrenzExploit3(t *testing.T) {
+	const data = "A, B, 11111 X, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 2, testLogger())
+	require.NoError(t, err)
+
+	_, err = repo.Add(context.Background(), domain.Person{Name: "N", Lastname: "P", Color: "rot"})
+	require.NoError(t, err)
+
+	_, err = repo.Add(context.Background(), domain.Person{Name: "Z", Lastname: "Q", Color: "blau"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+}
+
+func TestCapacity(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 4, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	assertCapacity := func(wantUsed int) {
+		t.Helper()
+		used, max, err := repo.Capacity(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, wantUsed, used)
+		assert.Equal(t, 4, max)
+	}
+
+	assertCapacity(3)
+	_, err = repo.Add(ctx, domain.Person{Name: "Noch", Lastname: "Einer", Color: "rot"})
+	require.NoError(t, err)
+	assertCapacity(4)
+	_, err = repo.Add(ctx, domain.Person{Name: "Zu", Lastname: "Viel", Color: "blau"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+	assertCapacity(4)
+	require.NoError(t, repo.Delete(ctx, 1, time.Now()))
+	assertCapacity(3)
+
+	unbegrenzt, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	used, max, err := unbegrenzt.Capacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, used)
+	assert.Zero(t, max, "0 bedeutet unbegrenzt")
+}
+
+func TestAdd_KeineIDKollisionNachUebersprungeneEintraege(t *testing.T) {
+	const data = "A, B, 11111 X, 99\nMüller, Hans, 67742 Lauterecken, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	created, err := repo.Add(context.Background(), domain.Person{
+		Name: "Neu", Lastname: "Person", Color: "rot",
+	})
 	require.NoError(t, err)
 	assert.Equal(t, 3, created.ID)
 }
 
+func TestInsert_VorgegebeneID(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	inserted, err := repo.Insert(ctx, domain.Person{ID: 10, Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 10, inserted.ID)
+	assert.Equal(t, 1, inserted.Version)
+
+	_, err = repo.Insert(ctx, domain.Person{ID: 2, Name: "X", Lastname: "Y", Color: "rot"})
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 2, conflict.ID)
+
+	added, err := repo.Add(ctx, domain.Person{Name: "Danach", Lastname: "Person", Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, 11, added.ID, "add vergibt ids oberhalb der vorgegebenen")
+
+	_, err = repo.Insert(ctx, domain.Person{ID: 5, Name: "Lücke", Lastname: "Person", Color: "grün"})
+	require.NoError(t, err)
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	got := make([]int, 0, len(all))
+	for _, p := range all {
+		got = append(got, p.ID)
+	}
+	assert.Equal(t, []int{1, 2, 5, 10, 11}, got, "reihenfolge nach id bleibt erhalten")
+}
+
+func TestLoad_IDsMitVerstreutenUngueltigenEintraegen(t *testing.T) {
+	const data = "A, B, 11111 X, 99\n" + // 1: ungültige Farb-ID
+		"Müller, Hans, 67742 Lauterecken, 1\n" + // 2
+		"C, D, 22222 Y, abc\n" + // 3: ungültige Farb-ID
+		"E, F, 33333 Z, 0\n" + // 4: ungültige Farb-ID
+		"Petersen, Peter, 18439 Stralsund, 2\n" + // 5
+		"G, H, 44444 W, 42\n" // 6: ungültige Farb-ID
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, 2, all[0].ID)
+	assert.Equal(t, 5, all[1].ID)
+
+	for _, id := range []int{1, 3, 4, 6} {
+		_, err := repo.GetByID(context.Background(), id)
+		assert.ErrorIs(t, err, domain.ErrNotFound, "id %d", id)
+	}
+
+	// Die nächste ID folgt der höchsten gültigen, nicht der Anzahl der Datensätze.
+	created, err := repo.Add(context.Background(), domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 6, created.ID)
+
+	// Erneutes Laden derselben Datei ergibt dieselben IDs.
+	reloaded, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	p, err := reloaded.GetByID(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, "Peter", p.Name)
+}
+
+// ─── Update ───────────────────────────────────────────────────────────────────
+
+func TestUpdate_Versionen(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	before, err := repo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, 1, before.Version)
+
+	change := before
+	change.City = "Rostock"
+	change.CreatedAt = time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC) // wird ignoriert
+	updated, err := repo.Update(ctx, change, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Rostock", updated.City)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, before.CreatedAt, updated.CreatedAt)
+
+	_, err = repo.Update(ctx, change, 1)
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 2, conflict.Current)
+
+	_, err = repo.Update(ctx, domain.Person{ID: 99, Name: "X", Lastname: "Y", Color: "rot"}, 1)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+// Mehrere Schreiber lesen dieselbe Version und ändern gleichzeitig. Genau einer
+// darf gewinnen; ein parallel laufender Stream sieht nur vollständige Stände.
+func TestUpdate_VerlorenesUpdateParallel(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	read, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+
+	const writers = 8
+	errs := make(chan error, writers)
+	for i := range writers {
+		go func() {
+			change := read
+			change.City = fmt.Sprintf("stadt-%d", i)
+			_, err := repo.Update(ctx, change, read.Version)
+			errs <- err
+		}()
+	}
+	go func() {
+		_ = repo.GetAllStream(ctx, func(domain.Person) error { return nil })
+	}()
+
+	won := 0
+	for range writers {
+		err := <-errs
+		if err == nil {
+			won++
+			continue
+		}
+		require.ErrorIs(t, err, domain.ErrVersionConflict)
+	}
+	assert.Equal(t, 1, won)
+
+	final, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, final.Version)
+}
+
+// ─── Vorläufiges Löschen ──────────────────────────────────────────────────────
+
+func TestDelete_Vorlaeufig(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Delete(ctx, 1, at))
+	require.ErrorIs(t, repo.Delete(ctx, 1, at), domain.ErrNotFound, "bereits gelöscht")
+	require.ErrorIs(t, repo.Delete(ctx, 99, at), domain.ErrNotFound)
+
+	_, err = repo.GetByID(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrGone)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.GetByID(ctx, 99)
+	require.NotErrorIs(t, err, domain.ErrGone)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	blau, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, blau, 1)
+	byIDs, err := repo.GetByIDs(ctx, []int{1, 2})
+	require.NoError(t, err)
+	assert.Len(t, byIDs, 1)
+	after, err := repo.GetAllAfter(ctx, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, after, 1)
+	assert.Equal(t, 2, after[0].ID)
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, 1, stats.ByColor["blau"])
+
+	withDeleted, err := repo.Find(ctx, domain.PersonFilter{IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, withDeleted, 3)
+	assert.True(t, at.Equal(withDeleted[0].DeletedAt))
+	n, err := repo.Count(ctx, domain.PersonFilter{Color: "blau", IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = repo.Update(ctx, domain.Person{ID: 1, Name: "X", Lastname: "Y", Color: "rot"}, 0)
+	require.ErrorIs(t, err, domain.ErrGone)
+
+	restored, err := repo.Restore(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, restored.Deleted())
+	assert.Equal(t, 3, restored.Version, "löschen und wiederherstellen zählen als änderung")
+	_, err = repo.Restore(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	got, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, restored, got)
+}
+
+func TestDelete_KapazitaetZaehltNurNichtGeloeschte(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 3, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = repo.Add(ctx, domain.Person{Name: "Zu", Lastname: "Viel", Color: "rot"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+
+	require.NoError(t, repo.Delete(ctx, 2, time.Now()))
+	_, err = repo.Add(ctx, domain.Person{Name: "Nach", Lastname: "Rücker", Color: "rot"})
+	require.NoError(t, err)
+
+	_, err = repo.Restore(ctx, 2)
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+}
+
+func TestPurge(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Delete(ctx, 1, base))
+	require.NoError(t, repo.Delete(ctx, 3, base.Add(time.Hour)))
+
+	n, err := repo.Purge(ctx, base.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = repo.GetByID(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	require.NotErrorIs(t, err, domain.ErrGone, "endgültig entfernt")
+	_, err = repo.GetByID(ctx, 3)
+	require.ErrorIs(t, err, domain.ErrGone)
+
+	created, err := repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, created.ID, "ids werden nicht erneut vergeben")
+}
+
+// ─── Kontextabbruch ───────────────────────────────────────────────────────────
+
+func TestKontextAbbruch_VorDemAufruf(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"GetAll":        func() error { _, err := repo.GetAll(ctx); return err },
+		"GetAllAfter":   func() error { _, err := repo.GetAllAfter(ctx, 0, 10); return err },
+		"GetByID":       func() error { _, err := repo.GetByID(ctx, 1); return err },
+		"GetByIDs":      func() error { _, err := repo.GetByIDs(ctx, []int{1}); return err },
+		"GetByColor":    func() error { _, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0); return err },
+		"Find":          func() error { _, err := repo.Find(ctx, domain.PersonFilter{}); return err },
+		"Count":         func() error { _, err := repo.Count(ctx, domain.PersonFilter{}); return err },
+		"CountsByColor": func() error { _, err := repo.CountsByColor(ctx); return err },
+		"Stats":         func() error { _, err := repo.Stats(ctx); return err },
+		"Add": func() error {
+			_, err := repo.Add(ctx, domain.Person{Name: "N", Lastname: "L", Color: "rot"})
+			return err
+		},
+		"Update": func() error {
+			_, err := repo.Update(ctx, domain.Person{ID: 1, Name: "N", Lastname: "L", Color: "rot"}, 0)
+			return err
+		},
+		"Delete":    func() error { return repo.Delete(ctx, 1, time.Now()) },
+		"Restore":   func() error { _, err := repo.Restore(ctx, 1); return err },
+		"Purge":     func() error { _, err := repo.Purge(ctx, time.Now()); return err },
+		"DeleteAll": func() error { return repo.DeleteAll(ctx) },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			require.ErrorIs(t, call(), context.Canceled)
+		})
+	}
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 2, "abgebrochene schreibzugriffe ändern nichts")
+}
+
+// abbruchNachCtx meldet ab dem after. Aufruf von Err einen Abbruch und
+// simuliert so einen Client, der mitten in einem Durchlauf aufgibt.
+type abbruchNachCtx struct {
+	context.Context
+	calls, after int
+}
+
+func (c *abbruchNachCtx) Err() error {
+	c.calls++
+	if c.calls >= c.after {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestKontextAbbruch_WaehrendDesDurchlaufs(t *testing.T) {
+	repo, err := NewPersonRepository(tempCSV(t, ""), 0, testLogger())
+	require.NoError(t, err)
+	for i := 0; i < 3*ctxCheckInterval; i++ {
+		_, err := repo.Add(context.Background(), domain.Person{Name: "N", Lastname: "L", Color: "blau"})
+		require.NoError(t, err)
+	}
+
+	tests := map[string]func(context.Context) error{
+		"GetByColor": func(ctx context.Context) error {
+			_, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+			return err
+		},
+		"Find": func(ctx context.Context) error {
+			_, err := repo.Find(ctx, domain.PersonFilter{Color: "blau"})
+			return err
+		},
+		"CountsByColor": func(ctx context.Context) error { _, err := repo.CountsByColor(ctx); return err },
+		"Stats":         func(ctx context.Context) error { _, err := repo.Stats(ctx); return err },
+	}
+	for name, call := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Aufruf 1 vor dem Zugriff, 2 zu Beginn des Durchlaufs, 3 nach
+			// ctxCheckInterval Personen.
+			ctx := &abbruchNachCtx{Context: context.Background(), after: 3}
+			require.ErrorIs(t, call(ctx), context.Canceled)
+			assert.Equal(t, 3, ctx.calls, "durchlauf endet bei der ersten prüfung nach dem abbruch")
+		})
+	}
+}
+
+// ─── DeleteAll ────────────────────────────────────────────────────────────────
+
+func TestDeleteByColor(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\nE, F, 33333 Z, 1\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Delete(ctx, 3, at.Add(-time.Hour)))
+
+	n, err := repo.DeleteByColor(ctx, "blau", at)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "bereits gelöschte zählen nicht")
+	n, err = repo.DeleteByColor(ctx, "rot", at)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	all, err := repo.Find(ctx, domain.PersonFilter{IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.True(t, at.Equal(all[0].DeletedAt))
+	assert.Equal(t, 2, all[0].Version)
+	assert.False(t, all[1].Deleted())
+	assert.True(t, at.Add(-time.Hour).Equal(all[2].DeletedAt), "frühere löschung bleibt")
+
+	_, err = repo.Restore(ctx, 1)
+	require.NoError(t, err)
+}
+
+func TestDeleteAll(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+
+	require.NoError(t, repo.DeleteAll(context.Background()))
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	created, err := repo.Add(context.Background(), domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.ID)
+}
+
 // ─── Integrationstest gegen echte sample-input.csv ────────────────────────────
 
 func TestLoad_SampleInputCSV(t *testing.T) {
@@ -374,17 +1542,495 @@ func TestLoad_SampleInputCSV(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, all, 10)
 
-	blau, _ := repo.GetByColor(context.Background(), "blau")
+	blau, _ := repo.GetByColor(context.Background(), "blau", domain.Order{}, 0, 0)
 	assert.Len(t, blau, 2)
 
-	gruen, _ := repo.GetByColor(context.Background(), "grün")
+	gruen, _ := repo.GetByColor(context.Background(), "grün", domain.Order{}, 0, 0)
 	assert.Len(t, gruen, 3)
 
+	counts, err := repo.CountsByColor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, counts["blau"])
+	assert.Equal(t, 3, counts["grün"])
+	assert.Zero(t, counts["weiß"])
+
+	stats, err := repo.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 10, stats.Total)
+	assert.Equal(t, map[domain.Color]int{
+		"blau": 2, "grün": 3, "violett": 2, "rot": 1, "gelb": 1, "türkis": 1,
+	}, stats.ByColor)
+	assert.Len(t, stats.ByCity, 10)
+	assert.Equal(t, 1, stats.ByCity["Schweden - ☀"])
+
 	bart, err := repo.GetByID(context.Background(), 8)
 	require.NoError(t, err)
 	assert.Equal(t, "Bart", bart.Lastname)
 	assert.Equal(t, "Bertram", bart.Name)
 	assert.Equal(t, "12313", bart.Zipcode)
 	assert.Equal(t, "Wasweißich", bart.City)
-	assert.Equal(t, "blau", bart.Color)
+	assert.Equal(t, domain.Color("blau"), bart.Color)
+}
+
+// ─── Snapshots ────────────────────────────────────────────────────────────────
+
+func TestSnapshot_ErgebnisBleibtNachSchreibzugriffenStabil(t *testing.T) {
+	const data = "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n"
+	repo, err := NewPersonRepository(tempCSV(t, data), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	before, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(before), cap(before), "append auf dem ergebnis muss kopieren")
+
+	_, err = repo.Update(ctx, domain.Person{ID: 1, Name: "Neu", Lastname: "B", Color: "blau"}, 0)
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, 2, time.Now()))
+	_, err = repo.Add(ctx, domain.Person{Name: "E", Lastname: "F", Color: "rot"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "B", before[0].Name)
+	assert.False(t, before[1].Deleted())
+	assert.Len(t, before, 2)
+
+	_ = append(before, domain.Person{ID: 99})
+	after, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, []int{after[0].ID, after[1].ID})
+}
+
+func TestSnapshot_ParallelesAddUndGetAll(t *testing.T) {
+	repo, err := NewPersonRepository(tempCSV(t, ""), 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	const writers, perWriter = 4, 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				p, err := repo.Add(ctx, domain.Person{Name: "N", Lastname: "L", Color: "blau"})
+				assert.NoError(t, err)
+				if i%10 == 0 {
+					_, err = repo.Update(ctx, domain.Person{ID: p.ID, Name: "U", Lastname: "L", Color: "rot"}, 0)
+					assert.NoError(t, err)
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	seen := 0
+	for {
+		persons, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(persons), seen, "snapshots wachsen monoton")
+		seen = len(persons)
+		for i, p := range persons {
+			assert.Equal(t, i+1, p.ID, "ids lückenlos und sortiert")
+		}
+		select {
+		case <-done:
+			persons, err := repo.GetAll(ctx)
+			require.NoError(t, err)
+			assert.Len(t, persons, writers*perWriter)
+			return
+		default:
+		}
+	}
+}
+
+// ─── Neu laden ────────────────────────────────────────────────────────────────
+
+func TestReload_ErsetztBestand(t *testing.T) {
+	path := tempCSV(t, "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n")
+	repo, err := NewPersonRepository(path, 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = repo.Add(ctx, domain.Person{Name: "E", Lastname: "F", Color: "rot"})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("G, H, 33333 Z, 3\nkaputt\n"), 0o644))
+	report, err := repo.Reload(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Loaded)
+	assert.Len(t, report.Skipped, 1)
+
+	persons, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, persons, 1, "auch hinzugefügte personen werden ersetzt")
+	assert.Equal(t, "H", persons[0].Name)
+	assert.Equal(t, report.Loaded, repo.LoadReport().Loaded)
+
+	p, err := repo.Add(ctx, domain.Person{Name: "I", Lastname: "J", Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.ID, "ids laufen nach dem neuen bestand weiter")
+}
+
+func TestReload_FehlerBehaeltAltenBestand(t *testing.T) {
+	path := tempCSV(t, "A, B, 11111 X, 1\nC, D, 22222 Y, 2\n")
+	repo, err := NewPersonRepository(path, 0, testLogger(), WithStrict(true))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(path, []byte("G, H, 33333 Z, 99\n"), 0o644))
+	_, err = repo.Reload(ctx)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	require.NoError(t, os.Remove(path))
+	_, err = repo.Reload(ctx)
+	assert.Error(t, err)
+
+	persons, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, persons, 2)
+	assert.Equal(t, 2, repo.LoadReport().Loaded)
+}
+
+func TestReload_KontextAbbruch(t *testing.T) {
+	path := tempCSV(t, "A, B, 11111 X, 1\n")
+	repo, err := NewPersonRepository(path, 0, testLogger())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("C, D, 22222 Y, 2\n", 2*ctxCheckInterval)), 0o644))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.Reload(ctx)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "csv-repository: context canceled", err.Error())
+	assert.Equal(t, 1, repo.LoadReport().Loaded, "alter bestand bleibt erhalten")
+}
+
+func TestReload_StandardeingabeNichtMoeglich(t *testing.T) {
+	repo := &PersonRepository{source: stdinPath}
+
+	_, err := repo.Reload(context.Background())
+
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestReload_LeserSehenNurVollstaendigeBestaende(t *testing.T) {
+	small, large := largeCSV(t, 10), largeCSV(t, 5000)
+	path := filepath.Join(t.TempDir(), "wechsel.csv")
+	// copyFile läuft auch in der schreibenden Goroutine und nutzt deshalb assert.
+	copyFile := func(src string) {
+		data, err := os.ReadFile(src)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(path, data, 0o644))
+	}
+	copyFile(small)
+	repo, err := NewPersonRepository(path, 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+	sizes := map[int]bool{repo.LoadReport().Loaded: true}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 0; i < 6; i++ {
+			src := large
+			if i%2 == 1 {
+				src = small
+			}
+			copyFile(src)
+			report, err := repo.Reload(ctx)
+			assert.NoError(t, err)
+			sizes[report.Loaded] = true
+		}
+	}()
+
+	var seen []int
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		persons, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		seen = append(seen, len(persons))
+	}
+	wg.Wait()
+	for _, n := range seen {
+		assert.True(t, sizes[n], "halb geladener bestand mit %d personen gesehen", n)
+	}
+}
+
+// ─── Große Dateien ────────────────────────────────────────────────────────────
+
+// largeCSV schreibt n Datensätze in eine temporäre Datei. Jeder zehnte
+// Datensatz bricht über zwei Zeilen um, jeder tausendste hat die ungültige
+// Farb-ID 99.
+func largeCSV(tb testing.TB, n int) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "large.csv")
+	f, err := os.Create(path)
+	require.NoError(tb, err)
+	w := bufio.NewWriter(f)
+	for i := 1; i <= n; i++ {
+		color := i%7 + 1
+		switch {
+		case i%1000 == 0:
+			color = 99
+		case i%10 == 0:
+			fmt.Fprintf(w, "Nachname%d, Vorname%d,\n%05d Stadt, %d\n", i, i, i%100000, color)
+			continue
+		}
+		fmt.Fprintf(w, "Nachname%d, Vorname%d, %05d Stadt, %d\n", i, i, i%100000, color)
+	}
+	require.NoError(tb, w.Flush())
+	require.NoError(tb, f.Close())
+	return path
+}
+
+func TestLoad_GrosseDatei(t *testing.T) {
+	if testing.Short() {
+		t.Skip("erzeugt eine datei mit einer million datensätzen")
+	}
+	const n = 1_000_000
+	repo, err := NewPersonRepository(largeCSV(t, n), 0, zap.NewNop())
+	require.NoError(t, err)
+
+	report := repo.LoadReport()
+	assert.Equal(t, n-n/1000, report.Loaded)
+	require.Len(t, report.Skipped, n/1000)
+	assert.Equal(t, []string{"Nachname1000", "Vorname1000", "01000 Stadt", "99"}, report.Skipped[0].Fields)
+
+	// Datensatz 20 ist über zwei Zeilen verteilt.
+	p, err := repo.GetByID(context.Background(), 20)
+	require.NoError(t, err)
+	assert.Equal(t, "Nachname20", p.Lastname)
+	assert.Equal(t, "00020", p.Zipcode)
+	assert.Equal(t, "Stadt", p.City)
+
+	p, err = repo.GetByID(context.Background(), n-1)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("Nachname%d", n-1), p.Lastname)
+	_, err = repo.GetByID(context.Background(), n)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+// zeilenQuelle erzeugt n Datensätze erst beim Lesen, jeden zehnten wie in
+// largeCSV auf zwei Zeilen verteilt, und zählt die gelieferten Bytes.
+type zeilenQuelle struct {
+	n, next int
+	buf     []byte
+	gelesen int64
+}
+
+func (q *zeilenQuelle) Read(p []byte) (int, error) {
+	for len(q.buf) == 0 {
+		if q.next >= q.n {
+			return 0, io.EOF
+		}
+		q.next++
+		sep := " "
+		if q.next%10 == 0 {
+			sep = "\n"
+		}
+		q.buf = fmt.Appendf(q.buf, "Nachname%d, Vorname%d,%s%05d Stadt, 1\n", q.next, q.next, sep, q.next%100000)
+	}
+	n := copy(p, q.buf)
+	q.buf = q.buf[n:]
+	q.gelesen += int64(n)
+	return n, nil
+}
+
+func TestRecordReader_LiestStueckweise(t *testing.T) {
+	src := &zeilenQuelle{n: 1_000_000} // rund 40 MB
+	rr, err := newRecordReader(src, ',', zap.NewNop())
+	require.NoError(t, err)
+
+	for i := 1; i <= 20; i++ {
+		dto, _, err := rr.Next()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("Nachname%d", i), dto.Lastname)
+	}
+	assert.Less(t, src.gelesen, int64(64<<10), "nur der lesepuffer darf vorauslaufen, nicht die ganze quelle")
+}
+
+// ─── Benchmarks ───────────────────────────────────────────────────────────────
+
+// benchRepo legt ein Repository mit n Personen an.
+func benchRepo(b *testing.B, n int) *PersonRepository {
+	b.Helper()
+	repo, err := NewPersonRepository(tempCSV(b, ""), 0, zap.NewNop())
+	require.NoError(b, err)
+	colors := domain.AllColors()
+	for i := 0; i < n; i++ {
+		p := domain.Person{Name: "Hans", Lastname: "Müller", City: "Lauterecken", Color: colors[i%len(colors)]}
+		_, err := repo.Add(context.Background(), p)
+		require.NoError(b, err)
+	}
+	return repo
+}
+
+func BenchmarkGetAll(b *testing.B) {
+	repo := benchRepo(b, 10_000)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.GetAll(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFind_Paginiert(b *testing.B) {
+	repo := benchRepo(b, 10_000)
+	ctx := context.Background()
+	filter := domain.PersonFilter{Limit: 20, Offset: 5_000}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.Find(ctx, filter); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAll_MitParallelemAdd(b *testing.B) {
+	repo := benchRepo(b, 10_000)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if i%100 == 0 {
+				if _, err := repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"}); err != nil {
+					b.Fatal(err)
+				}
+				continue
+			}
+			if _, err := repo.GetAll(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchSizes sind die Dateigrößen der Lade-Benchmarks. Bei linearem Aufwand
+// bleibt ns/datensatz über alle Größen etwa gleich; wächst der Wert mit der
+// Größe, ist das Zusammensetzen oder Laden überlinear geworden.
+var benchSizes = []int{10_000, 100_000}
+
+// Basiswerte in ns/datensatz, gemessen bei Einführung der Benchmarks (Intel
+// Xeon, Go 1.24, 10k und 100k Zeilen). Jeder Lauf meldet zusätzlich
+// x_basis, das Verhältnis zum Basiswert; auf vergleichbarer Hardware deutet
+// ein Wert ab etwa 1.5 auf eine Regression. Nach einer gewollten Änderung
+// werden die Basiswerte neu gemessen und hier eingetragen.
+const (
+	basisRecordReader = 800.0
+	basisLoad         = 1400.0
+)
+
+// maxWachstum begrenzt, um welchen Faktor ns/datensatz von der kleinsten zur
+// größten Größe in benchSizes steigen darf. Anders als x_basis hängt das
+// nicht von der Hardware ab; darüber ist der Aufwand überlinear und der
+// Benchmark schlägt fehl. Bei linearem Aufwand liegt der Faktor um 1.
+const maxWachstum = 3.0
+
+// benchPerRecord führt run für jede Größe aus benchSizes als eigenen
+// Benchmark aus, meldet ns/datensatz und x_basis und prüft anschließend
+// maxWachstum. run misst b.N Durchläufe über n Datensätze.
+func benchPerRecord(b *testing.B, basis float64, run func(b *testing.B, n int)) {
+	perRecord := make(map[int]float64, len(benchSizes))
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			run(b, n)
+			ns := float64(b.Elapsed().Nanoseconds()) / float64(b.N*n)
+			b.ReportMetric(ns, "ns/datensatz")
+			b.ReportMetric(ns/basis, "x_basis")
+			// Der letzte, längste Lauf jeder Größe zählt.
+			perRecord[n] = ns
+		})
+	}
+	smallest, largest := benchSizes[0], benchSizes[len(benchSizes)-1]
+	if perRecord[smallest] == 0 || perRecord[largest] == 0 {
+		return // per -bench auf eine Größe eingeschränkt
+	}
+	if growth := perRecord[largest] / perRecord[smallest]; growth > maxWachstum {
+		b.Errorf("ns/datensatz steigt von %d auf %d datensätze um faktor %.1f (erlaubt %.1f): aufwand ist überlinear",
+			smallest, largest, growth, maxWachstum)
+	}
+}
+
+func BenchmarkRecordReader(b *testing.B) {
+	benchPerRecord(b, basisRecordReader, func(b *testing.B, n int) {
+		data, err := os.ReadFile(largeCSV(b, n))
+		require.NoError(b, err)
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			records, err := newRecordReader(bytes.NewReader(data), ',', zap.NewNop())
+			if err != nil {
+				b.Fatal(err)
+			}
+			for {
+				if _, _, err := records.Next(); err == io.EOF {
+					break
+				} else if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkLoad(b *testing.B) {
+	benchPerRecord(b, basisLoad, func(b *testing.B, n int) {
+		path := largeCSV(b, n)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewPersonRepository(path, 0, zap.NewNop()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestLastModified_DateiUndAenderungen(t *testing.T) {
+	path := tempCSV(t, "A, B, 11111 X, 1\n")
+	dateiStand := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, dateiStand, dateiStand))
+	repo, err := NewPersonRepository(path, 0, testLogger())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	modified, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.True(t, dateiStand.Equal(modified), "nach dem laden gilt die datei")
+
+	n, err := repo.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, n)
+	unveraendert, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.True(t, dateiStand.Equal(unveraendert), "purge ohne treffer ändert nichts")
+
+	_, err = repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	added, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.True(t, added.After(dateiStand))
+
+	_, err = repo.Reload(ctx)
+	require.NoError(t, err)
+	reloaded, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.False(t, reloaded.Before(added), "reload ist selbst eine änderung")
 }
@@ -0,0 +1,373 @@
+package csv
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultFetchMaxBytes begrenzt die Größe einer entfernten Quelle, wenn keine
+// eigene Grenze gesetzt ist.
+const defaultFetchMaxBytes = 64 << 20
+
+// S3Config beschreibt den Zugang zu einem S3-kompatiblen Objektspeicher.
+// Ohne AccessKeyID werden Anfragen unsigniert gestellt, etwa für öffentliche
+// Buckets.
+type S3Config struct {
+	Endpoint        string // Basis-URL, z. B. "http://minio:9000"; leer = AWS für Region
+	Region          string // leer = "us-east-1"
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // nur für temporäre Zugangsdaten
+}
+
+// WithFetchMaxBytes begrenzt die Größe einer über HTTP(S) oder S3 geladenen
+// Quelle. Größere Quellen lassen das Laden scheitern.
+func WithFetchMaxBytes(n int64) Option {
+	return func(r *PersonRepository) {
+		if n > 0 {
+			r.fetchMaxBytes = n
+		}
+	}
+}
+
+// WithBearerToken sendet beim Laden über HTTP(S) einen
+// Authorization-Header "Bearer <token>".
+func WithBearerToken(token string) Option {
+	return func(r *PersonRepository) {
+		r.bearerToken = token
+	}
+}
+
+// WithS3 setzt den Zugang für s3://bucket/key-Quellen.
+func WithS3(cfg S3Config) Option {
+	return func(r *PersonRepository) {
+		r.s3 = cfg
+	}
+}
+
+// WithCachePath legt fest, wohin eine entfernte Quelle nach erfolgreichem
+// Laden kopiert wird. Ist die Quelle bei einem Reload nicht erreichbar, wird
+// stattdessen diese Kopie geladen. Leer schaltet den Zwischenspeicher ab.
+func WithCachePath(path string) Option {
+	return func(r *PersonRepository) {
+		r.cachePath = path
+	}
+}
+
+// isRemote meldet, ob source über das Netz geladen wird.
+func isRemote(source string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://"} {
+		if strings.HasPrefix(source, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchError ist ein gescheitertes Laden einer entfernten Quelle. Status ist
+// 0, wenn keine Antwort kam.
+type fetchError struct {
+	source string
+	status int
+	err    error
+}
+
+func (e *fetchError) Error() string {
+	return fmt.Sprintf("url laden %s: %v", e.source, e.err)
+}
+
+func (e *fetchError) Unwrap() error { return e.err }
+
+// unreachable meldet, ob die Quelle vorübergehend nicht erreichbar war, im
+// Gegensatz zu einer Antwort wie 404 oder 403, die auf eine falsche
+// Konfiguration hinweist.
+func (e *fetchError) unreachable() bool {
+	return e.status == 0 || e.status == http.StatusTooManyRequests || e.status >= 500
+}
+
+// statusError beschreibt eine Antwort außer 200. 404 wird zu fs.ErrNotExist
+// und 401/403 zu fs.ErrPermission, damit Aufrufer sie wie bei lokalen Dateien
+// unterscheiden können.
+func statusError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("nicht gefunden (%s): %w", resp.Status, fs.ErrNotExist)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("zugriff verweigert (%s), zugangsdaten prüfen: %w", resp.Status, fs.ErrPermission)
+	}
+	return fmt.Errorf("unerwarteter status %s", resp.Status)
+}
+
+// fetch startet ein GET auf eine http(s)://- oder s3://-Quelle und gibt den
+// Body, seine Länge laut Content-Length und den Last-Modified-Header zurück.
+// Das Timeout umfasst Verbindungsaufbau und Lesen des Bodys; jeder Status
+// außer 200 gilt als Fehler. Mit Zwischenspeicher wird der Body beim Lesen
+// zusätzlich in eine temporäre Datei kopiert (siehe cachingBody).
+func (r *PersonRepository) fetch(ctx context.Context, source string) (io.ReadCloser, int64, time.Time, error) {
+	req, err := r.newFetchRequest(ctx, source)
+	if err != nil {
+		return nil, 0, time.Time{}, &fetchError{source: source, err: err}
+	}
+	client := &http.Client{Timeout: r.fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, time.Time{}, &fetchError{source: source, err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, time.Time{}, &fetchError{source: source, status: resp.StatusCode, err: statusError(resp)}
+	}
+	if resp.ContentLength > r.fetchMaxBytes {
+		resp.Body.Close()
+		return nil, 0, time.Time{}, &fetchError{source: source, status: resp.StatusCode,
+			err: fmt.Errorf("%d bytes, erlaubt sind max %d", resp.ContentLength, r.fetchMaxBytes)}
+	}
+	// Ein fehlender oder ungültiger Header ergibt die Nullzeit.
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	body := io.ReadCloser(&limitedBody{body: resp.Body, remaining: r.fetchMaxBytes, max: r.fetchMaxBytes})
+	if r.cachePath != "" {
+		body = r.newCachingBody(body)
+	}
+	return body, resp.ContentLength, modified, nil
+}
+
+// newFetchRequest baut das GET für source. http(s)-Quellen erhalten das
+// Bearer-Token, s3-Quellen werden auf den Endpunkt abgebildet und signiert.
+func (r *PersonRepository) newFetchRequest(ctx context.Context, source string) (*http.Request, error) {
+	if !strings.HasPrefix(source, "s3://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		if r.bearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+r.bearerToken)
+		}
+		return req, nil
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(source, "s3://"), "/")
+	if bucket == "" || key == "" {
+		return nil, errors.New("erwartet s3://bucket/key")
+	}
+	region := r.s3.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := strings.TrimSuffix(r.s3.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	// Pfadadressierung funktioniert bei AWS wie bei MinIO & Co.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/"+bucket+"/"+escapePath(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.s3.AccessKeyID != "" {
+		signV4(req, r.s3, region, time.Now())
+	}
+	return req, nil
+}
+
+// limitedBody liest höchstens max Bytes und meldet danach einen Fehler statt
+// EOF, damit eine zu große Quelle nicht abgeschnitten geladen wird.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	max       int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Erst ein weiteres Byte zeigt, ob die Quelle die Grenze überschreitet
+		// oder genau an ihr endet.
+		var probe [1]byte
+		n, err := b.body.Read(probe[:])
+		if n > 0 || err == nil {
+			return 0, fmt.Errorf("quelle größer als %d bytes", b.max)
+		}
+		return 0, err
+	}
+	p = p[:min(int64(len(p)), b.remaining)]
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error { return b.body.Close() }
+
+// cachingBody kopiert alles Gelesene in eine temporäre Datei neben dem
+// Zwischenspeicher. Erst commit ersetzt den Zwischenspeicher, damit er immer
+// eine vollständig gelesene und gültige Quelle enthält; ohne commit wird die
+// temporäre Datei beim Schließen entfernt.
+type cachingBody struct {
+	body      io.ReadCloser
+	tmp       *os.File
+	cachePath string
+	logger    *zap.Logger
+}
+
+// newCachingBody umhüllt body. Lässt sich keine temporäre Datei anlegen, wird
+// ohne Zwischenspeicher geladen.
+func (r *PersonRepository) newCachingBody(body io.ReadCloser) io.ReadCloser {
+	tmp, err := os.CreateTemp(filepath.Dir(r.cachePath), filepath.Base(r.cachePath)+".*.tmp")
+	if err != nil {
+		r.logger.Warn("csv-zwischenspeicher nicht verfügbar", zap.String("datei", r.cachePath), zap.Error(err))
+		return body
+	}
+	return &cachingBody{body: body, tmp: tmp, cachePath: r.cachePath, logger: r.logger}
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && b.tmp != nil {
+		if _, werr := b.tmp.Write(p[:n]); werr != nil {
+			b.logger.Warn("csv-zwischenspeicher nicht geschrieben", zap.String("datei", b.cachePath), zap.Error(werr))
+			b.discard()
+		}
+	}
+	return n, err
+}
+
+// commit ersetzt den Zwischenspeicher durch die temporäre Datei.
+func (b *cachingBody) commit() {
+	if b.tmp == nil {
+		return
+	}
+	tmp := b.tmp
+	b.tmp = nil
+	if err := tmp.Close(); err != nil {
+		b.logger.Warn("csv-zwischenspeicher nicht geschrieben", zap.String("datei", b.cachePath), zap.Error(err))
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), b.cachePath); err != nil {
+		b.logger.Warn("csv-zwischenspeicher nicht geschrieben", zap.String("datei", b.cachePath), zap.Error(err))
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (b *cachingBody) discard() {
+	if b.tmp == nil {
+		return
+	}
+	_ = b.tmp.Close()
+	_ = os.Remove(b.tmp.Name())
+	b.tmp = nil
+}
+
+func (b *cachingBody) Close() error {
+	b.discard()
+	return b.body.Close()
+}
+
+// openCache öffnet den Zwischenspeicher, wenn source bei einem Reload nicht
+// erreichbar war. Andere Fehler und das erste Laden reicht es unverändert
+// durch: Ein Start mit veralteten Daten soll nicht unbemerkt bleiben.
+func (r *PersonRepository) openCache(source string, fetchErr error) (io.ReadCloser, error) {
+	var fe *fetchError
+	if r.cachePath == "" || r.snap.Load() == nil || !errors.As(fetchErr, &fe) || !fe.unreachable() {
+		return nil, fetchErr
+	}
+	f, err := os.Open(r.cachePath)
+	if err != nil {
+		return nil, fetchErr
+	}
+	r.logger.Warn("quelle nicht erreichbar, zwischengespeicherte kopie wird geladen",
+		zap.String("quelle", source), zap.String("datei", r.cachePath), zap.Error(fetchErr))
+	return f, nil
+}
+
+// ─── AWS Signature Version 4 ──────────────────────────────────────────────────
+
+// emptyPayloadHash ist der SHA-256 eines leeren Bodys, wie ihn S3 in
+// x-amz-content-sha256 erwartet.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signV4 signiert ein GET ohne Body nach AWS Signature Version 4. Signiert
+// werden Host und alle bereits gesetzten Header.
+func signV4(req *http.Request, cfg S3Config, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretAccessKey), date)
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePath kodiert einen Objektschlüssel so, wie SigV4 ihn im kanonischen
+// Pfad erwartet: alles außer A-Z, a-z, 0-9, "-", "_", ".", "~" und "/" als
+// %XX.
+func escapePath(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
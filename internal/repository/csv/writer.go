@@ -0,0 +1,40 @@
+package csv
+
+import (
+	stdcsv "encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// Writer schreibt Personen im Format der Quell-CSV (Nachname, Vorname,
+// "PLZ Stadt", Farb-ID), sodass NewPersonRepository die Ausgabe wieder laden
+// kann. IDs und Zeitstempel gehen dabei verloren; die ID ergibt sich beim
+// Laden aus der Position.
+type Writer struct {
+	csv *stdcsv.Writer
+}
+
+// NewWriter gibt einen Writer zurück, der Felder mit delimiter trennt.
+func NewWriter(w io.Writer, delimiter rune) *Writer {
+	cw := stdcsv.NewWriter(w)
+	cw.Comma = delimiter
+	return &Writer{csv: cw}
+}
+
+// Write schreibt person als eine Zeile. Die Ausgabe ist gepuffert, bis Flush
+// aufgerufen wird.
+func (w *Writer) Write(person domain.Person) error {
+	zipCity := strings.TrimSpace(person.Zipcode + " " + person.City)
+	return w.csv.Write([]string{
+		person.Lastname, person.Name, zipCity, strconv.Itoa(person.Color.ID()),
+	})
+}
+
+// Flush schreibt gepufferte Zeilen und meldet den ersten Schreibfehler.
+func (w *Writer) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
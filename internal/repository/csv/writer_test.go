@@ -0,0 +1,50 @@
+package csv
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+func TestWriter_Format(t *testing.T) {
+	var out strings.Builder
+	w := NewWriter(&out, ',')
+	require.NoError(t, w.Write(domain.Person{Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"}))
+	require.NoError(t, w.Write(domain.Person{Name: "Klaus", Lastname: "Meyer, Dr.", Zipcode: "12345", Color: "rot"}))
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, "Müller,Hans,67742 Lauterecken,1\n\"Meyer, Dr.\",Klaus,12345,4\n", out.String())
+}
+
+func TestWriter_LaedtWieder(t *testing.T) {
+	persons := []domain.Person{
+		{ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"},
+		{ID: 2, Name: "Klaus", Lastname: "Meyer, Dr.", Zipcode: "12345", City: "Bad Homburg", Color: "rot"},
+		{ID: 3, Name: "Anna \"Anni\"", Lastname: "Schmidt", Zipcode: "99999", Color: "türkis"},
+	}
+	for _, delimiter := range []rune{',', ';'} {
+		t.Run(string(delimiter), func(t *testing.T) {
+			var out strings.Builder
+			w := NewWriter(&out, delimiter)
+			for _, p := range persons {
+				require.NoError(t, w.Write(p))
+			}
+			require.NoError(t, w.Flush())
+
+			repo, err := NewPersonRepository(tempCSV(t, out.String()), 0, testLogger(), WithDelimiter(delimiter))
+			require.NoError(t, err)
+			loaded, err := repo.GetAll(context.Background())
+			require.NoError(t, err)
+			require.Len(t, loaded, len(persons))
+			for i, p := range loaded {
+				p.Version = 0
+				assert.Equal(t, persons[i], ohneZeitstempel(p))
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// Package jsonfile stellt ein PersonRepository bereit, dessen Anfangsbestand
+// aus einer JSON-Datei stammt. Zur Laufzeit verhält es sich wie das
+// memory-Repository: Änderungen bleiben im Arbeitsspeicher, die Datei wird
+// nie geschrieben.
+//
+// Die Datei enthält ein Array von Objekten mit den Feldern id (optional),
+// name, lastname, zipcode, city und color. color ist ein Farbname wie "blau"
+// oder eine Farb-ID wie 1. Ungültige Datensätze werden mit Warnung
+// übersprungen und im Ladebericht geführt:
+//
+//   - unbekannte oder fehlende Felder und Felder mit falschem Typ,
+//   - unbekannte Farben,
+//   - eine id, die nicht positiv ist oder schon ein früherer Datensatz trägt.
+//
+// Datensätze ohne id erhalten fortlaufende IDs ab der höchsten vergebenen.
+// Eine leere Datei ergibt einen leeren Bestand; ist die Datei kein JSON-Array,
+// scheitert das Laden.
+package jsonfile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository/memory"
+)
+
+// knownFields sind die Felder, die ein Datensatz tragen darf.
+var knownFields = []string{"id", "name", "lastname", "zipcode", "city", "color"}
+
+// PersonRepository ist ein memory.PersonRepository mit Ladebericht.
+type PersonRepository struct {
+	*memory.PersonRepository
+	report         domain.LoadReport
+	loaded         time.Time // Änderungszeitpunkt des memory-Repositorys nach dem Laden
+	sourceModified time.Time
+}
+
+// NewPersonRepository lädt filePath. Enthält die Datei mehr als maxPersons
+// gültige Datensätze (0 = unbegrenzt), werden nur die ersten geladen und der
+// Rest im Ladebericht als Overflow gezählt.
+func NewPersonRepository(filePath string, maxPersons int, logger *zap.Logger) (*PersonRepository, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("json-datei lesen: %w", err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("json-datei prüfen: %w", err)
+	}
+
+	loadedAt := time.Now().UTC()
+	persons, skipped, err := parse(data, logger)
+	if err != nil {
+		return nil, fmt.Errorf("json-datei %s: %w", filePath, err)
+	}
+	overflow := 0
+	if maxPersons > 0 && len(persons) > maxPersons {
+		overflow = len(persons) - maxPersons
+		persons = persons[:maxPersons]
+		logger.Warn("kapazitätsgrenze erreicht, überzählige datensätze wurden nicht geladen",
+			zap.Int("max_persons", maxPersons), zap.Int("nicht_geladen", overflow))
+	}
+	for i := range persons {
+		// Die Datei enthält keine Zeitstempel; geladene Personen gelten als
+		// zum Ladezeitpunkt angelegt.
+		persons[i].CreatedAt, persons[i].UpdatedAt = loadedAt, loadedAt
+		persons[i].Version = 1
+	}
+
+	mem := memory.NewPersonRepository(maxPersons, persons...)
+	loaded, _ := mem.LastModified(context.Background())
+	r := &PersonRepository{
+		PersonRepository: mem,
+		report: domain.LoadReport{
+			Source: filePath, LoadedAt: loadedAt, Loaded: len(persons), Overflow: overflow, Skipped: skipped,
+		},
+		loaded:         loaded,
+		sourceModified: info.ModTime().UTC(),
+	}
+
+	level := zap.InfoLevel
+	if len(skipped) > 0 {
+		level = zap.WarnLevel
+	}
+	logger.Log(level, "personen aus JSON geladen",
+		zap.Int("anzahl", len(persons)), zap.Int("verworfen", len(skipped)), zap.String("datei", filePath))
+	return r, nil
+}
+
+// LoadReport liefert den Bericht des Ladens. Skipped ist nie nil.
+func (r *PersonRepository) LoadReport() domain.LoadReport {
+	report := r.report
+	report.Skipped = append([]domain.SkippedRecord{}, report.Skipped...)
+	return report
+}
+
+// LastModified liefert bis zur ersten Änderung den Änderungszeitpunkt der
+// Datei, danach den der letzten Änderung.
+func (r *PersonRepository) LastModified(ctx context.Context) (time.Time, error) {
+	modified, err := r.PersonRepository.LastModified(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if modified.Equal(r.loaded) {
+		return r.sourceModified, nil
+	}
+	return modified, nil
+}
+
+// parse liest das Array aus data. Ungültige Datensätze landen in skipped;
+// ein Fehler bedeutet, dass die Datei als Ganzes unlesbar ist.
+func parse(data []byte, logger *zap.Logger) (persons []domain.Person, skipped []domain.SkippedRecord, err error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		logger.Warn("json-datei ist leer, start mit leerem bestand")
+		return nil, []domain.SkippedRecord{}, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, nil, fmt.Errorf("erwartet ein json-array von personen: %w", domain.ErrInvalidInput)
+	}
+
+	firstLine := make(map[int]int) // id -> zeile des ersten datensatzes
+	for n := 1; dec.More(); n++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, syntaxError(data, err)
+		}
+		line := lineAt(data, int(dec.InputOffset())-len(raw))
+
+		person, err := toPerson(raw)
+		if err == nil && person.ID > 0 {
+			if first, dup := firstLine[person.ID]; dup {
+				err = fmt.Errorf("doppelte id %d, zuerst in zeile %d", person.ID, first)
+			} else {
+				firstLine[person.ID] = line
+			}
+		}
+		if err != nil {
+			logger.Warn("ungültiger datensatz wird übersprungen",
+				zap.Int("datensatz", n), zap.Int("zeile", line), zap.Error(err))
+			skipped = append(skipped, domain.SkippedRecord{Line: line, Fields: []string{compact(raw)}, Reason: err.Error()})
+			continue
+		}
+		persons = append(persons, person)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, syntaxError(data, err)
+	}
+	if dec.More() {
+		return nil, nil, fmt.Errorf("daten nach dem json-array: %w", domain.ErrInvalidInput)
+	}
+	if skipped == nil {
+		skipped = []domain.SkippedRecord{}
+	}
+	return persons, skipped, nil
+}
+
+// toPerson prüft einen einzelnen Datensatz und wandelt ihn um.
+func toPerson(raw json.RawMessage) (domain.Person, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return domain.Person{}, errors.New("datensatz ist kein json-objekt")
+	}
+	var unknown []string
+	for key := range fields {
+		if !slices.Contains(knownFields, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return domain.Person{}, fmt.Errorf("unbekannte felder: %s", strings.Join(unknown, ", "))
+	}
+
+	var p domain.Person
+	if rawID, ok := fields["id"]; ok {
+		var id int
+		if err := json.Unmarshal(rawID, &id); err != nil || id <= 0 {
+			return domain.Person{}, fmt.Errorf("id muss eine positive ganzzahl sein, ist %s", rawID)
+		}
+		p.ID = id
+	}
+	for _, f := range []struct {
+		key string
+		dst *string
+	}{
+		{"name", &p.Name},
+		{"lastname", &p.Lastname},
+		{"zipcode", &p.Zipcode},
+		{"city", &p.City},
+	} {
+		rawValue, ok := fields[f.key]
+		if !ok {
+			return domain.Person{}, fmt.Errorf("feld %s fehlt", f.key)
+		}
+		// null würde json.Unmarshal stillschweigend als "" übernehmen.
+		if err := json.Unmarshal(rawValue, f.dst); err != nil || string(rawValue) == "null" {
+			return domain.Person{}, fmt.Errorf("feld %s muss ein string sein", f.key)
+		}
+	}
+
+	rawColor, ok := fields["color"]
+	if !ok {
+		return domain.Person{}, errors.New("feld color fehlt")
+	}
+	color, err := parseColor(rawColor)
+	if err != nil {
+		return domain.Person{}, err
+	}
+	p.Color = color
+	return p, nil
+}
+
+// parseColor akzeptiert einen Farbnamen oder eine numerische Farb-ID.
+func parseColor(raw json.RawMessage) (domain.Color, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return domain.ParseColor(name)
+	}
+	var id int
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return domain.ColorByID(id)
+	}
+	return "", fmt.Errorf("color muss ein farbname oder eine farb-id sein, ist %s", raw)
+}
+
+// syntaxError ergänzt einen Dekodierfehler um die Zeile, sofern bekannt.
+func syntaxError(data []byte, err error) error {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return fmt.Errorf("ungültiges json in zeile %d: %v: %w", lineAt(data, int(syntax.Offset)), err, domain.ErrInvalidInput)
+	}
+	return fmt.Errorf("ungültiges json: %v: %w", err, domain.ErrInvalidInput)
+}
+
+// lineAt liefert die 1-basierte Zeile zu offset.
+func lineAt(data []byte, offset int) int {
+	offset = min(max(offset, 0), len(data))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// compact gibt raw ohne Leerraum zurück, für den Ladebericht.
+func compact(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
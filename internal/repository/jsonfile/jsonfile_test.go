@@ -0,0 +1,192 @@
+package jsonfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+func tempJSON(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "persons.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func ids(persons []domain.Person) []int {
+	out := make([]int, 0, len(persons))
+	for _, p := range persons {
+		out = append(out, p.ID)
+	}
+	return out
+}
+
+func TestNewPersonRepository_FarbnameUndFarbID(t *testing.T) {
+	path := tempJSON(t, `[
+  {"id": 1, "name": "Hans", "lastname": "Müller", "zipcode": "67742", "city": "Lauterecken", "color": "blau"},
+  {"id": 2, "name": "Peter", "lastname": "Petersen", "zipcode": "18439", "city": "Stralsund", "color": 2},
+  {"name": "Anna", "lastname": "Schmidt", "zipcode": "10115", "city": "Berlin", "color": "Rot"}
+]`)
+	repo, err := NewPersonRepository(path, 0, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, domain.Color("blau"), all[0].Color)
+	assert.Equal(t, domain.Color("grün"), all[1].Color)
+	assert.Equal(t, 3, all[2].ID, "ohne id folgt auf die höchste")
+	assert.Equal(t, domain.Color("rot"), all[2].Color)
+	assert.Equal(t, 1, all[0].Version)
+	assert.False(t, all[0].CreatedAt.IsZero())
+
+	added, err := repo.Add(ctx, domain.Person{Name: "Neu", Color: "gelb"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, added.ID)
+
+	report := repo.LoadReport()
+	assert.Equal(t, path, report.Source)
+	assert.Equal(t, 3, report.Loaded)
+	assert.Empty(t, report.Skipped)
+	assert.NotNil(t, report.Skipped)
+}
+
+func TestNewPersonRepository_UngueltigeDatensaetze(t *testing.T) {
+	path := tempJSON(t, `[
+  {"id": 1, "name": "Hans", "lastname": "Müller", "zipcode": "67742", "city": "Lauterecken", "color": "blau"},
+  {"id": 2, "name": "Peter", "lastname": "Petersen", "zipcode": "18439", "city": "Stralsund", "color": 2, "email": "p@x.de"},
+  {"id": 1, "name": "Doppelt", "lastname": "X", "zipcode": "1", "city": "Y", "color": 1},
+  {"id": 0, "name": "Null", "lastname": "X", "zipcode": "1", "city": "Y", "color": 1},
+  {"id": 5, "name": "Lila", "lastname": "X", "zipcode": "1", "city": "Y", "color": "lila"},
+  {"id": 6, "name": "Ohne", "lastname": "X", "zipcode": "1", "color": 1},
+  {"id": 7, "name": null, "lastname": "X", "zipcode": "1", "city": "Y", "color": 1},
+  {"id": 8, "name": "Zahl", "lastname": "X", "zipcode": 12345, "city": "Y", "color": 1},
+  "kein objekt",
+  {"id": 10, "name": "Gut", "lastname": "X", "zipcode": "1", "city": "Y", "color": 7}
+]`)
+	core, logs := observer.New(zap.WarnLevel)
+	repo, err := NewPersonRepository(path, 0, zap.New(core))
+	require.NoError(t, err)
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 10}, ids(all))
+
+	report := repo.LoadReport()
+	assert.Equal(t, 2, report.Loaded)
+	var reasons []string
+	var lines []int
+	for _, s := range report.Skipped {
+		reasons = append(reasons, s.Reason)
+		lines = append(lines, s.Line)
+	}
+	assert.Equal(t, []int{3, 4, 5, 6, 7, 8, 9, 10}, lines)
+	assert.Equal(t, []string{
+		"unbekannte felder: email",
+		"doppelte id 1, zuerst in zeile 2",
+		"id muss eine positive ganzzahl sein, ist 0",
+		"ungültige farbe: ungültige eingabe",
+		"feld city fehlt",
+		"feld name muss ein string sein",
+		"feld zipcode muss ein string sein",
+		"datensatz ist kein json-objekt",
+	}, reasons)
+	assert.Equal(t, `{"id":0,"name":"Null","lastname":"X","zipcode":"1","city":"Y","color":1}`, report.Skipped[2].Fields[0])
+
+	assert.Equal(t, 8, logs.FilterMessage("ungültiger datensatz wird übersprungen").Len())
+	assert.Equal(t, 1, logs.FilterMessage("personen aus JSON geladen").Len(), "zusammenfassung als warnung")
+}
+
+func TestNewPersonRepository_LeereDatei(t *testing.T) {
+	for _, content := range []string{"", "  \n", "[]"} {
+		core, logs := observer.New(zap.WarnLevel)
+		repo, err := NewPersonRepository(tempJSON(t, content), 0, zap.New(core))
+		require.NoError(t, err, "%q", content)
+
+		all, err := repo.GetAll(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, all)
+		assert.Zero(t, repo.LoadReport().Loaded)
+		wantWarning := content != "[]"
+		assert.Equal(t, wantWarning, logs.FilterMessage("json-datei ist leer, start mit leerem bestand").Len() == 1, "%q", content)
+	}
+}
+
+func TestNewPersonRepository_UnlesbareDatei(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"objekt statt array", `{"id": 1}`, "erwartet ein json-array"},
+		{"null", `null`, "erwartet ein json-array"},
+		{"syntaxfehler", "[\n  {\"id\": 1,}\n]", "ungültiges json in zeile 2"},
+		{"abgeschnitten", `[{"id": 1, "name": "A", "lastname": "B", "zipcode": "1", "city": "C", "color": 1}`, "ungültiges json"},
+		{"daten nach dem array", `[] []`, "daten nach dem json-array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPersonRepository(tempJSON(t, tt.content), 0, zap.NewNop())
+			require.ErrorIs(t, err, domain.ErrInvalidInput)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := NewPersonRepository(filepath.Join(t.TempDir(), "fehlt.json"), 0, zap.NewNop())
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestNewPersonRepository_Kapazitaet(t *testing.T) {
+	path := tempJSON(t, `[
+  {"name": "A", "lastname": "X", "zipcode": "1", "city": "Y", "color": 1},
+  {"name": "B", "lastname": "X", "zipcode": "1", "city": "Y", "color": 1},
+  {"name": "C", "lastname": "X", "zipcode": "1", "city": "Y", "color": 1}
+]`)
+	repo, err := NewPersonRepository(path, 2, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	report := repo.LoadReport()
+	assert.Equal(t, 2, report.Loaded)
+	assert.Equal(t, 1, report.Overflow)
+
+	_, err = repo.Add(ctx, domain.Person{Name: "D", Color: "blau"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+	require.NoError(t, repo.Delete(ctx, 1, time.Now()))
+	_, err = repo.Add(ctx, domain.Person{Name: "D", Color: "blau"})
+	require.NoError(t, err)
+
+	page, err := repo.Find(ctx, domain.PersonFilter{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, ids(page))
+}
+
+func TestLastModified_DateiBisZurErstenAenderung(t *testing.T) {
+	path := tempJSON(t, `[{"name": "A", "lastname": "X", "zipcode": "1", "city": "Y", "color": 1}]`)
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	repo, err := NewPersonRepository(path, 0, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	modified, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, mtime, modified)
+
+	_, err = repo.Add(ctx, domain.Person{Name: "B", Color: "blau"})
+	require.NoError(t, err)
+	modified, err = repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.True(t, modified.After(mtime))
+}
@@ -0,0 +1,495 @@
+// Package memory stellt ein flüchtiges PersonRepository bereit, das weder
+// Dateien noch eine Datenbank benötigt. Es dient Tests sowie Lasttests und
+// CI-Läufen mit DATA_SOURCE=memory.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// PersonRepository hält alle Personen in einer Map und implementiert
+// repository.PersonRepository. Lesezugriffe liefern stets Kopien in
+// ID-Reihenfolge, sodass Aufrufer den Bestand nicht verändern können.
+type PersonRepository struct {
+	mu         sync.RWMutex
+	persons    map[int]domain.Person
+	nextID     int
+	maxPersons int
+	modified   time.Time // letzte Änderung, siehe LastModified
+}
+
+// NewPersonRepository legt ein Repository mit der Kapazitätsgrenze
+// maxPersons (0 = unbegrenzt) an. Personen aus seed behalten ihre ID, sofern
+// sie eine haben; alle übrigen erhalten fortlaufende IDs. Die Grenze gilt nur
+// für spätere Zugriffe, nicht für seed.
+func NewPersonRepository(maxPersons int, seed ...domain.Person) *PersonRepository {
+	r := &PersonRepository{
+		persons:    make(map[int]domain.Person, len(seed)),
+		nextID:     1,
+		maxPersons: maxPersons,
+		modified:   time.Now().UTC(),
+	}
+	for _, p := range seed {
+		if p.ID > 0 {
+			r.nextID = max(r.nextID, p.ID+1)
+		}
+	}
+	for _, p := range seed {
+		if p.ID <= 0 {
+			p.ID = r.nextID
+			r.nextID++
+		}
+		r.persons[p.ID] = p
+	}
+	return r
+}
+
+func ctxErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("memory-repository: %w", err)
+	}
+	return nil
+}
+
+// sorted liefert eine Kopie der Personen, die keep erfüllen, nach ID
+// sortiert. Der Aufrufer hält mu mindestens zum Lesen.
+func (r *PersonRepository) sorted(keep func(domain.Person) bool) []domain.Person {
+	out := make([]domain.Person, 0, len(r.persons))
+	for _, id := range slices.Sorted(maps.Keys(r.persons)) {
+		if p := r.persons[id]; keep(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// live liefert alle nicht gelöschten Personen nach ID sortiert.
+func (r *PersonRepository) live() []domain.Person {
+	return r.sorted(func(p domain.Person) bool { return !p.Deleted() })
+}
+
+// used zählt die nicht gelöschten Personen. Der Aufrufer hält mu.
+func (r *PersonRepository) used() int {
+	n := 0
+	for _, p := range r.persons {
+		if !p.Deleted() {
+			n++
+		}
+	}
+	return n
+}
+
+// touch vermerkt die aktuelle Zeit als letzte Änderung. Der Aufrufer hält mu.
+func (r *PersonRepository) touch() {
+	r.modified = time.Now().UTC()
+}
+
+// checkCapacity prüft die Kapazitätsgrenze; gelöschte Personen zählen nicht.
+// Der Aufrufer hält mu.
+func (r *PersonRepository) checkCapacity() error {
+	if r.maxPersons > 0 && r.used() >= r.maxPersons {
+		return fmt.Errorf("max %d personen: %w", r.maxPersons, domain.ErrCapacityReached)
+	}
+	return nil
+}
+
+// GetAll gibt alle nicht gelöschten Personen zurück.
+func (r *PersonRepository) GetAll(ctx context.Context) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.live(), nil
+}
+
+// GetAllStream ruft fn für jede Person des beim Aufruf aktuellen Stands auf.
+// fn läuft ohne Sperre, ein langsamer Aufrufer blockiert also keine Schreiber.
+func (r *PersonRepository) GetAllStream(ctx context.Context, fn func(domain.Person) error) error {
+	r.mu.RLock()
+	persons := r.live()
+	r.mu.RUnlock()
+
+	for _, p := range persons {
+		if err := ctxErr(ctx); err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllAfter liefert höchstens limit Personen mit einer ID größer als afterID.
+func (r *PersonRepository) GetAllAfter(ctx context.Context, afterID, limit int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	after := r.sorted(func(p domain.Person) bool { return !p.Deleted() && p.ID > afterID })
+	return domain.Paginate(after, limit, 0), nil
+}
+
+// GetByID sucht eine Person anhand ihrer ID.
+func (r *PersonRepository) GetByID(ctx context.Context, id int) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.persons[id]
+	if !ok {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
+	}
+	if p.Deleted() {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrGone)
+	}
+	return p, nil
+}
+
+// GetByIDs liefert die nicht gelöschten Personen zu ids nach ID sortiert.
+func (r *PersonRepository) GetByIDs(ctx context.Context, ids []int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]domain.Person, 0, len(ids))
+	for _, id := range slices.Compact(slices.Sorted(slices.Values(ids))) {
+		if p, ok := r.persons[id]; ok && !p.Deleted() {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// GetByColor gibt die Personen mit passender Lieblingsfarbe in der
+// Reihenfolge order zurück, beschränkt auf die Seite aus limit und offset.
+func (r *PersonRepository) GetByColor(ctx context.Context, color domain.Color, order domain.Order, limit, offset int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := r.sorted(func(p domain.Person) bool { return !p.Deleted() && p.Color == color })
+	if !order.IsZero() {
+		domain.SortPersons(out, order)
+	}
+	return domain.Paginate(out, limit, offset), nil
+}
+
+// GetByColors sortiert die Treffer stabil nach Farbe, sodass die
+// ID-Reihenfolge je Farbe erhalten bleibt.
+func (r *PersonRepository) GetByColors(ctx context.Context, colors []domain.Color, limit, offset int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := r.sorted(func(p domain.Person) bool { return !p.Deleted() && slices.Contains(colors, p.Color) })
+	slices.SortStableFunc(out, func(a, b domain.Person) int { return strings.Compare(string(a.Color), string(b.Color)) })
+	return domain.Paginate(out, limit, offset), nil
+}
+
+// GetByZipcode vergleicht die Postleitzahlen exakt oder als Präfix.
+func (r *PersonRepository) GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := r.sorted(func(p domain.Person) bool {
+		return !p.Deleted() && (p.Zipcode == zip || prefix && strings.HasPrefix(p.Zipcode, zip))
+	})
+	return domain.Paginate(out, limit, offset), nil
+}
+
+// Find filtert und wendet anschließend Limit und Offset an.
+func (r *PersonRepository) Find(ctx context.Context, filter domain.PersonFilter) ([]domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return domain.Paginate(r.sorted(filter.Matches), filter.Limit, filter.Offset), nil
+}
+
+// Count zählt die Treffer von filter.
+func (r *PersonRepository) Count(ctx context.Context, filter domain.PersonFilter) (int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, p := range r.persons {
+		if filter.Matches(p) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// CountsByColor zählt die Personen je Farbe.
+func (r *PersonRepository) CountsByColor(ctx context.Context) (map[domain.Color]int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[domain.Color]int)
+	for _, p := range r.persons {
+		if !p.Deleted() {
+			counts[p.Color]++
+		}
+	}
+	return counts, nil
+}
+
+// Stats zählt Farben und Städte unter derselben Sperre.
+func (r *PersonRepository) Stats(ctx context.Context) (domain.PersonStats, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.PersonStats{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := domain.PersonStats{
+		ByColor: make(map[domain.Color]int),
+		ByCity:  make(map[string]int),
+	}
+	for _, p := range r.persons {
+		if p.Deleted() {
+			continue
+		}
+		stats.Total++
+		stats.ByColor[p.Color]++
+		stats.ByCity[p.City]++
+	}
+	return stats, nil
+}
+
+// DistinctCities sammelt die Städte in einer Map und sortiert sie.
+func (r *PersonRepository) DistinctCities(ctx context.Context) ([]string, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]struct{})
+	for _, p := range r.persons {
+		if !p.Deleted() && p.City != "" {
+			seen[p.City] = struct{}{}
+		}
+	}
+	return slices.Sorted(maps.Keys(seen)), nil
+}
+
+// Capacity liefert die Anzahl nicht gelöschter Personen und die Grenze.
+func (r *PersonRepository) Capacity(ctx context.Context) (int, int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.used(), r.maxPersons, nil
+}
+
+// LastModified liefert den Zeitpunkt der letzten Änderung oder, solange es
+// keine gab, den des Anlegens.
+func (r *PersonRepository) LastModified(ctx context.Context) (time.Time, error) {
+	if err := ctxErr(ctx); err != nil {
+		return time.Time{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.modified, nil
+}
+
+// Add vergibt die nächste freie ID und legt die Person an.
+func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkCapacity(); err != nil {
+		return domain.Person{}, err
+	}
+	person.ID = r.nextID
+	person.Version = 1
+	r.nextID++
+	r.persons[person.ID] = person
+	r.touch()
+	return person, nil
+}
+
+// Insert legt die Person unter ihrer ID an und hebt nextID bei Bedarf über sie.
+func (r *PersonRepository) Insert(ctx context.Context, person domain.Person) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.persons[person.ID]; ok {
+		return domain.Person{}, &domain.VersionConflictError{ID: person.ID, Current: existing.Version}
+	}
+	if err := r.checkCapacity(); err != nil {
+		return domain.Person{}, err
+	}
+	person.Version = 1
+	r.persons[person.ID] = person
+	r.nextID = max(r.nextID, person.ID+1)
+	r.touch()
+	return person, nil
+}
+
+// Update vergleicht die Version und ersetzt die Person.
+func (r *PersonRepository) Update(ctx context.Context, person domain.Person, expectedVersion int) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.persons[person.ID]
+	if !ok {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", person.ID, domain.ErrNotFound)
+	}
+	if current.Deleted() {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", person.ID, domain.ErrGone)
+	}
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return domain.Person{}, &domain.VersionConflictError{ID: person.ID, Current: current.Version}
+	}
+
+	person.CreatedAt = current.CreatedAt
+	person.DeletedAt = time.Time{}
+	person.Version = current.Version + 1
+	r.persons[person.ID] = person
+	r.touch()
+	return person, nil
+}
+
+// Delete setzt DeletedAt der Person; sie bleibt bis zum Purge erhalten.
+func (r *PersonRepository) Delete(ctx context.Context, id int, at time.Time) error {
+	if err := ctxErr(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.persons[id]
+	if !ok || p.Deleted() {
+		return fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
+	}
+	p.DeletedAt = at
+	p.Version++
+	r.persons[id] = p
+	r.touch()
+	return nil
+}
+
+// DeleteByColor löscht alle Treffer unter einer Sperre.
+func (r *PersonRepository) DeleteByColor(ctx context.Context, color domain.Color, at time.Time) (int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, p := range r.persons {
+		if p.Color != color || p.Deleted() {
+			continue
+		}
+		p.DeletedAt = at
+		p.Version++
+		r.persons[id] = p
+		n++
+	}
+	if n > 0 {
+		r.touch()
+	}
+	return n, nil
+}
+
+// Restore hebt die Löschung auf, sofern die Kapazitätsgrenze es zulässt.
+func (r *PersonRepository) Restore(ctx context.Context, id int) (domain.Person, error) {
+	if err := ctxErr(ctx); err != nil {
+		return domain.Person{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.persons[id]
+	if !ok || !p.Deleted() {
+		return domain.Person{}, fmt.Errorf("gelöschte person mit id %d: %w", id, domain.ErrNotFound)
+	}
+	if err := r.checkCapacity(); err != nil {
+		return domain.Person{}, err
+	}
+	p.DeletedAt = time.Time{}
+	p.Version++
+	r.persons[id] = p
+	r.touch()
+	return p, nil
+}
+
+// Purge entfernt die vor before gelöschten Personen. nextID bleibt
+// unverändert, sodass IDs nicht erneut vergeben werden.
+func (r *PersonRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	if err := ctxErr(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, p := range r.persons {
+		if p.Deleted() && p.DeletedAt.Before(before) {
+			delete(r.persons, id)
+			n++
+		}
+	}
+	if n > 0 {
+		r.touch()
+	}
+	return n, nil
+}
+
+// DeleteAll entfernt alle Personen; die nächste vergebene ID ist wieder 1.
+func (r *PersonRepository) DeleteAll(ctx context.Context) error {
+	if err := ctxErr(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clear(r.persons)
+	r.nextID = 1
+	r.touch()
+	return nil
+}
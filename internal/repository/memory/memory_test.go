@@ -0,0 +1,254 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+func neuesRepo(maxPersons int) *PersonRepository {
+	return NewPersonRepository(maxPersons,
+		domain.Person{ID: 1, Name: "Hans", Lastname: "Müller", City: "Lauterecken", Color: "blau"},
+		domain.Person{ID: 3, Name: "Anna", Lastname: "Schmidt", City: "Berlin", Color: "blau"},
+		domain.Person{Name: "Peter", Lastname: "Petersen", City: "Stralsund", Color: "grün"},
+	)
+}
+
+func ids(persons []domain.Person) []int {
+	out := make([]int, 0, len(persons))
+	for _, p := range persons {
+		out = append(out, p.ID)
+	}
+	return out
+}
+
+func TestNewPersonRepository_IDsAusSeed(t *testing.T) {
+	repo := neuesRepo(0)
+	ctx := context.Background()
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3, 4}, ids(all), "personen ohne id folgen auf die höchste")
+
+	added, err := repo.Add(ctx, domain.Person{Name: "Neu", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, added.ID)
+	assert.Equal(t, 1, added.Version)
+}
+
+func TestInsert_VorgegebeneID(t *testing.T) {
+	repo := neuesRepo(0)
+	ctx := context.Background()
+
+	inserted, err := repo.Insert(ctx, domain.Person{ID: 2, Name: "Lücke", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted.Version)
+
+	inserted, err = repo.Insert(ctx, domain.Person{ID: 10, Name: "Neu", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 10, inserted.ID)
+
+	_, err = repo.Insert(ctx, domain.Person{ID: 3, Name: "Doppelt"})
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+
+	added, err := repo.Add(ctx, domain.Person{Name: "Danach"})
+	require.NoError(t, err)
+	assert.Equal(t, 11, added.ID)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 10, 11}, ids(all))
+}
+
+func TestDelete_VorlaeufigBisPurge(t *testing.T) {
+	repo := neuesRepo(0)
+	ctx := context.Background()
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Delete(ctx, 1, at))
+	require.ErrorIs(t, repo.Delete(ctx, 1, at), domain.ErrNotFound)
+
+	_, err := repo.GetByID(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrGone)
+	_, err = repo.Update(ctx, domain.Person{ID: 1}, 0)
+	require.ErrorIs(t, err, domain.ErrGone)
+
+	live, err := repo.Find(ctx, domain.PersonFilter{Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, ids(live))
+	all, err := repo.Find(ctx, domain.PersonFilter{Color: "blau", IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, ids(all))
+
+	n, err := repo.Purge(ctx, at)
+	require.NoError(t, err)
+	assert.Zero(t, n, "nur vor before gelöschte personen")
+	n, err = repo.Purge(ctx, at.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = repo.Restore(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestGetByColor_Sortiert(t *testing.T) {
+	repo := neuesRepo(0)
+	ctx := context.Background()
+
+	byName, err := repo.GetByColor(ctx, "blau", domain.Order{Field: "lastname", Desc: true}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 1}, ids(byName))
+
+	byID, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, ids(byID))
+
+	page, err := repo.GetByColor(ctx, "blau", domain.Order{Field: "lastname", Desc: true}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids(page))
+
+	page, err = repo.GetByColor(ctx, "blau", domain.Order{}, 1, 2)
+	require.NoError(t, err)
+	assert.NotNil(t, page)
+	assert.Empty(t, page)
+
+	_, err = repo.GetByColor(ctx, "blau", domain.Order{Field: "color"}, 0, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestDeleteByColor(t *testing.T) {
+	repo := neuesRepo(0)
+	ctx := context.Background()
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+
+	n, err := repo.DeleteByColor(ctx, "rot", at)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	unchanged, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, before, unchanged, "ohne treffer keine änderung")
+
+	blau, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+	require.NoError(t, err)
+	n, err = repo.DeleteByColor(ctx, "blau", at)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	live, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, ids(live))
+	deleted, err := repo.Find(ctx, domain.PersonFilter{Color: "blau", IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, deleted, len(blau))
+	for i, p := range deleted {
+		assert.True(t, at.Equal(p.DeletedAt))
+		assert.Equal(t, blau[i].Version+1, p.Version)
+	}
+}
+
+func TestUpdate_Versionskonflikt(t *testing.T) {
+	repo := neuesRepo(0)
+	ctx := context.Background()
+
+	updated, err := repo.Update(ctx, domain.Person{ID: 3, Name: "Anna", Color: "rot"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.Version)
+
+	_, err = repo.Update(ctx, domain.Person{ID: 3, Name: "Anna", Color: "gelb"}, 5)
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 1, conflict.Current)
+}
+
+func TestKapazitaet_GeloeschteZaehlenNicht(t *testing.T) {
+	repo := neuesRepo(3)
+	ctx := context.Background()
+
+	_, err := repo.Add(ctx, domain.Person{Name: "Neu"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+
+	require.NoError(t, repo.Delete(ctx, 1, time.Now()))
+	_, err = repo.Add(ctx, domain.Person{Name: "Neu"})
+	require.NoError(t, err)
+
+	_, err = repo.Restore(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+	used, max, err := repo.Capacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, used)
+	assert.Equal(t, 3, max)
+}
+
+func TestGetAllAfter_Keyset(t *testing.T) {
+	repo := neuesRepo(0)
+
+	page, err := repo.GetAllAfter(context.Background(), 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, ids(page))
+
+	page, err = repo.GetAllAfter(context.Background(), 4, 10)
+	require.NoError(t, err)
+	assert.NotNil(t, page)
+	assert.Empty(t, page)
+}
+
+func TestAbgebrochenerKontext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := neuesRepo(0).GetAll(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAdd_Nebenlaeufig(t *testing.T) {
+	repo := NewPersonRepository(0)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Add(ctx, domain.Person{Name: "Neu"})
+			assert.NoError(t, err)
+			_, err = repo.Stats(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 50)
+	assert.Equal(t, 50, all[49].ID)
+}
+
+func TestLastModified_SteigtMitAenderungen(t *testing.T) {
+	repo := neuesRepo(0)
+	ctx := context.Background()
+
+	vorher, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	require.False(t, vorher.IsZero())
+
+	time.Sleep(time.Millisecond)
+	_, err = repo.Update(ctx, domain.Person{ID: 99}, 0)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	unveraendert, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, vorher, unveraendert)
+
+	_, err = repo.Add(ctx, domain.Person{Name: "Neu"})
+	require.NoError(t, err)
+	nachher, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.True(t, nachher.After(vorher))
+}
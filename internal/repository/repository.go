@@ -2,15 +2,94 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"assecor-assessment-backend/internal/domain"
 )
 
 // PersonRepository abstrahiert den Datenzugriff auf Personen, sodass die
 // zugrunde liegende Datenquelle (CSV, SQLite usw.) austauschbar bleibt.
+//
+// Vorläufig gelöschte Personen (siehe Delete) liefern die Lesemethoden nicht;
+// GetByID meldet für sie domain.ErrGone. Nur Find und Count schließen sie mit
+// PersonFilter.IncludeDeleted ein. Die Kapazitätsgrenze zählt sie nicht mit.
+//
+// Zurückgegebene Slices können sich den Speicher mit dem internen Stand der
+// Implementierung teilen und dürfen von Aufrufern nicht verändert werden.
 type PersonRepository interface {
 	GetAll(ctx context.Context) ([]domain.Person, error)
+	// GetAllStream ruft fn für jede Person in ID-Reihenfolge auf, ohne alle
+	// Personen gleichzeitig im Speicher zu halten. Ein Fehler von fn oder ein
+	// abgebrochener ctx beendet die Iteration umgehend.
+	GetAllStream(ctx context.Context, fn func(domain.Person) error) error
+	// GetAllAfter liefert höchstens limit Personen mit einer ID größer als
+	// afterID in ID-Reihenfolge (Keyset-Paginierung). limit <= 0 bedeutet
+	// unbegrenzt. Das Ergebnis ist nie nil.
+	GetAllAfter(ctx context.Context, afterID, limit int) ([]domain.Person, error)
 	GetByID(ctx context.Context, id int) (domain.Person, error)
-	GetByColor(ctx context.Context, color string) ([]domain.Person, error)
+	// GetByIDs liefert die Personen zu ids in einem Zugriff. Unbekannte IDs
+	// werden ausgelassen; die Reihenfolge des Ergebnisses ist nicht festgelegt.
+	GetByIDs(ctx context.Context, ids []int) ([]domain.Person, error)
+	// GetByColor liefert die Personen mit der Farbe color, sortiert nach
+	// order (siehe domain.Order). offset und limit gelten für diese
+	// Reihenfolge; limit <= 0 bedeutet unbegrenzt. Eine Sortierung, die
+	// order.Validate ablehnt, ergibt domain.ErrInvalidInput. Das Ergebnis ist
+	// nie nil.
+	GetByColor(ctx context.Context, color domain.Color, order domain.Order, limit, offset int) ([]domain.Person, error)
+	// GetByColors liefert die Personen mit einer der Farben colors in einem
+	// Zugriff, sortiert nach Farbname (bytewise) und je Farbe nach ID. offset
+	// und limit gelten für diese Reihenfolge; limit <= 0 bedeutet unbegrenzt.
+	// Ohne colors ist das Ergebnis leer, aber nie nil.
+	GetByColors(ctx context.Context, colors []domain.Color, limit, offset int) ([]domain.Person, error)
+	// GetByZipcode liefert die Personen mit der Postleitzahl zip, mit prefix
+	// alle, deren Postleitzahl mit zip beginnt, jeweils nach ID sortiert.
+	// offset und limit wie bei GetByColors. Das Ergebnis ist nie nil.
+	GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) ([]domain.Person, error)
+	// Find liefert alle Personen, die filter erfüllen, in ID-Reihenfolge und
+	// wendet Limit und Offset in derselben Abfrage an. Das Ergebnis ist nie nil.
+	Find(ctx context.Context, filter domain.PersonFilter) ([]domain.Person, error)
+	// Count zählt alle Personen, die filter erfüllen; Limit und Offset
+	// werden ignoriert.
+	Count(ctx context.Context, filter domain.PersonFilter) (int, error)
 	Add(ctx context.Context, person domain.Person) (domain.Person, error)
+	// Insert legt person unter der vorgegebenen person.ID mit Version 1 an.
+	// Spätere Add-Aufrufe vergeben IDs oberhalb der höchsten je benutzten. Ist
+	// die ID belegt, auch durch eine gelöschte Person, schlägt Insert mit
+	// *domain.VersionConflictError fehl, ohne etwas zu ändern.
+	Insert(ctx context.Context, person domain.Person) (domain.Person, error)
+	// Update ersetzt die Felder der Person mit person.ID; CreatedAt bleibt
+	// erhalten, die Version steigt um 1. Ist expectedVersion > 0 und weicht die
+	// gespeicherte Version ab, schlägt Update mit *domain.VersionConflictError
+	// fehl, ohne etwas zu ändern. Prüfung und Änderung erfolgen atomar.
+	Update(ctx context.Context, person domain.Person, expectedVersion int) (domain.Person, error)
+	// CountsByColor zählt die Personen je Farbname. Farben ohne Personen
+	// dürfen in der Map fehlen.
+	CountsByColor(ctx context.Context) (map[domain.Color]int, error)
+	// Stats aggregiert Gesamtzahl sowie Anzahl je Farbe und je Stadt in einem
+	// konsistenten Stand. Farben und Städte ohne Personen dürfen fehlen.
+	Stats(ctx context.Context) (domain.PersonStats, error)
+	// DistinctCities liefert alle Städte nicht gelöschter Personen ohne
+	// Duplikate und ohne leere Namen, bytewise sortiert. Das Ergebnis ist nie nil.
+	DistinctCities(ctx context.Context) ([]string, error)
+	// Delete löscht die Person vorläufig, indem DeletedAt auf at gesetzt wird.
+	// Unbekannte oder bereits gelöschte Personen ergeben domain.ErrNotFound.
+	Delete(ctx context.Context, id int, at time.Time) error
+	// DeleteByColor löscht alle nicht gelöschten Personen der Farbe color
+	// vorläufig wie Delete und liefert deren Anzahl; ohne Treffer 0.
+	DeleteByColor(ctx context.Context, color domain.Color, at time.Time) (int, error)
+	// Restore hebt eine vorläufige Löschung auf. Ist die Person nicht
+	// gelöscht, ergibt das domain.ErrNotFound.
+	Restore(ctx context.Context, id int) (domain.Person, error)
+	// Purge entfernt alle vor before gelöschten Personen endgültig und
+	// liefert deren Anzahl.
+	Purge(ctx context.Context, before time.Time) (int, error)
+	// DeleteAll entfernt alle Personen und setzt die ID-Vergabe zurück.
+	DeleteAll(ctx context.Context) error
+	// Capacity liefert die Anzahl nicht gelöschter Personen und die
+	// Kapazitätsgrenze (0 = unbegrenzt).
+	Capacity(ctx context.Context) (used, max int, err error)
+	// LastModified liefert den Zeitpunkt der letzten Änderung am Bestand.
+	// Jeder Schreibzugriff, der Daten ändert, hebt ihn an, auch Delete und
+	// Purge; vor der ersten Änderung gilt der Stand der Datenquelle.
+	LastModified(ctx context.Context) (time.Time, error)
 }
@@ -0,0 +1,333 @@
+// Package retrying stellt ein PersonRepository bereit, das Zugriffe bei
+// vorübergehend gesperrter Datenbank (SQLITE_BUSY, SQLITE_LOCKED) mit
+// exponentiellem Backoff wiederholt.
+package retrying
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository"
+)
+
+// Vorgaben für Versuche und Backoff, siehe WithMaxAttempts und WithBackoff.
+const (
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = 10 * time.Millisecond
+	defaultMaxDelay    = 500 * time.Millisecond
+)
+
+// Primäre SQLite-Ergebniscodes für eine belegte bzw. gesperrte Datenbank.
+// Erweiterte Codes (z. B. SQLITE_BUSY_SNAPSHOT) tragen sie im unteren Byte.
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// PersonRepository umschließt ein anderes PersonRepository und wiederholt
+// Zugriffe, die an einer belegten Datenbank scheitern. Alle übrigen Fehler
+// reicht es unverändert durch.
+type PersonRepository struct {
+	next        repository.PersonRepository
+	logger      *zap.Logger
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// Option konfiguriert optionale Eigenschaften des PersonRepository.
+type Option func(*PersonRepository)
+
+// WithMaxAttempts begrenzt die Anzahl der Versuche je Zugriff einschließlich
+// des ersten. Werte unter 1 werden ignoriert.
+func WithMaxAttempts(n int) Option {
+	return func(r *PersonRepository) {
+		if n > 0 {
+			r.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff setzt die Wartezeit vor dem ersten Wiederholen und ihre
+// Obergrenze. Die Wartezeit verdoppelt sich mit jedem Versuch.
+func WithBackoff(base, max time.Duration) Option {
+	return func(r *PersonRepository) {
+		if base > 0 {
+			r.baseDelay = base
+		}
+		if max > 0 {
+			r.maxDelay = max
+		}
+	}
+}
+
+// New umschließt next.
+func New(next repository.PersonRepository, logger *zap.Logger, opts ...Option) *PersonRepository {
+	r := &PersonRepository{
+		next:        next,
+		logger:      logger,
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// transient meldet, ob err auf eine belegte oder gesperrte SQLite-Datenbank
+// zurückgeht. Der Treiber wird nicht importiert; es genügt ein Fehler in der
+// Kette, der seinen Ergebniscode über Code() preisgibt.
+func transient(err error) bool {
+	var coder interface{ Code() int }
+	if !errors.As(err, &coder) {
+		return false
+	}
+	switch coder.Code() & 0xff {
+	case sqliteBusy, sqliteLocked:
+		return true
+	}
+	return false
+}
+
+// backoff liefert die Wartezeit vor dem Versuch attempt+1. Sie liegt
+// zufällig zwischen der Hälfte und dem Ganzen des exponentiellen Werts,
+// damit konkurrierende Schreiber nicht im Gleichtakt erneut zugreifen.
+func (r *PersonRepository) backoff(attempt int) time.Duration {
+	d := r.baseDelay << (attempt - 1)
+	if d <= 0 || d > r.maxDelay {
+		d = r.maxDelay
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// do führt fn aus und wiederholt es, solange der Fehler transient ist und
+// Versuche übrig sind. Reicht die Frist von ctx nicht für die nächste
+// Wartezeit oder endet ctx währenddessen, bleibt es beim letzten Fehler.
+func (r *PersonRepository) do(ctx context.Context, op string, fn func() error) error {
+	return r.doWhile(ctx, op, fn, func() bool { return true })
+}
+
+// doWhile ist do, wiederholt aber nur, solange again true meldet.
+func (r *PersonRepository) doWhile(ctx context.Context, op string, fn func() error, again func() bool) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !transient(err) || attempt >= r.maxAttempts || !again() {
+			return err
+		}
+
+		delay := r.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		r.logger.Debug("datenbank belegt, erneuter versuch",
+			zap.String("vorgang", op),
+			zap.Int("versuch", attempt),
+			zap.Duration("wartezeit", delay),
+			zap.Error(err),
+		)
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// ─── PersonRepository ─────────────────────────────────────────────────────────
+
+func (r *PersonRepository) GetAll(ctx context.Context) (persons []domain.Person, err error) {
+	err = r.do(ctx, "GetAll", func() (err error) {
+		persons, err = r.next.GetAll(ctx)
+		return err
+	})
+	return persons, err
+}
+
+// GetAllStream wiederholt nur, solange fn noch keine Person erhalten hat;
+// danach würde ein neuer Versuch Personen doppelt liefern.
+func (r *PersonRepository) GetAllStream(ctx context.Context, fn func(domain.Person) error) error {
+	started := false
+	return r.doWhile(ctx, "GetAllStream", func() error {
+		return r.next.GetAllStream(ctx, func(p domain.Person) error {
+			started = true
+			return fn(p)
+		})
+	}, func() bool { return !started })
+}
+
+func (r *PersonRepository) GetAllAfter(ctx context.Context, afterID, limit int) (persons []domain.Person, err error) {
+	err = r.do(ctx, "GetAllAfter", func() (err error) {
+		persons, err = r.next.GetAllAfter(ctx, afterID, limit)
+		return err
+	})
+	return persons, err
+}
+
+func (r *PersonRepository) GetByID(ctx context.Context, id int) (p domain.Person, err error) {
+	err = r.do(ctx, "GetByID", func() (err error) {
+		p, err = r.next.GetByID(ctx, id)
+		return err
+	})
+	return p, err
+}
+
+func (r *PersonRepository) GetByIDs(ctx context.Context, ids []int) (persons []domain.Person, err error) {
+	err = r.do(ctx, "GetByIDs", func() (err error) {
+		persons, err = r.next.GetByIDs(ctx, ids)
+		return err
+	})
+	return persons, err
+}
+
+func (r *PersonRepository) GetByColor(ctx context.Context, color domain.Color, order domain.Order, limit, offset int) (persons []domain.Person, err error) {
+	err = r.do(ctx, "GetByColor", func() (err error) {
+		persons, err = r.next.GetByColor(ctx, color, order, limit, offset)
+		return err
+	})
+	return persons, err
+}
+
+func (r *PersonRepository) GetByColors(ctx context.Context, colors []domain.Color, limit, offset int) (persons []domain.Person, err error) {
+	err = r.do(ctx, "GetByColors", func() (err error) {
+		persons, err = r.next.GetByColors(ctx, colors, limit, offset)
+		return err
+	})
+	return persons, err
+}
+
+func (r *PersonRepository) GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) (persons []domain.Person, err error) {
+	err = r.do(ctx, "GetByZipcode", func() (err error) {
+		persons, err = r.next.GetByZipcode(ctx, zip, prefix, limit, offset)
+		return err
+	})
+	return persons, err
+}
+
+func (r *PersonRepository) Find(ctx context.Context, filter domain.PersonFilter) (persons []domain.Person, err error) {
+	err = r.do(ctx, "Find", func() (err error) {
+		persons, err = r.next.Find(ctx, filter)
+		return err
+	})
+	return persons, err
+}
+
+func (r *PersonRepository) Count(ctx context.Context, filter domain.PersonFilter) (n int, err error) {
+	err = r.do(ctx, "Count", func() (err error) {
+		n, err = r.next.Count(ctx, filter)
+		return err
+	})
+	return n, err
+}
+
+// Add darf wiederholt werden, weil SQLite eine an SQLITE_BUSY gescheiterte
+// Anweisung vollständig verwirft; es entstehen keine Duplikate.
+func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (added domain.Person, err error) {
+	err = r.do(ctx, "Add", func() (err error) {
+		added, err = r.next.Add(ctx, person)
+		return err
+	})
+	return added, err
+}
+
+func (r *PersonRepository) Insert(ctx context.Context, person domain.Person) (inserted domain.Person, err error) {
+	err = r.do(ctx, "Insert", func() (err error) {
+		inserted, err = r.next.Insert(ctx, person)
+		return err
+	})
+	return inserted, err
+}
+
+func (r *PersonRepository) Update(ctx context.Context, person domain.Person, expectedVersion int) (updated domain.Person, err error) {
+	err = r.do(ctx, "Update", func() (err error) {
+		updated, err = r.next.Update(ctx, person, expectedVersion)
+		return err
+	})
+	return updated, err
+}
+
+func (r *PersonRepository) CountsByColor(ctx context.Context) (counts map[domain.Color]int, err error) {
+	err = r.do(ctx, "CountsByColor", func() (err error) {
+		counts, err = r.next.CountsByColor(ctx)
+		return err
+	})
+	return counts, err
+}
+
+func (r *PersonRepository) Stats(ctx context.Context) (stats domain.PersonStats, err error) {
+	err = r.do(ctx, "Stats", func() (err error) {
+		stats, err = r.next.Stats(ctx)
+		return err
+	})
+	return stats, err
+}
+
+func (r *PersonRepository) DistinctCities(ctx context.Context) (cities []string, err error) {
+	err = r.do(ctx, "DistinctCities", func() (err error) {
+		cities, err = r.next.DistinctCities(ctx)
+		return err
+	})
+	return cities, err
+}
+
+func (r *PersonRepository) Delete(ctx context.Context, id int, at time.Time) error {
+	return r.do(ctx, "Delete", func() error {
+		return r.next.Delete(ctx, id, at)
+	})
+}
+
+func (r *PersonRepository) DeleteByColor(ctx context.Context, color domain.Color, at time.Time) (n int, err error) {
+	err = r.do(ctx, "DeleteByColor", func() (err error) {
+		n, err = r.next.DeleteByColor(ctx, color, at)
+		return err
+	})
+	return n, err
+}
+
+func (r *PersonRepository) Restore(ctx context.Context, id int) (restored domain.Person, err error) {
+	err = r.do(ctx, "Restore", func() (err error) {
+		restored, err = r.next.Restore(ctx, id)
+		return err
+	})
+	return restored, err
+}
+
+func (r *PersonRepository) Purge(ctx context.Context, before time.Time) (n int, err error) {
+	err = r.do(ctx, "Purge", func() (err error) {
+		n, err = r.next.Purge(ctx, before)
+		return err
+	})
+	return n, err
+}
+
+func (r *PersonRepository) DeleteAll(ctx context.Context) error {
+	return r.do(ctx, "DeleteAll", func() error {
+		return r.next.DeleteAll(ctx)
+	})
+}
+
+func (r *PersonRepository) Capacity(ctx context.Context) (used, max int, err error) {
+	err = r.do(ctx, "Capacity", func() (err error) {
+		used, max, err = r.next.Capacity(ctx)
+		return err
+	})
+	return used, max, err
+}
+
+func (r *PersonRepository) LastModified(ctx context.Context) (modified time.Time, err error) {
+	err = r.do(ctx, "LastModified", func() (err error) {
+		modified, err = r.next.LastModified(ctx)
+		return err
+	})
+	return modified, err
+}
@@ -0,0 +1,178 @@
+package retrying
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository"
+)
+
+// codeError ahmt den Fehler des SQLite-Treibers mit Ergebniscode nach.
+type codeError struct{ code int }
+
+func (e *codeError) Error() string { return fmt.Sprintf("sqlite code %d", e.code) }
+func (e *codeError) Code() int     { return e.code }
+
+var (
+	errBusy       = fmt.Errorf("abfrage: %w", &codeError{code: 5})
+	errConstraint = fmt.Errorf("einfügen: %w", &codeError{code: 19})
+)
+
+// flakyRepo scheitert bei den ersten fails Aufrufen mit err.
+type flakyRepo struct {
+	repository.PersonRepository
+	fails int
+	err   error
+	calls int
+}
+
+func (f *flakyRepo) next() error {
+	f.calls++
+	if f.calls <= f.fails {
+		return f.err
+	}
+	return nil
+}
+
+func (f *flakyRepo) GetByID(_ context.Context, id int) (domain.Person, error) {
+	if err := f.next(); err != nil {
+		return domain.Person{}, err
+	}
+	return domain.Person{ID: id}, nil
+}
+
+func (f *flakyRepo) Capacity(_ context.Context) (int, int, error) {
+	if err := f.next(); err != nil {
+		return 0, 0, err
+	}
+	return 3, 10, nil
+}
+
+func (f *flakyRepo) GetAllStream(_ context.Context, fn func(domain.Person) error) error {
+	f.calls++
+	if err := fn(domain.Person{ID: 1}); err != nil {
+		return err
+	}
+	if f.calls <= f.fails {
+		return f.err
+	}
+	return fn(domain.Person{ID: 2})
+}
+
+func neuesRepo(inner repository.PersonRepository) *PersonRepository {
+	return New(inner, zap.NewNop(), WithMaxAttempts(3), WithBackoff(time.Millisecond, 2*time.Millisecond))
+}
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"busy", errBusy, true},
+		{"locked", &codeError{code: 6}, true},
+		{"erweiterter busy-code", &codeError{code: 517}, true},
+		{"constraint", errConstraint, false},
+		{"nicht gefunden", domain.ErrNotFound, false},
+		{"ungültige eingabe", fmt.Errorf("name: %w", domain.ErrInvalidInput), false},
+		{"ohne code", errors.New("database is locked"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, transient(tt.err))
+		})
+	}
+}
+
+func TestGetByID_Wiederholung(t *testing.T) {
+	tests := []struct {
+		name      string
+		fails     int
+		err       error
+		wantErr   error
+		wantCalls int
+	}{
+		{"erfolg nach zwei fehlschlägen", 2, errBusy, nil, 3},
+		{"versuche erschöpft", 5, errBusy, errBusy, 3},
+		{"nicht gefunden wird nicht wiederholt", 5, domain.ErrNotFound, domain.ErrNotFound, 1},
+		{"constraint wird nicht wiederholt", 5, errConstraint, errConstraint, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyRepo{fails: tt.fails, err: tt.err}
+
+			p, err := neuesRepo(inner).GetByID(context.Background(), 7)
+
+			assert.Equal(t, tt.wantCalls, inner.calls)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 7, p.ID)
+		})
+	}
+}
+
+func TestCapacity_Wiederholung(t *testing.T) {
+	inner := &flakyRepo{fails: 1, err: errBusy}
+
+	used, max, err := neuesRepo(inner).Capacity(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, used)
+	assert.Equal(t, 10, max)
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestWiederholung_NieUeberDieFristHinaus(t *testing.T) {
+	inner := &flakyRepo{fails: 5, err: errBusy}
+	repo := New(inner, zap.NewNop(), WithBackoff(time.Second, time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := repo.GetByID(ctx, 1)
+
+	assert.ErrorIs(t, err, errBusy)
+	assert.Equal(t, 1, inner.calls, "die wartezeit passt nicht mehr in die frist")
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestWiederholung_AbbruchWaehrendDesWartens(t *testing.T) {
+	inner := &flakyRepo{fails: 5, err: errBusy}
+	repo := New(inner, zap.NewNop(), WithBackoff(time.Second, time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := repo.GetByID(ctx, 1)
+
+	assert.ErrorIs(t, err, errBusy)
+	assert.Equal(t, 1, inner.calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestGetAllStream_KeineWiederholungNachErsterPerson(t *testing.T) {
+	inner := &flakyRepo{fails: 1, err: errBusy}
+	var ids []int
+
+	err := neuesRepo(inner).GetAllStream(context.Background(), func(p domain.Person) error {
+		ids = append(ids, p.ID)
+		return nil
+	})
+
+	assert.ErrorIs(t, err, errBusy)
+	assert.Equal(t, 1, inner.calls)
+	assert.Equal(t, []int{1}, ids, "keine person doppelt")
+}
@@ -0,0 +1,109 @@
+//go:build integration
+
+package sqlite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// Die Tests in dieser Datei arbeiten mit einer echten Datenbankdatei statt
+// :memory: und laufen nur mit -tags integration (make test-integration).
+// Sie prüfen, was :memory: nicht zeigen kann: dass Daten ein Schließen und
+// erneutes Öffnen überstehen und alle Verbindungen des Pools dieselbe Datei
+// sehen.
+
+// openFile öffnet das Repository zur Datei dsn mit mehreren Verbindungen,
+// damit Lese- und Schreibzugriffe nicht zufällig dieselbe Verbindung nutzen.
+func openFile(t *testing.T, dsn string) *PersonRepository {
+	t.Helper()
+	repo, err := NewPersonRepository(dsn, 0, testLogger(), WithPool(PoolConfig{MaxOpenConns: 4}))
+	require.NoError(t, err)
+	return repo
+}
+
+func TestIntegration_DatenUeberstehenNeustart(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "persons.db")
+	ctx := context.Background()
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := openFile(t, dsn)
+	for i, p := range []domain.Person{
+		{Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"},
+		{Name: "Peter", Lastname: "Petersen", Zipcode: "18439", City: "Stralsund", Color: "grün"},
+		{Name: "Johnny", Lastname: "Johnson", Zipcode: "88888", City: "made up", Color: "violett"},
+	} {
+		p.CreatedAt = at.Add(time.Duration(i) * time.Minute)
+		p.UpdatedAt = p.CreatedAt
+		_, err := repo.Add(ctx, p)
+		require.NoError(t, err)
+	}
+	changed := domain.Person{ID: 2, Name: "Peter", Lastname: "Petersen", Zipcode: "18439", City: "Rostock", Color: "grün",
+		CreatedAt: at.Add(time.Minute), UpdatedAt: at.Add(time.Hour)}
+	_, err := repo.Update(ctx, changed, 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, 3, at.Add(2*time.Hour)))
+
+	before, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	modified, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	_, err = os.Stat(dsn)
+	require.NoError(t, err, "die datenbank liegt als datei vor")
+
+	reopened := openFile(t, dsn)
+	defer func() { _ = reopened.Close() }()
+
+	after, err := reopened.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "bestand, zeitstempel und versionen bleiben erhalten")
+	require.Len(t, after, 2)
+	assert.Equal(t, "Rostock", after[1].City)
+	assert.Equal(t, 2, after[1].Version)
+
+	stillModified, err := reopened.LastModified(ctx)
+	require.NoError(t, err)
+	assert.True(t, modified.Equal(stillModified), "änderungszeitpunkt bleibt erhalten")
+
+	_, err = reopened.GetByID(ctx, 3)
+	assert.ErrorIs(t, err, domain.ErrGone, "vorläufig gelöschte person bleibt gelöscht")
+	restored, err := reopened.Restore(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "Johnny", restored.Name)
+
+	added, err := reopened.Add(ctx, domain.Person{Name: "Anna", Lastname: "Schmidt", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, added.ID, "ids werden nach dem neustart fortgezählt")
+}
+
+func TestIntegration_ZweiRepositoriesTeilenDieDatei(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "shared.db") + "?_pragma=busy_timeout(5000)"
+	ctx := context.Background()
+
+	writer := openFile(t, dsn)
+	defer func() { _ = writer.Close() }()
+	reader := openFile(t, dsn)
+	defer func() { _ = reader.Close() }()
+
+	added, err := writer.Add(ctx, domain.Person{Name: "Hans", Lastname: "Müller", Color: "blau"})
+	require.NoError(t, err)
+
+	got, err := reader.GetByID(ctx, added.ID)
+	require.NoError(t, err)
+	assert.Equal(t, added, got)
+
+	require.NoError(t, reader.DeleteAll(ctx))
+	n, err := writer.Count(ctx, domain.PersonFilter{})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
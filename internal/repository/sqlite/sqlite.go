@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	_ "modernc.org/sqlite"
@@ -11,40 +13,206 @@ import (
 	"assecor-assessment-backend/internal/domain"
 )
 
+// personColumns ist die Spaltenliste aller Personenabfragen in der Reihenfolge von scanPerson.
+const personColumns = "id, name, lastname, zipcode, city, color, created_at, updated_at, version, deleted_at"
+
+// notDeleted schränkt Abfragen auf nicht gelöschte Personen ein.
+const notDeleted = "deleted_at = ''"
+
+// timeLayout speichert Zeitstempel in UTC mit fester Breite, damit der
+// Textvergleich in SQL der zeitlichen Reihenfolge entspricht.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
 // PersonRepository implementiert repository.PersonRepository
 type PersonRepository struct {
 	db         *sql.DB
 	maxPersons int
+	pool       PoolConfig
 	logger     *zap.Logger
 }
 
+// PoolConfig steuert den Verbindungspool von database/sql. Nullwerte lassen
+// die Voreinstellungen von database/sql unverändert (unbegrenzt viele offene,
+// zwei ruhende Verbindungen, keine Höchstlebensdauer).
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Option konfiguriert optionale Eigenschaften des PersonRepository.
+type Option func(*PersonRepository)
+
+// WithPool setzt die Grenzen des Verbindungspools. Für In-Memory-Datenbanken
+// wird sie ignoriert, siehe applyPool.
+func WithPool(p PoolConfig) Option {
+	return func(r *PersonRepository) {
+		r.pool = p
+	}
+}
+
+// IsMemoryDSN meldet, ob dsn eine In-Memory-Datenbank beschreibt.
+func IsMemoryDSN(dsn string) bool {
+	return dsn == ":memory:" || strings.HasPrefix(dsn, "file::memory:") || strings.Contains(dsn, "mode=memory")
+}
+
 // NewPersonRepository öffnet die SQLite-Datenbank unter dsn, erstellt das
 // Schema und gibt ein einsatzbereites Repository zurück.
 // maxPersons begrenzt die Zeilenanzahl; 0 bedeutet unbegrenzt.
-func NewPersonRepository(dsn string, maxPersons int, logger *zap.Logger) (*PersonRepository, error) {
+func NewPersonRepository(dsn string, maxPersons int, logger *zap.Logger, opts ...Option) (*PersonRepository, error) {
+	r := &PersonRepository{maxPersons: maxPersons, logger: logger}
+	for _, opt := range opts {
+		opt(r)
+	}
+
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("sqlite öffnen: %w", err)
 	}
+	r.db = db
+	r.applyPool(IsMemoryDSN(dsn))
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("sqlite ping: %w", err)
 	}
 
 	if _, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS persons (
-			id       INTEGER PRIMARY KEY AUTOINCREMENT,
-			name     TEXT NOT NULL,
-			lastname TEXT NOT NULL,
-			zipcode  TEXT NOT NULL DEFAULT '',
-			city     TEXT NOT NULL DEFAULT '',
-			color    TEXT NOT NULL
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			name       TEXT NOT NULL,
+			lastname   TEXT NOT NULL,
+			zipcode    TEXT NOT NULL DEFAULT '',
+			city       TEXT NOT NULL DEFAULT '',
+			color      TEXT NOT NULL,
+			created_at TEXT NOT NULL DEFAULT '',
+			updated_at TEXT NOT NULL DEFAULT '',
+			version    INTEGER NOT NULL DEFAULT 1,
+			deleted_at TEXT NOT NULL DEFAULT ''
 		)
 	`); err != nil {
 		return nil, fmt.Errorf("tabelle erstellen: %w", err)
 	}
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+	if err := createMeta(db); err != nil {
+		return nil, err
+	}
 
 	logger.Info("sqlite-repository initialisiert", zap.String("dsn", dsn))
-	return &PersonRepository{db: db, maxPersons: maxPersons, logger: logger}, nil
+	return r, nil
+}
+
+// applyPool setzt die Poolgrenzen direkt nach sql.Open.
+//
+// Eine In-Memory-Datenbank lebt nur so lange wie ihre Verbindung, und jede
+// weitere Verbindung sähe eine eigene, leere Datenbank ("no such table").
+// Dort gilt daher immer genau eine offene Verbindung, die ruhend erhalten
+// bleibt und nie altert; abweichende Einstellungen werden mit einer Warnung
+// ignoriert.
+func (r *PersonRepository) applyPool(memory bool) {
+	if memory {
+		if r.pool != (PoolConfig{}) {
+			r.logger.Warn("poolgrenzen werden für in-memory-datenbank ignoriert",
+				zap.Int("max_open_conns", r.pool.MaxOpenConns),
+				zap.Int("max_idle_conns", r.pool.MaxIdleConns),
+				zap.Duration("conn_max_lifetime", r.pool.ConnMaxLifetime))
+		}
+		r.db.SetMaxOpenConns(1)
+		r.db.SetMaxIdleConns(1)
+		r.db.SetConnMaxLifetime(0)
+		return
+	}
+	if r.pool.MaxOpenConns > 0 {
+		r.db.SetMaxOpenConns(r.pool.MaxOpenConns)
+	}
+	if r.pool.MaxIdleConns > 0 {
+		r.db.SetMaxIdleConns(r.pool.MaxIdleConns)
+	}
+	if r.pool.ConnMaxLifetime > 0 {
+		r.db.SetConnMaxLifetime(r.pool.ConnMaxLifetime)
+	}
+}
+
+// migrate ergänzt Spalten, die in Datenbanken älterer Versionen fehlen.
+// Bestehende Zeilen erhalten leere Zeitstempel, die als „unbekannt“ gelten,
+// und die Version 1.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('persons')")
+	if err != nil {
+		return fmt.Errorf("schema lesen: %w", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("schema lesen: %w", err)
+		}
+		existing[name] = true
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("schema lesen: %w", err)
+	}
+
+	for _, column := range []struct{ name, definition string }{
+		{"created_at", "TEXT NOT NULL DEFAULT ''"},
+		{"updated_at", "TEXT NOT NULL DEFAULT ''"},
+		{"version", "INTEGER NOT NULL DEFAULT 1"},
+		{"deleted_at", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE persons ADD COLUMN " + column.name + " " + column.definition); err != nil {
+			return fmt.Errorf("spalte %s ergänzen: %w", column.name, err)
+		}
+	}
+	return nil
+}
+
+// lastModifiedKey ist der Schlüssel des Änderungszeitpunkts in meta.
+const lastModifiedKey = "last_modified"
+
+// createMeta legt die Tabelle meta für Werte über den gesamten Bestand an.
+// Den Änderungszeitpunkt setzen Trigger bei jeder eingefügten, geänderten
+// oder gelöschten Zeile in derselben Transaktion, sodass keine Schreibmethode
+// ihn vergessen kann. Eine neue Datenbank gilt als zum Anlegen geändert.
+func createMeta(db *sql.DB) error {
+	// Das Format entspricht timeLayout mit Millisekunden, was für
+	// Last-Modified mit Sekundengenauigkeit genügt.
+	const now = "strftime('%Y-%m-%dT%H:%M:%fZ', 'now')"
+	stmts := []string{
+		"CREATE TABLE IF NOT EXISTS meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)",
+		"INSERT OR IGNORE INTO meta (key, value) VALUES ('" + lastModifiedKey + "', " + now + ")",
+	}
+	for _, event := range []string{"INSERT", "UPDATE", "DELETE"} {
+		stmts = append(stmts, "CREATE TRIGGER IF NOT EXISTS persons_modified_"+strings.ToLower(event)+
+			" AFTER "+event+" ON persons BEGIN "+
+			"UPDATE meta SET value = "+now+" WHERE key = '"+lastModifiedKey+"'; END")
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("metadaten anlegen: %w", err)
+		}
+	}
+	return nil
+}
+
+// LastModified liest den von den Triggern gepflegten Änderungszeitpunkt.
+func (r *PersonRepository) LastModified(ctx context.Context) (modified time.Time, err error) {
+	ctx, span := startSpan(ctx, "meta.last_modified")
+	defer func() { endSpan(span, err) }()
+
+	var value string
+	if err := r.db.QueryRowContext(ctx, "SELECT value FROM meta WHERE key = ?", lastModifiedKey).Scan(&value); err != nil {
+		return time.Time{}, fmt.Errorf("änderungszeitpunkt abfragen: %w", err)
+	}
+	modified, err = time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("änderungszeitpunkt %q: %w", value, err)
+	}
+	return modified, nil
 }
 
 // Close schließt die zugrunde liegende Datenbankverbindung.
@@ -52,55 +220,389 @@ func (r *PersonRepository) Close() error {
 	return r.db.Close()
 }
 
-// GetAll gibt alle Personen zurück.
-func (r *PersonRepository) GetAll(ctx context.Context) ([]domain.Person, error) {
+// GetAll gibt alle nicht gelöschten Personen zurück.
+func (r *PersonRepository) GetAll(ctx context.Context) (persons []domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.select_all")
+	defer func() { endSpan(span, err, returnedRows(len(persons))) }()
+
 	return r.queryPersons(ctx,
-		"SELECT id, name, lastname, zipcode, city, color FROM persons ORDER BY id")
+		"SELECT "+personColumns+" FROM persons WHERE "+notDeleted+" ORDER BY id")
+}
+
+// streamBatchSize ist die Anzahl Personen, die GetAllStream je Abfrage liest.
+const streamBatchSize = 500
+
+// GetAllStream liest alle Personen in Blöcken von streamBatchSize per
+// Keyset-Abfrage (wie GetAllAfter) und ruft fn für jede auf, sodass der
+// Speicherverbrauch unabhängig von der Tabellengröße bleibt. Während fn läuft,
+// ist keine Abfrage offen: Ein langsamer Empfänger hält so keine Verbindung
+// fest, was bei :memory: mit nur einer Verbindung alle anderen Zugriffe
+// blockieren würde. Die Ausgabe ist daher kein Schnappschuss; während des
+// Streams angelegte Personen mit höherer ID erscheinen noch. Ein
+// abgebrochener ctx beendet die Iteration vor der nächsten Person.
+func (r *PersonRepository) GetAllStream(ctx context.Context, fn func(domain.Person) error) (err error) {
+	ctx, span := startSpan(ctx, "persons.select_all_stream")
+	read := 0
+	defer func() { endSpan(span, err, returnedRows(read)) }()
+
+	afterID := 0
+	for {
+		batch, err := r.queryPersons(ctx,
+			"SELECT "+personColumns+" FROM persons WHERE id > ? AND "+notDeleted+" ORDER BY id LIMIT ?",
+			afterID, streamBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, p := range batch {
+			// database/sql bemerkt den Abbruch erst asynchron; die explizite
+			// Prüfung beendet die Iteration sofort.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			read++
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+		if len(batch) < streamBatchSize {
+			return nil
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+}
+
+// GetAllAfter nutzt den Primärschlüssel-Index über WHERE id > ? statt OFFSET,
+// sodass die Kosten nicht mit der Seitennummer wachsen.
+func (r *PersonRepository) GetAllAfter(ctx context.Context, afterID, limit int) (persons []domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.select_after")
+	defer func() { endSpan(span, err, returnedRows(len(persons))) }()
+
+	if limit <= 0 {
+		limit = -1
+	}
+	return r.queryPersons(ctx,
+		"SELECT "+personColumns+" FROM persons WHERE id > ? AND "+notDeleted+" ORDER BY id LIMIT ?",
+		afterID, limit)
 }
 
 // GetByID sucht eine Person anhand ihrer ID.
-func (r *PersonRepository) GetByID(ctx context.Context, id int) (domain.Person, error) {
-	var p domain.Person
-	err := r.db.QueryRowContext(ctx,
-		"SELECT id, name, lastname, zipcode, city, color FROM persons WHERE id = ?", id,
-	).Scan(&p.ID, &p.Name, &p.Lastname, &p.Zipcode, &p.City, &p.Color)
+func (r *PersonRepository) GetByID(ctx context.Context, id int) (p domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.select_by_id")
+	defer func() { endSpan(span, err, returnedRows(min(p.ID, 1))) }()
+
+	row := r.db.QueryRowContext(ctx,
+		"SELECT "+personColumns+" FROM persons WHERE id = ?", id)
+	err = scanPerson(row, &p)
 	if err == sql.ErrNoRows {
 		return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
 	}
 	if err != nil {
 		return domain.Person{}, fmt.Errorf("abfrage person id %d: %w", id, err)
 	}
+	if p.Deleted() {
+		return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrGone)
+	}
 	return p, nil
 }
 
-// GetByColor gibt alle Personen mit passender Lieblingsfarbe zurück.
-func (r *PersonRepository) GetByColor(ctx context.Context, color string) ([]domain.Person, error) {
+// GetByIDs lädt alle angefragten IDs mit einer einzigen WHERE id IN (...)-Abfrage.
+func (r *PersonRepository) GetByIDs(ctx context.Context, ids []int) (persons []domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.select_by_ids")
+	defer func() { endSpan(span, err, returnedRows(len(persons))) }()
+
+	if len(ids) == 0 {
+		return []domain.Person{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return r.queryPersons(ctx,
+		"SELECT "+personColumns+" FROM persons WHERE id IN ("+placeholders+") AND "+notDeleted+" ORDER BY id",
+		args...)
+}
+
+// GetByColor gibt die Personen mit passender Lieblingsfarbe in der
+// Reihenfolge order zurück und überlässt die Seitenbildung SQLite.
+func (r *PersonRepository) GetByColor(ctx context.Context, color domain.Color, order domain.Order, limit, offset int) (persons []domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.select_by_color")
+	defer func() { endSpan(span, err, returnedRows(len(persons))) }()
+
+	orderBy, err := orderClause(order)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	return r.queryPersons(ctx,
+		"SELECT "+personColumns+" FROM persons WHERE color = ? AND "+notDeleted+" ORDER BY "+orderBy+" LIMIT ? OFFSET ?",
+		color, limit, offset)
+}
+
+// sortColumns bildet die Felder aus domain.SortFields auf Spalten ab. Nur
+// diese Spalten gelangen in ein ORDER BY.
+var sortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"lastname":   "lastname",
+	"zipcode":    "zipcode",
+	"city":       "city",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// orderClause übersetzt order in den Ausdruck hinter ORDER BY. Gleichstand
+// löst die ID auf, wie domain.Order.Compare es vorgibt; die Zeitstempel
+// sortieren dank timeLayout als Text richtig.
+func orderClause(order domain.Order) (string, error) {
+	if err := order.Validate(); err != nil {
+		return "", err
+	}
+	if order.IsZero() {
+		return "id", nil
+	}
+	column, ok := sortColumns[order.Field]
+	if !ok {
+		column = "id"
+	}
+	if order.Desc {
+		column += " DESC"
+	}
+	if order.Field == "id" {
+		return column, nil
+	}
+	return column + ", id", nil
+}
+
+// GetByColors liest alle Farben mit einer einzigen WHERE color IN (...)-Abfrage
+// und überlässt Sortierung und Seitenbildung SQLite.
+func (r *PersonRepository) GetByColors(ctx context.Context, colors []domain.Color, limit, offset int) (persons []domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.select_by_colors")
+	defer func() { endSpan(span, err, returnedRows(len(persons))) }()
+
+	if len(colors) == 0 {
+		return []domain.Person{}, nil
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(colors)), ",")
+	args := make([]any, 0, len(colors)+2)
+	for _, c := range colors {
+		args = append(args, c)
+	}
+	args = append(args, limit, offset)
+	return r.queryPersons(ctx,
+		"SELECT "+personColumns+" FROM persons WHERE color IN ("+placeholders+") AND "+notDeleted+
+			" ORDER BY color, id LIMIT ? OFFSET ?",
+		args...)
+}
+
+// GetByZipcode sucht exakt per = oder als Präfix per LIKE. Platzhalter in zip
+// werden maskiert, sodass "6_7" nicht auf "6a7…" passt.
+func (r *PersonRepository) GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) (persons []domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.select_by_zipcode")
+	defer func() { endSpan(span, err, returnedRows(len(persons))) }()
+
+	cond, arg := "zipcode = ?", zip
+	if prefix {
+		cond, arg = `zipcode LIKE ? ESCAPE '\'`, escapeLike(zip)+"%"
+	}
+	if limit <= 0 {
+		limit = -1
+	}
 	return r.queryPersons(ctx,
-		"SELECT id, name, lastname, zipcode, city, color FROM persons WHERE color = ? ORDER BY id",
-		color)
+		"SELECT "+personColumns+" FROM persons WHERE "+cond+" AND "+notDeleted+" ORDER BY id LIMIT ? OFFSET ?",
+		arg, limit, offset)
+}
+
+// likeEscaper maskiert die LIKE-Platzhalter und das Escape-Zeichen selbst.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike macht s zu einem wörtlichen LIKE-Muster (ESCAPE '\').
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// Find setzt filter in WHERE-, LIMIT- und OFFSET-Klauseln einer einzigen Abfrage um.
+func (r *PersonRepository) Find(ctx context.Context, filter domain.PersonFilter) (persons []domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.find")
+	defer func() { endSpan(span, err, returnedRows(len(persons))) }()
+
+	where, args := filterWhere(filter)
+	query := "SELECT " + personColumns + " FROM persons" + where + " ORDER BY id"
+	if filter.Limit > 0 || filter.Offset > 0 {
+		// LIMIT -1 steht in SQLite für „unbegrenzt“ und erlaubt OFFSET ohne Limit.
+		limit := filter.Limit
+		if limit == 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, filter.Offset)
+	}
+	return r.queryPersons(ctx, query, args...)
+}
+
+// Count zählt die Treffer von filter per COUNT mit derselben WHERE-Klausel wie Find.
+func (r *PersonRepository) Count(ctx context.Context, filter domain.PersonFilter) (n int, err error) {
+	ctx, span := startSpan(ctx, "persons.count")
+	defer func() { endSpan(span, err) }()
+
+	where, args := filterWhere(filter)
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM persons"+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("anzahl abfragen: %w", err)
+	}
+	return n, nil
+}
+
+// filterWhere setzt die Bedingungen von filter in eine WHERE-Klausel samt
+// Argumenten um. Ohne Bedingungen ist die Klausel leer.
+func filterWhere(filter domain.PersonFilter) (string, []any) {
+	var where []string
+	var args []any
+	if !filter.IncludeDeleted {
+		where = append(where, notDeleted)
+	}
+	if filter.Color != "" {
+		where = append(where, "color = ?")
+		args = append(args, filter.Color)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, "created_at > ?")
+		args = append(args, formatTime(filter.CreatedAfter))
+	}
+	if len(where) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+// CountsByColor zählt die Personen je Farbe per GROUP BY.
+func (r *PersonRepository) CountsByColor(ctx context.Context) (counts map[domain.Color]int, err error) {
+	ctx, span := startSpan(ctx, "persons.count_by_color")
+	defer func() { endSpan(span, err, returnedRows(len(counts))) }()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT color, COUNT(*) FROM persons WHERE "+notDeleted+" GROUP BY color")
+	if err != nil {
+		return nil, fmt.Errorf("abfrage: %w", err)
+	}
+	defer rows.Close()
+
+	counts = make(map[domain.Color]int)
+	for rows.Next() {
+		var color domain.Color
+		var n int
+		if err := rows.Scan(&color, &n); err != nil {
+			return nil, fmt.Errorf("zeile lesen: %w", err)
+		}
+		counts[color] = n
+	}
+	return counts, rows.Err()
+}
+
+// DistinctCities fragt die Städte per SELECT DISTINCT ab.
+func (r *PersonRepository) DistinctCities(ctx context.Context) (cities []string, err error) {
+	ctx, span := startSpan(ctx, "persons.distinct_cities")
+	defer func() { endSpan(span, err, returnedRows(len(cities))) }()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT DISTINCT city FROM persons WHERE "+notDeleted+" AND city <> '' ORDER BY city")
+	if err != nil {
+		return nil, fmt.Errorf("abfrage: %w", err)
+	}
+	defer rows.Close()
+
+	cities = []string{}
+	for rows.Next() {
+		var city string
+		if err := rows.Scan(&city); err != nil {
+			return nil, fmt.Errorf("zeile lesen: %w", err)
+		}
+		cities = append(cities, city)
+	}
+	return cities, rows.Err()
+}
+
+// Capacity zählt die nicht gelöschten Personen per COUNT.
+func (r *PersonRepository) Capacity(ctx context.Context) (used, max int, err error) {
+	ctx, span := startSpan(ctx, "persons.capacity")
+	defer func() { endSpan(span, err) }()
+
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM persons WHERE "+notDeleted).Scan(&used); err != nil {
+		return 0, 0, fmt.Errorf("anzahl abfragen: %w", err)
+	}
+	return used, r.maxPersons, nil
+}
+
+// Stats ermittelt die Kennzahlen per COUNT und GROUP BY innerhalb einer
+// Lesetransaktion, damit alle Werte denselben Stand beschreiben.
+func (r *PersonRepository) Stats(ctx context.Context) (stats domain.PersonStats, err error) {
+	ctx, span := startSpan(ctx, "persons.stats")
+	defer func() { endSpan(span, err) }()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return domain.PersonStats{}, fmt.Errorf("transaktion starten: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stats = domain.PersonStats{
+		ByColor: make(map[domain.Color]int),
+		ByCity:  make(map[string]int),
+	}
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM persons WHERE "+notDeleted).Scan(&stats.Total); err != nil {
+		return domain.PersonStats{}, fmt.Errorf("anzahl abfragen: %w", err)
+	}
+	if err := groupCounts(ctx, tx, "color", func(key string, n int) { stats.ByColor[domain.Color(key)] = n }); err != nil {
+		return domain.PersonStats{}, err
+	}
+	if err := groupCounts(ctx, tx, "city", func(key string, n int) { stats.ByCity[key] = n }); err != nil {
+		return domain.PersonStats{}, err
+	}
+	return stats, nil
+}
+
+// groupCounts zählt die Personen je Wert der Spalte column und übergibt jedes
+// Paar an add. column stammt ausschließlich aus festen Aufrufen in diesem Paket.
+func groupCounts(ctx context.Context, tx *sql.Tx, column string, add func(key string, n int)) error {
+	rows, err := tx.QueryContext(ctx, "SELECT "+column+", COUNT(*) FROM persons WHERE "+notDeleted+" GROUP BY "+column)
+	if err != nil {
+		return fmt.Errorf("abfrage je %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return fmt.Errorf("zeile lesen: %w", err)
+		}
+		add(key, n)
+	}
+	return rows.Err()
 }
 
 // Add fügt eine neue Person hinzu und prüft die Kapazitätsgrenze.
-func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (domain.Person, error) {
+func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (added domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.insert")
+	defer func() { endSpan(span, err, affectedRows(min(added.ID, 1))) }()
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return domain.Person{}, fmt.Errorf("transaktion starten: %w", err)
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	if r.maxPersons > 0 {
-		var count int
-		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM persons").Scan(&count); err != nil {
-			return domain.Person{}, fmt.Errorf("anzahl abfragen: %w", err)
-		}
-		if count >= r.maxPersons {
-			return domain.Person{}, fmt.Errorf("max %d personen: %w", r.maxPersons, domain.ErrCapacityReached)
-		}
+	if err := r.checkCapacity(ctx, tx); err != nil {
+		return domain.Person{}, err
 	}
 
 	res, err := tx.ExecContext(ctx,
-		"INSERT INTO persons (name, lastname, zipcode, city, color) VALUES (?, ?, ?, ?, ?)",
+		"INSERT INTO persons (name, lastname, zipcode, city, color, created_at, updated_at, version) VALUES (?, ?, ?, ?, ?, ?, ?, 1)",
 		person.Name, person.Lastname, person.Zipcode, person.City, person.Color,
+		formatTime(person.CreatedAt), formatTime(person.UpdatedAt),
 	)
 	if err != nil {
 		return domain.Person{}, fmt.Errorf("person einfügen: %w", err)
@@ -111,6 +613,7 @@ func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (domai
 		return domain.Person{}, fmt.Errorf("letzte id: %w", err)
 	}
 	person.ID = int(id)
+	person.Version = 1
 
 	if err := tx.Commit(); err != nil {
 		return domain.Person{}, fmt.Errorf("commit: %w", err)
@@ -118,6 +621,240 @@ func (r *PersonRepository) Add(ctx context.Context, person domain.Person) (domai
 	return person, nil
 }
 
+// Insert schreibt die ID ausdrücklich mit. Eine belegte ID wird in derselben
+// Transaktion erkannt und bleibt unverändert; AUTOINCREMENT hebt
+// sqlite_sequence selbst über die neue ID, sodass Add danach keine kleinere
+// vergibt.
+func (r *PersonRepository) Insert(ctx context.Context, person domain.Person) (inserted domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.insert_with_id")
+	defer func() { endSpan(span, err, affectedRows(min(inserted.ID, 1))) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Person{}, fmt.Errorf("transaktion starten: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current int
+	err = tx.QueryRowContext(ctx, "SELECT version FROM persons WHERE id = ?", person.ID).Scan(&current)
+	switch {
+	case err == nil:
+		return domain.Person{}, &domain.VersionConflictError{ID: person.ID, Current: current}
+	case err != sql.ErrNoRows:
+		return domain.Person{}, fmt.Errorf("abfrage person id %d: %w", person.ID, err)
+	}
+	if err := r.checkCapacity(ctx, tx); err != nil {
+		return domain.Person{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO persons (id, name, lastname, zipcode, city, color, created_at, updated_at, version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1)",
+		person.ID, person.Name, person.Lastname, person.Zipcode, person.City, person.Color,
+		formatTime(person.CreatedAt), formatTime(person.UpdatedAt),
+	); err != nil {
+		return domain.Person{}, fmt.Errorf("person einfügen: %w", err)
+	}
+	person.Version = 1
+
+	if err := tx.Commit(); err != nil {
+		return domain.Person{}, fmt.Errorf("commit: %w", err)
+	}
+	return person, nil
+}
+
+// Update prüft die Version in der WHERE-Klausel des UPDATE selbst, sodass
+// zwischen Vergleich und Schreiben keine andere Änderung liegen kann. Betrifft
+// das UPDATE keine Zeile, unterscheidet eine Folgeabfrage zwischen fehlender
+// Person und Versionskonflikt.
+func (r *PersonRepository) Update(ctx context.Context, person domain.Person, expectedVersion int) (updated domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.update")
+	defer func() { endSpan(span, err, affectedRows(min(updated.ID, 1))) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Person{}, fmt.Errorf("transaktion starten: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := "UPDATE persons SET name = ?, lastname = ?, zipcode = ?, city = ?, color = ?, updated_at = ?, version = version + 1 WHERE id = ? AND " + notDeleted
+	args := []any{
+		person.Name, person.Lastname, person.Zipcode, person.City, person.Color,
+		formatTime(person.UpdatedAt), person.ID,
+	}
+	if expectedVersion > 0 {
+		query += " AND version = ?"
+		args = append(args, expectedVersion)
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return domain.Person{}, fmt.Errorf("person aktualisieren: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return domain.Person{}, fmt.Errorf("betroffene zeilen: %w", err)
+	}
+
+	if n == 0 {
+		var current int
+		var deletedAt string
+		err := tx.QueryRowContext(ctx, "SELECT version, deleted_at FROM persons WHERE id = ?", person.ID).Scan(&current, &deletedAt)
+		if err == sql.ErrNoRows {
+			return domain.Person{}, fmt.Errorf("person mit id %d: %w", person.ID, domain.ErrNotFound)
+		}
+		if err != nil {
+			return domain.Person{}, fmt.Errorf("version abfragen: %w", err)
+		}
+		if deletedAt != "" {
+			return domain.Person{}, fmt.Errorf("person mit id %d: %w", person.ID, domain.ErrGone)
+		}
+		return domain.Person{}, &domain.VersionConflictError{ID: person.ID, Current: current}
+	}
+
+	row := tx.QueryRowContext(ctx, "SELECT "+personColumns+" FROM persons WHERE id = ?", person.ID)
+	if err := scanPerson(row, &updated); err != nil {
+		return domain.Person{}, fmt.Errorf("abfrage person id %d: %w", person.ID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.Person{}, fmt.Errorf("commit: %w", err)
+	}
+	return updated, nil
+}
+
+// Delete setzt deleted_at der Person; die Zeile bleibt bis zum Purge bestehen.
+func (r *PersonRepository) Delete(ctx context.Context, id int, at time.Time) (err error) {
+	ctx, span := startSpan(ctx, "persons.soft_delete")
+	defer func() { endSpan(span, err) }()
+
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE persons SET deleted_at = ?, version = version + 1 WHERE id = ? AND "+notDeleted,
+		formatTime(at), id)
+	if err != nil {
+		return fmt.Errorf("person löschen: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("betroffene zeilen: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteByColor setzt deleted_at aller Treffer mit einer einzigen Anweisung.
+func (r *PersonRepository) DeleteByColor(ctx context.Context, color domain.Color, at time.Time) (n int, err error) {
+	ctx, span := startSpan(ctx, "persons.soft_delete_by_color")
+	defer func() { endSpan(span, err, affectedRows(n)) }()
+
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE persons SET deleted_at = ?, version = version + 1 WHERE color = ? AND "+notDeleted,
+		formatTime(at), color)
+	if err != nil {
+		return 0, fmt.Errorf("personen nach farbe löschen: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("betroffene zeilen: %w", err)
+	}
+	return int(affected), nil
+}
+
+// Restore leert deleted_at innerhalb einer Transaktion, die zuvor die
+// Kapazitätsgrenze prüft.
+func (r *PersonRepository) Restore(ctx context.Context, id int) (restored domain.Person, err error) {
+	ctx, span := startSpan(ctx, "persons.restore")
+	defer func() { endSpan(span, err, affectedRows(min(restored.ID, 1))) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.Person{}, fmt.Errorf("transaktion starten: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := r.checkCapacity(ctx, tx); err != nil {
+		return domain.Person{}, err
+	}
+	res, err := tx.ExecContext(ctx,
+		"UPDATE persons SET deleted_at = '', version = version + 1 WHERE id = ? AND deleted_at != ''", id)
+	if err != nil {
+		return domain.Person{}, fmt.Errorf("person wiederherstellen: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return domain.Person{}, fmt.Errorf("betroffene zeilen: %w", err)
+	}
+	if n == 0 {
+		return domain.Person{}, fmt.Errorf("gelöschte person mit id %d: %w", id, domain.ErrNotFound)
+	}
+
+	row := tx.QueryRowContext(ctx, "SELECT "+personColumns+" FROM persons WHERE id = ?", id)
+	if err := scanPerson(row, &restored); err != nil {
+		return domain.Person{}, fmt.Errorf("abfrage person id %d: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.Person{}, fmt.Errorf("commit: %w", err)
+	}
+	return restored, nil
+}
+
+// Purge löscht alle Zeilen, deren deleted_at vor before liegt. Das feste
+// Zeitformat erlaubt den Vergleich als Text.
+func (r *PersonRepository) Purge(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, span := startSpan(ctx, "persons.purge")
+	defer func() { endSpan(span, err, affectedRows(n)) }()
+
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM persons WHERE deleted_at != '' AND deleted_at < ?", formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("gelöschte personen entfernen: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("betroffene zeilen: %w", err)
+	}
+	return int(affected), nil
+}
+
+// checkCapacity prüft innerhalb von tx, ob eine weitere nicht gelöschte Person
+// Platz hat.
+func (r *PersonRepository) checkCapacity(ctx context.Context, tx *sql.Tx) error {
+	if r.maxPersons <= 0 {
+		return nil
+	}
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM persons WHERE "+notDeleted).Scan(&count); err != nil {
+		return fmt.Errorf("anzahl abfragen: %w", err)
+	}
+	if count >= r.maxPersons {
+		return fmt.Errorf("max %d personen: %w", r.maxPersons, domain.ErrCapacityReached)
+	}
+	return nil
+}
+
+// DeleteAll entfernt alle Personen und setzt den AUTOINCREMENT-Zähler zurück,
+// sodass die nächste Person wieder die ID 1 erhält.
+func (r *PersonRepository) DeleteAll(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "persons.delete_all")
+	defer func() { endSpan(span, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("transaktion starten: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM persons"); err != nil {
+		return fmt.Errorf("personen löschen: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM sqlite_sequence WHERE name = 'persons'"); err != nil {
+		return fmt.Errorf("autoincrement zurücksetzen: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
 // queryPersons führt eine Abfrage aus und sammelt die Zeilen als Personen.
 func (r *PersonRepository) queryPersons(ctx context.Context, query string, args ...any) ([]domain.Person, error) {
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -129,10 +866,50 @@ func (r *PersonRepository) queryPersons(ctx context.Context, query string, args
 	out := make([]domain.Person, 0)
 	for rows.Next() {
 		var p domain.Person
-		if err := rows.Scan(&p.ID, &p.Name, &p.Lastname, &p.Zipcode, &p.City, &p.Color); err != nil {
+		if err := scanPerson(rows, &p); err != nil {
 			return nil, fmt.Errorf("zeile lesen: %w", err)
 		}
 		out = append(out, p)
 	}
 	return out, rows.Err()
 }
+
+// rowScanner wird von *sql.Row und *sql.Rows erfüllt.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPerson liest eine Zeile mit den Spalten aus personColumns in p.
+func scanPerson(row rowScanner, p *domain.Person) error {
+	var createdAt, updatedAt, deletedAt string
+	if err := row.Scan(&p.ID, &p.Name, &p.Lastname, &p.Zipcode, &p.City, &p.Color, &createdAt, &updatedAt, &p.Version, &deletedAt); err != nil {
+		return err
+	}
+	var err error
+	if p.CreatedAt, err = parseTime(createdAt); err != nil {
+		return fmt.Errorf("created_at: %w", err)
+	}
+	if p.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return fmt.Errorf("updated_at: %w", err)
+	}
+	if p.DeletedAt, err = parseTime(deletedAt); err != nil {
+		return fmt.Errorf("deleted_at: %w", err)
+	}
+	return nil
+}
+
+// formatTime wandelt t in das Speicherformat um; der Nullwert wird zu "".
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(timeLayout)
+}
+
+// parseTime ist die Umkehrung von formatTime.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(timeLayout, s)
+}
@@ -2,11 +2,19 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
 	"assecor-assessment-backend/internal/domain"
 )
@@ -41,6 +49,106 @@ func TestGetAll(t *testing.T) {
 	assert.Len(t, all, 3)
 }
 
+func TestGetAllStream(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	var names []string
+	err := repo.GetAllStream(context.Background(), func(p domain.Person) error {
+		names = append(names, p.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hans", "Peter", "Johnny"}, names)
+}
+
+func TestGetAllStream_CallbackFehlerBrichtAb(t *testing.T) {
+	repo := seedRepo(t, 0)
+	stop := errors.New("stop")
+
+	calls := 0
+	err := repo.GetAllStream(context.Background(), func(domain.Person) error {
+		calls++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestGetAllStream_KontextAbbruch(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := repo.GetAllStream(ctx, func(domain.Person) error {
+		calls++
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, calls, 3)
+}
+
+func TestGetAllStream_InBloecken(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	for i := 0; i < 2*streamBatchSize; i++ {
+		_, err := repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Delete(ctx, streamBatchSize, time.Now()))
+
+	var ids []int
+	err := repo.GetAllStream(ctx, func(p domain.Person) error {
+		ids = append(ids, p.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2*streamBatchSize+2, "gelöschte person fehlt, keine doppelt")
+	assert.True(t, slices.IsSorted(ids))
+	assert.NotContains(t, ids, streamBatchSize)
+}
+
+// TestGetAllStream_PausierterStreamBlockiertNicht hält einen Stream in fn an,
+// wie es ein langsamer NDJSON-Client tut. :memory: hat nur eine Verbindung;
+// ein Add muss trotzdem durchgehen, statt auf das Ende des Streams zu warten.
+func TestGetAllStream_PausierterStreamBlockiertNicht(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+
+	paused := make(chan struct{})
+	resume := make(chan struct{})
+	done := make(chan error, 1)
+	var names []string
+	go func() {
+		done <- repo.GetAllStream(ctx, func(p domain.Person) error {
+			if len(names) == 0 {
+				close(paused)
+				<-resume
+			}
+			names = append(names, p.Name)
+			return nil
+		})
+	}()
+	<-paused
+
+	added := make(chan error, 1)
+	go func() {
+		_, err := repo.Add(ctx, domain.Person{Name: "Anna", Lastname: "Schmidt", Color: "rot"})
+		added <- err
+	}()
+	select {
+	case err := <-added:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		close(resume)
+		t.Fatal("add wartet auf den pausierten stream")
+	}
+
+	close(resume)
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"Hans", "Peter", "Johnny"}, names[:3])
+}
+
 func TestGetByID(t *testing.T) {
 	repo := seedRepo(t, 0)
 
@@ -52,23 +160,271 @@ func TestGetByID(t *testing.T) {
 	require.ErrorIs(t, err, domain.ErrNotFound)
 }
 
+func TestGetByIDs(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	persons, err := repo.GetByIDs(context.Background(), []int{3, 99, 1})
+	require.NoError(t, err)
+	got := make([]int, 0, len(persons))
+	for _, p := range persons {
+		got = append(got, p.ID)
+	}
+	assert.ElementsMatch(t, []int{1, 3}, got)
+
+	none, err := repo.GetByIDs(context.Background(), []int{42})
+	require.NoError(t, err)
+	assert.NotNil(t, none)
+	assert.Empty(t, none)
+}
+
+func TestGetAllAfter(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	tests := []struct {
+		name    string
+		afterID int
+		limit   int
+		want    []int
+	}{
+		{"erste seite", 0, 2, []int{1, 2}},
+		{"folgeseite", 2, 2, []int{3}},
+		{"hinter dem ende leer", 3, 2, []int{}},
+		{"limit 0 bedeutet unbegrenzt", 1, 0, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := repo.GetAllAfter(context.Background(), tt.afterID, tt.limit)
+			require.NoError(t, err)
+			require.NotNil(t, persons)
+			got := make([]int, 0, len(persons))
+			for _, p := range persons {
+				got = append(got, p.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestGetByColor(t *testing.T) {
 	repo := seedRepo(t, 0)
 
-	blau, err := repo.GetByColor(context.Background(), "blau")
+	blau, err := repo.GetByColor(context.Background(), "blau", domain.Order{}, 0, 0)
 	require.NoError(t, err)
 	assert.Len(t, blau, 2)
 
-	gruen, err := repo.GetByColor(context.Background(), "grün")
+	gruen, err := repo.GetByColor(context.Background(), "grün", domain.Order{}, 0, 0)
 	require.NoError(t, err)
 	assert.Len(t, gruen, 1)
 
-	rot, err := repo.GetByColor(context.Background(), "rot")
+	rot, err := repo.GetByColor(context.Background(), "rot", domain.Order{}, 0, 0)
 	require.NoError(t, err)
 	assert.NotNil(t, rot)
 	assert.Empty(t, rot)
 }
 
+func TestGetByColor_Sortiert(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	for _, p := range []domain.Person{
+		{Name: "Anna", Lastname: "Zander", Zipcode: "10115", City: "Berlin", Color: "blau"},
+		{Name: "Bernd", Lastname: "Müller", Zipcode: "20095", City: "Hamburg", Color: "blau"},
+	} {
+		_, err := repo.Add(ctx, p)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		order domain.Order
+		want  []int
+	}{
+		{domain.Order{}, []int{1, 3, 4, 5}},
+		{domain.Order{Field: "lastname"}, []int{3, 1, 5, 4}},
+		{domain.Order{Field: "lastname", Desc: true}, []int{4, 1, 5, 3}},
+		{domain.Order{Field: "city"}, []int{4, 5, 1, 3}},
+		{domain.Order{Field: "id", Desc: true}, []int{5, 4, 3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v", tt.order), func(t *testing.T) {
+			persons, err := repo.GetByColor(ctx, "blau", tt.order, 0, 0)
+			require.NoError(t, err)
+			got := make([]int, len(persons))
+			for i, p := range persons {
+				got[i] = p.ID
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := repo.GetByColor(ctx, "blau", domain.Order{Field: "name; DROP TABLE persons"}, 0, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput, "nur felder aus domain.SortFields gelangen ins ORDER BY")
+}
+
+func TestGetByColor_Seitenweise(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	for _, p := range []domain.Person{
+		{Name: "Anna", Lastname: "Zander", Zipcode: "10115", City: "Berlin", Color: "blau"},
+		{Name: "Bernd", Lastname: "Müller", Zipcode: "20095", City: "Hamburg", Color: "blau"},
+	} {
+		_, err := repo.Add(ctx, p)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		order         domain.Order
+		limit, offset int
+		want          []int
+	}{
+		{domain.Order{}, 2, 0, []int{1, 3}},
+		{domain.Order{}, 2, 2, []int{4, 5}},
+		{domain.Order{}, 0, 3, []int{5}},
+		{domain.Order{}, 2, 4, []int{}},
+		{domain.Order{Field: "lastname"}, 2, 1, []int{1, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v/%d/%d", tt.order, tt.limit, tt.offset), func(t *testing.T) {
+			persons, err := repo.GetByColor(ctx, "blau", tt.order, tt.limit, tt.offset)
+			require.NoError(t, err)
+			require.NotNil(t, persons)
+			got := make([]int, len(persons))
+			for i, p := range persons {
+				got[i] = p.ID
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetByColors_SortiertNachFarbeUndID(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	ids := func(persons []domain.Person) []int {
+		out := make([]int, 0, len(persons))
+		for _, p := range persons {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	persons, err := repo.GetByColors(ctx, []domain.Color{"grün", "blau", "rot"}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3, 2}, ids(persons))
+
+	persons, err = repo.GetByColors(ctx, []domain.Color{"grün", "blau"}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, ids(persons))
+
+	persons, err = repo.GetByColors(ctx, nil, 0, 0)
+	require.NoError(t, err)
+	assert.NotNil(t, persons)
+	assert.Empty(t, persons)
+}
+
+func TestGetByZipcode_PlatzhalterWoertlich(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	for _, zip := range []string{"6_742", "6a742", `6\742`} {
+		_, err := repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Zipcode: zip, City: "Stadt", Color: "rot"})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		zip    string
+		prefix bool
+		want   []string
+	}{
+		{"677", true, []string{"67742"}},
+		{"677", false, []string{}},
+		{"6_", true, []string{"6_742"}},
+		{"%", true, []string{}},
+		{`6\`, true, []string{`6\742`}},
+		{"", true, []string{"67742", "18439", "88888", "6_742", "6a742", `6\742`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zip, func(t *testing.T) {
+			persons, err := repo.GetByZipcode(ctx, tt.zip, tt.prefix, 0, 0)
+			require.NoError(t, err)
+			got := make([]string, 0, len(persons))
+			for _, p := range persons {
+				got = append(got, p.Zipcode)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	tests := []struct {
+		name    string
+		filter  domain.PersonFilter
+		wantIDs []int
+	}{
+		{"ohne filter", domain.PersonFilter{}, []int{1, 2, 3}},
+		{"nach farbe", domain.PersonFilter{Color: "blau"}, []int{1, 3}},
+		{"farbe mit limit", domain.PersonFilter{Color: "blau", Limit: 1}, []int{1}},
+		{"farbe mit offset", domain.PersonFilter{Color: "blau", Offset: 1}, []int{3}},
+		{"limit und offset", domain.PersonFilter{Limit: 1, Offset: 1}, []int{2}},
+		{"offset hinter dem ende", domain.PersonFilter{Offset: 10}, []int{}},
+		{"kein treffer", domain.PersonFilter{Color: "rot"}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := repo.Find(context.Background(), tt.filter)
+			require.NoError(t, err)
+			require.NotNil(t, persons)
+			ids := make([]int, 0, len(persons))
+			for _, p := range persons {
+				ids = append(ids, p.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	tests := []struct {
+		name   string
+		filter domain.PersonFilter
+		want   int
+	}{
+		{"ohne filter", domain.PersonFilter{}, 3},
+		{"nach farbe", domain.PersonFilter{Color: "blau"}, 2},
+		{"limit und offset werden ignoriert", domain.PersonFilter{Color: "blau", Limit: 1, Offset: 1}, 2},
+		{"kein treffer", domain.PersonFilter{Color: "rot"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := repo.Count(context.Background(), tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, n)
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	stats, err := repo.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, map[domain.Color]int{"blau": 2, "grün": 1}, stats.ByColor)
+	assert.Equal(t, map[string]int{"Lauterecken": 1, "Stralsund": 1, "made up": 1}, stats.ByCity)
+}
+
+func TestCountsByColor(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	counts, err := repo.CountsByColor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[domain.Color]int{"blau": 2, "grün": 1}, counts)
+}
+
 func TestAdd_AutoIncrementID(t *testing.T) {
 	repo, err := NewPersonRepository(":memory:", 0, testLogger())
 	require.NoError(t, err)
@@ -83,6 +439,369 @@ func TestAdd_AutoIncrementID(t *testing.T) {
 	assert.Equal(t, 2, p2.ID)
 }
 
+func TestInsert_VorgegebeneIDHebtAutoIncrement(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+
+	inserted, err := repo.Insert(ctx, domain.Person{ID: 10, Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 10, inserted.ID)
+	assert.Equal(t, 1, inserted.Version)
+
+	got, err := repo.GetByID(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "Neu", got.Name)
+
+	added, err := repo.Add(ctx, domain.Person{Name: "Danach", Lastname: "Person", Color: "blau"})
+	require.NoError(t, err)
+	assert.Equal(t, 11, added.ID)
+
+	require.NoError(t, repo.Delete(ctx, 2, time.Now()))
+	_, err = repo.Insert(ctx, domain.Person{ID: 2, Name: "X", Lastname: "Y", Color: "rot"})
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict, "auch gelöschte personen belegen ihre id")
+	assert.Equal(t, 2, conflict.Current)
+}
+
+func TestInsert_Kapazitaet(t *testing.T) {
+	repo := seedRepo(t, 3)
+
+	_, err := repo.Insert(context.Background(), domain.Person{ID: 10, Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+}
+
+func TestZeitstempel_RundreiseUndFilter(t *testing.T) {
+	repo := seedRepo(t, 0) // Personen ohne Zeitstempel
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	early, err := repo.Add(context.Background(), domain.Person{
+		Name: "Früh", Lastname: "X", Color: "rot", CreatedAt: base, UpdatedAt: base,
+	})
+	require.NoError(t, err)
+	late, err := repo.Add(context.Background(), domain.Person{
+		Name: "Spät", Lastname: "Y", Color: "rot",
+		CreatedAt: base.Add(1500 * time.Millisecond), UpdatedAt: base.Add(1500 * time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	got, err := repo.GetByID(context.Background(), late.ID)
+	require.NoError(t, err)
+	assert.True(t, late.CreatedAt.Equal(got.CreatedAt))
+
+	legacy, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, legacy.CreatedAt.IsZero())
+
+	persons, err := repo.Find(context.Background(), domain.PersonFilter{CreatedAfter: base.Add(time.Second)})
+	require.NoError(t, err)
+	require.Len(t, persons, 1)
+	assert.Equal(t, late.ID, persons[0].ID)
+
+	persons, err = repo.Find(context.Background(), domain.PersonFilter{CreatedAfter: base.Add(-time.Nanosecond)})
+	require.NoError(t, err)
+	assert.Len(t, persons, 2, "personen ohne zeitstempel werden nicht gefunden")
+	assert.Equal(t, early.ID, persons[0].ID)
+}
+
+func TestMigration_ErgaenztZeitstempelSpalten(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "alt.db")
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE persons (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, lastname TEXT NOT NULL,
+		zipcode TEXT NOT NULL DEFAULT '', city TEXT NOT NULL DEFAULT '', color TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO persons (name, lastname, color) VALUES ('Alt', 'Bestand', 'blau')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo, err := NewPersonRepository(dsn, 0, testLogger())
+	require.NoError(t, err)
+	defer func() { _ = repo.Close() }()
+
+	p, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Alt", p.Name)
+	assert.True(t, p.CreatedAt.IsZero())
+	assert.Equal(t, 1, p.Version)
+
+	// Ein zweites Öffnen darf die Migration nicht erneut versuchen.
+	again, err := NewPersonRepository(dsn, 0, testLogger())
+	require.NoError(t, err)
+	_ = again.Close()
+}
+
+func TestPool_GrenzenWerdenGesetzt(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "pool.db")
+	repo, err := NewPersonRepository(dsn, 0, testLogger(), WithPool(PoolConfig{
+		MaxOpenConns:    3,
+		MaxIdleConns:    1,
+		ConnMaxLifetime: 10 * time.Millisecond,
+	}))
+	require.NoError(t, err)
+	defer func() { _ = repo.Close() }()
+	ctx := context.Background()
+
+	assert.Equal(t, 3, repo.db.Stats().MaxOpenConnections)
+
+	// Drei gleichzeitig gehaltene Verbindungen; nach der Rückgabe bleibt nur eine ruhend.
+	var conns []*sql.Conn
+	for range 3 {
+		c, err := repo.db.Conn(ctx)
+		require.NoError(t, err)
+		conns = append(conns, c)
+	}
+	for _, c := range conns {
+		require.NoError(t, c.Close())
+	}
+	assert.Equal(t, 1, repo.db.Stats().Idle)
+	assert.Positive(t, repo.db.Stats().MaxIdleClosed)
+
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, repo.db.PingContext(ctx))
+	assert.Positive(t, repo.db.Stats().MaxLifetimeClosed, "abgelaufene verbindung wird ersetzt")
+}
+
+func TestPool_InMemoryBleibtBeiEinerVerbindung(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	repo, err := NewPersonRepository(":memory:", 0, zap.New(core), WithPool(PoolConfig{
+		MaxOpenConns:    5,
+		ConnMaxLifetime: time.Millisecond,
+	}))
+	require.NoError(t, err)
+	defer func() { _ = repo.Close() }()
+
+	assert.Equal(t, 1, repo.db.Stats().MaxOpenConnections)
+	assert.Equal(t, 1, logs.FilterMessage("poolgrenzen werden für in-memory-datenbank ignoriert").Len())
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = repo.Add(context.Background(), domain.Person{Name: "Hans", Lastname: "Müller", Color: "blau"})
+	require.NoError(t, err, "die datenbank überlebt die eingestellte lebensdauer")
+}
+
+// Ohne feste Einzelverbindung öffnet der Pool unter Last weitere Verbindungen,
+// die je eine eigene, leere In-Memory-Datenbank sehen ("no such table").
+func TestPool_InMemoryUnterParallelerLast(t *testing.T) {
+	for _, dsn := range []string{":memory:", "file::memory:"} {
+		t.Run(dsn, func(t *testing.T) {
+			repo, err := NewPersonRepository(dsn, 0, testLogger())
+			require.NoError(t, err)
+			defer func() { _ = repo.Close() }()
+			ctx := context.Background()
+			const workers, perWorker = 8, 25
+
+			var wg sync.WaitGroup
+			for range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range perWorker {
+						_, err := repo.Add(ctx, domain.Person{Name: "Hans", Lastname: "Müller", Color: "blau"})
+						assert.NoError(t, err)
+						_, err = repo.GetAll(ctx)
+						assert.NoError(t, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			persons, err := repo.GetAll(ctx)
+			require.NoError(t, err)
+			assert.Len(t, persons, workers*perWorker)
+			assert.LessOrEqual(t, repo.db.Stats().OpenConnections, 1)
+		})
+	}
+}
+
+func TestUpdate_Versionen(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+
+	before, err := repo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, 1, before.Version)
+
+	change := before
+	change.City = "Rostock"
+	change.CreatedAt = time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC) // wird ignoriert
+	updated, err := repo.Update(ctx, change, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Rostock", updated.City)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, before.CreatedAt, updated.CreatedAt)
+
+	_, err = repo.Update(ctx, change, 1)
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 2, conflict.Current)
+
+	unconditional, err := repo.Update(ctx, change, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, unconditional.Version)
+
+	_, err = repo.Update(ctx, domain.Person{ID: 99, Name: "X", Lastname: "Y", Color: "rot"}, 1)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+// Mehrere Schreiber lesen dieselbe Version und ändern gleichzeitig. Genau einer
+// darf gewinnen; alle anderen erhalten einen Versionskonflikt.
+func TestUpdate_VerlorenesUpdateParallel(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "race.db") + "?_pragma=busy_timeout(5000)"
+	repo, err := NewPersonRepository(dsn, 0, testLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	ctx := context.Background()
+	read, err := repo.Add(ctx, domain.Person{Name: "Hans", Lastname: "Müller", Color: "blau"})
+	require.NoError(t, err)
+
+	const writers = 8
+	errs := make(chan error, writers)
+	for i := range writers {
+		go func() {
+			change := read
+			change.City = fmt.Sprintf("stadt-%d", i)
+			_, err := repo.Update(ctx, change, read.Version)
+			errs <- err
+		}()
+	}
+
+	won := 0
+	for range writers {
+		err := <-errs
+		if err == nil {
+			won++
+			continue
+		}
+		require.ErrorIs(t, err, domain.ErrVersionConflict)
+	}
+	assert.Equal(t, 1, won)
+
+	final, err := repo.GetByID(ctx, read.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, final.Version)
+}
+
+func TestDelete_Vorlaeufig(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Delete(ctx, 1, at))
+	require.ErrorIs(t, repo.Delete(ctx, 1, at), domain.ErrNotFound, "bereits gelöscht")
+	require.ErrorIs(t, repo.Delete(ctx, 99, at), domain.ErrNotFound)
+
+	_, err := repo.GetByID(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrGone)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repo.GetByID(ctx, 99)
+	require.NotErrorIs(t, err, domain.ErrGone)
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	blau, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, blau, 1)
+	byIDs, err := repo.GetByIDs(ctx, []int{1, 2})
+	require.NoError(t, err)
+	assert.Len(t, byIDs, 1)
+	after, err := repo.GetAllAfter(ctx, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, after, 1)
+	assert.Equal(t, 2, after[0].ID)
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, 1, stats.ByColor["blau"])
+
+	withDeleted, err := repo.Find(ctx, domain.PersonFilter{IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, withDeleted, 3)
+	assert.True(t, at.Equal(withDeleted[0].DeletedAt))
+	n, err := repo.Count(ctx, domain.PersonFilter{Color: "blau", IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = repo.Update(ctx, domain.Person{ID: 1, Name: "X", Lastname: "Y", Color: "rot"}, 0)
+	require.ErrorIs(t, err, domain.ErrGone)
+
+	restored, err := repo.Restore(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, restored.Deleted())
+	assert.Equal(t, 3, restored.Version, "löschen und wiederherstellen zählen als änderung")
+	_, err = repo.Restore(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	got, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, restored, got)
+}
+
+func TestDeleteByColor(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	at := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	n, err := repo.DeleteByColor(ctx, "blau", at)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = repo.DeleteByColor(ctx, "blau", at)
+	require.NoError(t, err)
+	assert.Zero(t, n, "bereits gelöschte zählen nicht")
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, 2, all[0].ID)
+
+	deleted, err := repo.Find(ctx, domain.PersonFilter{Color: "blau", IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, deleted, 2)
+	for _, p := range deleted {
+		assert.True(t, at.Equal(p.DeletedAt))
+		assert.Equal(t, 2, p.Version)
+	}
+}
+
+func TestDelete_KapazitaetZaehltNurNichtGeloeschte(t *testing.T) {
+	repo := seedRepo(t, 3)
+	ctx := context.Background()
+
+	_, err := repo.Add(ctx, domain.Person{Name: "Zu", Lastname: "Viel", Color: "rot"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+
+	require.NoError(t, repo.Delete(ctx, 2, time.Now()))
+	_, err = repo.Add(ctx, domain.Person{Name: "Nach", Lastname: "Rücker", Color: "rot"})
+	require.NoError(t, err)
+
+	_, err = repo.Restore(ctx, 2)
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+}
+
+func TestPurge(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Delete(ctx, 1, base))
+	require.NoError(t, repo.Delete(ctx, 3, base.Add(time.Hour)))
+
+	n, err := repo.Purge(ctx, base.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = repo.GetByID(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	require.NotErrorIs(t, err, domain.ErrGone, "endgültig entfernt")
+	_, err = repo.GetByID(ctx, 3)
+	require.ErrorIs(t, err, domain.ErrGone)
+
+	created, err := repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, created.ID, "ids werden nicht erneut vergeben")
+}
+
 func TestAdd_KapazitaetsgrenzExploit3(t *testing.T) {
 	repo := seedRepo(t, 4)
 
@@ -92,3 +811,174 @@ func TestAdd_KapazitaetsgrenzExploit3(t *testing.T) {
 	_, err = repo.Add(context.Background(), domain.Person{Name: "Zu", Lastname: "Viel", Color: "blau"})
 	require.ErrorIs(t, err, domain.ErrCapacityReached)
 }
+
+func TestDistinctCities(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+	for _, p := range []domain.Person{
+		{Name: "Anna", Lastname: "Meyer", Zipcode: "18439", City: "Stralsund", Color: "rot"},
+		{Name: "Ohne", Lastname: "Stadt", Zipcode: "11111", Color: "rot"},
+		{Name: "Bald", Lastname: "Weg", Zipcode: "10115", City: "Berlin", Color: "rot"},
+	} {
+		_, err := repo.Add(ctx, p)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Delete(ctx, 6, time.Now()))
+
+	cities, err := repo.DistinctCities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Lauterecken", "Stralsund", "made up"}, cities)
+
+	empty, err := seedRepo(t, 0).DistinctCities(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+}
+
+func TestCapacity(t *testing.T) {
+	repo := seedRepo(t, 4)
+	ctx := context.Background()
+	assertCapacity := func(wantUsed int) {
+		t.Helper()
+		used, max, err := repo.Capacity(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, wantUsed, used)
+		assert.Equal(t, 4, max)
+	}
+
+	assertCapacity(3)
+	_, err := repo.Add(ctx, domain.Person{Name: "Noch", Lastname: "Einer", Color: "rot"})
+	require.NoError(t, err)
+	assertCapacity(4)
+	_, err = repo.Add(ctx, domain.Person{Name: "Zu", Lastname: "Viel", Color: "blau"})
+	require.ErrorIs(t, err, domain.ErrCapacityReached)
+	assertCapacity(4)
+	require.NoError(t, repo.Delete(ctx, 1, time.Now()))
+	assertCapacity(3)
+
+	used, max, err := seedRepo(t, 0).Capacity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, used)
+	assert.Zero(t, max, "0 bedeutet unbegrenzt")
+}
+
+func TestDeleteAll_SetztAutoIncrementZurueck(t *testing.T) {
+	repo := seedRepo(t, 0)
+
+	require.NoError(t, repo.DeleteAll(context.Background()))
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	p, err := repo.Add(context.Background(), domain.Person{Name: "A", Lastname: "B", Color: "rot"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.ID)
+}
+
+func TestKontextAbbruch_VorDemAufruf(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"GetAll":        func() error { _, err := repo.GetAll(ctx); return err },
+		"GetAllAfter":   func() error { _, err := repo.GetAllAfter(ctx, 0, 10); return err },
+		"GetByID":       func() error { _, err := repo.GetByID(ctx, 1); return err },
+		"GetByIDs":      func() error { _, err := repo.GetByIDs(ctx, []int{1}); return err },
+		"GetByColor":    func() error { _, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0); return err },
+		"Find":          func() error { _, err := repo.Find(ctx, domain.PersonFilter{}); return err },
+		"Count":         func() error { _, err := repo.Count(ctx, domain.PersonFilter{}); return err },
+		"CountsByColor": func() error { _, err := repo.CountsByColor(ctx); return err },
+		"Stats":         func() error { _, err := repo.Stats(ctx); return err },
+		"Add": func() error {
+			_, err := repo.Add(ctx, domain.Person{Name: "N", Lastname: "L", Color: "rot"})
+			return err
+		},
+		"Update": func() error {
+			_, err := repo.Update(ctx, domain.Person{ID: 1, Name: "N", Lastname: "L", Color: "rot"}, 0)
+			return err
+		},
+		"Delete":    func() error { return repo.Delete(ctx, 1, time.Now()) },
+		"Restore":   func() error { _, err := repo.Restore(ctx, 1); return err },
+		"Purge":     func() error { _, err := repo.Purge(ctx, time.Now()); return err },
+		"DeleteAll": func() error { return repo.DeleteAll(ctx) },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			require.ErrorIs(t, call(), context.Canceled)
+		})
+	}
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "abgebrochene schreibzugriffe ändern nichts")
+}
+
+func TestKontextAbbruch_WaehrendDerAbfrage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("benötigt einen großen datenbestand")
+	}
+	repo := seedRepo(t, 0)
+	_, err := repo.db.Exec(`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 300000)
+		INSERT INTO persons (name, lastname, color) SELECT 'N', 'L', 'blau' FROM n`)
+	require.NoError(t, err)
+
+	tests := map[string]func(context.Context) error{
+		"GetAll": func(ctx context.Context) error { _, err := repo.GetAll(ctx); return err },
+		"GetByColor": func(ctx context.Context) error {
+			_, err := repo.GetByColor(ctx, "blau", domain.Order{}, 0, 0)
+			return err
+		},
+		"Stats": func(ctx context.Context) error { _, err := repo.Stats(ctx); return err },
+	}
+	for name, call := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := call(ctx)
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), 250*time.Millisecond, "abfrage endet zeitnah nach dem abbruch")
+		})
+	}
+}
+
+func TestLastModified_TriggerHebenZeitpunkt(t *testing.T) {
+	repo := seedRepo(t, 0)
+	ctx := context.Background()
+
+	vorher, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	require.False(t, vorher.IsZero())
+
+	time.Sleep(2 * time.Millisecond)
+	_, err = repo.Update(ctx, domain.Person{ID: 7, Name: "X", Lastname: "Y", Color: "rot"}, 0)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	unveraendert, err := repo.LastModified(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, vorher, unveraendert, "ohne geänderte zeile bleibt der zeitpunkt")
+
+	schritte := []struct {
+		name    string
+		aendern func() error
+	}{
+		{"add", func() error {
+			_, err := repo.Add(ctx, domain.Person{Name: "Neu", Lastname: "Person", Color: "rot"})
+			return err
+		}},
+		{"delete", func() error { return repo.Delete(ctx, 1, time.Now()) }},
+		{"purge", func() error {
+			_, err := repo.Purge(ctx, time.Now().Add(time.Hour))
+			return err
+		}},
+	}
+	for _, s := range schritte {
+		time.Sleep(2 * time.Millisecond)
+		require.NoError(t, s.aendern(), s.name)
+		nachher, err := repo.LastModified(ctx)
+		require.NoError(t, err)
+		assert.True(t, nachher.After(vorher), s.name)
+		vorher = nachher
+	}
+}
@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// tracerName benennt den Tracer des SQLite-Repositories.
+const tracerName = "assecor-assessment-backend/internal/repository/sqlite"
+
+// returnedRows ist die Zahl gelesener Zeilen.
+func returnedRows(n int) attribute.KeyValue {
+	return semconv.DBResponseReturnedRows(n)
+}
+
+// affectedRows ist die Zahl der von INSERT, UPDATE oder DELETE betroffenen Zeilen.
+func affectedRows(n int) attribute.KeyValue {
+	return attribute.Int("db.response.affected_rows", n)
+}
+
+// startSpan beginnt einen Client-Span für statement. statement ist ein fester
+// Name wie "persons.select_by_id" und enthält nie Werte aus der Abfrage.
+func startSpan(ctx context.Context, statement string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "sqlite "+statement,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameSQLite, semconv.DBOperationName(statement)))
+}
+
+// endSpan ergänzt attrs, markiert unerwartete Fehler und beendet span.
+// Fachliche Ergebnisse wie ErrNotFound oder ein Versionskonflikt gelten
+// nicht als Fehler der Datenbank.
+func endSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	span.SetAttributes(attrs...)
+	if err != nil && !isDomainError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func isDomainError(err error) bool {
+	return errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrGone) ||
+		errors.Is(err, domain.ErrVersionConflict) || errors.Is(err, domain.ErrCapacityReached)
+}
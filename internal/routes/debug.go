@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/go-chi/chi/v5"
+
+	"assecor-assessment-backend/internal/handler"
+)
+
+// Debug liefert den Router für den separaten Debug-Listener (DEBUG_ADDR) mit
+// den pprof-Profilen unter /debug/pprof/ und Laufzeitkennzahlen unter
+// /debug/runtime. Die Endpunkte werden bewusst nie am Hauptrouter registriert.
+func Debug() http.Handler {
+	r := chi.NewRouter()
+	r.NotFound(handler.NotFound)
+
+	r.Get("/debug/runtime", handler.RuntimeStats)
+	r.HandleFunc("/debug/pprof/", pprof.Index)
+	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// Benannte Profile wie heap oder goroutine liefert pprof.Index anhand des Pfads.
+	r.HandleFunc("/debug/pprof/*", pprof.Index)
+	return r
+}
@@ -1,25 +1,179 @@
 package routes
 
 import (
+	"cmp"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 
+	"assecor-assessment-backend/internal/env"
 	"assecor-assessment-backend/internal/handler"
+	"assecor-assessment-backend/internal/i18n"
+	"assecor-assessment-backend/internal/metrics"
 	"assecor-assessment-backend/internal/middleware"
 )
 
+// defaultExemptPaths sind die Probe- und Metrik-Pfade, die ohne EXEMPT_PATHS
+// weder das Rate-Limit belasten noch das Zugriffslog füllen.
+var defaultExemptPaths = []string{"/healthz", "/readyz", "/metrics"}
+
 // Setup registriert globale Middleware und alle Personen-Endpunkte am Router.
-func Setup(r chi.Router, h *handler.PersonHandler, logger *zap.Logger, rps float64) {
+// Der Health-Endpunkt liegt außerhalb von Lastbegrenzung und Rate-Limit, damit
+// er auch unter Last zuverlässig antwortet. Mit BASE_PATH liegen alle Routen
+// samt Health und Metriken unter diesem Präfix; die Middleware gilt weiter
+// für den ganzen Router, damit auch 404 außerhalb des Präfixes Request-ID und
+// Sicherheits-Header tragen. EXEMPT_PATHS sind relativ zum Präfix.
+func Setup(r chi.Router, h *handler.PersonHandler, logger *zap.Logger, cfg env.Config) {
+	root := r
+	base := cfg.BasePath
+	readOnly := handler.NewReadOnlyMode(cfg.ReadOnly, logger)
+	exemptPaths := cfg.ExemptPaths
+	if len(exemptPaths) == 0 {
+		exemptPaths = defaultExemptPaths
+	}
+	exempt := middleware.Exempt(root, withBasePath(base, exemptPaths)...)
+
 	r.Use(chimw.RequestID)
-	r.Use(middleware.Recovery(logger))
-	r.Use(middleware.Logging(logger))
-	r.Use(middleware.RateLimit(rps, logger))
-
-	r.Route("/persons", func(r chi.Router) {
-		r.Get("/", h.GetAll)
-		r.Post("/", h.Create)
-		r.Get("/{id}", h.GetByID)
-		r.Get("/color/{color}", h.GetByColor)
-	})
+	r.Use(middleware.RequestIDHeader)
+	r.Use(middleware.SecureHeaders())
+	// Ohne eigene Vorgabe darf keine Antwort gespeichert werden; lesende
+	// Personen-Routen setzen unten ihre max-age.
+	r.Use(middleware.NoStore())
+	// Der Server-Span umschließt auch Recovery, damit Panics als 500 im Trace landen.
+	r.Use(middleware.Tracing())
+	if !cfg.DisableRecovery {
+		r.Use(middleware.Recovery(logger))
+	}
+	if !cfg.DisableRequestLog {
+		r.Use(middleware.Logging(logger, cfg.LogSampleRate, accessLog(cfg.LogAccessFormat), exempt))
+	}
+	r.Use(middleware.APIKey(cfg.APIKeys, withBasePath(base, []string{"/healthz", "/metrics"})...))
+
+	r.NotFound(handler.NotFound)
+	r.MethodNotAllowed(handler.MethodNotAllowed(r))
+
+	// Alle Endpunkte; mit BASE_PATH unter dem Präfix. Schreibschutz und
+	// Exempt suchen Routen weiter in root, weil nur der äußere Router die
+	// vollständigen Pfade kennt.
+	api := func(r chi.Router) {
+		r.Get("/healthz", handler.Healthz)
+		r.Get("/version", handler.Version(cfg.DataSource))
+		if cfg.EnableMetrics {
+			r.Method(http.MethodGet, "/metrics", metrics.Handler())
+		}
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.ConcurrencyLimit(cfg.MaxConcurrent, cfg.ConcurrencyQueueTimeout))
+			// Ohne eigene Grenzen gilt RATE_LIMIT für beide Buckets.
+			if !cfg.DisableRateLimit {
+				r.Use(middleware.RateLimitPerGroup(
+					cmp.Or(cfg.RateLimitRead, cfg.RateLimit),
+					cmp.Or(cfg.RateLimitWrite, cfg.RateLimit),
+					logger, exempt))
+			}
+			r.Use(middleware.Compress(cfg.CompressMinBytes))
+
+			// Schreibende Routen (POST/PUT/DELETE) verlangen Basic-Auth, sofern konfiguriert.
+			writeAuth := middleware.BasicAuth(cfg.BasicAuthUser, cfg.BasicAuthPass)
+			// Gelöschte Personen verlangen dagegen immer Zugangsdaten: Ohne API-Schlüssel
+			// und Basic-Auth kann sich niemand ausweisen, dann antwortet die Route 403.
+			deletedAuth := writeAuth
+			if !hasAPIKey(cfg.APIKeys) && cfg.BasicAuthUser == "" && cfg.BasicAuthPass == "" {
+				deletedAuth = middleware.Deny(i18n.CodeNoCredentials)
+			}
+
+			// Lesende Routen erhalten max-age je Gruppe und Last-Modified. Cache-Control
+			// liegt außen, damit auch die 304 von LastModified es trägt.
+			list := chi.Chain(middleware.CacheControl(cfg.CacheMaxAgeList), h.LastModified)
+			item := chi.Chain(middleware.CacheControl(cfg.CacheMaxAgeItem), h.LastModified)
+
+			r.Route("/persons", func(r chi.Router) {
+				r.Use(readOnly.Guard(root))
+				// Gelöschte Personen sehen nur Clients mit Schreibrechten.
+				r.With(list...).With(chimw.Maybe(deletedAuth, handler.IncludeDeleted)).Get("/", h.GetAll)
+				r.With(writeAuth).Post("/", h.Create)
+				r.With(writeAuth).Delete("/", h.DeleteAll)
+				r.With(list...).Get("/stats", h.Stats)
+				r.With(list...).Get("/by-color", h.GroupByColor)
+				r.With(item...).Get("/{id}", h.GetByID)
+				r.With(item...).Head("/{id}", h.HeadByID)
+				r.With(writeAuth).Put("/{id}", h.Update)
+				r.With(writeAuth).Patch("/{id}", h.Patch)
+				r.With(writeAuth).Delete("/{id}", h.Delete)
+				r.With(writeAuth).Post("/{id}/restore", h.Restore)
+				r.With(list...).Get("/{id}/same-color", h.SameColor)
+				r.With(list...).Get("/color/{color}", h.GetByColor)
+				r.With(writeAuth).Delete("/color/{color}", h.DeleteByColor)
+				r.With(list...).Get("/color/id/{id}", h.GetByColorID)
+				r.With(list...).Get("/zipcode/{zip}", h.GetByZipcode)
+				r.With(list...).Get("/zipcode/{zip}/prefix", h.GetByZipcodePrefix)
+			})
+
+			// Queries sind wie lesende Routen offen; Mutationen verlangen wie
+			// schreibende Routen Basic-Auth und scheitern am Schreibschutz.
+			mutation := func(next http.Handler) http.Handler {
+				return chi.Chain(writeAuth, readOnly.Guard(root)).Handler(next)
+			}
+			r.Get("/graphql", h.GraphQL)
+			r.With(chimw.Maybe(mutation, h.IsGraphQLMutation)).Post("/graphql", h.GraphQL)
+
+			// Farben und Farbwerte stehen ab dem Start fest und hängen nicht am
+			// Bestand, daher ohne Last-Modified.
+			r.With(middleware.CacheControl(cfg.CacheMaxAgeList)).Get("/colors", h.Colors)
+			r.With(list...).Get("/colors/counts", h.ColorCounts)
+			r.With(list...).Get("/cities", h.Cities)
+			// Der Ladebericht enthält Rohdaten verworfener Datensätze und ist
+			// deshalb wie die schreibenden Routen geschützt.
+			r.With(writeAuth).Get("/admin/load-report", h.LoadReport)
+			r.With(writeAuth).Get("/admin/capacity", h.Capacity)
+
+			// Den Schreibschutz schaltet nur um, wer einen API-Schlüssel hat; ohne
+			// konfigurierte Schlüssel gibt es den Endpunkt nicht.
+			if hasAPIKey(cfg.APIKeys) {
+				r.Post("/admin/readonly", readOnly.Toggle)
+			}
+		})
+
+		// Neuladen liegt außerhalb des Rate-Limits, damit ein Betreiber die Daten
+		// auch unter Last austauschen kann. Wie der Schreibschutz nur mit API-Schlüssel.
+		if hasAPIKey(cfg.APIKeys) {
+			r.Post("/admin/reload", h.Reload)
+		}
+	}
+	if base == "" {
+		api(r)
+	} else {
+		r.Route(base, api)
+	}
+}
+
+// withBasePath stellt base vor jeden Pfad in paths.
+func withBasePath(base string, paths []string) []string {
+	if base == "" {
+		return paths
+	}
+	prefixed := make([]string, len(paths))
+	for i, p := range paths {
+		prefixed[i] = base + p
+	}
+	return prefixed
+}
+
+// hasAPIKey meldet, ob mindestens ein nicht leerer API-Schlüssel konfiguriert ist.
+func hasAPIKey(keys []string) bool {
+	return slices.ContainsFunc(keys, func(k string) bool { return k != "" })
+}
+
+// accessLog liefert das Ziel für Zugriffslogs im Combined-Format oder nil,
+// wenn Anfragen als strukturierte zap-Einträge geloggt werden.
+func accessLog(format string) io.Writer {
+	if format == "combined" {
+		return os.Stdout
+	}
+	return nil
 }
@@ -0,0 +1,899 @@
+package routes
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/env"
+	"assecor-assessment-backend/internal/handler"
+	"assecor-assessment-backend/internal/repository"
+	csvrepo "assecor-assessment-backend/internal/repository/csv"
+	sqliterepo "assecor-assessment-backend/internal/repository/sqlite"
+	"assecor-assessment-backend/internal/service"
+)
+
+// neuerTestRouter baut den vollständigen Router samt Middleware auf einer
+// leeren In-Memory-SQLite-Datenbank auf.
+func neuerTestRouter(t *testing.T, cfg env.Config) (*chi.Mux, *observer.ObservedLogs) {
+	router, logs, _ := neuerTestRouterMitRepo(t, cfg)
+	return router, logs
+}
+
+// neuerTestRouterMitRepo liefert zusätzlich das Repository, um Testdaten einzuspielen.
+func neuerTestRouterMitRepo(t *testing.T, cfg env.Config) (*chi.Mux, *observer.ObservedLogs, *sqliterepo.PersonRepository) {
+	t.Helper()
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(core)
+
+	repo, err := sqliterepo.NewPersonRepository(":memory:", 0, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	return neuerTestRouterFuer(logger, cfg, repo), logs, repo
+}
+
+// neuerTestRouterFuer baut den vollständigen Router über einem beliebigen Repository auf.
+func neuerTestRouterFuer(logger *zap.Logger, cfg env.Config, repo repository.PersonRepository) *chi.Mux {
+	svc := service.NewPersonService(repo, logger)
+	h := handler.NewPersonHandler(svc, logger, handler.Options{BasePath: cfg.BasePath})
+
+	r := chi.NewRouter()
+	Setup(r, h, logger, cfg)
+	return r
+}
+
+func TestRequestID_InLogHeaderUndFehlerBody(t *testing.T) {
+	router, logs := neuerTestRouter(t, env.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/persons/999", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	headerID := rec.Header().Get("X-Request-Id")
+	require.NotEmpty(t, headerID)
+	bodyLen := rec.Body.Len()
+
+	var body struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, headerID, body.RequestID)
+
+	entries := logs.FilterMessage("anfrage").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, headerID, fields["request_id"])
+	assert.EqualValues(t, http.StatusNotFound, fields["status"])
+	assert.EqualValues(t, bodyLen, fields["bytes"])
+	assert.Equal(t, "192.0.2.1", fields["remote_ip"])
+}
+
+func TestRequestID_VomClientUebernommen(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/persons", nil)
+	req.Header.Set("X-Request-Id", "client-123")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "client-123", rec.Header().Get("X-Request-Id"))
+}
+
+func TestCompress_GetAllRoundTrip(t *testing.T) {
+	router, _, repo := neuerTestRouterMitRepo(t, env.Config{CompressMinBytes: 1024})
+	for i := 0; i < 500; i++ {
+		_, err := repo.Add(context.Background(), domain.Person{
+			Name: fmt.Sprintf("Name%d", i), Lastname: "Müller",
+			Zipcode: "67742", City: "Lauterecken", Color: "blau",
+		})
+		require.NoError(t, err)
+	}
+
+	plain := httptest.NewRecorder()
+	router.ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	require.Equal(t, http.StatusOK, plain.Code)
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+
+	req := httptest.NewRequest(http.MethodGet, "/persons", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	compressed := httptest.NewRecorder()
+	router.ServeHTTP(compressed, req)
+	require.Equal(t, http.StatusOK, compressed.Code)
+	assert.Equal(t, "gzip", compressed.Header().Get("Content-Encoding"))
+	assert.Contains(t, compressed.Header().Values("Vary"), "Accept-Encoding")
+	assert.Less(t, compressed.Body.Len(), plain.Body.Len())
+
+	gz, err := gzip.NewReader(compressed.Body)
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, plain.Body.String(), string(decompressed))
+}
+
+func TestBasicAuth_NurSchreibendeRoutenGeschuetzt(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{BasicAuthUser: "admin", BasicAuthPass: "geheim"})
+	body := `{"name":"Neu","lastname":"Person","zipcode":"12345","city":"Stadt","color":"rot"}`
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/persons", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth("admin", "geheim")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestGraphQL_MutationenWieSchreibendeRouten(t *testing.T) {
+	const (
+		query    = `{"query":"{ persons { id name } }"}`
+		mutation = `{"query":"mutation { createPerson(input: {name: \"Neu\", lastname: \"Person\", zipcode: \"12345\", city: \"Stadt\", color: \"rot\"}) { id } }"}`
+	)
+	post := func(router http.Handler, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if auth {
+			req.SetBasicAuth("admin", "geheim")
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("basic-auth", func(t *testing.T) {
+		router, _ := neuerTestRouter(t, env.Config{BasicAuthUser: "admin", BasicAuthPass: "geheim"})
+
+		assert.Equal(t, http.StatusOK, post(router, query, false).Code)
+		assert.Equal(t, http.StatusUnauthorized, post(router, mutation, false).Code)
+		rec := post(router, mutation, true)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":{"createPerson":{"id":1}}}`, rec.Body.String())
+	})
+
+	t.Run("schreibschutz", func(t *testing.T) {
+		router, _ := neuerTestRouter(t, env.Config{ReadOnly: true})
+
+		rec := post(router, query, false)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":{"persons":[]}}`, rec.Body.String())
+		rec = post(router, mutation, false)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "GET", rec.Header().Get("Allow"))
+	})
+}
+
+func TestIncludeDeleted_NurMitSchreibrechten(t *testing.T) {
+	router, _, repo := neuerTestRouterMitRepo(t, env.Config{BasicAuthUser: "admin", BasicAuthPass: "geheim"})
+	for _, name := range []string{"Hans", "Peter"} {
+		_, err := repo.Add(context.Background(), domain.Person{Name: name, Lastname: "Müller", Color: "blau"})
+		require.NoError(t, err)
+	}
+
+	del := httptest.NewRequest(http.MethodDelete, "/persons/1", nil)
+	del.SetBasicAuth("admin", "geheim")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, del)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var persons []domain.Person
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	assert.Len(t, persons, 1)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons?includeDeleted=true", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/persons?includeDeleted=true", nil)
+	req.SetBasicAuth("admin", "geheim")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&persons))
+	require.Len(t, persons, 2)
+	assert.False(t, persons[0].DeletedAt.IsZero())
+}
+
+func TestIncludeDeleted_OhneZugangsdatenVerboten(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        env.Config
+		apiKey     string
+		wantStatus int
+	}{
+		{"nichts konfiguriert", env.Config{}, "", http.StatusForbidden},
+		{"api-schlüssel", env.Config{APIKeys: []string{"schluessel"}}, "schluessel", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := neuerTestRouter(t, tt.cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/persons?includeDeleted=true", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusForbidden {
+				assert.Contains(t, rec.Body.String(), `"code":"no_credentials"`)
+			}
+
+			rec = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodGet, "/persons", nil)
+			req.Header.Set("X-API-Key", tt.apiKey)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code, "ohne includeDeleted bleibt die liste offen")
+		})
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{MaxConcurrent: 1})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+func TestMetrics_NurWennAktiviert(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		wantStatus int
+	}{
+		{"abgeschaltet", false, http.StatusNotFound},
+		{"aktiviert", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Auch mit API-Schlüsseln bleibt /metrics für den Scraper offen.
+			router, _ := neuerTestRouter(t, env.Config{EnableMetrics: tt.enabled, APIKeys: []string{"geheim"}})
+
+			rec := sende(router, http.MethodGet, "/metrics", "", "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.enabled {
+				assert.Contains(t, rec.Body.String(), "go_goroutines")
+			}
+		})
+	}
+}
+
+func TestVersion_OhneLdflagsEntwicklungsVorgaben(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{DataSource: "sqlite"})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, fmt.Sprintf(
+		`{"version":"dev","commit":"unknown","built_at":"unknown","go_version":%q,"data_source":"sqlite"}`,
+		runtime.Version()), rec.Body.String())
+}
+
+func TestSetup_MiddlewareAbschaltbar(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         env.Config
+		wantLimited bool
+		wantLogs    int
+	}{
+		{name: "standard", cfg: env.Config{RateLimit: 0.5}, wantLimited: true, wantLogs: 3},
+		{name: "ohne rate-limit", cfg: env.Config{RateLimit: 0.5, DisableRateLimit: true}, wantLogs: 3},
+		{name: "ohne zugriffslog", cfg: env.Config{RateLimit: 0.5, DisableRequestLog: true}, wantLimited: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, logs := neuerTestRouter(t, tt.cfg)
+
+			codes := make([]int, 3)
+			for i := range codes {
+				codes[i] = sende(router, http.MethodGet, "/persons", "", "").Code
+			}
+
+			assert.Equal(t, tt.wantLimited, slices.Contains(codes, http.StatusTooManyRequests), "codes %v", codes)
+			assert.Equal(t, tt.wantLogs, logs.FilterMessage("anfrage").Len())
+		})
+	}
+}
+
+func TestExemptPaths_TrotzErschoepftemRateLimit(t *testing.T) {
+	router, logs := neuerTestRouter(t, env.Config{
+		RateLimit:   0.5,
+		ExemptPaths: []string{"/healthz", "/persons/{id}"},
+	})
+
+	require.Equal(t, http.StatusOK, sende(router, http.MethodGet, "/persons", "", "").Code)
+	require.Equal(t, http.StatusTooManyRequests, sende(router, http.MethodGet, "/persons", "", "").Code)
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, sende(router, http.MethodGet, "/healthz", "", "").Code)
+		assert.Equal(t, http.StatusNotFound, sende(router, http.MethodGet, fmt.Sprintf("/persons/%d", i+1), "", "").Code,
+			"ausgenommene route erreicht den handler")
+	}
+	assert.Equal(t, 10, logs.FilterMessage("anfrage").FilterLevelExact(zap.DebugLevel).Len())
+	assert.Equal(t, 2, logs.FilterMessage("anfrage").FilterLevelExact(zap.InfoLevel).Len())
+}
+
+func TestAPIKey_GiltFuerAlleRoutenAusserHealthz(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{APIKeys: []string{"geheim"}})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/persons", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/persons", nil)
+	req.Header.Set("X-API-Key", "geheim")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestBasePath_RoutenUnterPraefix(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{BasePath: "/api/v1", EnableMetrics: true, APIKeys: []string{"geheim"}})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		apiKey     string
+		body       string
+		wantStatus int
+	}{
+		{"health ohne schlüssel", http.MethodGet, "/api/v1/healthz", "", "", http.StatusOK},
+		{"metriken ohne schlüssel", http.MethodGet, "/api/v1/metrics", "", "", http.StatusOK},
+		{"version", http.MethodGet, "/api/v1/version", "geheim", "", http.StatusOK},
+		{"personen", http.MethodGet, "/api/v1/persons", "geheim", "", http.StatusOK},
+		{"anlegen", http.MethodPost, "/api/v1/persons", "geheim", neuePerson, http.StatusCreated},
+		{"personen ohne schlüssel", http.MethodGet, "/api/v1/persons", "", "", http.StatusUnauthorized},
+		{"personen ohne präfix", http.MethodGet, "/persons", "geheim", "", http.StatusNotFound},
+		{"health ohne präfix", http.MethodGet, "/healthz", "geheim", "", http.StatusNotFound},
+		{"nur das präfix", http.MethodGet, "/api/v1", "geheim", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := sende(router, tt.method, tt.target, tt.apiKey, tt.body)
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	rec := sende(router, http.MethodPost, "/api/v1/persons", "geheim", neuePerson)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/api/v1/persons/2", rec.Header().Get("Location"))
+
+	rec = sende(router, http.MethodPatch, "/api/v1/persons", "geheim", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, POST, DELETE", rec.Header().Get("Allow"))
+}
+
+func TestBasePath_AusnahmenUndSchreibschutzRelativZumPraefix(t *testing.T) {
+	router, logs := neuerTestRouter(t, env.Config{
+		BasePath:    "/api/v1",
+		RateLimit:   0.5,
+		ExemptPaths: []string{"/healthz"},
+		ReadOnly:    true,
+	})
+
+	require.Equal(t, http.StatusOK, sende(router, http.MethodGet, "/api/v1/persons", "", "").Code)
+	require.Equal(t, http.StatusTooManyRequests, sende(router, http.MethodGet, "/api/v1/persons", "", "").Code)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, sende(router, http.MethodGet, "/api/v1/healthz", "", "").Code)
+	}
+	assert.Equal(t, 3, logs.FilterMessage("anfrage").FilterLevelExact(zap.DebugLevel).Len())
+
+	rec := sende(router, http.MethodPost, "/api/v1/persons", "", neuePerson)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET", rec.Header().Get("Allow"))
+}
+
+// ─── Schreibschutz ────────────────────────────────────────────────────────────
+
+// sende schickt eine Anfrage mit optionalem JSON-Body und API-Schlüssel.
+func sende(router http.Handler, method, target, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+const neuePerson = `{"name":"Hans","lastname":"Müller","zipcode":"67742","city":"Lauterecken","color":"blau"}`
+
+func TestReadOnly_AusKonfiguration(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{ReadOnly: true})
+
+	tests := []struct {
+		method, target string
+		wantAllow      string
+	}{
+		{http.MethodPost, "/persons", "GET"},
+		{http.MethodDelete, "/persons", "GET"},
+		{http.MethodPut, "/persons/1", "GET, HEAD"},
+		{http.MethodPatch, "/persons/1", "GET, HEAD"},
+		{http.MethodDelete, "/persons/1", "GET, HEAD"},
+		{http.MethodPost, "/persons/1/restore", ""},
+	}
+	for _, tt := range tests {
+		rec := sende(router, tt.method, tt.target, "", neuePerson)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code, tt.method+" "+tt.target)
+		assert.Equal(t, tt.wantAllow, rec.Header().Get("Allow"), tt.method+" "+tt.target)
+		assert.Contains(t, rec.Body.String(), "schreibschutz aktiv")
+	}
+
+	assert.Equal(t, http.StatusOK, sende(router, http.MethodGet, "/persons", "", "").Code)
+	assert.Equal(t, http.StatusNotFound, sende(router, http.MethodPost, "/admin/readonly", "", `{"enabled":false}`).Code,
+		"ohne api-schlüssel kein umschalt-endpunkt")
+}
+
+func TestReadOnly_ZurLaufzeitUmschalten(t *testing.T) {
+	const key = "geheim"
+	router, logs := neuerTestRouter(t, env.Config{APIKeys: []string{key}})
+	umschalten := func(enabled bool) {
+		t.Helper()
+		rec := sende(router, http.MethodPost, "/admin/readonly", key, fmt.Sprintf(`{"enabled":%t}`, enabled))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"enabled":%t}`, enabled), rec.Body.String())
+	}
+
+	require.Equal(t, http.StatusCreated, sende(router, http.MethodPost, "/persons", key, neuePerson).Code)
+
+	umschalten(true)
+	assert.Equal(t, http.StatusMethodNotAllowed, sende(router, http.MethodPost, "/persons", key, neuePerson).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, sende(router, http.MethodDelete, "/persons/1", key, "").Code)
+	assert.Equal(t, http.StatusOK, sende(router, http.MethodGet, "/persons/1", key, "").Code)
+	assert.Equal(t, http.StatusOK, sende(router, http.MethodGet, "/persons", key, "").Code)
+
+	umschalten(false)
+	assert.Equal(t, http.StatusCreated, sende(router, http.MethodPost, "/persons", key, neuePerson).Code)
+	assert.Equal(t, 2, logs.FilterMessage("schreibschutz umgeschaltet").Len())
+
+	assert.Equal(t, http.StatusUnauthorized, sende(router, http.MethodPost, "/admin/readonly", "", `{"enabled":true}`).Code)
+	assert.Equal(t, http.StatusBadRequest, sende(router, http.MethodPost, "/admin/readonly", key, `{}`).Code)
+	assert.Equal(t, http.StatusCreated, sende(router, http.MethodPost, "/persons", key, neuePerson).Code,
+		"abgelehnte umschaltungen ändern nichts")
+}
+
+func TestReload_NurMitAPISchluessel(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{})
+	assert.Equal(t, http.StatusNotFound, sende(router, http.MethodPost, "/admin/reload", "", "").Code)
+
+	const key = "geheim"
+	router, _ = neuerTestRouter(t, env.Config{APIKeys: []string{key}, RateLimit: 0.001})
+	assert.Equal(t, http.StatusUnauthorized, sende(router, http.MethodPost, "/admin/reload", "", "").Code)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusNotImplemented, sende(router, http.MethodPost, "/admin/reload", key, "").Code,
+			"sqlite kann nicht neu laden; das rate-limit greift nicht")
+	}
+}
+
+// ─── Debug-Listener ───────────────────────────────────────────────────────────
+
+func TestDebug_NieAmHauptrouter(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{EnablePprof: true})
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/runtime"} {
+		assert.Equal(t, http.StatusNotFound, sende(router, http.MethodGet, path, "", "").Code, path)
+	}
+}
+
+func TestDebug_EigenerListener(t *testing.T) {
+	srv := httptest.NewServer(Debug())
+	t.Cleanup(srv.Close)
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := srv.Client().Get(srv.URL + path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap?debug=1", "/debug/pprof/goroutine?debug=1", "/debug/pprof/cmdline"} {
+		assert.Equal(t, http.StatusOK, get(path).StatusCode, path)
+	}
+
+	resp := get("/debug/runtime")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Positive(t, stats["goroutines"])
+	assert.Positive(t, stats["heap_alloc_bytes"])
+
+	assert.Equal(t, http.StatusNotFound, get("/persons").StatusCode)
+}
+
+// ─── Tracing ──────────────────────────────────────────────────────────────────
+
+// aufzeichnen installiert für die Dauer des Tests einen TracerProvider, der
+// alle beendeten Spans im Speicher sammelt.
+func aufzeichnen(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+		_ = tp.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestTracing_SpanHierarchieFuerGetByID(t *testing.T) {
+	router, _, repo := neuerTestRouterMitRepo(t, env.Config{})
+	_, err := repo.Add(context.Background(), domain.Person{Name: "Hans", Lastname: "Müller", Color: "blau"})
+	require.NoError(t, err)
+	recorder := aufzeichnen(t)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/persons/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	server, svc, db := spans["GET /persons/{id}"], spans["PersonService.GetByID"], spans["sqlite persons.select_by_id"]
+	require.NotNil(t, server, "server-span nach routenmuster benannt")
+	require.NotNil(t, svc)
+	require.NotNil(t, db)
+
+	assert.Equal(t, traceID, server.SpanContext().TraceID().String(), "trace des aufrufers wird fortgesetzt")
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, server.SpanContext().SpanID(), svc.Parent().SpanID())
+	assert.Equal(t, svc.SpanContext().SpanID(), db.Parent().SpanID())
+	assert.Equal(t, trace.SpanKindClient, db.SpanKind())
+
+	attrs := func(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range s.Attributes() {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+	assert.Equal(t, "/persons/{id}", attrs(server)["http.route"].AsString())
+	assert.Equal(t, int64(200), attrs(server)["http.response.status_code"].AsInt64())
+	assert.Equal(t, int64(1), attrs(svc)["person.id"].AsInt64())
+	assert.Equal(t, "persons.select_by_id", attrs(db)["db.operation.name"].AsString())
+	assert.Equal(t, int64(1), attrs(db)["db.response.returned_rows"].AsInt64())
+	for _, kv := range db.Attributes() {
+		assert.NotContains(t, kv.Value.Emit(), "SELECT", "keine rohen abfragen im span")
+	}
+}
+
+func TestTracing_NichtGefundenIstKeinDatenbankfehler(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{})
+	recorder := aufzeichnen(t)
+
+	require.Equal(t, http.StatusNotFound, sende(router, http.MethodGet, "/persons/42", "", "").Code)
+
+	for _, s := range recorder.Ended() {
+		assert.Equal(t, codes.Unset, s.Status().Code, s.Name())
+	}
+}
+
+func TestSecureHeaders_AuchAufFehlerantworten(t *testing.T) {
+	router, _ := neuerTestRouter(t, env.Config{APIKeys: []string{"geheim"}})
+
+	for _, path := range []string{"/healthz", "/persons", "/nonsense"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"), path)
+		assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"), path)
+		assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"), path)
+	}
+}
+
+func TestFallback_JSONFuer404Und405(t *testing.T) {
+	router, logs := neuerTestRouter(t, env.Config{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{"unbekannter pfad", http.MethodGet, "/nonsense", http.StatusNotFound, ""},
+		{"falsche methode auf einzelperson", http.MethodPost, "/persons/1", http.StatusMethodNotAllowed, "GET, HEAD, PUT, PATCH, DELETE"},
+		{"falsche methode auf sammlung", http.MethodPatch, "/persons", http.StatusMethodNotAllowed, "GET, POST, DELETE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantAllow, rec.Header().Get("Allow"))
+
+			var body struct {
+				Error     string `json:"error"`
+				RequestID string `json:"request_id"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.NotEmpty(t, body.Error)
+			assert.Equal(t, rec.Header().Get("X-Request-Id"), body.RequestID)
+
+			entries := logs.FilterMessage("anfrage").FilterField(zap.String("path", tt.path)).All()
+			require.NotEmpty(t, entries)
+			assert.EqualValues(t, tt.wantStatus, entries[len(entries)-1].ContextMap()["status"])
+		})
+	}
+}
+
+func TestFarbfilter_PfadUndQueryByteIdentisch(t *testing.T) {
+	const data = "Müller, Hans, 67742 Lauterecken, 1\n" +
+		"Petersen, Peter, 18439 Stralsund, 2\n" +
+		"Johnson, Johnny, 88888 made up, 1\n" +
+		"Fujitsu, Tastatur, 42 Japan, 1\n"
+
+	path := filepath.Join(t.TempDir(), "persons.csv")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	csvRepo, err := csvrepo.NewPersonRepository(path, 0, zap.NewNop())
+	require.NoError(t, err)
+
+	sqliteRepo, err := sqliterepo.NewPersonRepository(":memory:", 0, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqliteRepo.Close() })
+	all, err := csvRepo.GetAll(context.Background())
+	require.NoError(t, err)
+	for _, p := range all {
+		_, err := sqliteRepo.Add(context.Background(), p)
+		require.NoError(t, err)
+	}
+
+	backends := map[string]repository.PersonRepository{"csv": csvRepo, "sqlite": sqliteRepo}
+	for name, repo := range backends {
+		router := neuerTestRouterFuer(zap.NewNop(), env.Config{}, repo)
+		get := func(target string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			return rec
+		}
+
+		for _, color := range []string{"blau", "BLAU", "gruen", "rot", "pink"} {
+			t.Run(name+"/"+color, func(t *testing.T) {
+				byPath := get("/persons/color/" + url.PathEscape(color))
+				byQuery := get("/persons?color=" + url.QueryEscape(color))
+
+				assert.Equal(t, byPath.Code, byQuery.Code)
+				if byPath.Code == http.StatusOK {
+					assert.Equal(t, byPath.Body.String(), byQuery.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestGroupByColor_Beispieldaten(t *testing.T) {
+	samplePath := filepath.Join("..", "..", "sample-input.csv")
+	csvRepo, err := csvrepo.NewPersonRepository(samplePath, 0, zap.NewNop())
+	require.NoError(t, err)
+
+	sqliteRepo, err := sqliterepo.NewPersonRepository(":memory:", 0, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqliteRepo.Close() })
+	all, err := csvRepo.GetAll(context.Background())
+	require.NoError(t, err)
+	for _, p := range all {
+		_, err := sqliteRepo.Add(context.Background(), p)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   map[string]int
+	}{
+		{
+			"alle farben samt leerer",
+			"/persons/by-color",
+			map[string]int{"blau": 2, "gelb": 1, "grün": 3, "rot": 1, "türkis": 1, "violett": 2, "weiß": 0},
+		},
+		{
+			"eingeschränkt",
+			"/persons/by-color?colors=" + url.QueryEscape("weiß, BLAU"),
+			map[string]int{"blau": 2, "weiß": 0},
+		},
+		{
+			"limit nach farbname",
+			"/persons/by-color?limit=4",
+			map[string]int{"blau": 2, "gelb": 1, "grün": 1, "rot": 0, "türkis": 0, "violett": 0, "weiß": 0},
+		},
+	}
+
+	backends := map[string]repository.PersonRepository{"csv": csvRepo, "sqlite": sqliteRepo}
+	for name, repo := range backends {
+		router := neuerTestRouterFuer(zap.NewNop(), env.Config{}, repo)
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var groups map[string][]domain.Person
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&groups))
+				got := make(map[string]int, len(groups))
+				for color, persons := range groups {
+					require.NotNil(t, persons, "leere farben als [] statt null")
+					got[color] = len(persons)
+					for _, p := range persons {
+						assert.Equal(t, color, p.Color.String())
+					}
+				}
+				assert.Equal(t, tt.want, got)
+			})
+		}
+	}
+}
+
+func TestLastModified_PostHebtZeitpunkt(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "sample-input.csv"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "persons.csv")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	dateiStand := time.Date(2024, 3, 1, 12, 0, 0, 700_000_000, time.UTC)
+	require.NoError(t, os.Chtimes(path, dateiStand, dateiStand))
+
+	repo, err := csvrepo.NewPersonRepository(path, 0, zap.NewNop())
+	require.NoError(t, err)
+	router := neuerTestRouterFuer(zap.NewNop(), env.Config{}, repo)
+
+	bedingt := func(target, since string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if since != "" {
+			req.Header.Set("If-Modified-Since", since)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := bedingt("/persons", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	vorher := rec.Header().Get("Last-Modified")
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", vorher, "änderungszeitpunkt der datei")
+
+	assert.Equal(t, http.StatusNotModified, bedingt("/persons", vorher).Code)
+	assert.Equal(t, http.StatusNotModified, bedingt("/cities", vorher).Code)
+
+	rec = sende(router, http.MethodPost, "/persons", "", neuePerson)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = bedingt("/persons", vorher)
+	require.Equal(t, http.StatusOK, rec.Code)
+	nachher := rec.Header().Get("Last-Modified")
+	alt, err := http.ParseTime(vorher)
+	require.NoError(t, err)
+	neu, err := http.ParseTime(nachher)
+	require.NoError(t, err)
+	assert.True(t, neu.After(alt), "post hebt den zeitpunkt: %s", nachher)
+
+	assert.Equal(t, http.StatusNotModified, bedingt("/persons/stats", nachher).Code)
+}
+
+func TestCacheControl_JeRouteUndStatus(t *testing.T) {
+	router, _, repo := neuerTestRouterMitRepo(t, env.Config{
+		CacheMaxAgeList: 30 * time.Second,
+		CacheMaxAgeItem: time.Minute,
+	})
+	for range 2 {
+		_, err := repo.Add(context.Background(), domain.Person{Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		method     string
+		target     string
+		body       string
+		since      bool
+		wantStatus int
+		want       string
+	}{
+		{http.MethodGet, "/persons", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/persons/stats", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/persons/by-color", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/persons/color/blau", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/persons/zipcode/67742", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/colors", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/colors", "", true, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/colors/counts", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/cities", "", false, http.StatusOK, "public, max-age=30"},
+		{http.MethodGet, "/persons", "", true, http.StatusNotModified, "public, max-age=30"},
+		{http.MethodGet, "/persons/1", "", false, http.StatusOK, "public, max-age=60"},
+		{http.MethodHead, "/persons/1", "", false, http.StatusOK, "public, max-age=60"},
+		{http.MethodGet, "/persons/1", "", true, http.StatusNotModified, "public, max-age=60"},
+		{http.MethodGet, "/persons/99", "", false, http.StatusNotFound, "no-store"},
+		{http.MethodGet, "/persons/abc", "", false, http.StatusBadRequest, "no-store"},
+		{http.MethodGet, "/persons/color/pink", "", false, http.StatusBadRequest, "no-store"},
+		{http.MethodPost, "/persons", neuePerson, false, http.StatusCreated, "no-store"},
+		{http.MethodPost, "/persons", "{}", false, http.StatusBadRequest, "no-store"},
+		{http.MethodDelete, "/persons/2", "", false, http.StatusNoContent, "no-store"},
+		{http.MethodGet, "/admin/capacity", "", false, http.StatusOK, "no-store"},
+		{http.MethodGet, "/healthz", "", false, http.StatusOK, "no-store"},
+		{http.MethodGet, "/gibt-es-nicht", "", false, http.StatusNotFound, "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s %d", tt.method, tt.target, tt.wantStatus), func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.since {
+				req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.want, rec.Header().Get("Cache-Control"))
+			if tt.wantStatus >= http.StatusBadRequest {
+				assert.Empty(t, rec.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
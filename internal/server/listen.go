@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// unixPrefix kennzeichnet in SERVER_ADDR einen Unix-Domain-Socket, z. B. "unix:/run/app.sock".
+const unixPrefix = "unix:"
+
+// Listen öffnet den Listener für addr. Beginnt addr mit "unix:", wird ein
+// Unix-Domain-Socket unter dem folgenden Pfad angelegt; eine verwaiste
+// Socket-Datei aus einem früheren Lauf wird vorher entfernt. Andere Dateien
+// an diesem Pfad werden nicht angetastet. Sonst wird per TCP gelauscht.
+// Beim Schließen des Listeners wird die Socket-Datei wieder entfernt.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if path == "" {
+		return nil, errors.New("unix-socket ohne pfad")
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("unix-socket %s: %w", path, err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(true)
+	return ln, nil
+}
+
+// removeStaleSocket löscht path, sofern dort ein Socket liegt.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unix-socket %s prüfen: %w", path, err)
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("unix-socket %s: pfad existiert und ist kein socket", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("verwaisten unix-socket %s entfernen: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unixClient liefert einen HTTP-Client, der jede Verbindung über path aufbaut.
+func unixClient(path string) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+func TestListen_UnixSocketMitVerwaisterDatei(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	// Ein nicht sauber beendeter Vorgänger hinterlässt die Socket-Datei.
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	require.FileExists(t, path)
+
+	ln, err := Listen("unix:" + path)
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})}
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ln) }()
+
+	resp, err := unixClient(path).Get("http://unix/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+
+	require.NoError(t, srv.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-done, http.ErrServerClosed)
+	assert.NoFileExists(t, path, "socket-datei muss beim herunterfahren entfernt werden")
+}
+
+func TestListen_UnixPfadIstKeinSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daten.txt")
+	require.NoError(t, os.WriteFile(path, []byte("wichtig"), 0o600))
+
+	_, err := Listen("unix:" + path)
+	require.Error(t, err)
+	assert.FileExists(t, path)
+}
+
+func TestListen_TCP(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	assert.Equal(t, "tcp", ln.Addr().Network())
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// modernCipherSuites enthält ausschließlich AEAD-Suiten mit Forward Secrecy.
+// Für TLS 1.3 wählt Go die Suiten selbst; die Liste wirkt nur auf TLS 1.2.
+var modernCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// TLSConfig erstellt die TLS-Konfiguration für den HTTP-Server.
+// Sind weder Zertifikat noch Schlüssel gesetzt, wird nil zurückgegeben und
+// der Server läuft ohne TLS. Ist clientCAFile gesetzt, wird ein gültiges
+// Client-Zertifikat dieser CA verlangt (mTLS).
+// Alle Dateien werden sofort gelesen, damit Fehlkonfigurationen beim Start
+// und nicht erst bei der ersten Anfrage auffallen.
+func TLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.New("client-ca ohne server-zertifikat und schlüssel konfiguriert")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("zertifikat und schlüssel müssen gemeinsam gesetzt sein")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("zertifikat laden: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: modernCipherSuites,
+	}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("client-ca lesen %s: %w", clientCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("client-ca %s enthält keine gültigen zertifikate", clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPKI enthält eine selbstsignierte Test-CA samt Server- und Client-Zertifikat.
+type testPKI struct {
+	caFile, certFile, keyFile string
+	caPool                    *x509.CertPool
+	client                    tls.Certificate
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+
+	serverCert, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+	client, err := tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return testPKI{
+		caFile:   write("ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		certFile: write("server.pem", serverCert),
+		keyFile:  write("server-key.pem", serverKey),
+		caPool:   pool,
+		client:   client,
+	}
+}
+
+// startTLSServer startet einen Server mit cfg auf einem freien Port und gibt dessen Adresse zurück.
+func startTLSServer(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}),
+		TLSConfig: cfg,
+	}
+	go func() { _ = srv.ServeTLS(ln, "", "") }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return ln.Addr().String()
+}
+
+func httpsClient(pki testPKI, certs ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      pki.caPool,
+			Certificates: certs,
+		}},
+	}
+}
+
+func TestTLSConfig_Deaktiviert(t *testing.T) {
+	cfg, err := TLSConfig("", "", "")
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+}
+
+func TestTLSConfig_Fehlkonfiguration(t *testing.T) {
+	pki := newTestPKI(t)
+
+	tests := []struct {
+		name                string
+		cert, key, clientCA string
+	}{
+		{"zertifikat ohne schlüssel", pki.certFile, "", ""},
+		{"schlüssel ohne zertifikat", "", pki.keyFile, ""},
+		{"client-ca ohne zertifikat", "", "", pki.caFile},
+		{"zertifikat nicht lesbar", "/nicht/vorhanden.pem", pki.keyFile, ""},
+		{"client-ca nicht lesbar", pki.certFile, pki.keyFile, "/nicht/vorhanden.pem"},
+		{"client-ca ohne zertifikate", pki.certFile, pki.keyFile, pki.keyFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TLSConfig(tt.cert, tt.key, tt.clientCA)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestTLSConfig_MindestversionTLS12(t *testing.T) {
+	pki := newTestPKI(t)
+	cfg, err := TLSConfig(pki.certFile, pki.keyFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	assert.Equal(t, tls.NoClientCert, cfg.ClientAuth)
+}
+
+func TestTLSServer_HTTPSErfolgreichUndKlartextAbgelehnt(t *testing.T) {
+	pki := newTestPKI(t)
+	cfg, err := TLSConfig(pki.certFile, pki.keyFile, "")
+	require.NoError(t, err)
+	addr := startTLSServer(t, cfg)
+
+	resp, err := httpsClient(pki).Get("https://" + addr)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", string(body))
+
+	plain := &http.Client{Timeout: 5 * time.Second}
+	resp, err = plain.Get("http://" + addr)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTLSServer_MTLSLehntClientOhneZertifikatAb(t *testing.T) {
+	pki := newTestPKI(t)
+	cfg, err := TLSConfig(pki.certFile, pki.keyFile, pki.caFile)
+	require.NoError(t, err)
+	addr := startTLSServer(t, cfg)
+
+	_, err = httpsClient(pki).Get("https://" + addr)
+	require.Error(t, err)
+	var netErr net.Error
+	assert.False(t, errors.As(err, &netErr) && netErr.Timeout(), "ablehnung statt timeout erwartet")
+
+	resp, err := httpsClient(pki, pki.client).Get("https://" + addr)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
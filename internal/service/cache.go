@@ -0,0 +1,61 @@
+package service
+
+import (
+	"sync"
+
+	"assecor-assessment-backend/internal/domain"
+)
+
+// colorQuery ist eine Abfrage an PersonRepository.GetByColor. Jede Seite in
+// jeder Sortierung ist ein eigener Eintrag im colorCache.
+type colorQuery struct {
+	color         domain.Color
+	order         domain.Order
+	limit, offset int
+}
+
+// colorCache hält die Ergebnisse von GetByColor je Abfrage, also nur die
+// angefragten Seiten statt ganzer Farbgruppen. Jede Änderung über den
+// Service leert ihn vollständig; Änderungen am Service vorbei (etwa ein
+// Neuladen der Quelle) müssen InvalidateCache aufrufen.
+//
+// generation verhindert, dass ein Leser, der vor einer Änderung aus dem
+// Repository gelesen hat, sein veraltetes Ergebnis nach dem Leeren einträgt.
+type colorCache struct {
+	mu         sync.RWMutex
+	byQuery    map[colorQuery][]domain.Person
+	generation uint64
+}
+
+func newColorCache() *colorCache {
+	return &colorCache{byQuery: make(map[colorQuery][]domain.Person)}
+}
+
+// get liefert den Eintrag zu q. Ohne Eintrag meldet ok false, zusammen mit
+// der Generation, die put für das nachgelesene Ergebnis braucht.
+func (c *colorCache) get(q colorQuery) (persons []domain.Person, generation uint64, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	persons, ok = c.byQuery[q]
+	return persons, c.generation, ok
+}
+
+// put trägt persons für q ein, sofern seit get nichts geleert wurde.
+func (c *colorCache) put(q colorQuery, persons []domain.Person, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.byQuery[q] = persons
+	}
+}
+
+// invalidate leert den Cache. Ein nil-Cache ist erlaubt und tut nichts.
+func (c *colorCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.byQuery)
+}
@@ -2,75 +2,768 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 	"unicode/utf8"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
+	"assecor-assessment-backend/internal/audit"
 	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/i18n"
 	"assecor-assessment-backend/internal/repository"
 )
 
+// Längengrenzen der Personenfelder in Zeichen.
 const (
-	nameMinLen    = 2
-	nameMaxLen    = 255
-	zipcodeMaxLen = 5
+	nameMinLen = 2
+	nameMaxLen = 255
+	// zipcodeMaxLen lässt neben deutschen fünfstelligen Postleitzahlen auch
+	// ausländische zu, etwa britische mit Leerzeichen ("SW1A 1AA"); das
+	// Format selbst wird nicht geprüft.
+	zipcodeMaxLen = 20
 	cityMinLen    = 2
 	cityMaxLen    = 255
 )
 
+// tracerName benennt den Tracer der Service-Schicht.
+const tracerName = "assecor-assessment-backend/internal/service"
+
+// startSpan beginnt einen Span für eine Service-Methode. Der Tracer wird bei
+// jedem Aufruf vom globalen Provider geholt, damit tracing.Setup auch nach
+// dem Anlegen des Services wirkt.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
 // PersonService kapselt die Geschäftslogik für Personenoperationen.
 type PersonService struct {
-	repo   repository.PersonRepository
-	logger *zap.Logger
+	repo        repository.PersonRepository
+	logger      *zap.Logger
+	now         func() time.Time
+	auditor     audit.Auditor
+	auditStrict bool
+	persons     Gauge
+	colors      *colorCache
+}
+
+// Gauge nimmt die Anzahl der nicht gelöschten Personen auf.
+// prometheus.Gauge erfüllt es.
+type Gauge interface {
+	Set(float64)
+	Add(float64)
+}
+
+// Option konfiguriert optionale Eigenschaften des PersonService.
+type Option func(*PersonService)
+
+// WithAuditor meldet jede Änderung an a. Ohne Auditor entstehen keine
+// Audit-Ereignisse.
+func WithAuditor(a audit.Auditor) Option {
+	return func(s *PersonService) {
+		s.auditor = a
+	}
+}
+
+// WithAuditStrict lässt eine Änderung mit Fehler enden, wenn ihr
+// Audit-Ereignis nicht geschrieben werden kann. Die Änderung selbst ist zu
+// diesem Zeitpunkt bereits gespeichert.
+func WithAuditStrict(strict bool) Option {
+	return func(s *PersonService) {
+		s.auditStrict = strict
+	}
+}
+
+// WithPersonsGauge führt g bei jeder Änderung mit, die die Anzahl der
+// Personen verändert. Den Ausgangswert setzt RefreshPersonCount.
+func WithPersonsGauge(g Gauge) Option {
+	return func(s *PersonService) {
+		s.persons = g
+	}
+}
+
+// WithColorCache hält die Ergebnisse von GetByColor je angefragter Seite und
+// von SameColorAs je Farbe im Speicher, bis eine Änderung über den Service
+// sie verwirft.
+func WithColorCache(enabled bool) Option {
+	return func(s *PersonService) {
+		if enabled {
+			s.colors = newColorCache()
+		}
+	}
 }
 
 // NewPersonService gibt einen einsatzbereiten PersonService zurück.
-func NewPersonService(repo repository.PersonRepository, logger *zap.Logger) *PersonService {
-	return &PersonService{repo: repo, logger: logger}
+func NewPersonService(repo repository.PersonRepository, logger *zap.Logger, opts ...Option) *PersonService {
+	s := &PersonService{repo: repo, logger: logger, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetAll gibt alle Personen zurück.
 func (s *PersonService) GetAll(ctx context.Context) ([]domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.GetAll")
+	defer span.End()
 	return s.repo.GetAll(ctx)
 }
 
+// StreamAll ruft fn für jede Person auf, ohne alle Personen zu puffern.
+func (s *PersonService) StreamAll(ctx context.Context, fn func(domain.Person) error) error {
+	ctx, span := startSpan(ctx, "PersonService.StreamAll")
+	defer span.End()
+	return s.repo.GetAllStream(ctx, fn)
+}
+
+// Find liefert alle Personen, die filter erfüllen. Eine gesetzte Farbe wird
+// wie bei GetByColor normalisiert; unbekannte Farben und negative Werte für
+// Limit oder Offset ergeben domain.ErrInvalidInput.
+func (s *PersonService) Find(ctx context.Context, filter domain.PersonFilter) ([]domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.Find")
+	defer span.End()
+	filter, err := s.normalizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Find(ctx, filter)
+}
+
+// Count zählt alle Personen, die filter erfüllen; Limit und Offset werden
+// ignoriert. Die Prüfungen entsprechen Find.
+func (s *PersonService) Count(ctx context.Context, filter domain.PersonFilter) (int, error) {
+	ctx, span := startSpan(ctx, "PersonService.Count")
+	defer span.End()
+	filter, err := s.normalizeFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.Count(ctx, filter)
+}
+
+// normalizeFilter prüft Limit und Offset und normalisiert eine gesetzte Farbe.
+func (s *PersonService) normalizeFilter(filter domain.PersonFilter) (domain.PersonFilter, error) {
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return filter, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeNegativePagination)
+	}
+	if filter.Color != "" {
+		parsed, err := domain.ParseColor(filter.Color.String())
+		if err != nil {
+			s.logger.Warn("unbekannte farbe angefragt", zap.Stringer("farbe", filter.Color))
+			return filter, err
+		}
+		filter.Color = parsed
+	}
+	return filter, nil
+}
+
+// ListAfter liefert eine Seite von höchstens limit Personen mit einer ID
+// größer als afterID. next ist die ID, ab der die folgende Seite beginnt, oder
+// 0, wenn keine weiteren Personen existieren.
+func (s *PersonService) ListAfter(ctx context.Context, afterID, limit int) (persons []domain.Person, next int, err error) {
+	ctx, span := startSpan(ctx, "PersonService.ListAfter")
+	defer span.End()
+	if afterID < 0 || limit <= 0 {
+		return nil, 0, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeInvalidCursorLimit)
+	}
+	// Eine zusätzliche Zeile zeigt an, ob es eine weitere Seite gibt.
+	persons, err = s.repo.GetAllAfter(ctx, afterID, limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(persons) > limit {
+		persons = persons[:limit]
+		next = persons[limit-1].ID
+	}
+	return persons, next, nil
+}
+
 // GetByID sucht eine einzelne Person anhand ihrer ID.
 func (s *PersonService) GetByID(ctx context.Context, id int) (domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.GetByID", attribute.Int("person.id", id))
+	defer span.End()
 	if id <= 0 {
-		return domain.Person{}, fmt.Errorf("id muss positiv sein: %w", domain.ErrInvalidInput)
+		return domain.Person{}, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeIDNotPositive)
 	}
 	return s.repo.GetByID(ctx, id)
 }
 
-// GetByColor gibt alle Personen mit passender Lieblingsfarbe zurück.
-func (s *PersonService) GetByColor(ctx context.Context, color string) ([]domain.Person, error) {
-	normalized := strings.ToLower(strings.TrimSpace(color))
-	if _, ok := domain.ColorNameID[normalized]; !ok {
+// GetByIDs liefert die Personen zu ids in der angefragten Reihenfolge.
+// Doppelte IDs erscheinen nur einmal an ihrer ersten Position, unbekannte IDs
+// werden stillschweigend ausgelassen. Eine leere Liste oder nicht positive IDs
+// ergeben domain.ErrInvalidInput.
+func (s *PersonService) GetByIDs(ctx context.Context, ids []int) ([]domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.GetByIDs")
+	defer span.End()
+	if len(ids) == 0 {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeIDsRequired)
+	}
+	unique := make([]int, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeIDNotPositive)
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	found, err := s.repo.GetByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]domain.Person, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+
+	out := make([]domain.Person, 0, len(found))
+	for _, id := range unique {
+		if p, ok := byID[id]; ok {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// GetByColor gibt alle Personen mit passender Lieblingsfarbe zurück,
+// sortiert nach sort (siehe domain.ParseOrder; "" sortiert nach ID). offset
+// überspringt Treffer, limit begrenzt sie (0 = unbegrenzt); beides wendet
+// das Repository an. Ein unbekanntes Sortierfeld ergibt
+// domain.ErrInvalidInput, eine unbekannte Farbe domain.ErrInvalidColor, eine
+// gültige ohne Personen domain.ErrNoPersonsWithColor. Eine Seite hinter dem
+// letzten Treffer ist dagegen eine leere Liste.
+func (s *PersonService) GetByColor(ctx context.Context, color, sort string, limit, offset int) ([]domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.GetByColor")
+	defer span.End()
+	if limit < 0 || offset < 0 {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeNegativePagination)
+	}
+	order, err := domain.ParseOrder(sort)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := domain.ParseColor(color)
+	if err != nil {
 		s.logger.Warn("unbekannte farbe angefragt", zap.String("farbe", color))
-		return nil, fmt.Errorf("ungültige farbe: %w", domain.ErrInvalidInput)
+		return nil, err
+	}
+	persons, err := s.byColor(ctx, colorQuery{color: parsed, order: order, limit: limit, offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	if len(persons) > 0 {
+		return persons, nil
+	}
+	// Eine leere Seite heißt nur ohne offset, dass die Farbe keine Personen
+	// hat; sonst entscheidet die Anzahl, ohne die Gruppe zu laden.
+	if offset > 0 {
+		n, err := s.repo.Count(ctx, domain.PersonFilter{Color: parsed})
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			return persons, nil
+		}
+	}
+	return nil, domain.ErrNoPersonsWithColor
+}
+
+// byColor liest die Personen zu q, mit WithColorCache aus dem Cache. Das
+// Ergebnis kann sich den Speicher mit dem Cache teilen und darf nicht
+// verändert werden.
+func (s *PersonService) byColor(ctx context.Context, q colorQuery) ([]domain.Person, error) {
+	if s.colors == nil {
+		return s.repo.GetByColor(ctx, q.color, q.order, q.limit, q.offset)
+	}
+	persons, generation, ok := s.colors.get(q)
+	if ok {
+		return persons, nil
+	}
+	persons, err := s.repo.GetByColor(ctx, q.color, q.order, q.limit, q.offset)
+	if err != nil {
+		return nil, err
+	}
+	s.colors.put(q, persons, generation)
+	return persons, nil
+}
+
+// InvalidateCache verwirft den Cache aus WithColorCache. Nötig nach
+// Änderungen am Service vorbei, etwa einem Neuladen der Datenquelle.
+func (s *PersonService) InvalidateCache() {
+	s.colors.invalidate()
+}
+
+// GroupByColor gruppiert die Personen der Farben colors (leer = alle bekannten)
+// nach Farbe. Jede angefragte Farbe erscheint, ohne Personen mit leerer Liste.
+// offset und limit (0 = unbegrenzt) gelten für alle Gruppen zusammen, in der
+// Reihenfolge Farbname, dann ID.
+func (s *PersonService) GroupByColor(ctx context.Context, colors []string, limit, offset int) (map[domain.Color][]domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.GroupByColor")
+	defer span.End()
+	if limit < 0 || offset < 0 {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeNegativePagination)
+	}
+
+	parsed := domain.AllColors()
+	if len(colors) > 0 {
+		parsed = make([]domain.Color, 0, len(colors))
+		for _, c := range colors {
+			color, err := domain.ParseColor(c)
+			if err != nil {
+				s.logger.Warn("unbekannte farbe angefragt", zap.String("farbe", c))
+				return nil, err
+			}
+			if !slices.Contains(parsed, color) {
+				parsed = append(parsed, color)
+			}
+		}
+	}
+
+	persons, err := s.repo.GetByColors(ctx, parsed, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[domain.Color][]domain.Person, len(parsed))
+	for _, color := range parsed {
+		groups[color] = []domain.Person{}
+	}
+	for _, p := range persons {
+		groups[p.Color] = append(groups[p.Color], p)
+	}
+	return groups, nil
+}
+
+// GetByZipcode gibt alle Personen mit der Postleitzahl zip nach ID sortiert
+// zurück, mit prefix alle, deren Postleitzahl mit zip beginnt. zip darf nicht
+// leer und nicht länger als eine gültige Postleitzahl sein. offset überspringt
+// Treffer, limit begrenzt sie (0 = unbegrenzt). Das Ergebnis ist nie nil.
+func (s *PersonService) GetByZipcode(ctx context.Context, zip string, prefix bool, limit, offset int) ([]domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.GetByZipcode", attribute.Bool("zipcode.prefix", prefix))
+	defer span.End()
+	if limit < 0 || offset < 0 {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeNegativePagination)
+	}
+	zip = strings.TrimSpace(zip)
+	if zip == "" {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeZipcodeRequired)
+	}
+	if n := utf8.RuneCountInString(zip); n > zipcodeMaxLen {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeZipcodeTooLong, zipcodeMaxLen)
+	}
+	return s.repo.GetByZipcode(ctx, zip, prefix, limit, offset)
+}
+
+// SameColorAs gibt alle anderen Personen mit derselben Lieblingsfarbe wie die
+// Person mit der angegebenen ID zurück, nach ID sortiert. offset überspringt
+// Treffer, limit begrenzt sie (0 = unbegrenzt). Das Ergebnis ist nie nil.
+func (s *PersonService) SameColorAs(ctx context.Context, id, limit, offset int) ([]domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.SameColorAs", attribute.Int("person.id", id))
+	defer span.End()
+	if limit < 0 || offset < 0 {
+		return nil, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeNegativePagination)
+	}
+
+	person, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.byColor(ctx, colorQuery{color: person.Color})
+	if err != nil {
+		return nil, err
+	}
+
+	others := make([]domain.Person, 0, len(candidates))
+	for _, p := range candidates {
+		if p.ID != person.ID {
+			others = append(others, p)
+		}
+	}
+	return domain.Paginate(others, limit, offset), nil
+}
+
+// CountsByColor liefert die Anzahl der Personen für jede bekannte Farbe,
+// sortiert nach Farb-ID. Farben ohne Personen erscheinen mit 0.
+func (s *PersonService) CountsByColor(ctx context.Context) ([]domain.ColorCount, error) {
+	ctx, span := startSpan(ctx, "PersonService.CountsByColor")
+	defer span.End()
+	counts, err := s.repo.CountsByColor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	colors := domain.AllColors()
+	out := make([]domain.ColorCount, 0, len(colors))
+	for _, color := range colors {
+		out = append(out, domain.ColorCount{Color: color, Count: counts[color]})
+	}
+	return out, nil
+}
+
+// Stats liefert Gesamtzahl sowie Anzahl je Farbe und je Stadt. Jede bekannte
+// Farbe erscheint, auch ohne Personen mit 0, damit Diagrammachsen stabil bleiben.
+func (s *PersonService) Stats(ctx context.Context) (domain.PersonStats, error) {
+	ctx, span := startSpan(ctx, "PersonService.Stats")
+	defer span.End()
+	stats, err := s.repo.Stats(ctx)
+	if err != nil {
+		return domain.PersonStats{}, err
+	}
+	for _, color := range domain.AllColors() {
+		if _, ok := stats.ByColor[color]; !ok {
+			stats.ByColor[color] = 0
+		}
+	}
+	return stats, nil
+}
+
+// DistinctCities liefert die sortierten Städte aller Personen, z. B. für
+// eine Autovervollständigung.
+func (s *PersonService) DistinctCities(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "PersonService.DistinctCities")
+	defer span.End()
+	return s.repo.DistinctCities(ctx)
+}
+
+// Capacity liefert die Auslastung der Kapazitätsgrenze.
+func (s *PersonService) Capacity(ctx context.Context) (domain.Capacity, error) {
+	ctx, span := startSpan(ctx, "PersonService.Capacity")
+	defer span.End()
+	used, max, err := s.repo.Capacity(ctx)
+	if err != nil {
+		return domain.Capacity{}, err
 	}
-	return s.repo.GetByColor(ctx, normalized)
+	return domain.Capacity{Used: used, Max: max}, nil
+}
+
+// LastModified liefert den Zeitpunkt der letzten Änderung am Bestand.
+func (s *PersonService) LastModified(ctx context.Context) (time.Time, error) {
+	ctx, span := startSpan(ctx, "PersonService.LastModified")
+	defer span.End()
+	return s.repo.LastModified(ctx)
 }
 
-// Add validiert und fügt eine neue Person hinzu. Der Farbname wird normalisiert.
+// Add validiert und fügt eine neue Person hinzu. Der Farbname wird über
+// domain.ParseColor normalisiert; CreatedAt und UpdatedAt werden auf die
+// aktuelle Zeit gesetzt.
 func (s *PersonService) Add(ctx context.Context, person domain.Person) (domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.Add")
+	defer span.End()
+	person, err := s.normalize(person)
+	if err != nil {
+		return domain.Person{}, err
+	}
+	now := s.now().UTC()
+	person.CreatedAt, person.UpdatedAt = now, now
+	added, err := s.repo.Add(ctx, person)
+	s.colors.invalidate()
+	if err != nil {
+		return domain.Person{}, err
+	}
+	s.countPersons(1)
+	if err := s.record(ctx, audit.OpCreate, added.ID, nil, &added); err != nil {
+		return domain.Person{}, err
+	}
+	return added, nil
+}
+
+// Update validiert person wie Add und ersetzt damit die Person mit der
+// angegebenen ID. expectedVersion > 0 verlangt, dass die gespeicherte Version
+// noch übereinstimmt (sonst *domain.VersionConflictError); 0 überschreibt
+// ohne Prüfung. UpdatedAt wird auf die aktuelle Zeit gesetzt.
+func (s *PersonService) Update(ctx context.Context, id int, person domain.Person, expectedVersion int) (domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.Update", attribute.Int("person.id", id))
+	defer span.End()
+	if id <= 0 {
+		return domain.Person{}, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeIDNotPositive)
+	}
+	if expectedVersion < 0 {
+		return domain.Person{}, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeNegativeVersion)
+	}
+	person, err := s.normalize(person)
+	if err != nil {
+		return domain.Person{}, err
+	}
+	person.ID = id
+	person.UpdatedAt = s.now().UTC()
+
+	before := s.snapshot(ctx, id)
+	updated, err := s.repo.Update(ctx, person, expectedVersion)
+	s.colors.invalidate()
+	if err != nil {
+		if errors.Is(err, domain.ErrVersionConflict) {
+			s.logger.Info("veraltete version beim aktualisieren",
+				zap.Int("id", id), zap.Int("erwartet", expectedVersion))
+		}
+		return domain.Person{}, err
+	}
+	if err := s.record(ctx, audit.OpUpdate, id, before, &updated); err != nil {
+		return domain.Person{}, err
+	}
+	return updated, nil
+}
+
+// Upsert ersetzt die Person wie Update oder legt sie unter genau dieser ID
+// an, wenn es sie nicht gibt. Angelegt wird nur ohne erwartete Version; eine
+// gelöschte Person bleibt ErrGone. created meldet, ob die Person neu ist.
+func (s *PersonService) Upsert(ctx context.Context, id int, person domain.Person, expectedVersion int) (_ domain.Person, created bool, _ error) {
+	ctx, span := startSpan(ctx, "PersonService.Upsert", attribute.Int("person.id", id))
+	defer span.End()
+
+	updated, err := s.Update(ctx, id, person, expectedVersion)
+	if err == nil || expectedVersion > 0 || !errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrGone) {
+		return updated, false, err
+	}
+
+	person, err = s.normalize(person)
+	if err != nil {
+		return domain.Person{}, false, err
+	}
+	now := s.now().UTC()
+	person.ID = id
+	person.CreatedAt, person.UpdatedAt = now, now
+
+	inserted, err := s.repo.Insert(ctx, person)
+	s.colors.invalidate()
+	if errors.Is(err, domain.ErrVersionConflict) {
+		// Ein anderer Aufruf hat die ID zwischen Update und Insert belegt.
+		updated, err := s.Update(ctx, id, person, 0)
+		return updated, false, err
+	}
+	if err != nil {
+		return domain.Person{}, false, err
+	}
+	s.logger.Info("person per put angelegt", zap.Int("id", id))
+	s.countPersons(1)
+	if err := s.record(ctx, audit.OpCreate, id, nil, &inserted); err != nil {
+		return domain.Person{}, false, err
+	}
+	return inserted, true, nil
+}
+
+// normalize trimmt die Textfelder, validiert sie und normalisiert den
+// Farbnamen über domain.ParseColor.
+func (s *PersonService) normalize(person domain.Person) (domain.Person, error) {
 	person.Name = strings.TrimSpace(person.Name)
 	person.Lastname = strings.TrimSpace(person.Lastname)
 	person.Zipcode = strings.TrimSpace(person.Zipcode)
 	person.City = strings.TrimSpace(person.City)
-	person.Color = strings.ToLower(strings.TrimSpace(person.Color))
 
 	if err := validatePerson(person); err != nil {
 		return domain.Person{}, err
 	}
 
-	if _, ok := domain.ColorNameID[person.Color]; !ok {
-		s.logger.Warn("ungültige farbe beim erstellen", zap.String("farbe", person.Color))
-		return domain.Person{}, fmt.Errorf("ungültige farbe: %w", domain.ErrInvalidInput)
+	color, err := domain.ParseColor(person.Color.String())
+	if err != nil {
+		s.logger.Warn("ungültige farbe", zap.Stringer("farbe", person.Color))
+		return domain.Person{}, err
+	}
+	person.Color = color
+	return person, nil
+}
+
+// Delete löscht die Person vorläufig. Sie lässt sich mit Restore
+// wiederherstellen, bis Purge sie nach Ablauf der Aufbewahrungsfrist entfernt.
+func (s *PersonService) Delete(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "PersonService.Delete", attribute.Int("person.id", id))
+	defer span.End()
+	if id <= 0 {
+		return i18n.Wrap(domain.ErrInvalidInput, i18n.CodeIDNotPositive)
+	}
+	before := s.snapshot(ctx, id)
+	err := s.repo.Delete(ctx, id, s.now().UTC())
+	s.colors.invalidate()
+	if err != nil {
+		return err
+	}
+	s.logger.Info("person vorläufig gelöscht", zap.Int("id", id))
+	s.countPersons(-1)
+	return s.record(ctx, audit.OpDelete, id, before, nil)
+}
+
+// DeleteByColor löscht alle Personen der Farbe color vorläufig und liefert
+// deren Anzahl. Die Farbe wird wie bei GetByColor normalisiert; eine
+// unbekannte Farbe ergibt domain.ErrInvalidInput.
+func (s *PersonService) DeleteByColor(ctx context.Context, color string) (int, error) {
+	ctx, span := startSpan(ctx, "PersonService.DeleteByColor")
+	defer span.End()
+	parsed, err := domain.ParseColor(color)
+	if err != nil {
+		s.logger.Warn("unbekannte farbe zum löschen", zap.String("farbe", color))
+		return 0, err
+	}
+
+	// Für das Audit-Log wird der Stand vorher gelesen, wie bei snapshot.
+	var before []domain.Person
+	if s.auditor != nil {
+		before, _ = s.repo.GetByColor(ctx, parsed, domain.Order{}, 0, 0)
 	}
-	return s.repo.Add(ctx, person)
+	n, err := s.repo.DeleteByColor(ctx, parsed, s.now().UTC())
+	s.colors.invalidate()
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Info("personen einer farbe vorläufig gelöscht", zap.Stringer("farbe", parsed), zap.Int("anzahl", n))
+	s.countPersons(-float64(n))
+	for i := range before {
+		if err := s.record(ctx, audit.OpDelete, before[i].ID, &before[i], nil); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Restore hebt die vorläufige Löschung der Person auf.
+func (s *PersonService) Restore(ctx context.Context, id int) (domain.Person, error) {
+	ctx, span := startSpan(ctx, "PersonService.Restore", attribute.Int("person.id", id))
+	defer span.End()
+	if id <= 0 {
+		return domain.Person{}, i18n.Wrap(domain.ErrInvalidInput, i18n.CodeIDNotPositive)
+	}
+	restored, err := s.repo.Restore(ctx, id)
+	s.colors.invalidate()
+	if err != nil {
+		return domain.Person{}, err
+	}
+	s.logger.Info("person wiederhergestellt", zap.Int("id", id))
+	s.countPersons(1)
+	if err := s.record(ctx, audit.OpRestore, id, nil, &restored); err != nil {
+		return domain.Person{}, err
+	}
+	return restored, nil
+}
+
+// Purge entfernt alle Personen endgültig, die vor mehr als retention
+// gelöscht wurden, und liefert deren Anzahl.
+func (s *PersonService) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	ctx, span := startSpan(ctx, "PersonService.Purge")
+	defer span.End()
+	n, err := s.repo.Purge(ctx, s.now().UTC().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		s.logger.Info("gelöschte personen endgültig entfernt", zap.Int("anzahl", n))
+	}
+	return n, nil
+}
+
+// RunPurge ruft Purge alle interval auf, bis ctx endet. Fehler werden
+// protokolliert; der nächste Durchlauf versucht es erneut.
+func (s *PersonService) RunPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Purge(ctx, retention); err != nil && ctx.Err() == nil {
+				s.logger.Error("gelöschte personen entfernen", zap.Error(err))
+			}
+		}
+	}
+}
+
+// RefreshPersonCount setzt das Gauge aus WithPersonsGauge auf die gezählte
+// Anzahl nicht gelöschter Personen. Ohne Gauge tut es nichts.
+func (s *PersonService) RefreshPersonCount(ctx context.Context) error {
+	if s.persons == nil {
+		return nil
+	}
+	ctx, span := startSpan(ctx, "PersonService.RefreshPersonCount")
+	defer span.End()
+	n, err := s.repo.Count(ctx, domain.PersonFilter{})
+	if err != nil {
+		return err
+	}
+	s.persons.Set(float64(n))
+	return nil
+}
+
+// RunPersonCountRefresh ruft RefreshPersonCount alle interval auf, bis ctx
+// endet. Das gleicht Änderungen ab, die am Service vorbei geschehen, etwa ein
+// Neuladen der CSV oder direkte Eingriffe in die Datenbank.
+func (s *PersonService) RunPersonCountRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.RefreshPersonCount(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Warn("personenanzahl nicht aktualisiert", zap.Error(err))
+			}
+		}
+	}
+}
+
+// countPersons verändert das Gauge aus WithPersonsGauge um delta.
+func (s *PersonService) countPersons(delta float64) {
+	if s.persons != nil {
+		s.persons.Add(delta)
+	}
+}
+
+// DeleteAll entfernt alle Personen aus dem Repository.
+func (s *PersonService) DeleteAll(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "PersonService.DeleteAll")
+	defer span.End()
+	err := s.repo.DeleteAll(ctx)
+	s.colors.invalidate()
+	if err != nil {
+		return err
+	}
+	s.logger.Warn("alle personen gelöscht")
+	if s.persons != nil {
+		s.persons.Set(0)
+	}
+	return s.record(ctx, audit.OpDeleteAll, 0, nil, nil)
+}
+
+// snapshot liest den Stand einer Person vor einer Änderung für das
+// Audit-Ereignis. Ohne Auditor oder wenn die Person nicht lesbar ist, liefert
+// es nil; über den Erfolg der Änderung entscheidet allein das Repository.
+// Eine gleichzeitige Änderung zwischen Lesen und Schreiben kann einen
+// älteren Stand als Before festhalten.
+func (s *PersonService) snapshot(ctx context.Context, id int) *domain.Person {
+	if s.auditor == nil {
+		return nil
+	}
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return &p
+}
+
+// record übergibt ein Audit-Ereignis an den Auditor. Scheitert das, wird es
+// protokolliert; als Fehler zurückgegeben wird es nur mit WithAuditStrict.
+func (s *PersonService) record(ctx context.Context, operation string, id int, before, after *domain.Person) error {
+	if s.auditor == nil {
+		return nil
+	}
+	event := audit.NewEvent(ctx, operation, s.now().UTC())
+	event.PersonID, event.Before, event.After = id, before, after
+	if err := s.auditor.Record(ctx, event); err != nil {
+		s.logger.Error("audit-eintrag nicht geschrieben",
+			zap.String("vorgang", operation), zap.Int("id", id), zap.Error(err))
+		if s.auditStrict {
+			return fmt.Errorf("audit-eintrag für %s: %w", operation, err)
+		}
+	}
+	return nil
 }
 
 // validatePerson prüft alle Pflichtfelder und Längengrenzen einer Person.
@@ -82,10 +775,10 @@ func validatePerson(p domain.Person) error {
 		return err
 	}
 	if p.Zipcode == "" {
-		return fmt.Errorf("postleitzahl ist erforderlich: %w", domain.ErrInvalidInput)
+		return i18n.Wrap(domain.ErrInvalidInput, i18n.CodeZipcodeRequired)
 	}
 	if n := utf8.RuneCountInString(p.Zipcode); n > zipcodeMaxLen {
-		return fmt.Errorf("postleitzahl darf maximal %d zeichen lang sein: %w", zipcodeMaxLen, domain.ErrInvalidInput)
+		return i18n.Wrap(domain.ErrInvalidInput, i18n.CodeZipcodeTooLong, zipcodeMaxLen)
 	}
 	if err := checkLength("stadt", p.City, cityMinLen, cityMaxLen); err != nil {
 		return err
@@ -97,10 +790,10 @@ func validatePerson(p domain.Person) error {
 func checkLength(field, s string, min, max int) error {
 	n := utf8.RuneCountInString(s)
 	if n < min {
-		return fmt.Errorf("%s muss mindestens %d zeichen lang sein: %w", field, min, domain.ErrInvalidInput)
+		return i18n.Wrap(domain.ErrInvalidInput, i18n.CodeFieldTooShort, field, min)
 	}
 	if n > max {
-		return fmt.Errorf("%s darf maximal %d zeichen lang sein: %w", field, max, domain.ErrInvalidInput)
+		return i18n.Wrap(domain.ErrInvalidInput, i18n.CodeFieldTooLong, field, max)
 	}
 	return nil
 }
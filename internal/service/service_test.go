@@ -2,67 +2,32 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
+	"assecor-assessment-backend/internal/audit"
 	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/repository/memory"
 )
 
-// mockRepo ist ein Test-Double, das repository.PersonRepository implementiert.
-type mockRepo struct {
-	persons []domain.Person
-	nextID  int
-}
-
-func newMockRepo(persons []domain.Person) *mockRepo {
-	return &mockRepo{persons: persons, nextID: len(persons) + 1}
-}
-
-func (m *mockRepo) GetAll(_ context.Context) ([]domain.Person, error) {
-	out := make([]domain.Person, len(m.persons))
-	copy(out, m.persons)
-	return out, nil
-}
-
-func (m *mockRepo) GetByID(_ context.Context, id int) (domain.Person, error) {
-	for _, p := range m.persons {
-		if p.ID == id {
-			return p, nil
-		}
-	}
-	return domain.Person{}, fmt.Errorf("person mit id %d: %w", id, domain.ErrNotFound)
-}
-
-func (m *mockRepo) GetByColor(_ context.Context, color string) ([]domain.Person, error) {
-	out := make([]domain.Person, 0)
-	for _, p := range m.persons {
-		if p.Color == color {
-			out = append(out, p)
-		}
-	}
-	return out, nil
-}
-
-func (m *mockRepo) Add(_ context.Context, person domain.Person) (domain.Person, error) {
-	person.ID = m.nextID
-	m.nextID++
-	m.persons = append(m.persons, person)
-	return person, nil
-}
-
-func seedRepo() *mockRepo {
-	return newMockRepo([]domain.Person{
+func seedRepo() *memory.PersonRepository {
+	return memory.NewPersonRepository(0, []domain.Person{
 		{ID: 1, Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"},
 		{ID: 2, Name: "Peter", Lastname: "Petersen", Zipcode: "18439", City: "Stralsund", Color: "grün"},
-	})
+	}...)
 }
 
-func neuerTestService(repo *mockRepo) *PersonService {
+func neuerTestService(repo *memory.PersonRepository) *PersonService {
 	logger, _ := zap.NewDevelopment()
 	return NewPersonService(repo, logger)
 }
@@ -88,6 +53,54 @@ func TestGetAll(t *testing.T) {
 	assert.Len(t, persons, 2)
 }
 
+func TestStreamAll(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+	var ids []int
+	err := svc.StreamAll(context.Background(), func(p domain.Person) error {
+		ids = append(ids, p.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+}
+
+// ─── ListAfter ────────────────────────────────────────────────────────────────
+
+func TestListAfter_SeitenweiseBisZumEnde(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+
+	var all []int
+	after, pages := 0, 0
+	for {
+		persons, next, err := svc.ListAfter(context.Background(), after, 3)
+		require.NoError(t, err)
+		all = append(all, ids(persons)...)
+		pages++
+		if next == 0 {
+			break
+		}
+		after = next
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, all)
+	assert.Equal(t, 2, pages)
+}
+
+func TestListAfter_LetzteSeiteGenauVoll(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	persons, next, err := svc.ListAfter(context.Background(), 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, ids(persons))
+	assert.Zero(t, next, "keine leere folgeseite ankündigen")
+}
+
+func TestListAfter_UngueltigeEingaben(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	_, _, err := svc.ListAfter(context.Background(), -1, 10)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	_, _, err = svc.ListAfter(context.Background(), 0, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
 // ─── GetByID ──────────────────────────────────────────────────────────────────
 
 func TestGetByID_Gueltig(t *testing.T) {
@@ -111,40 +124,334 @@ func TestGetByID_UngueltigeID(t *testing.T) {
 	assert.ErrorIs(t, err, domain.ErrInvalidInput)
 }
 
+// ─── GetByIDs ─────────────────────────────────────────────────────────────────
+
+func TestGetByIDs_ReihenfolgeDuplikateUnbekannte(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	persons, err := svc.GetByIDs(context.Background(), []int{4, 99, 1, 4, 2, 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 1, 2}, ids(persons))
+}
+
+func TestGetByIDs_UngueltigeEingaben(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+
+	for _, in := range [][]int{nil, {}, {1, 0}, {-3}} {
+		_, err := svc.GetByIDs(context.Background(), in)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, "%v", in)
+	}
+}
+
 // ─── GetByColor ───────────────────────────────────────────────────────────────
 
 func TestGetByColor_Gueltig(t *testing.T) {
 	svc := neuerTestService(seedRepo())
-	persons, err := svc.GetByColor(context.Background(), "blau")
+	persons, err := svc.GetByColor(context.Background(), "blau", "", 0, 0)
 	require.NoError(t, err)
 	assert.Len(t, persons, 1)
 }
 
 func TestGetByColor_Grossschreibung(t *testing.T) {
 	svc := neuerTestService(seedRepo())
-	persons, err := svc.GetByColor(context.Background(), "Blau")
+	persons, err := svc.GetByColor(context.Background(), "Blau", "", 0, 0)
 	require.NoError(t, err)
 	assert.Len(t, persons, 1)
 
-	persons2, err := svc.GetByColor(context.Background(), "BLAU")
+	persons2, err := svc.GetByColor(context.Background(), "BLAU", "", 0, 0)
 	require.NoError(t, err)
 	assert.Len(t, persons2, 1)
 }
 
 func TestGetByColor_UnbekannteFarbe(t *testing.T) {
 	svc := neuerTestService(seedRepo())
-	_, err := svc.GetByColor(context.Background(), "pink")
+	_, err := svc.GetByColor(context.Background(), "pink", "", 0, 0)
 	require.Error(t, err)
 	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	assert.ErrorIs(t, err, domain.ErrInvalidColor)
+	assert.NotErrorIs(t, err, domain.ErrNoPersonsWithColor)
+}
+
+func TestGetByColor_GueltigOhnePersonen(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+
+	persons, err := svc.GetByColor(context.Background(), "gelb", "", 0, 0)
+	assert.Nil(t, persons)
+	assert.ErrorIs(t, err, domain.ErrNoPersonsWithColor)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NotErrorIs(t, err, domain.ErrInvalidInput, "gültige farbe ist keine ungültige eingabe")
+
+	// Eine Seite hinter dem letzten Treffer ist kein Fehler.
+	persons, err = svc.GetByColor(context.Background(), "blau", "", 0, 5)
+	require.NoError(t, err)
+	assert.Empty(t, persons)
+
+	// Mit offset entscheidet die Anzahl, ob die Farbe Personen hat.
+	_, err = svc.GetByColor(context.Background(), "gelb", "", 1, 5)
+	assert.ErrorIs(t, err, domain.ErrNoPersonsWithColor)
+}
+
+func TestGetByColor_Paginierung(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+
+	persons, err := svc.GetByColor(context.Background(), "blau", "", 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, ids(persons))
+
+	_, err = svc.GetByColor(context.Background(), "blau", "", -1, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestGetByColor_SortiertSeitenweise(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	services := map[string]*PersonService{
+		"ohne cache": neuerTestService(sameColorRepo()),
+		"mit cache":  NewPersonService(sameColorRepo(), logger, WithColorCache(true)),
+	}
+	tests := []struct {
+		sort          string
+		limit, offset int
+		want          []int
+	}{
+		{"lastname", 2, 0, []int{4, 1}},
+		{"lastname", 2, 2, []int{3}},
+		{"lastname", 2, 4, []int{}},
+		{"-lastname", 2, 0, []int{3, 1}},
+		{"-lastname", 2, 2, []int{4}},
+		{"", 2, 0, []int{1, 3}},
+	}
+	for name, svc := range services {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, tt := range tests {
+				persons, err := svc.GetByColor(ctx, "blau", tt.sort, tt.limit, tt.offset)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, ids(persons), "sort=%s limit=%d offset=%d", tt.sort, tt.limit, tt.offset)
+			}
+
+			// Sortierte Seiten dürfen die ID-Reihenfolge nicht verändern.
+			persons, err := svc.GetByColor(ctx, "blau", "", 0, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []int{1, 3, 4}, ids(persons))
+
+			_, err = svc.GetByColor(ctx, "blau", "farbe", 0, 0)
+			assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		})
+	}
 }
 
 func TestGetByColor_GenericErrorOhneUserInput(t *testing.T) {
 	svc := neuerTestService(seedRepo())
-	_, err := svc.GetByColor(context.Background(), "xss<script>")
+	_, err := svc.GetByColor(context.Background(), "xss<script>", "", 0, 0)
 	require.Error(t, err)
 	assert.NotContains(t, err.Error(), "xss<script>")
 }
 
+// ─── GetByZipcode ─────────────────────────────────────────────────────────────
+
+func TestGetByZipcode(t *testing.T) {
+	tests := []struct {
+		name    string
+		zip     string
+		prefix  bool
+		want    []int
+		wantErr error
+	}{
+		{"exakt", "67742", false, []int{1}, nil},
+		{"exakt ohne präfix-treffer", "677", false, []int{}, nil},
+		{"präfix", "677", true, []int{1}, nil},
+		{"präfix über alle", "", true, nil, domain.ErrInvalidInput},
+		{"nur leerraum", "  ", false, nil, domain.ErrInvalidInput},
+		{"leerraum wird entfernt", " 18439 ", false, []int{2}, nil},
+		{"zu lang", strings.Repeat("1", 21), true, nil, domain.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := neuerTestService(seedRepo())
+			persons, err := svc.GetByZipcode(context.Background(), tt.zip, tt.prefix, 0, 0)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(persons))
+		})
+	}
+}
+
+// ─── Find ─────────────────────────────────────────────────────────────────────
+
+func TestFind_FarbeWirdNormalisiert(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	persons, err := svc.Find(context.Background(), domain.PersonFilter{Color: "BLAU", Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, ids(persons))
+}
+
+func TestFind_OhneFilterAlle(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	persons, err := svc.Find(context.Background(), domain.PersonFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4}, ids(persons))
+}
+
+func TestFind_UngueltigeEingaben(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+
+	for _, f := range []domain.PersonFilter{
+		{Color: "pink"},
+		{Limit: -1},
+		{Offset: -1},
+	} {
+		_, err := svc.Find(context.Background(), f)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput, "%+v", f)
+	}
+}
+
+func TestCount_FarbeWirdNormalisiert(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	n, err := svc.Count(context.Background(), domain.PersonFilter{Color: "BLAU", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	_, err = svc.Count(context.Background(), domain.PersonFilter{Color: "pink"})
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+// ─── SameColorAs ──────────────────────────────────────────────────────────────
+
+func sameColorRepo() *memory.PersonRepository {
+	return memory.NewPersonRepository(0, []domain.Person{
+		{ID: 1, Name: "Hans", Lastname: "Müller", Color: "blau"},
+		{ID: 2, Name: "Peter", Lastname: "Petersen", Color: "grün"},
+		{ID: 3, Name: "Anna", Lastname: "Schmidt", Color: "blau"},
+		{ID: 4, Name: "Lena", Lastname: "Klein", Color: "blau"},
+	}...)
+}
+
+// alle liefert den gesamten Bestand von repo einschließlich gelöschter Personen.
+func alle(t *testing.T, repo *memory.PersonRepository) []domain.Person {
+	t.Helper()
+	persons, err := repo.Find(context.Background(), domain.PersonFilter{IncludeDeleted: true})
+	require.NoError(t, err)
+	return persons
+}
+
+func ids(persons []domain.Person) []int {
+	out := make([]int, 0, len(persons))
+	for _, p := range persons {
+		out = append(out, p.ID)
+	}
+	return out
+}
+
+func TestSameColorAs_OhneSichSelbst(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	persons, err := svc.SameColorAs(context.Background(), 3, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 4}, ids(persons))
+}
+
+func TestSameColorAs_Paginierung(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		want          []int
+	}{
+		{"nur limit", 1, 0, []int{3}},
+		{"limit und offset", 1, 1, []int{4}},
+		{"offset hinter dem ende", 0, 5, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons, err := svc.SameColorAs(context.Background(), 1, tt.limit, tt.offset)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(persons))
+		})
+	}
+}
+
+func TestSameColorAs_NiemandSonstLeeresArray(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+	persons, err := svc.SameColorAs(context.Background(), 2, 0, 0)
+	require.NoError(t, err)
+	assert.NotNil(t, persons)
+	assert.Empty(t, persons)
+}
+
+func TestSameColorAs_Fehler(t *testing.T) {
+	svc := neuerTestService(sameColorRepo())
+
+	_, err := svc.SameColorAs(context.Background(), 99, 0, 0)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = svc.SameColorAs(context.Background(), 1, -1, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+// ─── CountsByColor ────────────────────────────────────────────────────────────
+
+func TestCountsByColor_AlleFarbenInIDReihenfolge(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+	counts, err := svc.CountsByColor(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []domain.ColorCount{
+		{Color: "blau", Count: 1},
+		{Color: "grün", Count: 1},
+		{Color: "violett", Count: 0},
+		{Color: "rot", Count: 0},
+		{Color: "gelb", Count: 0},
+		{Color: "türkis", Count: 0},
+		{Color: "weiß", Count: 0},
+	}, counts)
+}
+
+func TestCountsByColor_EigenerFarbsatz(t *testing.T) {
+	require.NoError(t, domain.LoadColors("1:blau,8:orange"))
+	t.Cleanup(func() { require.NoError(t, domain.LoadColors(domain.DefaultColorSpec)) })
+
+	svc := neuerTestService(seedRepo())
+	counts, err := svc.CountsByColor(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ColorCount{{Color: "blau", Count: 1}, {Color: "orange", Count: 0}}, counts)
+
+	p := validePerson()
+	p.Color = "Orange"
+	created, err := svc.Add(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Color("orange"), created.Color)
+}
+
+// ─── Stats ────────────────────────────────────────────────────────────────────
+
+func TestStats_AlleFarbenAuchOhnePersonen(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+	stats, err := svc.Stats(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, stats.Total)
+	assert.Len(t, stats.ByColor, len(domain.AllColors()))
+	assert.Equal(t, 1, stats.ByColor["blau"])
+	assert.Equal(t, 1, stats.ByColor["grün"])
+	assert.Equal(t, 0, stats.ByColor["weiß"])
+	assert.Equal(t, map[string]int{"Lauterecken": 1, "Stralsund": 1}, stats.ByCity)
+}
+
+// ─── DeleteAll ────────────────────────────────────────────────────────────────
+
+func TestDeleteAll(t *testing.T) {
+	repo := seedRepo()
+	svc := neuerTestService(repo)
+	require.NoError(t, svc.DeleteAll(context.Background()))
+
+	persons, err := svc.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, persons)
+}
+
 // ─── Add ──────────────────────────────────────────────────────────────────────
 
 func TestAdd_Gueltig(t *testing.T) {
@@ -155,13 +462,27 @@ func TestAdd_Gueltig(t *testing.T) {
 	assert.Equal(t, 3, created.ID)
 }
 
+func TestAdd_SetztZeitstempel(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("MEZ", 3600))
+	svc.now = func() time.Time { return fixed }
+
+	in := validePerson()
+	in.CreatedAt = time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC) // vom Client gesetzte Werte zählen nicht
+	created, err := svc.Add(context.Background(), in)
+	require.NoError(t, err)
+	assert.True(t, fixed.Equal(created.CreatedAt))
+	assert.Equal(t, time.UTC, created.CreatedAt.Location())
+	assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+}
+
 func TestAdd_FarbeGrossschreibung(t *testing.T) {
 	svc := neuerTestService(seedRepo())
 	p := validePerson()
 	p.Color = "ROT"
 	created, err := svc.Add(context.Background(), p)
 	require.NoError(t, err)
-	assert.Equal(t, "rot", created.Color)
+	assert.Equal(t, domain.Color("rot"), created.Color)
 }
 
 func TestAdd_FuehrendeLeerzechenWerdenGetrimmt(t *testing.T) {
@@ -398,3 +719,511 @@ func TestAdd_UnbekannteFarbe(t *testing.T) {
 	require.Error(t, err)
 	assert.ErrorIs(t, err, domain.ErrInvalidInput)
 }
+
+// ─── Update ───────────────────────────────────────────────────────────────────
+
+func TestUpdate_NormalisiertUndSetztUpdatedAt(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return fixed }
+
+	p := validePerson()
+	p.ID = 99 // die ID aus dem Pfad gewinnt
+	p.City = "  Berlin  "
+	p.Color = "GRÜN"
+	updated, err := svc.Update(context.Background(), 1, p, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.ID)
+	assert.Equal(t, "Berlin", updated.City)
+	assert.Equal(t, domain.Color("grün"), updated.Color)
+	assert.True(t, fixed.Equal(updated.UpdatedAt))
+	assert.Equal(t, 1, updated.Version)
+}
+
+func TestUpdate_Versionskonflikt(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+
+	_, err := svc.Update(context.Background(), 2, validePerson(), 0)
+	require.NoError(t, err)
+
+	_, err = svc.Update(context.Background(), 2, validePerson(), 6)
+	require.ErrorIs(t, err, domain.ErrVersionConflict)
+	var conflict *domain.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 1, conflict.Current)
+}
+
+func TestUpdate_Fehler(t *testing.T) {
+	svc := neuerTestService(seedRepo())
+	ungueltig := validePerson()
+	ungueltig.Name = "A"
+
+	tests := []struct {
+		name    string
+		id      int
+		person  domain.Person
+		version int
+		wantErr error
+	}{
+		{"id null", 0, validePerson(), 0, domain.ErrInvalidInput},
+		{"negative version", 1, validePerson(), -1, domain.ErrInvalidInput},
+		{"ungültige person", 1, ungueltig, 0, domain.ErrInvalidInput},
+		{"unbekannte id", 99, validePerson(), 0, domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Update(context.Background(), tt.id, tt.person, tt.version)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUpsert_LegtUnterVorgegebenerIDAn(t *testing.T) {
+	repo := seedRepo()
+	svc := neuerTestService(repo)
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return fixed }
+
+	p := validePerson()
+	p.Color = "ROT"
+	created, isNew, err := svc.Upsert(context.Background(), 7, p, 0)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, 7, created.ID)
+	assert.Equal(t, domain.Color("rot"), created.Color)
+	assert.True(t, fixed.Equal(created.CreatedAt))
+
+	updated, isNew, err := svc.Upsert(context.Background(), 7, validePerson(), 0)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, 2, updated.Version)
+
+	added, err := svc.Add(context.Background(), validePerson())
+	require.NoError(t, err)
+	assert.Equal(t, 8, added.ID)
+}
+
+func TestUpsert_Fehler(t *testing.T) {
+	repo := seedRepo()
+	svc := neuerTestService(repo)
+	require.NoError(t, svc.Delete(context.Background(), 2))
+
+	tests := []struct {
+		name    string
+		id      int
+		version int
+		wantErr error
+	}{
+		{"erwartete version legt nicht an", 99, 1, domain.ErrNotFound},
+		{"gelöschte person", 2, 0, domain.ErrGone},
+		{"id null", 0, 0, domain.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := svc.Upsert(context.Background(), tt.id, validePerson(), tt.version)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+	assert.Equal(t, []int{1, 2}, ids(alle(t, repo)))
+}
+
+// ─── Delete / Restore / Purge ─────────────────────────────────────────────────
+
+func TestDelete_SetztZeitpunkt(t *testing.T) {
+	repo := seedRepo()
+	svc := neuerTestService(repo)
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return fixed }
+
+	require.NoError(t, svc.Delete(context.Background(), 1))
+	assert.True(t, fixed.Equal(alle(t, repo)[0].DeletedAt))
+
+	require.ErrorIs(t, svc.Delete(context.Background(), 1), domain.ErrNotFound)
+	require.ErrorIs(t, svc.Delete(context.Background(), 0), domain.ErrInvalidInput)
+
+	restored, err := svc.Restore(context.Background(), 1)
+	require.NoError(t, err)
+	assert.False(t, restored.Deleted())
+}
+
+func TestDeleteByColor(t *testing.T) {
+	repo := seedRepo()
+	auditor := &aufzeichnenderAuditor{}
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "persons_total"})
+	logger, _ := zap.NewDevelopment()
+	svc := NewPersonService(repo, logger, WithAuditor(auditor), WithPersonsGauge(gauge))
+	ctx := context.Background()
+	require.NoError(t, svc.RefreshPersonCount(ctx))
+
+	n, err := svc.DeleteByColor(ctx, "Blau")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1.0, testutil.ToFloat64(gauge))
+	require.Len(t, auditor.events, 1)
+	assert.Equal(t, audit.OpDelete, auditor.events[0].Operation)
+	assert.Equal(t, 1, auditor.events[0].PersonID)
+	assert.True(t, alle(t, repo)[0].Deleted())
+
+	_, err = svc.DeleteByColor(ctx, "lila")
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+}
+
+func TestPurge_NurAbgelaufene(t *testing.T) {
+	repo := seedRepo()
+	svc := neuerTestService(repo)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	svc.now = func() time.Time { return base }
+	require.NoError(t, svc.Delete(context.Background(), 1))
+	svc.now = func() time.Time { return base.Add(20 * 24 * time.Hour) }
+	require.NoError(t, svc.Delete(context.Background(), 2))
+
+	svc.now = func() time.Time { return base.Add(31 * 24 * time.Hour) }
+	n, err := svc.Purge(context.Background(), 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int{2}, ids(alle(t, repo)))
+}
+
+func TestRunPurge_LaeuftBisKontextEndet(t *testing.T) {
+	repo := seedRepo()
+	core, logs := observer.New(zap.InfoLevel)
+	svc := NewPersonService(repo, zap.New(core))
+	require.NoError(t, svc.Delete(context.Background(), 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunPurge(ctx, -time.Hour, time.Millisecond)
+		close(done)
+	}()
+
+	// Das Repository wird erst nach Ende der Schleife gelesen; bis dahin
+	// zeigt der Logeintrag den Durchlauf an.
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage("gelöschte personen endgültig entfernt").Len() > 0
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, []int{2}, ids(alle(t, repo)))
+}
+
+// ─── Audit ────────────────────────────────────────────────────────────────────
+
+// aufzeichnenderAuditor sammelt Ereignisse oder scheitert mit err.
+type aufzeichnenderAuditor struct {
+	events []audit.Event
+	err    error
+}
+
+func (a *aufzeichnenderAuditor) Record(_ context.Context, event audit.Event) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.events = append(a.events, event)
+	return nil
+}
+
+func TestAudit_EreignisseJeAenderung(t *testing.T) {
+	auditor := &aufzeichnenderAuditor{}
+	logger, _ := zap.NewDevelopment()
+	svc := NewPersonService(seedRepo(), logger, WithAuditor(auditor))
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return fixed }
+
+	ctx := context.WithValue(context.Background(), chimw.RequestIDKey, "req-7")
+	ctx = audit.WithKeyID(ctx, audit.KeyIDFor("schluessel"))
+
+	added, err := svc.Add(ctx, validePerson())
+	require.NoError(t, err)
+	changed := validePerson()
+	changed.City = "Neustadt"
+	updated, err := svc.Update(ctx, added.ID, changed, 0)
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(ctx, added.ID))
+	restored, err := svc.Restore(ctx, added.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteAll(ctx))
+
+	require.Len(t, auditor.events, 5)
+	for _, e := range auditor.events {
+		assert.Equal(t, fixed, e.Time)
+		assert.Equal(t, "req-7", e.RequestID)
+		assert.Equal(t, audit.KeyIDFor("schluessel"), e.APIKey)
+	}
+
+	create, update, del, restore, deleteAll := auditor.events[0], auditor.events[1], auditor.events[2], auditor.events[3], auditor.events[4]
+	assert.Equal(t, audit.OpCreate, create.Operation)
+	assert.Equal(t, added.ID, create.PersonID)
+	assert.Nil(t, create.Before)
+	assert.Equal(t, &added, create.After)
+
+	assert.Equal(t, audit.OpUpdate, update.Operation)
+	assert.Equal(t, &added, update.Before)
+	assert.Equal(t, &updated, update.After)
+
+	assert.Equal(t, audit.OpDelete, del.Operation)
+	assert.Equal(t, &updated, del.Before)
+	assert.Nil(t, del.After)
+
+	assert.Equal(t, audit.OpRestore, restore.Operation)
+	assert.Equal(t, &restored, restore.After)
+
+	assert.Equal(t, audit.Event{Time: fixed, Operation: audit.OpDeleteAll, RequestID: "req-7", APIKey: audit.KeyIDFor("schluessel")}, deleteAll)
+}
+
+func TestAudit_GescheiterteAenderungOhneEreignis(t *testing.T) {
+	auditor := &aufzeichnenderAuditor{}
+	logger, _ := zap.NewDevelopment()
+	svc := NewPersonService(seedRepo(), logger, WithAuditor(auditor))
+
+	_, err := svc.Update(context.Background(), 99, validePerson(), 0)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = svc.Add(context.Background(), domain.Person{})
+	require.ErrorIs(t, err, domain.ErrInvalidInput)
+
+	assert.Empty(t, auditor.events)
+}
+
+func TestAudit_SchreibfehlerNurImStrengenModusFatal(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+	}{
+		{"nachsichtig", false},
+		{"streng", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := seedRepo()
+			auditErr := errors.New("platte voll")
+			core, logs := observer.New(zap.ErrorLevel)
+			svc := NewPersonService(repo, zap.New(core),
+				WithAuditor(&aufzeichnenderAuditor{err: auditErr}), WithAuditStrict(tt.strict))
+
+			added, err := svc.Add(context.Background(), validePerson())
+			deleteErr := svc.Delete(context.Background(), 1)
+
+			if tt.strict {
+				require.ErrorIs(t, err, auditErr)
+				assert.Zero(t, added)
+				require.ErrorIs(t, deleteErr, auditErr)
+			} else {
+				require.NoError(t, err)
+				assert.NotZero(t, added.ID)
+				require.NoError(t, deleteErr)
+			}
+			assert.Equal(t, 2, logs.FilterMessage("audit-eintrag nicht geschrieben").Len())
+			// Die Änderung ist in beiden Fällen gespeichert.
+			assert.Len(t, alle(t, repo), 3)
+			assert.True(t, alle(t, repo)[0].Deleted())
+		})
+	}
+}
+
+// ─── Personenanzahl ───────────────────────────────────────────────────────────
+
+func TestPersonsGauge_FolgtAenderungen(t *testing.T) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "persons_total"})
+	logger, _ := zap.NewDevelopment()
+	svc := NewPersonService(seedRepo(), logger, WithPersonsGauge(gauge))
+	ctx := context.Background()
+
+	require.NoError(t, svc.RefreshPersonCount(ctx))
+	assert.Equal(t, 2.0, testutil.ToFloat64(gauge))
+
+	added, err := svc.Add(ctx, validePerson())
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, testutil.ToFloat64(gauge))
+
+	_, created, err := svc.Upsert(ctx, 10, validePerson(), 0)
+	require.NoError(t, err)
+	require.True(t, created)
+	assert.Equal(t, 4.0, testutil.ToFloat64(gauge))
+
+	require.NoError(t, svc.Delete(ctx, added.ID))
+	require.Error(t, svc.Delete(ctx, added.ID), "zweites löschen zählt nicht")
+	assert.Equal(t, 3.0, testutil.ToFloat64(gauge))
+
+	_, err = svc.Restore(ctx, added.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, testutil.ToFloat64(gauge))
+
+	require.NoError(t, svc.DeleteAll(ctx))
+	assert.Equal(t, 0.0, testutil.ToFloat64(gauge))
+}
+
+func TestRunPersonCountRefresh_GleichtAenderungenAmServiceVorbeiAb(t *testing.T) {
+	repo := seedRepo()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "persons_total"})
+	logger, _ := zap.NewDevelopment()
+	svc := NewPersonService(repo, logger, WithPersonsGauge(gauge))
+	require.NoError(t, svc.RefreshPersonCount(context.Background()))
+
+	_, err := repo.Add(context.Background(), validePerson())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.RunPersonCountRefresh(ctx, time.Millisecond)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(gauge) == 3
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+// ─── Farb-Cache ───────────────────────────────────────────────────────────────
+
+// zaehlendesRepo zählt die GetByColor-Aufrufe, die den Cache verfehlen.
+type zaehlendesRepo struct {
+	*memory.PersonRepository
+	byColor int
+}
+
+func (r *zaehlendesRepo) GetByColor(ctx context.Context, color domain.Color, order domain.Order, limit, offset int) ([]domain.Person, error) {
+	r.byColor++
+	return r.PersonRepository.GetByColor(ctx, color, order, limit, offset)
+}
+
+func neuerCacheService(repo *zaehlendesRepo) *PersonService {
+	logger, _ := zap.NewDevelopment()
+	return NewPersonService(repo, logger, WithColorCache(true))
+}
+
+func TestColorCache_WiederholteLesezugriffeAusDemCache(t *testing.T) {
+	repo := &zaehlendesRepo{PersonRepository: seedRepo()}
+	svc := neuerCacheService(repo)
+	ctx := context.Background()
+
+	for range 3 {
+		persons, err := svc.GetByColor(ctx, "blau", "", 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1}, ids(persons))
+	}
+	_, err := svc.SameColorAs(ctx, 1, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.byColor)
+
+	_, err = svc.GetByColor(ctx, "grün", "", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.byColor, "jede farbe hat einen eigenen eintrag")
+
+	for range 2 {
+		_, err = svc.GetByColor(ctx, "blau", "", 1, 0)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, repo.byColor, "jede seite hat einen eigenen eintrag")
+
+	svc.InvalidateCache()
+	_, err = svc.GetByColor(ctx, "blau", "", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, repo.byColor)
+}
+
+func TestColorCache_AenderungenSindSofortSichtbar(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, svc *PersonService)
+		want   []int
+	}{
+		{"add", func(t *testing.T, svc *PersonService) {
+			p := validePerson()
+			p.Color = "blau"
+			_, err := svc.Add(context.Background(), p)
+			require.NoError(t, err)
+		}, []int{1, 3}},
+		{"upsert legt an", func(t *testing.T, svc *PersonService) {
+			p := validePerson()
+			p.Color = "blau"
+			_, created, err := svc.Upsert(context.Background(), 10, p, 0)
+			require.NoError(t, err)
+			require.True(t, created)
+		}, []int{1, 10}},
+		{"update wechselt farbe", func(t *testing.T, svc *PersonService) {
+			p := validePerson()
+			p.Color = "blau"
+			_, err := svc.Update(context.Background(), 2, p, 0)
+			require.NoError(t, err)
+		}, []int{1, 2}},
+		{"delete", func(t *testing.T, svc *PersonService) {
+			require.NoError(t, svc.Delete(context.Background(), 1))
+		}, []int{}},
+		{"delete nach farbe", func(t *testing.T, svc *PersonService) {
+			_, err := svc.DeleteByColor(context.Background(), "blau")
+			require.NoError(t, err)
+		}, []int{}},
+		{"restore", func(t *testing.T, svc *PersonService) {
+			require.NoError(t, svc.Delete(context.Background(), 1))
+			_, err := svc.GetByColor(context.Background(), "blau", "", 0, 0)
+			require.ErrorIs(t, err, domain.ErrNoPersonsWithColor)
+			_, err = svc.Restore(context.Background(), 1)
+			require.NoError(t, err)
+		}, []int{1}},
+		{"delete all", func(t *testing.T, svc *PersonService) {
+			require.NoError(t, svc.DeleteAll(context.Background()))
+		}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := neuerCacheService(&zaehlendesRepo{PersonRepository: seedRepo()})
+			ctx := context.Background()
+			_, err := svc.GetByColor(ctx, "blau", "", 0, 0)
+			require.NoError(t, err)
+
+			tt.change(t, svc)
+
+			persons, err := svc.GetByColor(ctx, "blau", "", 0, 0)
+			if len(tt.want) == 0 {
+				assert.ErrorIs(t, err, domain.ErrNoPersonsWithColor)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(persons))
+		})
+	}
+}
+
+func TestColorCache_VeraltetesErgebnisNachLeerenVerworfen(t *testing.T) {
+	c := newColorCache()
+	q := colorQuery{color: "blau"}
+	_, generation, ok := c.get(q)
+	require.False(t, ok)
+
+	c.invalidate()
+	c.put(q, []domain.Person{{ID: 1}}, generation)
+
+	_, _, ok = c.get(q)
+	assert.False(t, ok, "ergebnis von vor dem leeren darf nicht eingetragen werden")
+}
+
+// ─── Benchmarks ───────────────────────────────────────────────────────────────
+
+func BenchmarkGetByColor(b *testing.B) {
+	colors := domain.AllColors()
+	seed := make([]domain.Person, 100_000)
+	for i := range seed {
+		seed[i] = domain.Person{ID: i + 1, Name: "Hans", Lastname: "Müller", City: "Lauterecken", Color: colors[i%len(colors)]}
+	}
+	for _, enabled := range []bool{false, true} {
+		name := "ohne cache"
+		if enabled {
+			name = "mit cache"
+		}
+		b.Run(name, func(b *testing.B) {
+			svc := NewPersonService(memory.NewPersonRepository(0, seed...), zap.NewNop(), WithColorCache(enabled))
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.GetByColor(ctx, "blau", "", 20, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package tracing richtet OpenTelemetry-Tracing mit OTLP-Export ein.
+//
+// Handler-Middleware, Service und Repositories holen ihren Tracer bei jedem
+// Aufruf über otel.Tracer; ohne Setup (oder ohne Endpunkt) ist das der
+// No-op-Provider von OpenTelemetry, und Tracing kostet praktisch nichts.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+
+	"assecor-assessment-backend/internal/buildinfo"
+)
+
+// Config beschreibt Exporter und Sampling.
+type Config struct {
+	// Endpoint ist die OTLP/HTTP-URL des Collectors, z. B.
+	// "http://tempo:4318"; leer schaltet Tracing ab.
+	Endpoint string
+	// SampleRatio ist der Anteil neuer Traces, die aufgezeichnet werden
+	// (0 bis 1). Eingehende Traces folgen der Entscheidung des Aufrufers.
+	SampleRatio float64
+	// ServiceName erscheint als service.name an jedem Span.
+	ServiceName string
+}
+
+// Setup installiert einen globalen TracerProvider mit OTLP-Exporter sowie
+// den W3C-Propagator. Die zurückgegebene Funktion exportiert noch gepufferte
+// Spans und beendet den Provider; ohne Endpoint ist sie wirkungslos.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("otlp-exporter: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(buildinfo.Version),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	return tp.Shutdown, nil
+}
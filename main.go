@@ -2,93 +2,155 @@ package main
 
 import (
 	"context"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 
+	"assecor-assessment-backend/internal/app"
 	"assecor-assessment-backend/internal/env"
-	"assecor-assessment-backend/internal/handler"
-	"assecor-assessment-backend/internal/repository"
-	csvrepo "assecor-assessment-backend/internal/repository/csv"
-	sqliterepo "assecor-assessment-backend/internal/repository/sqlite"
-	"assecor-assessment-backend/internal/routes"
-	"assecor-assessment-backend/internal/service"
 )
 
+const usage = `verwendung: server [kommando] [argumente]
+
+kommandos:
+  serve            startet den HTTP-Server (Standard ohne kommando)
+  validate <csv>   prüft eine CSV-Datei und meldet jeden fehlerhaften datensatz
+  import <csv>     schreibt eine CSV-Datei in die SQLite-Datenbank unter SQLITE_PATH
+  export           schreibt alle personen aus DATA_SOURCE als CSV nach stdout
+
+Die Konfiguration kommt bei allen Kommandos aus den Umgebungsvariablen.
+Exit-Codes: 0 = erfolgreich, 1 = fehlgeschlagen, 2 = falscher Aufruf.
+`
+
+// command ist ein Unterkommando. args sind die Positionsargumente, deren
+// Anzahl run bereits gegen nargs geprüft hat.
+type command struct {
+	args  string // Positionsargumente für die Hilfe, z. B. "<csv>"
+	nargs int
+	run   func(ctx context.Context, cfg env.Config, logger *zap.Logger, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"serve":    {run: runServe},
+	"validate": {args: "<csv>", nargs: 1, run: runValidate},
+	"import":   {args: "<csv>", nargs: 1, run: runImport},
+	"export":   {run: runExport},
+}
+
 func main() {
-	logger, _ := zap.NewProduction()
-	defer func() { _ = logger.Sync() }()
-
-	cfg := env.MustLoad()
-	logger.Info("konfiguration geladen",
-		zap.String("data_source", cfg.DataSource),
-		zap.String("csv_file_path", cfg.CSVFilePath),
-		zap.String("server_addr", cfg.ServerAddr),
-		zap.Float64("rate_limit", cfg.RateLimit),
-		zap.Int("max_persons", cfg.MaxPersons),
-	)
-
-	repo, cleanup := mustInitRepo(cfg, logger)
-	if cleanup != nil {
-		defer cleanup()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run führt das Kommando in args aus und gibt den Exit-Code zurück. Ohne
+// Kommando startet es den Server wie vor der Einführung der Kommandos.
+func run(args []string, stdout, stderr io.Writer) int {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	switch name {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unbekanntes kommando %q\n\n%s", name, usage)
+		return 2
 	}
 
-	svc := service.NewPersonService(repo, logger)
-	h := handler.NewPersonHandler(svc, logger)
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprintf(stderr, "verwendung: server %s %s\n", name, cmd.args) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != cmd.nargs {
+		flags.Usage()
+		return 2
+	}
 
-	r := chi.NewRouter()
-	routes.Setup(r, h, logger, cfg.RateLimit)
+	cfg, logger, err := app.Setup()
+	if logger != nil {
+		defer func() { _ = logger.Sync() }()
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
 
-	srv := &http.Server{
-		Addr:         cfg.ServerAddr,
-		Handler:      r,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  30 * time.Second,
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := cmd.run(ctx, cfg, logger, flags.Args(), stdout); err != nil {
+		logger.Error(name+" fehlgeschlagen", zap.Error(err))
+		return 1
 	}
+	return 0
+}
 
-	go func() {
-		logger.Info("server wird gestartet", zap.String("adresse", srv.Addr))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal("listen", zap.Error(err))
-		}
-	}()
+// checkConfig prüft cfg vollständig und loggt jedes gefundene Problem.
+func checkConfig(cfg env.Config, logger *zap.Logger) error {
+	err := cfg.Validate()
+	if err == nil {
+		return nil
+	}
+	var invalid *env.ValidationError
+	if errors.As(err, &invalid) {
+		logger.Error("konfiguration ungültig", zap.Strings("probleme", invalid.Problems))
+		return errors.New("konfiguration ungültig")
+	}
+	return fmt.Errorf("konfiguration ungültig: %w", err)
+}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+func runServe(ctx context.Context, cfg env.Config, logger *zap.Logger, _ []string, _ io.Writer) error {
+	if err := checkConfig(cfg, logger); err != nil {
+		return err
+	}
+	return serve(ctx, cfg, logger)
+}
 
-	logger.Info("server wird heruntergefahren")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("erzwungenes herunterfahren", zap.Error(err))
+// runValidate schlägt fehl, sobald die CSV einen fehlerhaften Datensatz
+// enthält, damit CI-Läufe an Datendateien scheitern.
+func runValidate(_ context.Context, cfg env.Config, _ *zap.Logger, args []string, stdout io.Writer) error {
+	report, err := app.ValidateCSV(cfg, args[0], stdout)
+	if err != nil {
+		return err
+	}
+	if len(report.Skipped) > 0 {
+		return fmt.Errorf("%d fehlerhafte datensätze in %s", len(report.Skipped), args[0])
 	}
-	logger.Info("server gestoppt")
+	return nil
 }
 
-// mustInitRepo erstellt je nach DATA_SOURCE das passende PersonRepository.
-// Bei "sqlite" wird eine In-Memory-Datenbank verwendet; die zurückgegebene
-// cleanup-Funktion schließt die DB-Verbindung.
-func mustInitRepo(cfg env.Config, logger *zap.Logger) (repository.PersonRepository, func()) {
-	switch cfg.DataSource {
-	case "sqlite":
-		repo, err := sqliterepo.NewPersonRepository(":memory:", cfg.MaxPersons, logger)
-		if err != nil {
-			logger.Fatal("sqlite-repository konnte nicht initialisiert werden", zap.Error(err))
-		}
-		return repo, func() { _ = repo.Close() }
+func runImport(ctx context.Context, cfg env.Config, logger *zap.Logger, args []string, _ io.Writer) error {
+	_, err := app.Import(ctx, cfg, args[0], logger)
+	return err
+}
 
-	default:
-		repo, err := csvrepo.NewPersonRepository(cfg.CSVFilePath, cfg.MaxPersons, logger)
-		if err != nil {
-			logger.Fatal("csv-repository konnte nicht geladen werden", zap.Error(err))
-		}
-		return repo, nil
+func runExport(ctx context.Context, cfg env.Config, logger *zap.Logger, _ []string, stdout io.Writer) error {
+	if err := checkConfig(cfg, logger); err != nil {
+		return err
+	}
+	repo, cleanup, err := app.OpenRepository(cfg, logger)
+	if err != nil {
+		return err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	n, err := app.Export(ctx, repo, stdout, cfg.CSVDelimiter)
+	if err != nil {
+		return err
 	}
+	logger.Info("personen exportiert", zap.Int("anzahl", n), zap.String("data_source", cfg.DataSource))
+	return nil
 }
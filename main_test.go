@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/env"
+	"assecor-assessment-backend/internal/repository/memory"
+	"assecor-assessment-backend/internal/service"
+)
+
+// ausfuehren ruft run mit args auf und gibt Exit-Code, stdout und stderr zurück.
+func ausfuehren(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr strings.Builder
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func tempCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "persons.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestRun_Aufruf(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+		wantErr  string
+	}{
+		{"hilfe", []string{"help"}, 0, "verwendung: server [kommando]", ""},
+		{"hilfe als flag", []string{"-h"}, 0, "kommandos:", ""},
+		{"hilfe zum kommando", []string{"validate", "-h"}, 0, "", "verwendung: server validate <csv>"},
+		{"unbekanntes kommando", []string{"migrate"}, 2, "", `unbekanntes kommando "migrate"`},
+		{"unbekanntes flag", []string{"export", "-x"}, 2, "", "flag provided but not defined"},
+		{"argument fehlt", []string{"validate"}, 2, "", "verwendung: server validate <csv>"},
+		{"zu viele argumente", []string{"export", "a.csv"}, 2, "", "verwendung: server export"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := ausfuehren(t, tt.args...)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stdout, tt.wantOut)
+			assert.Contains(t, stderr, tt.wantErr)
+		})
+	}
+}
+
+func TestRun_Validate(t *testing.T) {
+	t.Run("gültig", func(t *testing.T) {
+		code, stdout, _ := ausfuehren(t, "validate", tempCSV(t, "Müller, Hans, 67742 Lauterecken, 1\n"))
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout, "1 gültige datensätze, 0 fehlerhaft")
+	})
+	t.Run("fehlerhafte zeile", func(t *testing.T) {
+		code, stdout, _ := ausfuehren(t, "validate", tempCSV(t, "Müller, Hans, 67742 Lauterecken, 1\nNur, Zwei\n"))
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout, "zeile 2: unvollständiger datensatz: Nur, Zwei")
+	})
+	t.Run("datei fehlt", func(t *testing.T) {
+		code, stdout, _ := ausfuehren(t, "validate", filepath.Join(t.TempDir(), "fehlt.csv"))
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout)
+	})
+}
+
+func TestRun_ImportUndExport(t *testing.T) {
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "persons.db"))
+	csvPath := tempCSV(t, "Müller, Hans, 67742 Lauterecken, 1\nPetersen, Peter, 18439 Stralsund, 2\n")
+
+	code, _, _ := ausfuehren(t, "import", csvPath)
+	require.Equal(t, 0, code)
+	code, _, _ = ausfuehren(t, "import", csvPath)
+	assert.Equal(t, 1, code, "zweiter import trifft belegte ids")
+
+	t.Setenv("DATA_SOURCE", "sqlite")
+	code, stdout, _ := ausfuehren(t, "export")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "Müller,Hans,67742 Lauterecken,1\nPetersen,Peter,18439 Stralsund,2\n", stdout)
+}
+
+func TestRun_ExportPrueftKonfiguration(t *testing.T) {
+	t.Setenv("DATA_SOURCE", "postgres")
+	code, stdout, _ := ausfuehren(t, "export")
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout)
+}
+
+func TestRun_NichtUmwandelbarerWertBrichtAb(t *testing.T) {
+	t.Setenv("MAX_PERSONS", "ten")
+	code, stdout, stderr := ausfuehren(t, "validate", tempCSV(t, "Müller, Hans, 67742 Lauterecken, 1\n"))
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, `MAX_PERSONS="ten": erwartet eine ganze zahl`)
+}
+
+func TestNewHTTPServer_Zeitlimits(t *testing.T) {
+	t.Setenv("READ_TIMEOUT", "15s")
+	t.Setenv("READ_HEADER_TIMEOUT", "2s")
+	t.Setenv("WRITE_TIMEOUT", "1m")
+	t.Setenv("IDLE_TIMEOUT", "90s")
+	t.Setenv("MAX_HEADER_BYTES", "8192")
+	cfg := env.MustLoad()
+	require.NoError(t, cfg.Validate())
+
+	srv := newHTTPServer(cfg, http.NotFoundHandler(), nil)
+
+	assert.Equal(t, 15*time.Second, srv.ReadTimeout)
+	assert.Equal(t, 2*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, time.Minute, srv.WriteTimeout)
+	assert.Equal(t, 90*time.Second, srv.IdleTimeout)
+	assert.Equal(t, 8192, srv.MaxHeaderBytes)
+}
+
+func TestNewHTTPServer_Standardwerte(t *testing.T) {
+	srv := newHTTPServer(env.MustLoad(), http.NotFoundHandler(), nil)
+
+	assert.Equal(t, 10*time.Second, srv.ReadTimeout)
+	assert.Zero(t, srv.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 30*time.Second, srv.IdleTimeout)
+	assert.Zero(t, srv.MaxHeaderBytes)
+}
+
+func TestServe_StartetUndFaehrtHerunter(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "server.sock")
+	t.Setenv("DATA_SOURCE", "memory")
+	t.Setenv("SERVER_ADDR", "unix:"+socket)
+	cfg := env.MustLoad()
+	require.NoError(t, cfg.Validate())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, cfg, zap.NewNop()) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://server/healthz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve endet nicht nach abbruch des kontexts")
+	}
+}
+
+func TestServe_StartfehlerWirdGemeldet(t *testing.T) {
+	t.Setenv("DATA_SOURCE", "memory")
+	t.Setenv("SERVER_ADDR", "unix:")
+	err := serve(context.Background(), env.MustLoad(), zap.NewNop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unix-socket ohne pfad")
+}
+
+// stubReloader ersetzt bei Reload den Bestand von repo durch next oder
+// scheitert mit err, ohne etwas zu ändern.
+type stubReloader struct {
+	repo *memory.PersonRepository
+	next []domain.Person
+	err  error
+}
+
+func (s *stubReloader) Reload(ctx context.Context) (domain.LoadReport, error) {
+	if s.err != nil {
+		return domain.LoadReport{}, s.err
+	}
+	if err := s.repo.DeleteAll(ctx); err != nil {
+		return domain.LoadReport{}, err
+	}
+	for _, p := range s.next {
+		if _, err := s.repo.Add(ctx, p); err != nil {
+			return domain.LoadReport{}, err
+		}
+	}
+	return domain.LoadReport{Loaded: len(s.next)}, nil
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	hans := domain.Person{Name: "Hans", Lastname: "Müller", Zipcode: "67742", City: "Lauterecken", Color: "blau"}
+	repo := memory.NewPersonRepository(0, hans)
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "persons_total"})
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+	svc := service.NewPersonService(repo, logger, service.WithPersonsGauge(gauge))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, svc.RefreshPersonCount(ctx))
+	require.Equal(t, 1.0, testutil.ToFloat64(gauge))
+
+	reloader := &stubReloader{repo: repo, next: []domain.Person{hans, hans, hans}}
+	hup := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		reloadOnSignal(ctx, hup, reloader, svc, logger)
+		close(done)
+	}()
+
+	t.Run("erfolg aktualisiert die anzahl", func(t *testing.T) {
+		hup <- syscall.SIGHUP
+		// Die Anzahl wird erst nach der Logmeldung gezählt.
+		require.Eventually(t, func() bool { return testutil.ToFloat64(gauge) == 3 }, 5*time.Second, 10*time.Millisecond)
+		loaded := logs.FilterMessage("datenquelle per sighup neu geladen").All()
+		require.Len(t, loaded, 1)
+		assert.Equal(t, int64(3), loaded[0].ContextMap()["anzahl"])
+	})
+
+	t.Run("fehler behält den bisherigen bestand", func(t *testing.T) {
+		reloader.err = errors.New("datei kaputt")
+		reloader.next = nil
+		hup <- syscall.SIGHUP
+		require.Eventually(t, func() bool {
+			return logs.FilterMessage("neu laden per sighup gescheitert, alter bestand bleibt erhalten").Len() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 3.0, testutil.ToFloat64(gauge))
+		persons, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		assert.Len(t, persons, 3)
+	})
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reloadOnSignal endet nicht nach abbruch des kontexts")
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"assecor-assessment-backend/internal/app"
+	"assecor-assessment-backend/internal/audit"
+	"assecor-assessment-backend/internal/buildinfo"
+	"assecor-assessment-backend/internal/domain"
+	"assecor-assessment-backend/internal/env"
+	"assecor-assessment-backend/internal/handler"
+	"assecor-assessment-backend/internal/metrics"
+	"assecor-assessment-backend/internal/routes"
+	"assecor-assessment-backend/internal/server"
+	"assecor-assessment-backend/internal/service"
+	"assecor-assessment-backend/internal/tracing"
+)
+
+// serve startet den HTTP-Server mit cfg und läuft, bis ctx endet; danach
+// fährt es den Server geordnet herunter. Ein Fehler beim Start oder beim
+// Herunterfahren wird zurückgegeben.
+func serve(ctx context.Context, cfg env.Config, logger *zap.Logger) error {
+	logger.Info("konfiguration geladen", settingFields(cfg)...)
+
+	build := buildinfo.Get()
+	logger.Info("build-info",
+		zap.String("version", build.Version),
+		zap.String("commit", build.Commit),
+		zap.String("built_at", build.BuiltAt),
+		zap.String("go_version", build.GoVersion),
+	)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+		ServiceName: cfg.TracingServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("tracing konnte nicht eingerichtet werden: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("offene spans nicht exportiert", zap.Error(err))
+		}
+	}()
+
+	repo, cleanup, err := app.OpenRepository(cfg, logger)
+	if err != nil {
+		return err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+
+	// Nur Repositories mit externer Quelle liefern einen Ladebericht.
+	reporter, _ := repo.(handler.LoadReporter)
+	reloader, _ := repo.(handler.Reloader)
+
+	var svcOpts []service.Option
+	auditor, closeAudit, err := initAuditor(cfg, logger)
+	if err != nil {
+		return err
+	}
+	if auditor != nil {
+		if closeAudit != nil {
+			defer closeAudit()
+		}
+		svcOpts = append(svcOpts, service.WithAuditor(auditor), service.WithAuditStrict(cfg.AuditStrict))
+	}
+
+	if cfg.EnableMetrics {
+		svcOpts = append(svcOpts, service.WithPersonsGauge(metrics.NewPersonsGauge(prometheus.DefaultRegisterer)))
+	}
+
+	svcOpts = append(svcOpts, service.WithColorCache(cfg.EnableColorCache))
+
+	svc := service.NewPersonService(repo, logger, svcOpts...)
+	if reloader != nil {
+		reloader = cacheInvalidatingReloader{Reloader: reloader, svc: svc}
+	}
+	if err := svc.RefreshPersonCount(context.Background()); err != nil {
+		logger.Warn("personenanzahl nicht gezählt", zap.Error(err))
+	}
+	h := handler.NewPersonHandler(svc, logger, handler.Options{
+		AllowDestructive:    cfg.AllowDestructive,
+		MaxBodyBytes:        cfg.MaxBodyBytes,
+		MaxIDs:              cfg.MaxIDs,
+		RequireIfMatch:      cfg.RequireIfMatch,
+		PutUpsert:           cfg.PutUpsert,
+		ShowGone:            cfg.ShowGone,
+		MaxPageSize:         cfg.MaxPageSize,
+		RejectOversizedPage: cfg.RejectOversizedPage,
+		StreamWriteTimeout:  cfg.WriteTimeout,
+		BasePath:            cfg.BasePath,
+		LoadReporter:        reporter,
+		Reloader:            reloader,
+	})
+
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if cfg.SoftDeleteRetention > 0 && cfg.PurgeInterval > 0 {
+		go svc.RunPurge(background, cfg.SoftDeleteRetention, cfg.PurgeInterval)
+	}
+	if cfg.EnableMetrics && cfg.MetricsRefreshInterval > 0 {
+		go svc.RunPersonCountRefresh(background, cfg.MetricsRefreshInterval)
+	}
+
+	// SIGHUP lädt die Datenquelle neu, sofern sie das kann. Sonst wird es
+	// ignoriert, statt den Prozess wie ohne Handler zu beenden.
+	if reloader != nil {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go reloadOnSignal(background, hup, reloader, svc, logger)
+	} else {
+		signal.Ignore(syscall.SIGHUP)
+	}
+
+	r := chi.NewRouter()
+	routes.Setup(r, h, logger, cfg)
+
+	tlsCfg, err := server.TLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile)
+	if err != nil {
+		return fmt.Errorf("tls-konfiguration ungültig: %w", err)
+	}
+
+	srv := newHTTPServer(cfg, r, tlsCfg)
+
+	ln, err := server.Listen(cfg.ServerAddr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	// Der Debug-Listener hat kein WriteTimeout, weil CPU-Profile und Traces
+	// absichtlich länger laufen als normale Anfragen.
+	var debugSrv *http.Server
+	if cfg.EnablePprof {
+		debugLn, err := server.Listen(cfg.DebugAddr)
+		if err != nil {
+			_ = ln.Close()
+			return fmt.Errorf("debug-listener: %w", err)
+		}
+		debugSrv = &http.Server{
+			Addr:              cfg.DebugAddr,
+			Handler:           routes.Debug(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			logger.Info("debug-listener wird gestartet", zap.String("adresse", cfg.DebugAddr))
+			if err := debugSrv.Serve(debugLn); err != nil && err != http.ErrServerClosed {
+				logger.Error("debug-listener", zap.Error(err))
+			}
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("server wird gestartet",
+			zap.String("adresse", srv.Addr),
+			zap.Bool("tls", tlsCfg != nil),
+			zap.Bool("mtls", cfg.TLSClientCAFile != ""),
+		)
+		var err error
+		if tlsCfg != nil {
+			err = srv.ServeTLS(ln, "", "")
+		} else {
+			err = srv.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serveErr:
+		if debugSrv != nil {
+			_ = debugSrv.Close()
+		}
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	logger.Info("server wird heruntergefahren")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("erzwungenes herunterfahren: %w", err)
+	}
+	// Erst nach dem Hauptserver, damit ein laufendes Profil dessen Frist nicht aufbraucht.
+	if debugSrv != nil {
+		if err := debugSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("debug-listener nicht sauber beendet", zap.Error(err))
+		}
+	}
+	logger.Info("server gestoppt")
+	return nil
+}
+
+// settingFields gibt jeden wirksamen Konfigurationswert mit seiner Herkunft
+// als eigenes Log-Feld zurück, etwa RATE_LIMIT: {wert: "100", quelle: "standard"}.
+func settingFields(cfg env.Config) []zap.Field {
+	settings := cfg.Settings()
+	fields := make([]zap.Field, 0, len(settings))
+	for _, s := range settings {
+		fields = append(fields, zap.Dict(s.Key, zap.String("wert", s.Value), zap.String("quelle", string(s.Source))))
+	}
+	return fields
+}
+
+// newHTTPServer erstellt den Server für die API mit den Zeitlimits und der
+// Header-Grenze aus cfg.
+func newHTTPServer(cfg env.Config, h http.Handler, tlsCfg *tls.Config) *http.Server {
+	return &http.Server{
+		Addr:              cfg.ServerAddr,
+		Handler:           h,
+		TLSConfig:         tlsCfg,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// cacheInvalidatingReloader verwirft nach jedem erfolgreichen Neuladen den
+// Farb-Cache des Service, da das Neuladen am Service vorbeigeht.
+type cacheInvalidatingReloader struct {
+	handler.Reloader
+	svc *service.PersonService
+}
+
+func (r cacheInvalidatingReloader) Reload(ctx context.Context) (domain.LoadReport, error) {
+	report, err := r.Reloader.Reload(ctx)
+	if err == nil {
+		r.svc.InvalidateCache()
+	}
+	return report, err
+}
+
+// reloadOnSignal lädt die Datenquelle bei jedem Signal auf hup neu, bis ctx
+// endet. Scheitert das Laden, bleibt der bisherige Bestand erhalten.
+func reloadOnSignal(ctx context.Context, hup chan os.Signal, reloader handler.Reloader, svc *service.PersonService, logger *zap.Logger) {
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			start := time.Now()
+			report, err := reloader.Reload(ctx)
+			if err != nil {
+				logger.Error("neu laden per sighup gescheitert, alter bestand bleibt erhalten", zap.Error(err))
+				continue
+			}
+			logger.Info("datenquelle per sighup neu geladen",
+				zap.Int("anzahl", report.Loaded),
+				zap.Int("verworfen", len(report.Skipped)),
+				zap.Duration("dauer", time.Since(start)),
+			)
+			if err := svc.RefreshPersonCount(ctx); err != nil {
+				logger.Warn("personenanzahl nicht gezählt", zap.Error(err))
+			}
+		}
+	}
+}
+
+// initAuditor erstellt den Auditor für AUDIT_LOG oder liefert nil, wenn
+// das Audit-Log abgeschaltet ist. "log" schreibt über logger, jeder andere
+// Wert ist der Pfad der Audit-Datei; die cleanup-Funktion schließt sie.
+func initAuditor(cfg env.Config, logger *zap.Logger) (audit.Auditor, func(), error) {
+	switch cfg.AuditLog {
+	case "":
+		return nil, nil, nil
+	case "log":
+		return audit.NewLogAuditor(logger), nil, nil
+	default:
+		a, err := audit.NewFileAuditor(cfg.AuditLog, cfg.AuditMaxBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("audit-log konnte nicht geöffnet werden: %w", err)
+		}
+		return a, func() {
+			if err := a.Close(); err != nil {
+				logger.Warn("audit-log nicht sauber geschlossen", zap.Error(err))
+			}
+		}, nil
+	}
+}